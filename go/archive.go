@@ -0,0 +1,388 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrUnsafePath means an archive entry's path would be extracted outside of
+// the destination directory.
+var ErrUnsafePath = errors.New("unsafe path")
+
+// ArchiveFormat is the format of an archive.
+type ArchiveFormat int
+
+const (
+	// ArchiveTar is an uncompressed tar archive.
+	ArchiveTar ArchiveFormat = iota
+	// ArchiveTarGz is a gzip-compressed tar archive.
+	ArchiveTarGz
+	// ArchiveZip is a zip archive.
+	ArchiveZip
+)
+
+// ArchiveProgress is passed to the progress callback of ArchiveOpts after
+// each file is archived or extracted.
+type ArchiveProgress struct {
+	// Path is the slash-separated path of the file within the archive.
+	Path string
+	// Files is the number of files processed so far.
+	Files int
+	// Bytes is the number of file bytes processed so far.
+	Bytes int64
+}
+
+// ArchiveOpts are options for ArchiveDir and ExtractArchive.
+type ArchiveOpts struct {
+	// Format is the format of the archive to create. It is ignored by
+	// ExtractArchive, which detects the format.
+	Format ArchiveFormat
+	// Include are glob patterns (see path.Match) of the files to include. Each
+	// pattern is matched against both the slash-separated path within the
+	// archive and the file's base name. If empty, all files are included.
+	Include []string
+	// Exclude are glob patterns, matched the same as Include, of files and
+	// directories to exclude. An excluded directory excludes everything in it.
+	Exclude []string
+	// Progress, if set, is called after each file.
+	Progress func(ArchiveProgress)
+}
+
+func (opts *ArchiveOpts) skipDir(p string) bool {
+	return archiveMatch(opts.Exclude, p)
+}
+
+func (opts *ArchiveOpts) skipFile(p string) bool {
+	if archiveMatch(opts.Exclude, p) {
+		return true
+	}
+	return len(opts.Include) != 0 && !archiveMatch(opts.Include, p)
+}
+
+func (opts *ArchiveOpts) progress(prog *ArchiveProgress, p string, n int64) {
+	prog.Path, prog.Files, prog.Bytes = p, prog.Files+1, prog.Bytes+n
+	if opts.Progress != nil {
+		opts.Progress(*prog)
+	}
+}
+
+func archiveMatch(patterns []string, p string) bool {
+	base := path.Base(p)
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, p); ok {
+			return true
+		}
+		if ok, _ := path.Match(pat, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveDir writes an archive of the contents of the directory to w. Paths
+// in the archive are relative to dir. Only directories and regular files are
+// archived; symlinks and other special files are skipped.
+func ArchiveDir(w io.Writer, dir string, opts ArchiveOpts) error {
+	var aw archiveWriter
+	switch opts.Format {
+	case ArchiveTar:
+		aw = tarArchiveWriter{tw: tar.NewWriter(w)}
+	case ArchiveTarGz:
+		gw := gzip.NewWriter(w)
+		aw = tarArchiveWriter{tw: tar.NewWriter(gw), gw: gw}
+	case ArchiveZip:
+		aw = zipArchiveWriter{zip.NewWriter(w)}
+	default:
+		return fmt.Errorf("unknown archive format: %d", opts.Format)
+	}
+	prog := ArchiveProgress{}
+	err := filepath.WalkDir(dir, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, fp)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if opts.skipDir(rel) {
+				return filepath.SkipDir
+			}
+		} else if !d.Type().IsRegular() || opts.skipFile(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return aw.dir(rel+"/", info)
+		}
+		n, err := aw.file(rel, fp, info)
+		if err != nil {
+			return err
+		}
+		opts.progress(&prog, rel, n)
+		return nil
+	})
+	if cerr := aw.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+type archiveWriter interface {
+	dir(name string, info fs.FileInfo) error
+	file(name, fp string, info fs.FileInfo) (int64, error)
+	close() error
+}
+
+type tarArchiveWriter struct {
+	tw *tar.Writer
+	gw *gzip.Writer
+}
+
+func (aw tarArchiveWriter) dir(name string, info fs.FileInfo) error {
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	return aw.tw.WriteHeader(hdr)
+}
+
+func (aw tarArchiveWriter) file(
+	name, fp string, info fs.FileInfo,
+) (int64, error) {
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return 0, err
+	}
+	hdr.Name = name
+	if err := aw.tw.WriteHeader(hdr); err != nil {
+		return 0, err
+	}
+	return copyFileTo(aw.tw, fp)
+}
+
+func (aw tarArchiveWriter) close() error {
+	err := aw.tw.Close()
+	if aw.gw != nil {
+		if gerr := aw.gw.Close(); err == nil {
+			err = gerr
+		}
+	}
+	return err
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (aw zipArchiveWriter) dir(name string, info fs.FileInfo) error {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	_, err = aw.zw.CreateHeader(hdr)
+	return err
+}
+
+func (aw zipArchiveWriter) file(
+	name, fp string, info fs.FileInfo,
+) (int64, error) {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return 0, err
+	}
+	hdr.Name, hdr.Method = name, zip.Deflate
+	w, err := aw.zw.CreateHeader(hdr)
+	if err != nil {
+		return 0, err
+	}
+	return copyFileTo(w, fp)
+}
+
+func (aw zipArchiveWriter) close() error {
+	return aw.zw.Close()
+}
+
+func copyFileTo(w io.Writer, fp string) (int64, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+// ExtractArchive extracts the archive read from r into the directory dst,
+// creating it if necessary. The format (tar, gzip-compressed tar, or zip) is
+// detected from the data; zip archives are read fully into memory since they
+// require random access. Entries whose paths would escape dst return an error
+// wrapping ErrUnsafePath. Only directories and regular files are extracted;
+// other entries (e.g., symlinks) are skipped.
+func ExtractArchive(r io.Reader, dst string, opts ArchiveOpts) error {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")),
+		bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		b, err := io.ReadAll(br)
+		if err != nil {
+			return err
+		}
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return err
+		}
+		return extractZip(zr, dst, &opts)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		return extractTar(tar.NewReader(gr), dst, &opts)
+	default:
+		return extractTar(tar.NewReader(br), dst, &opts)
+	}
+}
+
+func extractTar(tr *tar.Reader, dst string, opts *ArchiveOpts) error {
+	prog := ArchiveProgress{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var isDir bool
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			isDir = true
+		case tar.TypeReg, tar.TypeRegA:
+		default:
+			continue
+		}
+		err = extractEntry(
+			dst, hdr.Name, isDir, hdr.FileInfo().Mode(), hdr.ModTime,
+			func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+			opts, &prog,
+		)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(zr *zip.Reader, dst string, opts *ArchiveOpts) error {
+	prog := ArchiveProgress{}
+	for _, zf := range zr.File {
+		mode := zf.Mode()
+		if !mode.IsDir() && !mode.IsRegular() {
+			continue
+		}
+		err := extractEntry(
+			dst, zf.Name, mode.IsDir(), mode, zf.Modified, zf.Open, opts, &prog,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractEntry(
+	dst, name string,
+	isDir bool,
+	mode fs.FileMode,
+	modTime time.Time,
+	open func() (io.ReadCloser, error),
+	opts *ArchiveOpts,
+	prog *ArchiveProgress,
+) error {
+	rel, fp, err := safeArchivePath(dst, name)
+	if err != nil {
+		return err
+	}
+	if isDir {
+		if rel == "" || opts.skipDir(rel) {
+			return nil
+		}
+		return os.MkdirAll(fp, 0755)
+	}
+	if opts.skipFile(rel) || archiveDirExcluded(opts, rel) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		return err
+	}
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	f, err := os.OpenFile(
+		fp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0200,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, rc)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if !modTime.IsZero() {
+		os.Chtimes(fp, modTime, modTime)
+	}
+	opts.progress(prog, rel, n)
+	return nil
+}
+
+// archiveDirExcluded returns whether any parent directory of the path is
+// excluded, since archives may not contain entries for every directory.
+func archiveDirExcluded(opts *ArchiveOpts, rel string) bool {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if opts.skipDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeArchivePath returns the cleaned slash-separated path of the entry and
+// the path to extract it to, making sure it stays within dst.
+func safeArchivePath(dst, name string) (rel, fp string, err error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) || filepath.IsAbs(name) ||
+		filepath.VolumeName(name) != "" {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	rel = path.Clean(name)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	if rel == "." {
+		rel = ""
+	}
+	return rel, filepath.Join(dst, filepath.FromSlash(rel)), nil
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveDir(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"a.txt":       "a",
+		"b.log":       "bb",
+		"sub/c.txt":   "ccc",
+		"skip/d.txt":  "dddd",
+		"sub/e/f.txt": "fffff",
+	}
+	for name, content := range files {
+		fp := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	formats := []ArchiveFormat{ArchiveTar, ArchiveTarGz, ArchiveZip}
+	for _, format := range formats {
+		var buf bytes.Buffer
+		var progs []ArchiveProgress
+		opts := ArchiveOpts{
+			Format:  format,
+			Include: []string{"*.txt"},
+			Exclude: []string{"skip"},
+			Progress: func(p ArchiveProgress) {
+				progs = append(progs, p)
+			},
+		}
+		if err := ArchiveDir(&buf, src, opts); err != nil {
+			t.Fatalf("format %d: error archiving: %v", format, err)
+		}
+		if len(progs) != 3 || progs[2].Bytes != 9 {
+			t.Fatalf("format %d: unexpected progress: %+v", format, progs)
+		}
+
+		dst := t.TempDir()
+		err := ExtractArchive(&buf, dst, ArchiveOpts{Exclude: []string{"e"}})
+		if err != nil {
+			t.Fatalf("format %d: error extracting: %v", format, err)
+		}
+		for name, content := range files {
+			b, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+			switch name {
+			case "b.log", "skip/d.txt", "sub/e/f.txt":
+				if !os.IsNotExist(err) {
+					t.Fatalf("format %d: expected %s to not exist", format, name)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("format %d: %v", format, err)
+				} else if string(b) != content {
+					t.Fatalf(
+						"format %d: %s: expected %q, got %q",
+						format, name, content, b,
+					)
+				}
+			}
+		}
+	}
+}
+
+func TestExtractArchiveUnsafe(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "/evil.txt", "a/../../x"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		tw.WriteHeader(&tar.Header{
+			Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: 1,
+		})
+		tw.Write([]byte("x"))
+		tw.Close()
+		dst := t.TempDir()
+		err := ExtractArchive(&buf, dst, ArchiveOpts{})
+		if !errors.Is(err, ErrUnsafePath) {
+			t.Fatalf("%s: expected ErrUnsafePath, got %v", name, err)
+		}
+	}
+}
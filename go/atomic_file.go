@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// AtomicFile is a file that atomically replaces (or creates) a file at a path
+// once committed. Data is written to a temporary file in the same directory,
+// which is synced and renamed to the path on Commit (or Close), so readers
+// only ever see the old or new contents, never a partial write.
+type AtomicFile struct {
+	f    *os.File
+	path string
+	perm os.FileMode
+	done bool
+}
+
+// CreateAtomicFile creates a new AtomicFile that replaces the file at the
+// path, which will have the given permissions, once committed.
+func CreateAtomicFile(path string, perm os.FileMode) (*AtomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicFile{f: f, path: path, perm: perm}, nil
+}
+
+// Write writes to the temporary file.
+func (af *AtomicFile) Write(p []byte) (int, error) {
+	return af.f.Write(p)
+}
+
+// WriteString writes the string to the temporary file.
+func (af *AtomicFile) WriteString(s string) (int, error) {
+	return af.f.WriteString(s)
+}
+
+// File returns the underlying temporary file.
+func (af *AtomicFile) File() *os.File {
+	return af.f
+}
+
+// Path returns the path of the file that will be replaced.
+func (af *AtomicFile) Path() string {
+	return af.path
+}
+
+// Commit syncs and closes the temporary file, then renames it to the path.
+// If anything fails, the temporary file is removed and the file at the path
+// is left untouched. Returns ErrClosed if already committed or aborted.
+func (af *AtomicFile) Commit() error {
+	if af.done {
+		return ErrClosed
+	}
+	af.done = true
+	err := af.f.Sync()
+	if cerr := af.f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(af.f.Name(), af.perm)
+	}
+	if err == nil {
+		err = os.Rename(af.f.Name(), af.path)
+	}
+	if err != nil {
+		os.Remove(af.f.Name())
+		return err
+	}
+	syncDir(filepath.Dir(af.path))
+	return nil
+}
+
+// Close is the same as Commit, so an AtomicFile can be used as an
+// io.WriteCloser. Use Abort to discard the data instead.
+func (af *AtomicFile) Close() error {
+	return af.Commit()
+}
+
+// Abort closes and removes the temporary file, leaving the file at the path
+// untouched. It does nothing if already committed or aborted, so it can be
+// deferred right after creation.
+func (af *AtomicFile) Abort() error {
+	if af.done {
+		return nil
+	}
+	af.done = true
+	err := af.f.Close()
+	if rerr := os.Remove(af.f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// WriteFileAtomic writes the data to the file at the path atomically (see
+// AtomicFile), creating it with the given permissions if needed.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	af, err := CreateAtomicFile(path, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := af.Write(data); err != nil {
+		return errors.Join(err, af.Abort())
+	}
+	return af.Commit()
+}
+
+// syncDir syncs the directory so that a rename in it is durable. Errors are
+// ignored since not all platforms support syncing directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestAtomicFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := WriteFileAtomic(path, []byte("v1"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "v1" {
+		t.Fatalf("expected v1, got %q", b)
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(path); err != nil {
+			t.Fatal(err)
+		} else if info.Mode().Perm() != 0640 {
+			t.Fatalf("expected 0640, got %o", info.Mode().Perm())
+		}
+	}
+
+	af, err := CreateAtomicFile(path, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	af.WriteString("v2")
+	if b, _ := os.ReadFile(path); string(b) != "v1" {
+		t.Fatalf("expected v1 before commit, got %q", b)
+	}
+	if err := af.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "v2" {
+		t.Fatalf("expected v2, got %q", b)
+	}
+	if err := af.Commit(); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if err := af.Abort(); err != nil {
+		t.Fatalf("expected abort after commit to do nothing, got %v", err)
+	}
+
+	af, err = CreateAtomicFile(path, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	af.Write([]byte("v3"))
+	if err := af.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "v2" {
+		t.Fatalf("expected v2 after abort, got %q", b)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected temp files to be removed, got %d entries", len(entries))
+	}
+
+	missing := filepath.Join(dir, "nope", "x")
+	if err := WriteFileAtomic(missing, nil, 0644); err == nil {
+		t.Fatal("expected error for missing directory")
+	}
+}
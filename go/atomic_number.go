@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"encoding/json"
+	"math"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ANumber is an atomic number. Integers and floats are stored as their bits
+// in an atomic.Uint64, with compare-and-swap loops used for arithmetic where
+// atomic instructions can't be (floats and integers narrower than 64 bits).
+// The zero value is ready to use and holds 0.
+type ANumber[T Number] struct {
+	v atomic.Uint64
+}
+
+// NewANumber creates a new ANumber holding the given value.
+func NewANumber[T Number](t T) *ANumber[T] {
+	a := &ANumber[T]{}
+	a.Store(t)
+	return a
+}
+
+func isFloatType[T Number]() bool {
+	one := T(1)
+	return one/2 != 0
+}
+
+func toBits[T Number](t T) uint64 {
+	if isFloatType[T]() {
+		if unsafe.Sizeof(t) == 4 {
+			return uint64(math.Float32bits(float32(t)))
+		}
+		return math.Float64bits(float64(t))
+	}
+	return uint64(t)
+}
+
+func fromBits[T Number](bits uint64) T {
+	var t T
+	if isFloatType[T]() {
+		if unsafe.Sizeof(t) == 4 {
+			return T(math.Float32frombits(uint32(bits)))
+		}
+		return T(math.Float64frombits(bits))
+	}
+	return T(bits)
+}
+
+// Load loads the value.
+func (a *ANumber[T]) Load() T {
+	return fromBits[T](a.v.Load())
+}
+
+// Store stores the value.
+func (a *ANumber[T]) Store(t T) {
+	a.v.Store(toBits(t))
+}
+
+// Swap stores the new value, returning the old value.
+func (a *ANumber[T]) Swap(t T) T {
+	return fromBits[T](a.v.Swap(toBits(t)))
+}
+
+// CompareAndSwap swaps the value with new if it is currently old, returning
+// true if swapped. Floats are compared by their bits, so, e.g., NaN can be
+// swapped and 0 and -0 are different.
+func (a *ANumber[T]) CompareAndSwap(old, new T) bool {
+	return a.v.CompareAndSwap(toBits(old), toBits(new))
+}
+
+// Add adds the delta, returning the new value.
+func (a *ANumber[T]) Add(delta T) T {
+	var t T
+	if !isFloatType[T]() && unsafe.Sizeof(t) == 8 {
+		return T(a.v.Add(uint64(delta)))
+	}
+	return a.update(func(t T) T { return t + delta })
+}
+
+// Sub subtracts the delta, returning the new value.
+func (a *ANumber[T]) Sub(delta T) T {
+	var t T
+	if !isFloatType[T]() && unsafe.Sizeof(t) == 8 {
+		return T(a.v.Add(-uint64(delta)))
+	}
+	return a.update(func(t T) T { return t - delta })
+}
+
+// Min stores the given value if it is less than the current value, returning
+// the new value.
+func (a *ANumber[T]) Min(t T) T {
+	return a.update(func(cur T) T {
+		if t < cur {
+			return t
+		}
+		return cur
+	})
+}
+
+// Max stores the given value if it is greater than the current value,
+// returning the new value.
+func (a *ANumber[T]) Max(t T) T {
+	return a.update(func(cur T) T {
+		if t > cur {
+			return t
+		}
+		return cur
+	})
+}
+
+// update stores the result of f called with the current value using a
+// compare-and-swap loop, returning the new value.
+func (a *ANumber[T]) update(f func(T) T) T {
+	for {
+		oldBits := a.v.Load()
+		newT := f(fromBits[T](oldBits))
+		newBits := toBits(newT)
+		if oldBits == newBits || a.v.CompareAndSwap(oldBits, newBits) {
+			return newT
+		}
+	}
+}
+
+func (a *ANumber[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Load())
+}
+
+func (a *ANumber[T]) UnmarshalJSON(data []byte) error {
+	var t T
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	a.Store(t)
+	return nil
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestANumber(t *testing.T) {
+	t.Run("Int64", func(t *testing.T) {
+		testANumber[int64](t)
+	})
+	t.Run("Int8", func(t *testing.T) {
+		testANumber[int8](t)
+	})
+	t.Run("Uint32", func(t *testing.T) {
+		testANumber[uint32](t)
+	})
+	t.Run("Float32", func(t *testing.T) {
+		testANumber[float32](t)
+	})
+	t.Run("Float64", func(t *testing.T) {
+		testANumber[float64](t)
+	})
+
+	var i8 ANumber[int8]
+	i8.Store(127)
+	if v := i8.Add(1); v != -128 {
+		t.Fatalf("expected overflow to -128, got %d", v)
+	}
+	if !i8.CompareAndSwap(-128, 5) {
+		t.Fatal("CompareAndSwap failed after overflow")
+	}
+
+	f := NewANumber(1.5)
+	if v := f.Add(0.25); v != 1.75 {
+		t.Fatalf("expected 1.75, got %v", v)
+	}
+	b, err := json.Marshal(f)
+	if err != nil || string(b) != "1.75" {
+		t.Fatalf("bad marshal: %s %v", b, err)
+	}
+	if err := json.Unmarshal([]byte("-2.5"), f); err != nil || f.Load() != -2.5 {
+		t.Fatalf("bad unmarshal: %v %v", f.Load(), err)
+	}
+}
+
+func testANumber[T Number](t *testing.T) {
+	var a ANumber[T]
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Add(2)
+			a.Sub(1)
+		}()
+	}
+	wg.Wait()
+	if v := a.Load(); v != 50 {
+		t.Fatalf("expected 50, got %v", v)
+	}
+	if v := a.Max(49); v != 50 {
+		t.Fatalf("expected max of 50, got %v", v)
+	}
+	if v := a.Max(60); v != 60 {
+		t.Fatalf("expected max of 60, got %v", v)
+	}
+	if v := a.Min(3); v != 3 {
+		t.Fatalf("expected min of 3, got %v", v)
+	}
+	if old := a.Swap(7); old != 3 || a.Load() != 7 {
+		t.Fatalf("bad swap: %v %v", old, a.Load())
+	}
+	if a.CompareAndSwap(6, 8) || !a.CompareAndSwap(7, 8) {
+		t.Fatal("bad CompareAndSwap")
+	}
+}
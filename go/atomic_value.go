@@ -2,9 +2,11 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"reflect"
+	"sync"
 	"sync/atomic"
 )
 
@@ -106,6 +108,15 @@ func (a *AValue[T]) LoadSafe() (t T, ok bool) {
 	return
 }
 
+// LoadErr loads the value, returning ErrEmpty if there was no value stored.
+func (a *AValue[T]) LoadErr() (T, error) {
+	t, ok := a.LoadSafe()
+	if !ok {
+		return t, ErrEmpty
+	}
+	return t, nil
+}
+
 // Store stores a value.
 func (a *AValue[T]) Store(t T) {
 	a.v.Store(t)
@@ -162,3 +173,148 @@ func (a *AValue[T]) UnmarshalJSON(data []byte) (err error) {
 	a.v.Store(valPtr.Elem().Interface())
 	return
 }
+
+// AFlag is an atomic boolean flag that can be waited on (e.g., a shutdown
+// flag). The zero value is ready to use and is cleared.
+type AFlag struct {
+	v   atomic.Bool
+	mtx sync.Mutex
+	// Closed when the flag is set; nil until needed
+	ch chan struct{}
+}
+
+// IsSet returns whether the flag is set.
+func (f *AFlag) IsSet() bool {
+	return f.v.Load()
+}
+
+// Set sets the flag, waking all waiters. Returns false if it was already set.
+func (f *AFlag) Set() bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.v.Swap(true) {
+		return false
+	}
+	if f.ch != nil {
+		close(f.ch)
+		f.ch = nil
+	}
+	return true
+}
+
+// TrySet sets the flag if it isn't set, returning true if this call set it.
+// Useful for ensuring something only happens once.
+func (f *AFlag) TrySet() bool {
+	if f.v.Load() {
+		return false
+	}
+	return f.Set()
+}
+
+// Clear clears the flag. Returns false if it was already cleared.
+func (f *AFlag) Clear() bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.v.Swap(false)
+}
+
+// Done returns a chan that is closed once the flag is set. If the flag is
+// already set, the returned chan is closed.
+func (f *AFlag) Done() <-chan struct{} {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.v.Load() {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	if f.ch == nil {
+		f.ch = make(chan struct{})
+	}
+	return f.ch
+}
+
+// Wait waits for the flag to be set.
+func (f *AFlag) Wait() {
+	if !f.v.Load() {
+		<-f.Done()
+	}
+}
+
+// WaitContext waits for the flag to be set, returning ErrCanceled (wrapping
+// the context's error) if the context is done first.
+func (f *AFlag) WaitContext(ctx context.Context) error {
+	if f.v.Load() {
+		return nil
+	}
+	select {
+	case <-f.Done():
+		return nil
+	case <-ctx.Done():
+		return newCanceledError(ctx)
+	}
+}
+
+// AError is an atomic error, useful for recording the first error from
+// multiple goroutines. The zero value is ready to use and holds no error.
+type AError struct {
+	v AValue[ErrorValue]
+}
+
+// NewAError creates a new AError holding the given error.
+func NewAError(err error) *AError {
+	a := &AError{}
+	a.Store(err)
+	return a
+}
+
+// Load loads the error, returning nil if there is none.
+func (a *AError) Load() error {
+	ev, _ := a.v.LoadSafe()
+	return ev.Error
+}
+
+// Store stores the error. Storing nil clears the error.
+func (a *AError) Store(err error) {
+	a.v.Store(ErrorValue{Error: err})
+}
+
+// Swap stores the error, returning the old error.
+func (a *AError) Swap(err error) error {
+	old, _ := a.v.Swap(ErrorValue{Error: err})
+	return old.Error
+}
+
+// StoreIfEmpty stores the error if there is currently no error (nil errors
+// are ignored). Returns true if stored.
+func (a *AError) StoreIfEmpty(err error) bool {
+	if err == nil {
+		return false
+	}
+	for {
+		old, ok := a.v.LoadSafe()
+		if ok && old.Error != nil {
+			return false
+		}
+		if !ok {
+			if a.v.StoreIfEmpty(ErrorValue{Error: err}) {
+				return true
+			}
+			continue
+		}
+		if a.v.CompareAndSwap(old, ErrorValue{Error: err}) {
+			return true
+		}
+	}
+}
+
+// Is returns whether the stored error matches the target (see errors.Is).
+func (a *AError) Is(target error) bool {
+	return errors.Is(a.Load(), target)
+}
+
+// As finds the first error in the stored error's chain matching the target
+// (see errors.As).
+func (a *AError) As(target any) bool {
+	return errors.As(a.Load(), target)
+}
@@ -2,8 +2,12 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 )
 
 func TestAValueJSON(t *testing.T) {
@@ -45,3 +49,67 @@ func TestAValueJSON(t *testing.T) {
 		t.Fatalf("bytes not equal: %v != %v", b2, b)
 	}
 }
+
+func TestAValueLoadErr(t *testing.T) {
+	var a AValue[int]
+	if _, err := a.LoadErr(); err != ErrEmpty {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+	a.Store(5)
+	if v, err := a.LoadErr(); v != 5 || err != nil {
+		t.Fatalf("expected 5, nil, got %d, %v", v, err)
+	}
+}
+
+func TestAFlag(t *testing.T) {
+	var f AFlag
+	done := make(chan Unit)
+	go func() {
+		f.Wait()
+		close(done)
+	}()
+	if !f.TrySet() || f.TrySet() || f.Set() {
+		t.Fatal("flag set multiple times")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waiter not woken")
+	}
+	if !f.Clear() || f.IsSet() {
+		t.Fatal("failed to clear flag")
+	}
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*10,
+	)
+	defer cancel()
+	if err := f.WaitContext(ctx); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	f.Set()
+	if err := f.WaitContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAError(t *testing.T) {
+	var ae AError
+	if ae.Load() != nil || ae.StoreIfEmpty(nil) {
+		t.Fatal("expected no error")
+	}
+	if !ae.StoreIfEmpty(ErrClosed) || ae.StoreIfEmpty(ErrEmpty) {
+		t.Fatal("StoreIfEmpty should only store the first error")
+	}
+	wrapped := fmt.Errorf("wrapped: %w", &PanicError{Value: 1})
+	if old := ae.Swap(wrapped); old != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", old)
+	}
+	var pe *PanicError
+	if !ae.As(&pe) || pe.Value != 1 || ae.Is(ErrClosed) {
+		t.Fatal("bad As/Is passthrough")
+	}
+	ae.Store(nil)
+	if !ae.StoreIfEmpty(ErrEmpty) || !ae.Is(ErrEmpty) {
+		t.Fatal("StoreIfEmpty failed after clearing")
+	}
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+)
+
+// AwaitOpts are options for AwaitAll.
+type AwaitOpts struct {
+	// TaskTimeout is the timeout for each task. If 0, only the context passed
+	// to AwaitAll limits tasks.
+	TaskTimeout time.Duration
+	// MaxConcurrency is the maximum number of tasks run at once. If less than
+	// 1, all tasks are run at once.
+	MaxConcurrency int
+}
+
+// AwaitResult is the result of a task run by AwaitAll.
+type AwaitResult[T any] struct {
+	// Value is the value returned by the task.
+	Value T
+	// Err is the error returned by the task, a *PanicError if the task
+	// panicked, ErrTimedOut if the task timed out, or ErrCanceled (wrapping
+	// the context's error) if the context passed to AwaitAll was done before
+	// the task finished.
+	Err error
+	// Duration is how long the task ran or, if it didn't finish, how long it
+	// was waited on before timing out or being canceled.
+	Duration time.Duration
+	// Completed is whether the task returned (or panicked), meaning Value and
+	// Err came from the task itself.
+	Completed bool
+}
+
+// AwaitAll runs all the tasks concurrently and waits for all of them to
+// finish, returning a result for each task in the same order as the tasks.
+// Unlike errgroup-style helpers, a failed task doesn't cancel the others. If
+// the context is done before all tasks finish, AwaitAll returns right away;
+// the results of finished tasks are kept while unfinished tasks have
+// ErrCanceled set as their error. Tasks that don't respect their context may
+// keep running in the background after AwaitAll returns, though their
+// results are discarded.
+func AwaitAll[T any](
+	ctx context.Context,
+	tasks []func(context.Context) (T, error),
+	opts AwaitOpts,
+) []AwaitResult[T] {
+	results := make([]AwaitResult[T], len(tasks))
+	if len(tasks) == 0 {
+		return results
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type indexed struct {
+		i   int
+		res AwaitResult[T]
+	}
+	ch := make(chan indexed, len(tasks))
+	var sem chan struct{}
+	if opts.MaxConcurrency > 0 && opts.MaxConcurrency < len(tasks) {
+		sem = make(chan struct{}, opts.MaxConcurrency)
+	}
+	for i, task := range tasks {
+		go func() {
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					return
+				}
+			}
+			ch <- indexed{i, awaitTask(ctx, task, opts.TaskTimeout)}
+		}()
+	}
+
+	finished := make([]bool, len(tasks))
+	start := time.Now()
+	for left := len(tasks); left > 0; left-- {
+		select {
+		case r := <-ch:
+			results[r.i], finished[r.i] = r.res, true
+			continue
+		case <-ctx.Done():
+		}
+		err := newCanceledError(ctx)
+		for i, done := range finished {
+			if !done {
+				results[i].Err = err
+				results[i].Duration = time.Since(start)
+			}
+		}
+		// Pick up any results that came in at the same time.
+		for {
+			select {
+			case r := <-ch:
+				results[r.i] = r.res
+			default:
+				return results
+			}
+		}
+	}
+	return results
+}
+
+func awaitTask[T any](
+	ctx context.Context,
+	task func(context.Context) (T, error),
+	timeout time.Duration,
+) (res AwaitResult[T]) {
+	start := time.Now()
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ch := make(chan AwaitResult[T], 1)
+	go func() {
+		var r AwaitResult[T]
+		defer func() {
+			if v := recover(); v != nil {
+				r.Err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+			r.Completed = true
+			ch <- r
+		}()
+		r.Value, r.Err = task(ctx)
+	}()
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		if parent.Err() != nil {
+			res.Err = newCanceledError(parent)
+		} else {
+			res.Err = ErrTimedOut
+		}
+	}
+	res.Duration = time.Since(start)
+	return
+}
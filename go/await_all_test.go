@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAwaitAll(t *testing.T) {
+	testErr := errors.New("test error")
+	tasks := []func(context.Context) (int, error){
+		func(context.Context) (int, error) { return 1, nil },
+		func(context.Context) (int, error) { return 0, testErr },
+		func(context.Context) (int, error) { panic("test panic") },
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+		// Ignores its context
+		func(context.Context) (int, error) {
+			time.Sleep(time.Second)
+			return 5, nil
+		},
+		func(context.Context) (int, error) {
+			time.Sleep(time.Millisecond)
+			return 6, nil
+		},
+	}
+	results := AwaitAll(
+		context.Background(), tasks,
+		AwaitOpts{TaskTimeout: time.Millisecond * 20},
+	)
+	if len(results) != len(tasks) {
+		t.Fatalf("expected %d results, got %d", len(tasks), len(results))
+	}
+	if r := results[0]; r.Value != 1 || r.Err != nil || !r.Completed {
+		t.Fatalf("unexpected result 0: %+v", r)
+	}
+	if r := results[1]; r.Err != testErr || !r.Completed {
+		t.Fatalf("unexpected result 1: %+v", r)
+	}
+	if r := results[2]; !ErrAs[*PanicError](r.Err) || !r.Completed {
+		t.Fatalf("unexpected result 2: %+v", r)
+	}
+	// The task may finish (returning the context's error) before AwaitAll
+	// notices the timeout.
+	if r := results[3]; r.Err != ErrTimedOut &&
+		!errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected result 3: %+v", r)
+	}
+	if r := results[4]; r.Err != ErrTimedOut || r.Completed {
+		t.Fatalf("unexpected result 4: %+v", r)
+	} else if r.Duration >= time.Second {
+		t.Fatalf("waited too long on result 4: %v", r.Duration)
+	}
+	if r := results[5]; r.Value != 6 || r.Err != nil {
+		t.Fatalf("unexpected result 5: %+v", r)
+	} else if r.Duration < time.Millisecond {
+		t.Fatalf("expected duration of at least 1ms, got %v", r.Duration)
+	}
+}
+
+func TestAwaitAllDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*20,
+	)
+	defer cancel()
+	var running, maxRunning atomic.Int32
+	tasks := make([]func(context.Context) (int, error), 6)
+	for i := range tasks {
+		tasks[i] = func(context.Context) (int, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for m := maxRunning.Load(); n > m; m = maxRunning.Load() {
+				maxRunning.CompareAndSwap(m, n)
+			}
+			if i%2 == 0 {
+				return i, nil
+			}
+			time.Sleep(time.Second)
+			return i, nil
+		}
+	}
+	start := time.Now()
+	results := AwaitAll(ctx, tasks, AwaitOpts{MaxConcurrency: 2})
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Fatalf("deadline not honored, took %v", elapsed)
+	}
+	for i, r := range results {
+		if i%2 == 0 && r.Completed {
+			if r.Value != i || r.Err != nil {
+				t.Fatalf("unexpected result %d: %+v", i, r)
+			}
+			continue
+		}
+		if !errors.Is(r.Err, ErrCanceled) ||
+			!errors.Is(r.Err, context.DeadlineExceeded) {
+			t.Fatalf("expected canceled result %d, got %+v", i, r)
+		}
+	}
+	if n := maxRunning.Load(); n > 2 {
+		t.Fatalf("expected at most 2 tasks running at once, got %d", n)
+	}
+
+	if results := AwaitAll[int](ctx, nil, AwaitOpts{}); len(results) != 0 {
+		t.Fatalf("expected no results, got %v", results)
+	}
+}
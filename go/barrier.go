@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Barrier makes a fixed number of parties wait for each other. Once all
+// parties have called Await, they are all released and the barrier is reset
+// for the next generation, so it can be reused.
+type Barrier struct {
+	mtx     sync.Mutex
+	parties int
+	// waiting is the parties waiting in the current generation, in the order
+	// they arrived.
+	waiting []*int
+	gen     uint64
+	release chan struct{}
+}
+
+// NewBarrier creates a new Barrier for n parties. Panics if n is less than 1.
+func NewBarrier(n int) *Barrier {
+	if n < 1 {
+		panic("utils: barrier must have at least 1 party")
+	}
+	return &Barrier{parties: n, release: make(chan struct{})}
+}
+
+// Await waits for all parties to arrive, returning the arrival index of the
+// caller, from 0 (the first to arrive) to n-1 (the last, which releases the
+// others without waiting). If the context is done first, the caller's
+// arrival is withdrawn and ErrCanceled (wrapping the context's error) is
+// returned with an index of -1, unless the barrier was released at the same
+// time. Indexes are
+// assigned when the barrier is released, counting only the parties that
+// didn't withdraw, so each index is returned once per generation.
+func (b *Barrier) Await(ctx context.Context) (int, error) {
+	b.mtx.Lock()
+	if len(b.waiting)+1 == b.parties {
+		for i, index := range b.waiting {
+			*index = i
+		}
+		index := len(b.waiting)
+		b.waiting = nil
+		b.gen++
+		close(b.release)
+		b.release = make(chan struct{})
+		b.mtx.Unlock()
+		return index, nil
+	}
+	index := new(int)
+	b.waiting = append(b.waiting, index)
+	gen, release := b.gen, b.release
+	b.mtx.Unlock()
+
+	select {
+	case <-release:
+		return *index, nil
+	case <-ctx.Done():
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.gen != gen {
+		return *index, nil
+	}
+	b.waiting = slices.DeleteFunc(b.waiting, func(ip *int) bool {
+		return ip == index
+	})
+	return -1, newCanceledError(ctx)
+}
+
+// Parties returns the number of parties.
+func (b *Barrier) Parties() int {
+	return b.parties
+}
+
+// Waiting returns the number of parties currently waiting.
+func (b *Barrier) Waiting() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return len(b.waiting)
+}
+
+// Generation returns the number of times the barrier has been released.
+func (b *Barrier) Generation() uint64 {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.gen
+}
+
+// Phaser is a reusable barrier where the number of parties can change.
+// Parties register, then arrive at the end of each phase. Once all
+// registered parties have arrived, the phase advances, releasing those
+// waiting.
+type Phaser struct {
+	mtx     sync.Mutex
+	parties int
+	arrived int
+	phase   uint64
+	release chan struct{}
+}
+
+// NewPhaser creates a new Phaser with the given number of parties already
+// registered.
+func NewPhaser(parties int) *Phaser {
+	return &Phaser{parties: max(parties, 0), release: make(chan struct{})}
+}
+
+// Register adds a party, returning the current phase. The new party must
+// arrive for the current phase to advance.
+func (p *Phaser) Register() uint64 {
+	return p.BulkRegister(1)
+}
+
+// BulkRegister adds n parties, returning the current phase.
+func (p *Phaser) BulkRegister(n int) uint64 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.parties += n
+	return p.phase
+}
+
+// Arrive records the arrival of a party without waiting, returning the
+// phase arrived at. Panics if there are no unarrived parties.
+func (p *Phaser) Arrive() uint64 {
+	phase, _ := p.arrive(false)
+	return phase
+}
+
+// ArriveAndDeregister records the arrival of a party and removes it,
+// returning the phase arrived at. Panics if there are no unarrived parties.
+func (p *Phaser) ArriveAndDeregister() uint64 {
+	phase, _ := p.arrive(true)
+	return phase
+}
+
+// ArriveAndAwait records the arrival of a party and waits for the phase to
+// advance, returning the phase arrived at. If the context is done first,
+// ErrCanceled (wrapping the context's error) is returned; the arrival still
+// counts. Panics if there are no unarrived parties.
+func (p *Phaser) ArriveAndAwait(ctx context.Context) (uint64, error) {
+	phase, release := p.arrive(false)
+	return phase, p.wait(ctx, release)
+}
+
+func (p *Phaser) arrive(deregister bool) (uint64, chan struct{}) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.arrived >= p.parties {
+		panic("utils: phaser arrival without unarrived party")
+	}
+	phase, release := p.phase, p.release
+	if deregister {
+		p.parties--
+	} else {
+		p.arrived++
+	}
+	if p.parties > 0 && p.arrived == p.parties {
+		p.arrived = 0
+		p.phase++
+		close(p.release)
+		p.release = make(chan struct{})
+	}
+	return phase, release
+}
+
+// AwaitAdvance waits for the phase to advance past the given phase. Returns
+// right away if the current phase is different.
+func (p *Phaser) AwaitAdvance(ctx context.Context, phase uint64) error {
+	p.mtx.Lock()
+	if p.phase != phase {
+		p.mtx.Unlock()
+		return nil
+	}
+	release := p.release
+	p.mtx.Unlock()
+	return p.wait(ctx, release)
+}
+
+func (p *Phaser) wait(ctx context.Context, release chan struct{}) error {
+	select {
+	case <-release:
+		return nil
+	default:
+	}
+	select {
+	case <-release:
+		return nil
+	case <-ctx.Done():
+		return newCanceledError(ctx)
+	}
+}
+
+// Phase returns the current phase.
+func (p *Phaser) Phase() uint64 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.phase
+}
+
+// Parties returns the number of registered parties.
+func (p *Phaser) Parties() int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.parties
+}
+
+// Arrived returns the number of parties that have arrived at the current
+// phase.
+func (p *Phaser) Arrived() int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.arrived
+}
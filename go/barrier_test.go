@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	const n, rounds = 4, 5
+	b := NewBarrier(n)
+	var counter atomic.Int32
+	var wg sync.WaitGroup
+	lasts := make(chan int, n*rounds)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				counter.Add(1)
+				index, err := b.Await(context.Background())
+				if err != nil {
+					t.Error("unexpected error: ", err)
+					return
+				}
+				// Everyone has incremented for this round.
+				if c := counter.Load(); c < int32(n*(r+1)) {
+					t.Errorf("released early: counter %d in round %d", c, r)
+				}
+				if index == n-1 {
+					lasts <- r
+				}
+				b.Await(context.Background())
+			}
+		}()
+	}
+	wg.Wait()
+	close(lasts)
+	if len(lasts) != rounds {
+		t.Fatalf("expected %d last arrivals, got %d", rounds, len(lasts))
+	}
+	if g := b.Generation(); g != rounds*2 {
+		t.Fatalf("expected generation %d, got %d", rounds*2, g)
+	}
+}
+
+func TestBarrierCancel(t *testing.T) {
+	b := NewBarrier(2)
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond,
+	)
+	defer cancel()
+	if _, err := b.Await(ctx); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	if w := b.Waiting(); w != 0 {
+		t.Fatalf("expected canceled arrival withdrawn, got %d waiting", w)
+	}
+
+	done := make(chan int)
+	go func() {
+		index, _ := b.Await(context.Background())
+		done <- index
+	}()
+	if index, err := b.Await(context.Background()); err != nil {
+		t.Fatal("unexpected error: ", err)
+	} else if other := <-done; index+other != 1 {
+		t.Fatalf("expected indexes 0 and 1, got %d and %d", index, other)
+	}
+}
+
+func TestBarrierCancelThenArrive(t *testing.T) {
+	b := NewBarrier(3)
+	waitFor := func(n int) {
+		for b.Waiting() != n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := b.Await(ctx)
+		canceled <- err
+	}()
+	waitFor(1)
+	indexes := make(chan int, 3)
+	await := func() {
+		index, _ := b.Await(context.Background())
+		indexes <- index
+	}
+	go await()
+	waitFor(2)
+	// The first arrival withdraws, so the rest are renumbered.
+	cancel()
+	if err := <-canceled; !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	go await()
+	waitFor(2)
+	await()
+	seen := make([]bool, 3)
+	for i := 0; i < 3; i++ {
+		index := <-indexes
+		if index < 0 || index > 2 || seen[index] {
+			t.Fatalf("unexpected or repeated index %d", index)
+		}
+		seen[index] = true
+	}
+}
+
+func TestPhaser(t *testing.T) {
+	data := RangeSlice(8)
+	p := NewPhaser(1)
+	var wg sync.WaitGroup
+	// Each worker doubles its element, then adds its neighbor's doubled
+	// value, which requires all doubling to be done first.
+	sums := make([]int, len(data))
+	for i := range data {
+		p.Register()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data[i] *= 2
+			if _, err := p.ArriveAndAwait(context.Background()); err != nil {
+				t.Error("unexpected error: ", err)
+			}
+			sums[i] = data[i] + data[(i+1)%len(data)]
+			p.ArriveAndDeregister()
+		}()
+	}
+	// The main party lets the workers start the first phase.
+	if phase := p.Arrive(); phase != 0 {
+		t.Fatalf("expected phase 0, got %d", phase)
+	}
+	if err := p.AwaitAdvance(context.Background(), 0); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	wg.Wait()
+	for i, sum := range sums {
+		if want := 2*i + 2*((i+1)%len(data)); sum != want {
+			t.Fatalf("expected sums[%d] = %d, got %d", i, want, sum)
+		}
+	}
+	// Only the main party is left and it hasn't arrived at phase 1.
+	if p.Parties() != 1 || p.Phase() != 1 {
+		t.Fatalf("expected 1 party in phase 1, got %d in %d",
+			p.Parties(), p.Phase())
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond,
+	)
+	defer cancel()
+	p.Register()
+	if _, err := p.ArriveAndAwait(ctx); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	if a := p.Arrived(); a != 1 {
+		t.Fatalf("expected 1 arrived, got %d", a)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic on extra arrival")
+			}
+		}()
+		NewPhaser(0).Arrive()
+	}()
+}
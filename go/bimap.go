@@ -0,0 +1,138 @@
+package utils
+
+// BiMap is a bidirectional map, where both keys and values are unique, so
+// lookups by value are as fast as lookups by key.
+type BiMap[K, V comparable] struct {
+	fwd map[K]V
+	inv map[V]K
+}
+
+// NewBiMap creates a new BiMap.
+func NewBiMap[K, V comparable]() *BiMap[K, V] {
+	return &BiMap[K, V]{fwd: make(map[K]V), inv: make(map[V]K)}
+}
+
+// BiMapFromMap creates a new BiMap from the given map, returning false if the
+// map's values aren't unique.
+func BiMapFromMap[K, V comparable](m map[K]V) (*BiMap[K, V], bool) {
+	bm := &BiMap[K, V]{
+		fwd: make(map[K]V, len(m)),
+		inv: make(map[V]K, len(m)),
+	}
+	for k, v := range m {
+		if !bm.Insert(k, v) {
+			return nil, false
+		}
+	}
+	return bm, true
+}
+
+// Insert inserts the key/value pair only if neither the key nor the value
+// already exist in the BiMap. Otherwise, false is returned.
+func (bm *BiMap[K, V]) Insert(key K, value V) bool {
+	if _, ok := bm.fwd[key]; ok {
+		return false
+	}
+	if _, ok := bm.inv[value]; ok {
+		return false
+	}
+	bm.fwd[key], bm.inv[value] = value, key
+	return true
+}
+
+// Set sets the key to the value, removing any existing pairs with the key or
+// the value.
+func (bm *BiMap[K, V]) Set(key K, value V) {
+	bm.Delete(key)
+	bm.DeleteByValue(value)
+	bm.fwd[key], bm.inv[value] = value, key
+}
+
+// Get gets the value for the key or returns the default.
+func (bm *BiMap[K, V]) Get(key K) V {
+	return bm.fwd[key]
+}
+
+// GetOk gets the value for the key, returning true if it exists, or returns
+// the default and false otherwise.
+func (bm *BiMap[K, V]) GetOk(key K) (V, bool) {
+	v, ok := bm.fwd[key]
+	return v, ok
+}
+
+// GetByValue gets the key for the value, returning true if it exists, or
+// returns the default and false otherwise.
+func (bm *BiMap[K, V]) GetByValue(value V) (K, bool) {
+	k, ok := bm.inv[value]
+	return k, ok
+}
+
+// ContainsKey returns whether the BiMap contains the given key.
+func (bm *BiMap[K, V]) ContainsKey(key K) bool {
+	_, ok := bm.fwd[key]
+	return ok
+}
+
+// ContainsValue returns whether the BiMap contains the given value.
+func (bm *BiMap[K, V]) ContainsValue(value V) bool {
+	_, ok := bm.inv[value]
+	return ok
+}
+
+// Delete deletes the pair with the given key, returning the value and true if
+// it existed.
+func (bm *BiMap[K, V]) Delete(key K) (V, bool) {
+	v, ok := bm.fwd[key]
+	if ok {
+		delete(bm.fwd, key)
+		delete(bm.inv, v)
+	}
+	return v, ok
+}
+
+// DeleteByValue deletes the pair with the given value, returning the key and
+// true if it existed.
+func (bm *BiMap[K, V]) DeleteByValue(value V) (K, bool) {
+	k, ok := bm.inv[value]
+	if ok {
+		delete(bm.inv, value)
+		delete(bm.fwd, k)
+	}
+	return k, ok
+}
+
+// Len returns the number of pairs in the BiMap.
+func (bm *BiMap[K, V]) Len() int {
+	return len(bm.fwd)
+}
+
+// Range iterates over each pair in random order, applying a given function
+// that returns whether the iterations should stop.
+func (bm *BiMap[K, V]) Range(f func(K, V) bool) {
+	for k, v := range bm.fwd {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// Inverse returns a view of the BiMap with the keys and values swapped.
+// Changes to either are reflected in the other.
+func (bm *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	return &BiMap[V, K]{fwd: bm.inv, inv: bm.fwd}
+}
+
+// Clone clones the BiMap.
+func (bm *BiMap[K, V]) Clone() *BiMap[K, V] {
+	return &BiMap[K, V]{fwd: CloneMap(bm.fwd), inv: CloneMap(bm.inv)}
+}
+
+// ToGoMap returns a new map of the keys to values.
+func (bm *BiMap[K, V]) ToGoMap() map[K]V {
+	return CloneMap(bm.fwd)
+}
+
+// ToMap returns a new Map of the keys to values.
+func (bm *BiMap[K, V]) ToMap() *Map[K, V] {
+	return MapFromMap(bm.ToGoMap())
+}
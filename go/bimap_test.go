@@ -0,0 +1,75 @@
+package utils
+
+import "testing"
+
+func TestBiMap(t *testing.T) {
+	bm := NewBiMap[string, int]()
+	if !bm.Insert("a", 1) || !bm.Insert("b", 2) {
+		t.Fatal("expected inserts to succeed")
+	}
+	// Neither the key nor the value can already exist.
+	if bm.Insert("a", 3) || bm.Insert("c", 1) {
+		t.Fatal("expected inserts of existing key or value to fail")
+	}
+	if v := bm.Get("a"); v != 1 || bm.Len() != 2 {
+		t.Fatalf("expected a=1 and 2 pairs, got %d and %d", v, bm.Len())
+	}
+
+	// Overwriting removes the stale reverse mappings.
+	bm.Set("a", 3)
+	if bm.ContainsValue(1) {
+		t.Fatal("expected stale value 1 to be removed")
+	}
+	if k, ok := bm.GetByValue(3); !ok || k != "a" {
+		t.Fatalf("expected 3 to map to a, got %q, %v", k, ok)
+	}
+	bm.Set("c", 2)
+	if bm.ContainsKey("b") {
+		t.Fatal("expected stale key b to be removed")
+	}
+	if k, _ := bm.GetByValue(2); k != "c" || bm.Len() != 2 {
+		t.Fatalf("expected 2 to map to c with 2 pairs, got %q", k)
+	}
+	bm.Set("a", 2)
+	if bm.ContainsKey("c") || bm.ContainsValue(3) || bm.Len() != 1 {
+		t.Fatalf("expected only a=2, got %v", bm.ToGoMap())
+	}
+
+	bm.Set("b", 4)
+	if v, ok := bm.Delete("a"); !ok || v != 2 {
+		t.Fatalf("expected to delete a=2, got %d, %v", v, ok)
+	}
+	if bm.ContainsValue(2) {
+		t.Fatal("expected value of deleted key to be removed")
+	}
+	if _, ok := bm.Delete("a"); ok {
+		t.Fatal("expected deleting missing key to fail")
+	}
+	if k, ok := bm.DeleteByValue(4); !ok || k != "b" {
+		t.Fatalf("expected to delete b=4, got %q, %v", k, ok)
+	}
+	if bm.ContainsKey("b") || bm.Len() != 0 {
+		t.Fatal("expected key of deleted value to be removed")
+	}
+	if _, ok := bm.DeleteByValue(4); ok {
+		t.Fatal("expected deleting missing value to fail")
+	}
+
+	// The inverse shares the maps.
+	inv := bm.Inverse()
+	inv.Set(5, "e")
+	if v, ok := bm.GetOk("e"); !ok || v != 5 {
+		t.Fatalf("expected e=5 through inverse, got %d, %v", v, ok)
+	}
+
+	if _, ok := BiMapFromMap(map[string]int{"x": 1, "y": 1}); ok {
+		t.Fatal("expected failure with duplicate values")
+	}
+	bm, ok := BiMapFromMap(map[string]int{"x": 1, "y": 2})
+	if !ok || bm.Len() != 2 {
+		t.Fatal("expected BiMap from unique map")
+	}
+	if k, _ := bm.GetByValue(2); k != "y" {
+		t.Fatalf("expected 2 to map to y, got %q", k)
+	}
+}
@@ -88,35 +88,29 @@ func GetF(b []byte) float64 {
 	return math.Float64frombits(Get8(b))
 }
 
-type Unsigned interface {
-	~uint16 | uint32 | uint64
-}
-
-func Put[T Unsigned](i int) []byte {
-	u := T(i)
-	size := unsafe.Sizeof(u)
-	b := make([]byte, size)
-	for i := size - 1; i >= 0; i-- {
-		b[i] = byte(u)
-		u >>= 8
-	}
+// Put returns the big-endian encoding of u, using the size of T.
+func Put[T Unsigned](u T) []byte {
+	b := make([]byte, unsafe.Sizeof(u))
+	Place(b, u)
 	return b
 }
 
-func Place[T Unsigned](b []byte, i int) {
-	u := T(i)
-	size := unsafe.Sizeof(u)
-	for i := size - 1; i >= 0; i-- {
-		b[i] = byte(u)
-		u >>= 8
+// Place writes the big-endian encoding of u to b, using the size of T.
+// Panics if b is too short.
+func Place[T Unsigned](b []byte, u T) {
+	v := uint64(u)
+	for i := int(unsafe.Sizeof(u)) - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
 	}
 }
 
+// Get returns the big-endian decoding of b, using the size of T. Panics if b
+// is too short.
 func Get[T Unsigned](b []byte) T {
-	var res T
-	size := int(unsafe.Sizeof(res))
-	for i := 0; i < size; i++ {
-		res = (res << 8) | T(b[i])
+	var res uint64
+	for i := 0; i < int(unsafe.Sizeof(T(0))); i++ {
+		res = res<<8 | uint64(b[i])
 	}
-	return res
+	return T(res)
 }
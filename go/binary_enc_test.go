@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+type testUint32 uint32
+
+func TestPutGet(t *testing.T) {
+	if got := Put[uint8](0xab); !bytes.Equal(got, []byte{0xab}) {
+		t.Fatalf("unexpected uint8 encoding: %x", got)
+	}
+	if got := Put(testUint32(0x01020304)); !bytes.Equal(got, Put4(0x01020304)) {
+		t.Fatalf("unexpected uint32 encoding: %x", got)
+	}
+	if got := Put[uint](math.MaxUint); len(got) != 8 && len(got) != 4 {
+		t.Fatalf("unexpected uint encoding length: %d", len(got))
+	}
+
+	b := make([]byte, 10)
+	Place(b[1:], uint16(0xbeef))
+	if !bytes.Equal(b[:3], []byte{0, 0xbe, 0xef}) {
+		t.Fatalf("unexpected placed bytes: %x", b[:3])
+	}
+	if got := Get[uint16](b[1:]); got != 0xbeef {
+		t.Fatalf("expected 0xbeef, got %#x", got)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic for short slice")
+			}
+		}()
+		Get[uint64](b[:7])
+	}()
+}
+
+func FuzzPutGet(f *testing.F) {
+	f.Add(uint64(0))
+	f.Add(uint64(1))
+	f.Add(uint64(math.MaxUint64))
+	f.Add(uint64(0x0102030405060708))
+	f.Fuzz(func(t *testing.T, u uint64) {
+		checkPutGet(t, uint8(u), Put[uint8](uint8(u)))
+		checkPutGet(t, uint16(u), Put2(uint16(u)))
+		checkPutGet(t, uint32(u), Put4(uint32(u)))
+		checkPutGet(t, testUint32(u), Put4(uint32(u)))
+		checkPutGet(t, u, Put8(u))
+		checkPutGet(t, uint(u), Put(uint(u)))
+
+		if got := Get2(Put(uint16(u))); got != uint16(u) {
+			t.Fatalf("Get2: expected %d, got %d", uint16(u), got)
+		}
+		if got := Get4(Put(uint32(u))); got != uint32(u) {
+			t.Fatalf("Get4: expected %d, got %d", uint32(u), got)
+		}
+		if got := Get8(Put(u)); got != u {
+			t.Fatalf("Get8: expected %d, got %d", u, got)
+		}
+	})
+}
+
+func checkPutGet[T Unsigned](t *testing.T, u T, want []byte) {
+	t.Helper()
+	if got := Put(u); !bytes.Equal(got, want) {
+		t.Fatalf("Put(%d): expected %x, got %x", u, want, got)
+	}
+	b := make([]byte, len(want)+1)
+	Place(b, u)
+	if !bytes.Equal(b[:len(want)], want) || b[len(want)] != 0 {
+		t.Fatalf("Place(%d): expected %x, got %x", u, want, b)
+	}
+	if got := Get[T](want); got != u {
+		t.Fatalf("Get: expected %d, got %d", u, got)
+	}
+}
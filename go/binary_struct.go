@@ -0,0 +1,425 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrBinaryFormat means binary data is malformed.
+var ErrBinaryFormat = errors.New("malformed binary data")
+
+// EncodeBinaryStruct encodes the struct (or pointer to struct) as the given
+// schema version using a compact binary format. Fields are encoded in
+// declaration order and can be tagged to control versioning:
+//
+//	`bin:"-"`                  // Never encoded
+//	`bin:"added=2"`            // Encoded in versions >= 2
+//	`bin:"added=2,removed=4"`  // Encoded in versions >= 2 and < 4
+//
+// Each top-level field is length-prefixed, so decoders built against an
+// older schema skip trailing fields they don't know about. For this to work,
+// new fields must only be added to the end of the struct, and removed fields
+// must be kept (tagged with removed) rather than deleted.
+//
+// Supported field types are bools, integers, floats, strings, and slices,
+// arrays, maps, pointers, and structs of supported types. Unexported fields
+// are ignored.
+func EncodeBinaryStruct(v any, version uint64) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct, got %T", v)
+	}
+	fields, err := binaryFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	active := activeBinaryFields(fields, version)
+	b := binary.AppendUvarint(nil, version)
+	b = binary.AppendUvarint(b, uint64(len(active)))
+	var fb []byte
+	for _, f := range active {
+		fb, err = appendBinaryValue(fb[:0], rv.Field(f.index), version)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+		b = binary.AppendUvarint(b, uint64(len(fb)))
+		b = append(b, fb...)
+	}
+	return b, nil
+}
+
+// DecodeBinaryStruct decodes data encoded with EncodeBinaryStruct into the
+// struct pointed to by v, returning the schema version the data was encoded
+// with. Fields not present in that version are left unchanged and unknown
+// trailing fields (from newer versions) are skipped. Empty slices and maps
+// are decoded as nil.
+func DecodeBinaryStruct(b []byte, v any) (uint64, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() ||
+		rv.Elem().Kind() != reflect.Struct {
+		return 0, fmt.Errorf("expected non-nil pointer to struct, got %T", v)
+	}
+	rv = rv.Elem()
+	fields, err := binaryFields(rv.Type())
+	if err != nil {
+		return 0, err
+	}
+	version, b, err := readUvarint(b)
+	if err != nil {
+		return 0, err
+	}
+	count, b, err := readUvarint(b)
+	if err != nil {
+		return version, err
+	}
+	active := activeBinaryFields(fields, version)
+	for i := uint64(0); i < count; i++ {
+		var l uint64
+		if l, b, err = readUvarint(b); err != nil {
+			return version, err
+		} else if l > uint64(len(b)) {
+			return version, ErrBinaryFormat
+		}
+		fb := b[:l]
+		b = b[l:]
+		if i >= uint64(len(active)) {
+			// Unknown trailing field
+			continue
+		}
+		f := active[i]
+		rest, err := readBinaryValue(fb, rv.Field(f.index), version)
+		if err != nil {
+			return version, fmt.Errorf("field %s: %w", f.name, err)
+		} else if len(rest) != 0 {
+			return version, fmt.Errorf("field %s: %w", f.name, ErrBinaryFormat)
+		}
+	}
+	if len(b) != 0 {
+		return version, ErrBinaryFormat
+	}
+	return version, nil
+}
+
+type binaryField struct {
+	index   int
+	name    string
+	added   uint64
+	removed uint64
+}
+
+func (f binaryField) activeIn(version uint64) bool {
+	return version >= f.added && (f.removed == 0 || version < f.removed)
+}
+
+var binaryFieldsCache sync.Map
+
+func binaryFields(t reflect.Type) ([]binaryField, error) {
+	if fields, ok := binaryFieldsCache.Load(t); ok {
+		return fields.([]binaryField), nil
+	}
+	fields := make([]binaryField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("bin")
+		if !sf.IsExported() || tag == "-" {
+			continue
+		}
+		f := binaryField{index: i, name: sf.Name}
+		for _, opt := range strings.Split(tag, ",") {
+			if opt == "" {
+				continue
+			}
+			key, val, _ := strings.Cut(opt, "=")
+			n, err := strconv.ParseUint(val, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: bad bin tag %q", sf.Name, tag)
+			}
+			switch key {
+			case "added":
+				f.added = n
+			case "removed":
+				f.removed = n
+			default:
+				return nil, fmt.Errorf("field %s: bad bin tag %q", sf.Name, tag)
+			}
+		}
+		fields = append(fields, f)
+	}
+	binaryFieldsCache.Store(t, fields)
+	return fields, nil
+}
+
+func activeBinaryFields(fields []binaryField, version uint64) []binaryField {
+	active := make([]binaryField, 0, len(fields))
+	for _, f := range fields {
+		if f.activeIn(version) {
+			active = append(active, f)
+		}
+	}
+	return active
+}
+
+func appendBinaryValue(
+	b []byte, v reflect.Value, version uint64,
+) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return binary.AppendVarint(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(b, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return append(b, PutF(v.Float())...), nil
+	case reflect.String:
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		return append(b, v.String()...), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b = binary.AppendUvarint(b, uint64(v.Len()))
+			return append(b, v.Bytes()...), nil
+		}
+		fallthrough
+	case reflect.Array:
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		var err error
+		for i := 0; i < v.Len() && err == nil; i++ {
+			b, err = appendBinaryValue(b, v.Index(i), version)
+		}
+		return b, err
+	case reflect.Map:
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		var err error
+		iter := v.MapRange()
+		for iter.Next() && err == nil {
+			if b, err = appendBinaryValue(b, iter.Key(), version); err == nil {
+				b, err = appendBinaryValue(b, iter.Value(), version)
+			}
+		}
+		return b, err
+	case reflect.Pointer:
+		if v.IsNil() {
+			return append(b, 0), nil
+		}
+		return appendBinaryValue(append(b, 1), v.Elem(), version)
+	case reflect.Struct:
+		fields, err := binaryFields(v.Type())
+		if err != nil {
+			return b, err
+		}
+		for _, f := range fields {
+			if !f.activeIn(version) {
+				continue
+			}
+			if b, err = appendBinaryValue(b, v.Field(f.index), version); err != nil {
+				return b, fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+		return b, nil
+	default:
+		return b, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+func readBinaryValue(
+	b []byte, v reflect.Value, version uint64,
+) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if len(b) == 0 || b[0] > 1 {
+			return b, ErrBinaryFormat
+		}
+		v.SetBool(b[0] == 1)
+		return b[1:], nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		i, n := binary.Varint(b)
+		if n <= 0 || v.OverflowInt(i) {
+			return b, ErrBinaryFormat
+		}
+		v.SetInt(i)
+		return b[n:], nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		u, b, err := readUvarint(b)
+		if err != nil || v.OverflowUint(u) {
+			return b, ErrBinaryFormat
+		}
+		v.SetUint(u)
+		return b, nil
+	case reflect.Float32, reflect.Float64:
+		if len(b) < 8 {
+			return b, ErrBinaryFormat
+		}
+		f := GetF(b)
+		if v.Kind() == reflect.Float32 && !math.IsInf(f, 0) &&
+			!math.IsNaN(f) && v.OverflowFloat(f) {
+			return b, ErrBinaryFormat
+		}
+		v.SetFloat(f)
+		return b[8:], nil
+	case reflect.String:
+		l, b, err := readBinaryLen(b, 1)
+		if err != nil {
+			return b, err
+		}
+		v.SetString(string(b[:l]))
+		return b[l:], nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			l, b, err := readBinaryLen(b, 1)
+			if err != nil {
+				return b, err
+			}
+			v.SetBytes(append([]byte(nil), b[:l]...))
+			return b[l:], nil
+		}
+		minSize := binaryMinSize(v.Type().Elem(), version)
+		l, b, err := readBinaryLen(b, minSize)
+		if err != nil {
+			return b, err
+		} else if l == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return b, nil
+		}
+		s := reflect.MakeSlice(v.Type(), l, l)
+		for i := 0; i < l && err == nil; i++ {
+			b, err = readBinaryValue(b, s.Index(i), version)
+		}
+		if err == nil {
+			v.Set(s)
+		}
+		return b, err
+	case reflect.Array:
+		minSize := binaryMinSize(v.Type().Elem(), version)
+		l, b, err := readBinaryLen(b, minSize)
+		if err != nil {
+			return b, err
+		} else if l != v.Len() {
+			return b, ErrBinaryFormat
+		}
+		for i := 0; i < l && err == nil; i++ {
+			b, err = readBinaryValue(b, v.Index(i), version)
+		}
+		return b, err
+	case reflect.Map:
+		kt, vt := v.Type().Key(), v.Type().Elem()
+		minSize := binaryMinSize(kt, version) + binaryMinSize(vt, version)
+		l, b, err := readBinaryLen(b, minSize)
+		if err != nil {
+			return b, err
+		} else if l == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return b, nil
+		}
+		m := reflect.MakeMapWithSize(v.Type(), l)
+		for i := 0; i < l; i++ {
+			key, val := reflect.New(kt).Elem(), reflect.New(vt).Elem()
+			if b, err = readBinaryValue(b, key, version); err != nil {
+				return b, err
+			}
+			if b, err = readBinaryValue(b, val, version); err != nil {
+				return b, err
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+		return b, nil
+	case reflect.Pointer:
+		if len(b) == 0 || b[0] > 1 {
+			return b, ErrBinaryFormat
+		}
+		if b[0] == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return b[1:], nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return readBinaryValue(b[1:], v.Elem(), version)
+	case reflect.Struct:
+		fields, err := binaryFields(v.Type())
+		if err != nil {
+			return b, err
+		}
+		for _, f := range fields {
+			if !f.activeIn(version) {
+				continue
+			}
+			if b, err = readBinaryValue(b, v.Field(f.index), version); err != nil {
+				return b, fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+		return b, nil
+	default:
+		return b, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+func readUvarint(b []byte) (uint64, []byte, error) {
+	u, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, b, ErrBinaryFormat
+	}
+	return u, b[n:], nil
+}
+
+// maxBinaryZeroSizeLen is the max length of slices, arrays, and maps of
+// values that encode to 0 bytes (e.g., []struct{}), since their lengths can't
+// be checked against the remaining data.
+const maxBinaryZeroSizeLen = 1 << 20
+
+// readBinaryLen reads a length, making sure there are at least l*minSize
+// bytes left so that corrupt lengths can't cause huge allocations (or long
+// loops, for zero-size values).
+func readBinaryLen(b []byte, minSize int) (int, []byte, error) {
+	l, b, err := readUvarint(b)
+	if err != nil {
+		return 0, b, err
+	}
+	if minSize == 0 {
+		if l > maxBinaryZeroSizeLen {
+			return 0, b, ErrBinaryFormat
+		}
+	} else if l > uint64(len(b)/minSize) {
+		return 0, b, ErrBinaryFormat
+	}
+	return int(l), b, nil
+}
+
+// binaryMinSize returns the minimum number of bytes a value of the type
+// encodes to in the given version.
+func binaryMinSize(t reflect.Type, version uint64) int {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return 8
+	case reflect.Array:
+		return 1 + t.Len()*binaryMinSize(t.Elem(), version)
+	case reflect.Struct:
+		fields, err := binaryFields(t)
+		if err != nil {
+			return 0
+		}
+		size := 0
+		for _, f := range fields {
+			if f.activeIn(version) {
+				size += binaryMinSize(t.Field(f.index).Type, version)
+			}
+		}
+		return size
+	default:
+		// Bools, varints, lengths, and pointer flags are at least 1 byte.
+		return 1
+	}
+}
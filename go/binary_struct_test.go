@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type binaryInner struct {
+	A int16
+	B []string
+}
+
+type binaryV1 struct {
+	ID    uint64
+	Name  string
+	Old   bool `bin:"removed=2"`
+	Inner binaryInner
+}
+
+type binaryV2 struct {
+	ID     uint64
+	Name   string
+	Old    bool `bin:"removed=2"`
+	Inner  binaryInner
+	Score  float64            `bin:"added=2"`
+	Tags   map[string][]byte  `bin:"added=2"`
+	Parent *binaryInner       `bin:"added=2"`
+	Skip   int                `bin:"-"`
+	Fixed  [2]int8            `bin:"added=2"`
+	Extra  map[int32]struct{} `bin:"added=2"`
+}
+
+func TestBinaryStruct(t *testing.T) {
+	v2 := binaryV2{
+		ID:     7,
+		Name:   "seven",
+		Old:    true,
+		Inner:  binaryInner{A: -3, B: []string{"x", "y"}},
+		Score:  1.5,
+		Tags:   map[string][]byte{"k": []byte("v")},
+		Parent: &binaryInner{A: 1},
+		Skip:   100,
+		Fixed:  [2]int8{-1, 1},
+	}
+	b, err := EncodeBinaryStruct(&v2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got binaryV2
+	if version, err := DecodeBinaryStruct(b, &got); err != nil {
+		t.Fatal(err)
+	} else if version != 2 {
+		t.Fatalf("expected version 2, got %d", version)
+	}
+	want := v2
+	want.Old, want.Skip = false, 0
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	// Old decoder, new data
+	var old binaryV1
+	if _, err := DecodeBinaryStruct(b, &old); err != nil {
+		t.Fatal(err)
+	}
+	wantOld := binaryV1{ID: 7, Name: "seven", Inner: v2.Inner}
+	if !reflect.DeepEqual(old, wantOld) {
+		t.Fatalf("expected %+v, got %+v", wantOld, old)
+	}
+
+	// New decoder, old data
+	old.Old = true
+	if b, err = EncodeBinaryStruct(old, 1); err != nil {
+		t.Fatal(err)
+	}
+	got = binaryV2{}
+	if version, err := DecodeBinaryStruct(b, &got); err != nil {
+		t.Fatal(err)
+	} else if version != 1 {
+		t.Fatalf("expected version 1, got %d", version)
+	}
+	want = binaryV2{ID: 7, Name: "seven", Old: true, Inner: v2.Inner}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	// Truncated data
+	for i := 0; i < len(b); i++ {
+		got = binaryV2{}
+		if _, err := DecodeBinaryStruct(b[:i], &got); !errors.Is(
+			err, ErrBinaryFormat,
+		) {
+			t.Fatalf("%d: expected ErrBinaryFormat, got %v", i, err)
+		}
+	}
+}
+
+func TestBinaryStructZeroSize(t *testing.T) {
+	type zeroSize struct {
+		Set   map[uint8]struct{}
+		Empty []struct{}
+		Fixed [2]struct{}
+		Keys  map[struct{}]struct{}
+	}
+	v := zeroSize{
+		Set:   map[uint8]struct{}{1: {}, 2: {}},
+		Empty: make([]struct{}, 3),
+		Keys:  map[struct{}]struct{}{{}: {}},
+	}
+	b, err := EncodeBinaryStruct(v, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got zeroSize
+	if _, err := DecodeBinaryStruct(b, &got); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, v) {
+		t.Fatalf("expected %+v, got %+v", v, got)
+	}
+
+	// Lengths of zero-size values are still bounded.
+	type empties struct{ Empty []struct{} }
+	b, _ = EncodeBinaryStruct(
+		empties{make([]struct{}, maxBinaryZeroSizeLen+1)}, 1,
+	)
+	if _, err := DecodeBinaryStruct(b, &empties{}); !errors.Is(
+		err, ErrBinaryFormat,
+	) {
+		t.Fatalf("expected ErrBinaryFormat, got %v", err)
+	}
+}
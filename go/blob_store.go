@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// blobTempMaxAge is how old temporary files must be before being removed by
+// BlobStore.GC, so that in-progress writes from other processes aren't
+// removed.
+const blobTempMaxAge = time.Hour
+
+const blobTempPrefix = ".tmp-"
+
+// BlobStore is a content-addressable store of blobs in a directory, keyed by
+// the hex-encoded SHA-256 of their contents. Blobs are written to temporary
+// files and renamed into place, so concurrent writers (including other
+// processes) never expose partial blobs. It is safe for concurrent use.
+type BlobStore struct {
+	dir string
+	// Held for reading while writing and for writing during GC.
+	mtx sync.RWMutex
+}
+
+// OpenBlobStore opens the BlobStore in the given directory, creating it if
+// necessary.
+func OpenBlobStore(dir string) (*BlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &BlobStore{dir: dir}, nil
+}
+
+// Dir returns the directory of the store.
+func (bs *BlobStore) Dir() string {
+	return bs.dir
+}
+
+// Put stores the contents read from r, returning the hash.
+func (bs *BlobStore) Put(r io.Reader) (string, error) {
+	bs.mtx.RLock()
+	defer bs.mtx.RUnlock()
+	f, err := os.CreateTemp(bs.dir, blobTempPrefix+"*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := f.Name()
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), r)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	p := bs.path(hash)
+	if _, err := os.Stat(p); err == nil {
+		os.Remove(tmpPath)
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, p); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return hash, nil
+}
+
+// PutBytes stores the given bytes, returning the hash.
+func (bs *BlobStore) PutBytes(b []byte) (string, error) {
+	return bs.Put(bytes.NewReader(b))
+}
+
+// Get opens the blob with the given hash. If the blob doesn't exist, the
+// error satisfies errors.Is(err, fs.ErrNotExist).
+func (bs *BlobStore) Get(hash string) (io.ReadCloser, error) {
+	if err := checkBlobHash(hash); err != nil {
+		return nil, err
+	}
+	return os.Open(bs.path(hash))
+}
+
+// GetBytes reads the blob with the given hash.
+func (bs *BlobStore) GetBytes(hash string) ([]byte, error) {
+	if err := checkBlobHash(hash); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(bs.path(hash))
+}
+
+// Has returns whether the blob with the given hash exists.
+func (bs *BlobStore) Has(hash string) bool {
+	if checkBlobHash(hash) != nil {
+		return false
+	}
+	_, err := os.Stat(bs.path(hash))
+	return err == nil
+}
+
+// Verify re-hashes the blob with the given hash, returning an error wrapping
+// ErrChecksum if its contents don't match.
+func (bs *BlobStore) Verify(hash string) error {
+	rc, err := bs.Get(hash)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return err
+	}
+	return checkSum(h, hash, hash)
+}
+
+// Delete deletes the blob with the given hash. Deleting a blob that doesn't
+// exist isn't an error.
+func (bs *BlobStore) Delete(hash string) error {
+	if err := checkBlobHash(hash); err != nil {
+		return err
+	}
+	err := os.Remove(bs.path(hash))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Hashes returns the hashes of all blobs in the store.
+func (bs *BlobStore) Hashes() ([]string, error) {
+	var hashes []string
+	err := bs.walk(func(hash, _ string) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	return hashes, err
+}
+
+// GC deletes all blobs for which keep returns false, as well as temporary
+// files left over from failed writes, returning the number of blobs deleted.
+// Writes from this BlobStore are blocked while GC runs, but writes from other
+// processes are not, so a blob written concurrently by another process may be
+// deleted if keep doesn't account for it.
+func (bs *BlobStore) GC(keep func(hash string) bool) (int, error) {
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+	removed := 0
+	err := bs.walk(func(hash, p string) error {
+		if keep(hash) {
+			return nil
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+	// Remove stale temporary files and empty fan-out directories
+	entries, err := os.ReadDir(bs.dir)
+	if err != nil {
+		return removed, err
+	}
+	for _, e := range entries {
+		p := filepath.Join(bs.dir, e.Name())
+		if e.IsDir() {
+			// Fails if the directory isn't empty
+			os.Remove(p)
+			continue
+		}
+		if !strings.HasPrefix(e.Name(), blobTempPrefix) {
+			continue
+		}
+		if info, err := e.Info(); err == nil &&
+			time.Since(info.ModTime()) > blobTempMaxAge {
+			os.Remove(p)
+		}
+	}
+	return removed, nil
+}
+
+// walk calls f with the hash and path of each blob.
+func (bs *BlobStore) walk(f func(hash, p string) error) error {
+	dirs, err := os.ReadDir(bs.dir)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		if !d.IsDir() || len(d.Name()) != 2 {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(bs.dir, d.Name()))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			hash := d.Name() + e.Name()
+			if e.IsDir() || checkBlobHash(hash) != nil {
+				continue
+			}
+			p := filepath.Join(bs.dir, d.Name(), e.Name())
+			if err := f(hash, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// path returns the path of the blob, fanned out by the first 2 characters to
+// keep directories small.
+func (bs *BlobStore) path(hash string) string {
+	return filepath.Join(bs.dir, hash[:2], hash[2:])
+}
+
+func checkBlobHash(hash string) error {
+	if len(hash) != sha256.Size*2 {
+		return fmt.Errorf("invalid blob hash: %q", hash)
+	}
+	for _, c := range hash {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return fmt.Errorf("invalid blob hash: %q", hash)
+		}
+	}
+	return nil
+}
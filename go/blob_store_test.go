@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestBlobStore(t *testing.T) {
+	bs, err := OpenBlobStore(filepath.Join(t.TempDir(), "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	hashes := make([]string, 10)
+	for i := range hashes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h, err := bs.PutBytes([]byte("hello"))
+			if err != nil {
+				t.Error(err)
+			}
+			hashes[i] = h
+		}(i)
+	}
+	wg.Wait()
+	hello := hashes[0]
+	for _, h := range hashes {
+		if h != hello {
+			t.Fatalf("expected same hashes, got %v", hashes)
+		}
+	}
+	world, err := bs.PutBytes([]byte("world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := bs.GetBytes(hello); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "hello" {
+		t.Fatalf("expected hello, got %q", b)
+	}
+	if !bs.Has(world) || bs.Has("../../etc/passwd") {
+		t.Fatal("bad Has results")
+	}
+	if all, err := bs.Hashes(); err != nil {
+		t.Fatal(err)
+	} else if len(all) != 2 {
+		t.Fatalf("expected 2 hashes, got %v", all)
+	}
+	if err := bs.Verify(world); err != nil {
+		t.Fatal("unexpected verify error: ", err)
+	}
+
+	n, err := bs.GC(func(h string) bool { return h == world })
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("expected 1 blob removed, got %d", n)
+	}
+	if _, err := bs.Get(hello); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+
+	// Corrupt a blob
+	p := filepath.Join(bs.Dir(), world[:2], world[2:])
+	if err := os.WriteFile(p, []byte("w0rld"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := bs.Verify(world); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("expected ErrChecksum, got %v", err)
+	}
+}
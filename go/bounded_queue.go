@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrFull means an object is full.
+	ErrFull = errors.New("full")
+	// ErrEmpty means an object is empty.
+	ErrEmpty = errors.New("empty")
+)
+
+// BoundedQueue is a FIFO queue with a fixed capacity. Sends block while the
+// queue is full, providing backpressure (unlike UChan). Like UChan, values
+// sent before the queue is closed can still be received after it is closed.
+type BoundedQueue[T any] struct {
+	mtx      sync.Mutex
+	buf      []T
+	head     int
+	size     int
+	notFull  chan struct{}
+	notEmpty chan struct{}
+	isClosed bool
+}
+
+// NewBoundedQueue creates a new BoundedQueue with the given capacity. If cap
+// is less than 1, a capacity of 1 is used.
+func NewBoundedQueue[T any](cap int) *BoundedQueue[T] {
+	if cap < 1 {
+		cap = 1
+	}
+	return &BoundedQueue[T]{buf: make([]T, cap)}
+}
+
+// Send sends the value, blocking while the queue is full. Returns false if
+// the queue is closed.
+func (q *BoundedQueue[T]) Send(val T) bool {
+	return q.send(val, func(ch <-chan struct{}) error {
+		<-ch
+		return nil
+	}) == nil
+}
+
+// SendTimeout sends the value, waiting at most the given duration for space
+// to be available. If there is space immediately available, the timeout is not
+// used. Returns ErrClosed if the queue is closed and ErrTimedOut if the
+// timeout is reached.
+func (q *BoundedQueue[T]) SendTimeout(val T, dur time.Duration) error {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	return q.send(val, func(ch <-chan struct{}) error {
+		if timer == nil {
+			timer = time.NewTimer(dur)
+		}
+		select {
+		case <-ch:
+			return nil
+		case <-timer.C:
+			return ErrTimedOut
+		}
+	})
+}
+
+// SendContext sends the value, waiting for space to be available until the
+// context is done. Returns ErrClosed if the queue is closed and ErrCanceled
+// (wrapping the context's error) if the context is done first.
+func (q *BoundedQueue[T]) SendContext(ctx context.Context, val T) error {
+	return q.send(val, func(ch <-chan struct{}) error {
+		select {
+		case <-ch:
+			return nil
+		case <-ctx.Done():
+			return newCanceledError(ctx)
+		}
+	})
+}
+
+// TrySend attempts to send the value without blocking. Returns ErrClosed if
+// the queue is closed and ErrFull if the queue is full.
+func (q *BoundedQueue[T]) TrySend(val T) error {
+	return q.send(val, func(<-chan struct{}) error {
+		return ErrFull
+	})
+}
+
+// send attempts to send the value, calling wait with a chan that is closed
+// when the state of the queue changes whenever the queue is full.
+func (q *BoundedQueue[T]) send(
+	val T, wait func(<-chan struct{}) error,
+) error {
+	for {
+		q.mtx.Lock()
+		if q.isClosed {
+			q.mtx.Unlock()
+			return ErrClosed
+		}
+		if q.size < len(q.buf) {
+			q.buf[(q.head+q.size)%len(q.buf)] = val
+			q.size++
+			signalChan(&q.notEmpty)
+			q.mtx.Unlock()
+			return nil
+		}
+		ch := waitChan(&q.notFull)
+		q.mtx.Unlock()
+		if err := wait(ch); err != nil {
+			return err
+		}
+	}
+}
+
+// Recv receives a value, blocking while the queue is empty. Returns false if
+// the queue is closed and empty.
+func (q *BoundedQueue[T]) Recv() (T, bool) {
+	t, err := q.recv(func(ch <-chan struct{}) error {
+		<-ch
+		return nil
+	})
+	return t, err == nil
+}
+
+// RecvTimeout receives a value, waiting at most the given duration for one to
+// be available. If there is a value immediately available, the timeout is not
+// used. Returns ErrClosed if the queue is closed and empty and ErrTimedOut if
+// the timeout is reached.
+func (q *BoundedQueue[T]) RecvTimeout(dur time.Duration) (T, error) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	return q.recv(func(ch <-chan struct{}) error {
+		if timer == nil {
+			timer = time.NewTimer(dur)
+		}
+		select {
+		case <-ch:
+			return nil
+		case <-timer.C:
+			return ErrTimedOut
+		}
+	})
+}
+
+// RecvContext receives a value, waiting for one to be available until the
+// context is done. Returns ErrClosed if the queue is closed and empty and
+// ErrCanceled (wrapping the context's error) if the context is done first.
+func (q *BoundedQueue[T]) RecvContext(ctx context.Context) (T, error) {
+	return q.recv(func(ch <-chan struct{}) error {
+		select {
+		case <-ch:
+			return nil
+		case <-ctx.Done():
+			return newCanceledError(ctx)
+		}
+	})
+}
+
+// TryRecv attempts to receive a value without blocking. Returns ErrClosed if
+// the queue is closed and empty and ErrEmpty if the queue is empty.
+func (q *BoundedQueue[T]) TryRecv() (T, error) {
+	return q.recv(func(<-chan struct{}) error {
+		return ErrEmpty
+	})
+}
+
+func (q *BoundedQueue[T]) recv(wait func(<-chan struct{}) error) (T, error) {
+	for {
+		q.mtx.Lock()
+		if q.size != 0 {
+			t := q.pop()
+			q.mtx.Unlock()
+			return t, nil
+		}
+		if q.isClosed {
+			q.mtx.Unlock()
+			var t T
+			return t, ErrClosed
+		}
+		ch := waitChan(&q.notEmpty)
+		q.mtx.Unlock()
+		if err := wait(ch); err != nil {
+			var t T
+			return t, err
+		}
+	}
+}
+
+// pop removes the front value. The lock must be held and the queue must not be
+// empty.
+func (q *BoundedQueue[T]) pop() T {
+	var zero T
+	t := q.buf[q.head]
+	q.buf[q.head] = zero
+	q.head = (q.head + 1) % len(q.buf)
+	q.size--
+	signalChan(&q.notFull)
+	return t
+}
+
+// Drain removes and returns all values currently in the queue. This is
+// especially useful after the queue is closed.
+func (q *BoundedQueue[T]) Drain() []T {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	res := make([]T, 0, q.size)
+	for q.size != 0 {
+		res = append(res, q.pop())
+	}
+	return res
+}
+
+// Len returns the number of values in the queue.
+func (q *BoundedQueue[T]) Len() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.size
+}
+
+// Cap returns the capacity of the queue.
+func (q *BoundedQueue[T]) Cap() int {
+	return len(q.buf)
+}
+
+// Close closes the queue, returning false if the queue was already closed.
+// Blocked senders return immediately while blocked receivers continue to
+// receive the values remaining in the queue.
+func (q *BoundedQueue[T]) Close() bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.isClosed {
+		return false
+	}
+	q.isClosed = true
+	signalChan(&q.notFull)
+	signalChan(&q.notEmpty)
+	return true
+}
+
+// IsClosed returns whether the queue is closed.
+func (q *BoundedQueue[T]) IsClosed() bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.isClosed
+}
+
+// waitChan returns the chan pointed to by chp, creating it if it doesn't
+// exist. Used (with the appropriate lock held) to wait for a signal from
+// signalChan.
+func waitChan(chp *chan struct{}) <-chan struct{} {
+	if *chp == nil {
+		*chp = make(chan struct{})
+	}
+	return *chp
+}
+
+// signalChan closes the chan pointed to by chp (if it exists), waking all
+// waiters.
+func signalChan(chp *chan struct{}) {
+	if *chp != nil {
+		close(*chp)
+		*chp = nil
+	}
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBoundedQueueBasic(t *testing.T) {
+	q := NewBoundedQueue[int](10)
+	if q.Cap() != 10 {
+		t.Fatalf("expected cap of 10, got %d", q.Cap())
+	}
+	for i := 0; i < 10; i++ {
+		if err := q.TrySend(i); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+	if err := q.TrySend(10); err != ErrFull {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+	if err := q.SendTimeout(10, time.Millisecond); err != ErrTimedOut {
+		t.Fatalf("expected ErrTimedOut, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	err := q.SendContext(ctx, 10)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	} else if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if l := q.Len(); l != 10 {
+		t.Fatalf("expected length of 10, got %d", l)
+	}
+
+	for i := 0; i < 5; i++ {
+		if n, err := q.TryRecv(); err != nil {
+			t.Fatal("unexpected error: ", err)
+		} else if n != i {
+			t.Fatalf("expected %d, got %d", i, n)
+		}
+	}
+	for i := 10; i < 15; i++ {
+		if !q.Send(i) {
+			t.Fatal("queue unexpectedly closed")
+		}
+	}
+	for i := 5; i < 15; i++ {
+		if n, err := q.RecvTimeout(time.Millisecond); err != nil {
+			t.Fatal("unexpected error: ", err)
+		} else if n != i {
+			t.Fatalf("expected %d, got %d", i, n)
+		}
+	}
+	if _, err := q.TryRecv(); err != ErrEmpty {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+	if _, err := q.RecvTimeout(time.Millisecond); err != ErrTimedOut {
+		t.Fatalf("expected ErrTimedOut, got %v", err)
+	}
+
+	q.Send(1)
+	q.Send(2)
+	q.Send(3)
+	if !q.Close() {
+		t.Fatal("queue unexpectedly closed")
+	}
+	if q.Close() {
+		t.Fatal("queue not closed")
+	}
+	if q.Send(4) {
+		t.Fatal("queue not closed")
+	}
+	if n, ok := q.Recv(); !ok || n != 1 {
+		t.Fatalf("expected 1, true, got %d, %v", n, ok)
+	}
+	if got := q.Drain(); !SliceEq(got, []int{2, 3}) {
+		t.Fatalf("expected [2 3], got %v", got)
+	}
+	if _, err := q.TryRecv(); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestBoundedQueueBlocking(t *testing.T) {
+	q := NewBoundedQueue[int](1)
+	done := make(chan bool, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			done <- q.Send(1)
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := q.RecvTimeout(time.Second * 3); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+
+	// Unblock the rest of the senders once they've had time to block
+	time.Sleep(time.Millisecond * 10)
+	q.Close()
+	timer := time.NewTimer(time.Second * 3)
+	defer timer.Stop()
+	sent := 0
+	for i := 0; i < 10; i++ {
+		select {
+		case ok := <-done:
+			if ok {
+				sent++
+			}
+		case <-timer.C:
+			t.Fatal("timed out")
+		}
+	}
+	if want := 5 + q.Len(); sent != want {
+		t.Fatalf("expected %d successful sends, got %d", want, sent)
+	}
+
+	// Blocked receivers should be woken on close.
+	q = NewBoundedQueue[int](1)
+	go func() {
+		_, ok := q.Recv()
+		done <- ok
+	}()
+	time.Sleep(time.Millisecond * 10)
+	q.Close()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("expected false from Recv")
+		}
+	case <-timer.C:
+		t.Fatal("timed out")
+	}
+}
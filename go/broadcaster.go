@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BufferPolicy determines how values are buffered for a subscriber.
+type BufferPolicy int
+
+const (
+	// BufferUnbounded buffers all values.
+	BufferUnbounded BufferPolicy = iota
+	// BufferDropOldest drops the oldest buffered value to make room for a new
+	// value once the buffer is full.
+	BufferDropOldest
+	// BufferDropNewest drops new values while the buffer is full.
+	BufferDropNewest
+)
+
+// DefaultSubscriptionChanLen is the default chan length of a subscription's
+// UChan.
+const DefaultSubscriptionChanLen = 16
+
+// SubscribeOpts are options for a Broadcaster subscription.
+type SubscribeOpts struct {
+	// Policy is the buffering policy for the subscription.
+	Policy BufferPolicy
+	// Size is the maximum number of values buffered when using a dropping
+	// policy. If less than 1, a size of 1 is used. It is ignored for
+	// BufferUnbounded.
+	Size int
+	// ChanLen is the chan length passed to NewUChan for the subscription's
+	// UChan. If less than 1, DefaultSubscriptionChanLen is used.
+	ChanLen int
+}
+
+// Broadcaster is a fan-out (pub/sub) primitive where each published value is
+// delivered to every subscriber, each having their own UChan-backed
+// subscription.
+type Broadcaster[T any] struct {
+	mtx      sync.RWMutex
+	subs     map[*Subscription[T]]Unit
+	isClosed bool
+}
+
+// NewBroadcaster creates a new Broadcaster.
+func NewBroadcaster[T any]() *Broadcaster[T] {
+	return &Broadcaster[T]{subs: make(map[*Subscription[T]]Unit)}
+}
+
+// Subscribe creates a new subscription with the given options. Returns nil if
+// the Broadcaster is closed.
+func (b *Broadcaster[T]) Subscribe(opts SubscribeOpts) *Subscription[T] {
+	if opts.Size < 1 {
+		opts.Size = 1
+	}
+	if opts.ChanLen < 1 {
+		opts.ChanLen = DefaultSubscriptionChanLen
+	}
+	sub := &Subscription[T]{
+		b:      b,
+		uc:     NewUChan[T](opts.ChanLen),
+		policy: opts.Policy,
+		size:   opts.Size,
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.isClosed {
+		return nil
+	}
+	b.subs[sub] = Unit{}
+	return sub
+}
+
+// Unsubscribe removes the subscription, closing its UChan. Returns false if
+// the subscription was not subscribed to this Broadcaster.
+func (b *Broadcaster[T]) Unsubscribe(sub *Subscription[T]) bool {
+	b.mtx.Lock()
+	_, ok := b.subs[sub]
+	delete(b.subs, sub)
+	b.mtx.Unlock()
+	if ok {
+		sub.uc.Close()
+	}
+	return ok
+}
+
+// Publish sends the value to all subscribers, returning the number of
+// subscribers the value was delivered to (not dropped). Returns false if the
+// Broadcaster is closed.
+func (b *Broadcaster[T]) Publish(val T) (n int, ok bool) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	if b.isClosed {
+		return 0, false
+	}
+	for sub := range b.subs {
+		if sub.deliver(val) {
+			n++
+		}
+	}
+	return n, true
+}
+
+// NumSubscribers returns the number of active subscribers.
+func (b *Broadcaster[T]) NumSubscribers() int {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	return len(b.subs)
+}
+
+// Close closes the Broadcaster and the UChans of all subscriptions. Values
+// already delivered to subscriptions can still be received. Returns false if
+// the Broadcaster was already closed.
+func (b *Broadcaster[T]) Close() bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.isClosed {
+		return false
+	}
+	b.isClosed = true
+	for sub := range b.subs {
+		sub.uc.Close()
+	}
+	b.subs = nil
+	return true
+}
+
+// IsClosed returns whether the Broadcaster is closed.
+func (b *Broadcaster[T]) IsClosed() bool {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+	return b.isClosed
+}
+
+// Subscription is a subscription to a Broadcaster.
+type Subscription[T any] struct {
+	b       *Broadcaster[T]
+	uc      *UChan[T]
+	policy  BufferPolicy
+	size    int
+	mtx     sync.Mutex
+	dropped atomic.Uint64
+}
+
+// UChan returns the UChan values are delivered to. It should only be received
+// from.
+func (s *Subscription[T]) UChan() *UChan[T] {
+	return s.uc
+}
+
+// Recv receives a value, returning false if the subscription is closed (and
+// all values have been received). Shorthand for s.UChan().Recv().
+func (s *Subscription[T]) Recv() (T, bool) {
+	return s.uc.Recv()
+}
+
+// Unsubscribe unsubscribes from the Broadcaster. Returns false if already
+// unsubscribed.
+func (s *Subscription[T]) Unsubscribe() bool {
+	return s.b.Unsubscribe(s)
+}
+
+// Dropped returns the number of values dropped due to the subscription's
+// buffering policy.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription[T]) deliver(val T) bool {
+	switch s.policy {
+	case BufferDropOldest:
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		for s.uc.Len() >= s.size {
+			if _, err := s.uc.TryRecv(); err != nil {
+				break
+			}
+			s.dropped.Add(1)
+		}
+	case BufferDropNewest:
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		if s.uc.Len() >= s.size {
+			s.dropped.Add(1)
+			return false
+		}
+	}
+	return s.uc.Send(val)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestBroadcaster(t *testing.T) {
+	b := NewBroadcaster[int]()
+	unbounded := b.Subscribe(SubscribeOpts{})
+	dropOldest := b.Subscribe(SubscribeOpts{Policy: BufferDropOldest, Size: 3})
+	dropNewest := b.Subscribe(SubscribeOpts{Policy: BufferDropNewest, Size: 3})
+	if n := b.NumSubscribers(); n != 3 {
+		t.Fatalf("expected 3 subscribers, got %d", n)
+	}
+
+	for i := 0; i < 10; i++ {
+		n, ok := b.Publish(i)
+		if !ok {
+			t.Fatal("broadcaster unexpectedly closed")
+		}
+		if i < 3 && n != 3 {
+			t.Fatalf("%d: expected 3 deliveries, got %d", i, n)
+		} else if i >= 3 && n != 2 {
+			t.Fatalf("%d: expected 2 deliveries, got %d", i, n)
+		}
+	}
+
+	got := unbounded.UChan().Drain()
+	if !SliceEq(got, RangeSlice(10)) {
+		t.Fatalf("unbounded: unexpected values %v", got)
+	}
+	if got := dropOldest.UChan().Drain(); !SliceEq(got, []int{7, 8, 9}) {
+		t.Fatalf("drop oldest: expected [7 8 9], got %v", got)
+	} else if d := dropOldest.Dropped(); d != 7 {
+		t.Fatalf("drop oldest: expected 7 dropped, got %d", d)
+	}
+	if got := dropNewest.UChan().Drain(); !SliceEq(got, []int{0, 1, 2}) {
+		t.Fatalf("drop newest: expected [0 1 2], got %v", got)
+	} else if d := dropNewest.Dropped(); d != 7 {
+		t.Fatalf("drop newest: expected 7 dropped, got %d", d)
+	}
+
+	if !dropNewest.Unsubscribe() {
+		t.Fatal("expected unsubscribe to succeed")
+	}
+	if dropNewest.Unsubscribe() {
+		t.Fatal("expected unsubscribe to fail")
+	}
+	if _, ok := dropNewest.Recv(); ok {
+		t.Fatal("expected unsubscribed subscription to be closed")
+	}
+	if n := b.NumSubscribers(); n != 2 {
+		t.Fatalf("expected 2 subscribers, got %d", n)
+	}
+
+	b.Publish(100)
+	if !b.Close() {
+		t.Fatal("broadcaster unexpectedly closed")
+	}
+	if b.Close() {
+		t.Fatal("broadcaster not closed")
+	}
+	if _, ok := b.Publish(101); ok {
+		t.Fatal("broadcaster not closed")
+	}
+	if b.Subscribe(SubscribeOpts{}) != nil {
+		t.Fatal("expected nil subscription from closed broadcaster")
+	}
+	if n, ok := unbounded.Recv(); !ok || n != 100 {
+		t.Fatalf("expected 100, true, got %d, %v", n, ok)
+	}
+	if _, ok := unbounded.Recv(); ok {
+		t.Fatal("expected subscription to be closed")
+	}
+}
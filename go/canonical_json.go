@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalCanonicalJSON marshals the value to JSON with deterministic output,
+// suitable for signing, hashing, and diffing. Object keys (including struct
+// fields) are sorted, there is no insignificant whitespace, HTML characters
+// aren't escaped, and numbers are formatted consistently: integers exactly
+// as-is, and other numbers in the shortest form that round-trips (using
+// exponents only for very large or small magnitudes, like JavaScript).
+func MarshalCanonicalJSON(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalizeJSON(b)
+}
+
+// CanonicalizeJSON rewrites the JSON in canonical form (see
+// MarshalCanonicalJSON). Returns an error if there is anything but whitespace
+// after the JSON value.
+func CanonicalizeJSON(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return nil, errors.New("invalid JSON: data after top-level value")
+	}
+	var buf bytes.Buffer
+	if err := writeCanonicalJSON(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// JSONDigest returns the hex-encoded SHA-256 of the canonical JSON of the
+// value.
+func JSONDigest(v any) (string, error) {
+	b, err := MarshalCanonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func writeCanonicalJSON(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		s, err := canonicalJSONNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case string:
+		writeCanonicalJSONString(buf, v)
+	case []any:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalJSONString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonicalJSON(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return nil
+}
+
+func writeCanonicalJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	// Encoding a string can't fail
+	enc.Encode(s)
+	// Remove the newline added by Encode
+	buf.Truncate(buf.Len() - 1)
+}
+
+func canonicalJSONNumber(n json.Number) (string, error) {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if s == "-0" {
+			return "0", nil
+		}
+		return s, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return "", err
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	if abs := math.Abs(f); abs >= 1e21 || abs < 1e-6 {
+		s = strconv.FormatFloat(f, 'e', -1, 64)
+		// Remove leading zeros from the exponent (e.g., 1e-07 -> 1e-7)
+		mant, exp, _ := strings.Cut(s, "e")
+		sign := exp[:1]
+		exp = strings.TrimLeft(exp[1:], "0")
+		return mant + "e" + sign + exp, nil
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
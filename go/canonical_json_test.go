@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"testing"
+)
+
+type canonicalInner struct {
+	Z int     `json:"z"`
+	A float64 `json:"a"`
+}
+
+type canonicalOuter struct {
+	Name  string            `json:"name"`
+	Inner canonicalInner    `json:"inner"`
+	Tags  map[string]string `json:"tags"`
+	List  []any             `json:"list"`
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	v := canonicalOuter{
+		Name:  "<a&b>",
+		Inner: canonicalInner{Z: 1, A: 2.50},
+		Tags:  map[string]string{"y": "1", "x": "2"},
+		List:  []any{1e21, 1e-7, 0.1, 100.0, -0.0, nil, true},
+	}
+	got, err := MarshalCanonicalJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"inner":{"a":2.5,"z":1},"list":[1e+21,1e-7,0.1,100,0,null,true],` +
+		`"name":"<a&b>","tags":{"x":"2","y":"1"}}`
+	if string(got) != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	in := `{ "b" : [1.0, 2E2], "a": 12345678901234567890 }`
+	b, err := CanonicalizeJSON([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":12345678901234567890,"b":[1,200]}`; string(b) != want {
+		t.Fatalf("expected %s, got %s", want, b)
+	}
+
+	for _, in := range []string{`{} {}`, `1 2`, `[]x`, `null,`} {
+		if _, err := CanonicalizeJSON([]byte(in)); err == nil {
+			t.Fatalf("%s: expected error for trailing data", in)
+		}
+	}
+	if b, err := CanonicalizeJSON([]byte(" 1 \n")); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "1" {
+		t.Fatalf("expected 1, got %s", b)
+	}
+
+	d1, err := JSONDigest(map[string]int{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, _ := JSONDigest(struct {
+		B int `json:"b"`
+		A int `json:"a"`
+	}{2, 1})
+	if d1 != d2 || len(d1) != 64 {
+		t.Fatalf("expected equal digests, got %s and %s", d1, d2)
+	}
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ChangeKind is the kind of a ChangeEvent.
+type ChangeKind uint8
+
+const (
+	// ChangeInsert is when a new key is added.
+	ChangeInsert ChangeKind = iota
+	// ChangeUpdate is when the value of an existing key is replaced.
+	ChangeUpdate
+	// ChangeDelete is when a key is removed.
+	ChangeDelete
+)
+
+// String returns the name of the kind.
+func (ck ChangeKind) String() string {
+	switch ck {
+	case ChangeInsert:
+		return "insert"
+	case ChangeUpdate:
+		return "update"
+	case ChangeDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// ChangeEvent is a mutation of a container. Old is the zero value for
+// inserts, and New is the zero value for deletes.
+type ChangeEvent[K comparable, V any] struct {
+	Kind ChangeKind
+	Key  K
+	Old  V
+	New  V
+}
+
+// WatchOpts are options for watching a container's changes.
+type WatchOpts struct {
+	// Coalesce, if positive, is how long events are held before being sent.
+	// Events for the same key within the window are merged into one (e.g., an
+	// insert followed by an update is sent as a single insert with the final
+	// value, and an insert followed by a delete isn't sent at all).
+	Coalesce time.Duration
+	// ChanLen is the chan length passed to NewUChan for the watcher's UChan. If
+	// less than 1, DefaultSubscriptionChanLen is used.
+	ChanLen int
+}
+
+// changeFeed holds the watchers of a container. The zero value is usable.
+type changeFeed[K comparable, V any] struct {
+	mtx      sync.Mutex
+	watchers map[*changeWatcher[K, V]]Unit
+}
+
+// active returns whether there are any watchers. Used so that containers can
+// skip building events when nothing is watching.
+func (cf *changeFeed[K, V]) active() bool {
+	if cf == nil {
+		return false
+	}
+	cf.mtx.Lock()
+	defer cf.mtx.Unlock()
+	return len(cf.watchers) != 0
+}
+
+func (cf *changeFeed[K, V]) emit(ev ChangeEvent[K, V]) {
+	if cf == nil {
+		return
+	}
+	cf.mtx.Lock()
+	defer cf.mtx.Unlock()
+	for w := range cf.watchers {
+		w.push(ev)
+	}
+}
+
+func (cf *changeFeed[K, V]) watch(
+	ctx context.Context, opts WatchOpts,
+) <-chan ChangeEvent[K, V] {
+	if opts.ChanLen < 1 {
+		opts.ChanLen = DefaultSubscriptionChanLen
+	}
+	w := &changeWatcher[K, V]{
+		uc:       NewUChan[ChangeEvent[K, V]](opts.ChanLen),
+		coalesce: opts.Coalesce,
+	}
+	cf.mtx.Lock()
+	if cf.watchers == nil {
+		cf.watchers = make(map[*changeWatcher[K, V]]Unit)
+	}
+	cf.watchers[w] = Unit{}
+	cf.mtx.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		cf.mtx.Lock()
+		delete(cf.watchers, w)
+		cf.mtx.Unlock()
+		w.close()
+	})
+
+	out := make(chan ChangeEvent[K, V])
+	go func() {
+		defer close(out)
+		for {
+			ev, ok := w.uc.Recv()
+			if !ok {
+				return
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+type changeWatcher[K comparable, V any] struct {
+	uc       *UChan[ChangeEvent[K, V]]
+	coalesce time.Duration
+
+	// The following are only used when coalescing.
+	mtx     sync.Mutex
+	pending map[K]ChangeEvent[K, V]
+	order   []K
+	timer   *time.Timer
+	closed  bool
+}
+
+func (w *changeWatcher[K, V]) push(ev ChangeEvent[K, V]) {
+	if w.coalesce <= 0 {
+		w.uc.Send(ev)
+		return
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.closed {
+		return
+	}
+	if w.pending == nil {
+		w.pending = make(map[K]ChangeEvent[K, V])
+	}
+	prev, ok := w.pending[ev.Key]
+	if !ok {
+		w.pending[ev.Key] = ev
+		w.order = append(w.order, ev.Key)
+	} else if merged, keep := mergeChanges(prev, ev); keep {
+		w.pending[ev.Key] = merged
+	} else {
+		delete(w.pending, ev.Key)
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.coalesce, w.flush)
+	}
+}
+
+func (w *changeWatcher[K, V]) flush() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.timer = nil
+	for _, k := range w.order {
+		// A key may be in the order multiple times if its events canceled out and
+		// it was changed again.
+		if ev, ok := w.pending[k]; ok {
+			w.uc.Send(ev)
+			delete(w.pending, k)
+		}
+	}
+	w.order = w.order[:0]
+}
+
+func (w *changeWatcher[K, V]) close() {
+	w.mtx.Lock()
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.pending, w.order = nil, nil
+	w.mtx.Unlock()
+	w.uc.Close()
+}
+
+// mergeChanges merges two successive events for the same key, returning false
+// if they cancel out.
+func mergeChanges[K comparable, V any](
+	prev, next ChangeEvent[K, V],
+) (ChangeEvent[K, V], bool) {
+	switch {
+	case prev.Kind == ChangeInsert && next.Kind == ChangeDelete:
+		return next, false
+	case prev.Kind == ChangeInsert:
+		prev.New = next.New
+		return prev, true
+	case prev.Kind == ChangeDelete && next.Kind != ChangeDelete:
+		next.Kind, next.Old = ChangeUpdate, prev.Old
+		return next, true
+	default:
+		next.Old = prev.Old
+		return next, true
+	}
+}
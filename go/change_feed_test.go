@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func recvChanges[K comparable, V any](
+	t *testing.T, ch <-chan ChangeEvent[K, V], n int,
+) []ChangeEvent[K, V] {
+	t.Helper()
+	evs := make([]ChangeEvent[K, V], 0, n)
+	for len(evs) < n {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				t.Fatalf("chan closed after %d events: %v", len(evs), evs)
+			}
+			evs = append(evs, ev)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out after %d events: %v", len(evs), evs)
+		}
+	}
+	return evs
+}
+
+func TestMapWatchChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewMap[string, int]()
+	m.Set("unwatched", 0)
+	ch := m.WatchChanges(ctx)
+
+	m.Set("a", 1)
+	m.Set("a", 2)
+	m.Insert("b", 3)
+	if !m.TrySet("c", 4) || m.TrySet("c", 5) {
+		t.Fatal("unexpected TrySet results")
+	}
+	m.Delete("a")
+	m.Delete("missing")
+	m.GetDelete("b")
+	m.MapValues(func(v int) int { return v * 10 })
+	m.FilterKeys(func(k string) bool { return k != "c" })
+
+	type ev = ChangeEvent[string, int]
+	want := []ev{
+		{Kind: ChangeInsert, Key: "a", New: 1},
+		{Kind: ChangeUpdate, Key: "a", Old: 1, New: 2},
+		{Kind: ChangeInsert, Key: "b", New: 3},
+		{Kind: ChangeInsert, Key: "c", New: 4},
+		{Kind: ChangeDelete, Key: "a", Old: 2},
+		{Kind: ChangeDelete, Key: "b", Old: 3},
+	}
+	got := recvChanges(t, ch, len(want)+3)
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("event %d: expected %+v, got %+v", i, w, got[i])
+		}
+	}
+	// The MapValues updates happen in random order.
+	updates := map[string]ev{}
+	for _, e := range got[len(want) : len(want)+2] {
+		updates[e.Key] = e
+	}
+	if e := updates["c"]; e.Kind != ChangeUpdate || e.Old != 4 || e.New != 40 {
+		t.Errorf("unexpected event for c: %+v", e)
+	}
+	if e := updates["unwatched"]; e.Kind != ChangeUpdate {
+		t.Errorf("unexpected event for unwatched: %+v", e)
+	}
+	if e := got[len(want)+2]; e.Kind != ChangeDelete || e.Key != "c" {
+		t.Errorf("expected delete of c, got %+v", e)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		for ok {
+			_, ok = <-ch
+		}
+	case <-time.After(time.Second):
+		t.Fatal("chan not closed after cancel")
+	}
+	if m.feed.active() {
+		t.Fatal("watcher not removed after cancel")
+	}
+	m.Set("z", 1)
+}
+
+func TestSetWatchChangesCoalesce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewSet[int]()
+	s.Insert(1)
+	ch := s.WatchChangesOpts(ctx, WatchOpts{Coalesce: 20 * time.Millisecond})
+
+	s.Insert(2)
+	s.Remove(2) // Cancels out the insert.
+	s.Remove(1)
+	s.Insert(1) // Delete then insert is an update.
+	s.Insert(3)
+	s.Filter(func(i int) bool { return i != 3 })
+	s.Insert(4)
+
+	got := recvChanges(t, ch, 2)
+	want := []ChangeEvent[int, Unit]{
+		{Kind: ChangeUpdate, Key: 1},
+		{Kind: ChangeInsert, Key: 4},
+	}
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("event %d: expected %+v, got %+v", i, w, got[i])
+		}
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMergeChanges(t *testing.T) {
+	type ev = ChangeEvent[int, int]
+	upd := ev{Kind: ChangeUpdate, Old: 1, New: 2}
+	if m, ok := mergeChanges(upd, ev{Kind: ChangeUpdate, Old: 2, New: 3}); !ok ||
+		m != (ev{Kind: ChangeUpdate, Old: 1, New: 3}) {
+		t.Errorf("update+update: got %+v, %v", m, ok)
+	}
+	if m, ok := mergeChanges(upd, ev{Kind: ChangeDelete, Old: 2}); !ok ||
+		m != (ev{Kind: ChangeDelete, Old: 1}) {
+		t.Errorf("update+delete: got %+v, %v", m, ok)
+	}
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrChecksum means data didn't match its expected checksum.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// ChunkManifest describes a file split into chunks by SplitFile.
+type ChunkManifest struct {
+	// Name is the base name of the original file.
+	Name string `json:"name"`
+	// Size is the size of the original file.
+	Size int64 `json:"size"`
+	// ChunkSize is the maximum size of each chunk.
+	ChunkSize int64 `json:"chunkSize"`
+	// SHA256 is the hex-encoded SHA-256 of the original file.
+	SHA256 string `json:"sha256"`
+	// Chunks are the chunks, in order.
+	Chunks []ChunkInfo `json:"chunks"`
+}
+
+// ChunkInfo describes a single chunk in a ChunkManifest.
+type ChunkInfo struct {
+	// Name is the base name of the chunk file, which is in the same directory
+	// as the manifest.
+	Name string `json:"name"`
+	// Size is the size of the chunk.
+	Size int64 `json:"size"`
+	// SHA256 is the hex-encoded SHA-256 of the chunk.
+	SHA256 string `json:"sha256"`
+}
+
+// ChunkManifestPath returns the path of the manifest SplitFile writes for the
+// file at the given path.
+func ChunkManifestPath(path string) string {
+	return path + ".manifest.json"
+}
+
+// SplitFile splits the file at the given path into numbered chunk files of at
+// most chunkSize bytes (path.000, path.001, ...) next to the original, along
+// with a manifest at ChunkManifestPath(path). The manifest is written last, so
+// its existence means the split completed. An empty file produces no chunks.
+func SplitFile(path string, chunkSize int64) (*ChunkManifest, error) {
+	if chunkSize < 1 {
+		return nil, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &ChunkManifest{Name: filepath.Base(path), ChunkSize: chunkSize}
+	fileHash := sha256.New()
+	r := io.TeeReader(f, fileHash)
+	for i := 0; ; i++ {
+		chunkPath := fmt.Sprintf("%s.%03d", path, i)
+		info, err := writeChunk(chunkPath, io.LimitReader(r, chunkSize))
+		if err != nil {
+			return nil, err
+		}
+		if info.Size == 0 {
+			os.Remove(chunkPath)
+			break
+		}
+		m.Chunks = append(m.Chunks, info)
+		m.Size += info.Size
+		if info.Size < chunkSize {
+			break
+		}
+	}
+	m.SHA256 = hex.EncodeToString(fileHash.Sum(nil))
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(ChunkManifestPath(path), b, 0644); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func writeChunk(path string, r io.Reader) (info ChunkInfo, err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return info, err
+	}
+	h := sha256.New()
+	info.Size, err = io.Copy(io.MultiWriter(f, h), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	info.Name = filepath.Base(path)
+	info.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return info, err
+}
+
+// ReadChunkManifest reads the manifest at the given path.
+func ReadChunkManifest(path string) (*ChunkManifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &ChunkManifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// JoinChunks joins the chunks described by the manifest at the given path
+// into a file at out, verifying the size and checksum of each chunk as well as
+// the whole file. Chunks are looked for in the manifest's directory. On
+// failure, out is removed. Checksum and size mismatches are reported with
+// errors wrapping ErrChecksum.
+func JoinChunks(manifestPath, out string) error {
+	m, err := ReadChunkManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = joinChunks(m, filepath.Dir(manifestPath), f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(out)
+	}
+	return err
+}
+
+func joinChunks(m *ChunkManifest, dir string, w io.Writer) error {
+	fileHash := sha256.New()
+	w = io.MultiWriter(w, fileHash)
+	var total int64
+	for _, info := range m.Chunks {
+		n, err := copyChunk(w, filepath.Join(dir, info.Name), info)
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+	if total != m.Size {
+		return fmt.Errorf(
+			"%w: expected size %d, got %d", ErrChecksum, m.Size, total,
+		)
+	}
+	return checkSum(fileHash, m.SHA256, m.Name)
+}
+
+func copyChunk(w io.Writer, path string, info ChunkInfo) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	// Read at most one extra byte to detect chunks that are too long
+	n, err := io.Copy(io.MultiWriter(w, h), io.LimitReader(f, info.Size+1))
+	if err != nil {
+		return n, err
+	}
+	if n != info.Size {
+		return n, fmt.Errorf(
+			"%w: chunk %s: expected size %d, got at least %d",
+			ErrChecksum, info.Name, info.Size, n,
+		)
+	}
+	return n, checkSum(h, info.SHA256, info.Name)
+}
+
+func checkSum(h hash.Hash, want, name string) error {
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf(
+			"%w: %s: expected %s, got %s", ErrChecksum, name, want, got,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitJoinFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	data := make([]byte, 2500)
+	rand.New(rand.NewSource(1)).Read(data)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := SplitFile(path, 1000)
+	if err != nil {
+		t.Fatal("error splitting: ", err)
+	}
+	if len(m.Chunks) != 3 || m.Size != 2500 || m.Chunks[2].Size != 500 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	out := filepath.Join(dir, "out.bin")
+	if err := JoinChunks(ChunkManifestPath(path), out); err != nil {
+		t.Fatal("error joining: ", err)
+	}
+	if got, err := os.ReadFile(out); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(got, data) {
+		t.Fatal("joined data doesn't match")
+	}
+
+	// Corrupt a chunk
+	chunk := filepath.Join(dir, m.Chunks[1].Name)
+	if err := os.WriteFile(chunk, data[:1000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = JoinChunks(ChunkManifestPath(path), out)
+	if !errors.Is(err, ErrChecksum) {
+		t.Fatalf("expected ErrChecksum, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatal("expected output to be removed")
+	}
+
+	// Exact multiple of the chunk size
+	if err := os.WriteFile(path, data[:2000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := SplitFile(path, 1000); err != nil {
+		t.Fatal("error splitting: ", err)
+	} else if len(m.Chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(m.Chunks))
+	}
+	if _, err := os.Stat(path + ".002"); !os.IsNotExist(err) {
+		t.Fatal("expected no empty trailing chunk")
+	}
+}
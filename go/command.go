@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// UsageError is an error caused by invalid usage of a command (e.g., bad
+// flags or arguments). Command.Execute prints the command's usage when one is
+// encountered.
+type UsageError struct {
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (ue *UsageError) Error() string {
+	return ue.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (ue *UsageError) Unwrap() error {
+	return ue.Err
+}
+
+// ArgsValidator validates the positional arguments passed to a command.
+type ArgsValidator func(args []string) error
+
+// NoArgs returns an error if any arguments are passed.
+func NoArgs(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("expected no arguments, got %d", len(args))
+	}
+	return nil
+}
+
+// ExactArgs returns an ArgsValidator requiring exactly n arguments.
+func ExactArgs(n int) ArgsValidator {
+	return RangeArgs(n, n)
+}
+
+// MinArgs returns an ArgsValidator requiring at least n arguments.
+func MinArgs(n int) ArgsValidator {
+	return RangeArgs(n, -1)
+}
+
+// MaxArgs returns an ArgsValidator requiring at most n arguments.
+func MaxArgs(n int) ArgsValidator {
+	return RangeArgs(0, n)
+}
+
+// RangeArgs returns an ArgsValidator requiring between min and max arguments
+// (inclusive). A max less than 0 means no maximum.
+func RangeArgs(min, max int) ArgsValidator {
+	return func(args []string) error {
+		if n := len(args); n < min || (max >= 0 && n > max) {
+			switch {
+			case min == max:
+				return fmt.Errorf("expected %d arguments, got %d", min, n)
+			case max < 0:
+				return fmt.Errorf("expected at least %d arguments, got %d", min, n)
+			default:
+				return fmt.Errorf(
+					"expected between %d and %d arguments, got %d", min, max, n,
+				)
+			}
+		}
+		return nil
+	}
+}
+
+// Command is a CLI command, possibly with subcommands. Each command has its
+// own flags, which are parsed before dispatching to a subcommand or running
+// the command.
+type Command struct {
+	// Name is the name of the command, used to invoke it as a subcommand.
+	Name string
+	// ArgsUsage describes the positional arguments in the usage line (e.g.,
+	// "<src> <dst>").
+	ArgsUsage string
+	// Short is a one-line description shown in the parent's command list.
+	Short string
+	// Long is a longer description shown in the command's usage.
+	Long string
+	// Args validates the positional arguments before Run is called. If nil,
+	// any arguments are accepted.
+	Args ArgsValidator
+	// Run runs the command with the remaining positional arguments. If nil, the
+	// command must be invoked with a subcommand.
+	Run func(ctx context.Context, cmd *Command, args []string) error
+	// Out is where usage is written. If nil, the parent's is used, or
+	// os.Stderr if there is no parent.
+	Out io.Writer
+
+	flags  *FlagBinder
+	parent *Command
+	subs   []*Command
+}
+
+// AddCommand adds the given subcommands.
+func (c *Command) AddCommand(cmds ...*Command) {
+	for _, cmd := range cmds {
+		cmd.parent = c
+		c.subs = append(c.subs, cmd)
+	}
+}
+
+// Commands returns the subcommands.
+func (c *Command) Commands() []*Command {
+	return c.subs
+}
+
+// Parent returns the parent command, or nil if there is none.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Flags returns the command's flags, creating them if necessary. The
+// FlagBinder's EnvPrefix can be set to enable environment variable fallbacks.
+func (c *Command) Flags() *FlagBinder {
+	if c.flags == nil {
+		fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		c.flags = NewFlagBinder(fs, "")
+	}
+	return c.flags
+}
+
+// Path returns the full name of the command, including its parents' names.
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Output returns the writer usage is written to.
+func (c *Command) Output() io.Writer {
+	if c.Out != nil {
+		return c.Out
+	}
+	if c.parent != nil {
+		return c.parent.Output()
+	}
+	return os.Stderr
+}
+
+// Usage returns the usage text for the command.
+func (c *Command) Usage() string {
+	var sb strings.Builder
+	sb.WriteString("Usage: " + c.Path())
+	if c.hasFlags() {
+		sb.WriteString(" [flags]")
+	}
+	if len(c.subs) != 0 {
+		if c.Run == nil {
+			sb.WriteString(" <command>")
+		} else {
+			sb.WriteString(" [command]")
+		}
+	}
+	if c.ArgsUsage != "" {
+		sb.WriteString(" " + c.ArgsUsage)
+	}
+	sb.WriteByte('\n')
+	if c.Long != "" {
+		sb.WriteString("\n" + strings.TrimSpace(c.Long) + "\n")
+	} else if c.Short != "" {
+		sb.WriteString("\n" + c.Short + "\n")
+	}
+	if len(c.subs) != 0 {
+		sb.WriteString("\nCommands:\n")
+		tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+		for _, sub := range c.subs {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.Name, sub.Short)
+		}
+		tw.Flush()
+	}
+	if c.hasFlags() {
+		sb.WriteString("\nFlags:\n")
+		fs := c.flags.FlagSet
+		out := fs.Output()
+		fs.SetOutput(&sb)
+		fs.PrintDefaults()
+		fs.SetOutput(out)
+		c.flags.VisitAll(func(f *flag.Flag) {
+			if envName := c.flags.EnvName(f.Name); envName != "" {
+				fmt.Fprintf(&sb, "  -%s can be set with $%s\n", f.Name, envName)
+			}
+		})
+	}
+	return sb.String()
+}
+
+func (c *Command) hasFlags() bool {
+	if c.flags == nil {
+		return false
+	}
+	has := false
+	c.flags.VisitAll(func(*flag.Flag) { has = true })
+	return has
+}
+
+// Execute parses the flags in args (which shouldn't include the program
+// name), dispatching to the subcommand named by the first remaining argument
+// if there is one, otherwise, validating the remaining arguments and running
+// the command. On a UsageError, the usage of the command is written to its
+// output. If help is requested (-h or -help), the usage is written and
+// flag.ErrHelp is returned.
+func (c *Command) Execute(ctx context.Context, args []string) error {
+	cmd, err := c.execute(ctx, args)
+	var ue *UsageError
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(cmd.Output(), cmd.Usage())
+	} else if errors.As(err, &ue) {
+		fmt.Fprintf(cmd.Output(), "%s: %v\n\n%s", cmd.Path(), err, cmd.Usage())
+	}
+	return err
+}
+
+// execute executes the command, returning the command that was ultimately
+// run (or failed).
+func (c *Command) execute(
+	ctx context.Context, args []string,
+) (*Command, error) {
+	if err := c.Flags().Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return c, err
+		}
+		return c, &UsageError{Err: err}
+	}
+	args = c.flags.Args()
+	if len(args) != 0 {
+		for _, sub := range c.subs {
+			if sub.Name == args[0] {
+				return sub.execute(ctx, args[1:])
+			}
+		}
+	}
+	if c.Run == nil {
+		if len(args) == 0 {
+			return c, &UsageError{Err: errors.New("missing command")}
+		}
+		names := make([]string, len(c.subs))
+		for i, sub := range c.subs {
+			names[i] = sub.Name
+		}
+		return c, &UsageError{
+			Err: fmt.Errorf(
+				"unknown command %q%s", args[0], didYouMean(args[0], names),
+			),
+		}
+	}
+	if c.Args != nil {
+		if err := c.Args(args); err != nil {
+			return c, &UsageError{Err: err}
+		}
+	}
+	return c, c.Run(ctx, c, args)
+}
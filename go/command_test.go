@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	var out strings.Builder
+	var verbose *bool
+	var name *string
+	var gotArgs []string
+	// Flags are only meant to be parsed once, so create new commands for each
+	// execution.
+	newRoot := func() *Command {
+		root := &Command{Name: "app", Short: "An app", Out: &out}
+		verbose = root.Flags().Bool("verbose", false, "verbose output")
+		greet := &Command{
+			Name:      "greet",
+			ArgsUsage: "<target>...",
+			Short:     "Greet targets",
+			Args:      MinArgs(1),
+			Run: func(ctx context.Context, cmd *Command, args []string) error {
+				gotArgs = args
+				return nil
+			},
+		}
+		name = greet.Flags().String("name", "world", "name to use")
+		greet.Flags().EnvPrefix = "UTILS_TEST_GREET_"
+		root.AddCommand(greet)
+		return root
+	}
+
+	ctx := context.Background()
+	err := newRoot().Execute(
+		ctx, []string{"-verbose", "greet", "-name", "a", "b"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*verbose || *name != "a" || len(gotArgs) != 1 || gotArgs[0] != "b" {
+		t.Fatalf("bad parse: %v %q %v", *verbose, *name, gotArgs)
+	}
+
+	// Env fallback
+	t.Setenv("UTILS_TEST_GREET_NAME", "env")
+	if err := newRoot().Execute(ctx, []string{"greet", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if *name != "env" {
+		t.Fatalf("expected name from env, got %q", *name)
+	}
+	err = newRoot().Execute(ctx, []string{"greet", "-name=flag", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *name != "flag" {
+		t.Fatalf("expected name from flag, got %q", *name)
+	}
+
+	// Arg validation
+	out.Reset()
+	err = newRoot().Execute(ctx, []string{"greet"})
+	var ue *UsageError
+	if !errors.As(err, &ue) {
+		t.Fatalf("expected UsageError, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Usage: app greet [flags] <target>...") {
+		t.Fatalf("bad usage output:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "$UTILS_TEST_GREET_NAME") {
+		t.Fatalf("usage missing env var:\n%s", out.String())
+	}
+
+	// Unknown and missing commands
+	if err := newRoot().Execute(ctx, []string{"nope"}); !errors.As(err, &ue) {
+		t.Fatalf("expected UsageError, got %v", err)
+	} else if strings.Contains(err.Error(), "did you mean") {
+		t.Fatalf("unexpected suggestion: %v", err)
+	}
+	err = newRoot().Execute(ctx, []string{"gret"})
+	if err == nil || !strings.Contains(err.Error(), `did you mean "greet"?`) {
+		t.Fatalf("expected suggestion, got %v", err)
+	}
+	if err := newRoot().Execute(ctx, nil); !errors.As(err, &ue) {
+		t.Fatalf("expected UsageError, got %v", err)
+	}
+
+	// Help
+	out.Reset()
+	if err := newRoot().Execute(ctx, []string{"-h"}); err != flag.ErrHelp {
+		t.Fatalf("expected ErrHelp, got %v", err)
+	}
+	if !strings.Contains(out.String(), "greet  Greet targets") {
+		t.Fatalf("usage missing commands:\n%s", out.String())
+	}
+}
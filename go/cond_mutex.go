@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CondMutex is a Mutex paired with condition variable semantics, allowing
+// goroutines to wait (optionally with a timeout or context) for a condition on
+// the data to become true.
+type CondMutex[T any] struct {
+	data T
+	mtx  sync.Mutex
+
+	waitMtx sync.Mutex
+	waiters list.List
+}
+
+// NewCondMutex creates a new CondMutex.
+func NewCondMutex[T any](t T) *CondMutex[T] {
+	return &CondMutex[T]{data: t}
+}
+
+// Lock locks the mutex, returning a pointer to data.
+func (m *CondMutex[T]) Lock() *T {
+	m.mtx.Lock()
+	return &m.data
+}
+
+// Unlock unlocks the mutex. The data should no longer be used.
+func (m *CondMutex[T]) Unlock() {
+	m.mtx.Unlock()
+}
+
+// Apply locks the mutex and calls the passed function with a pointer to the
+// data.
+func (m *CondMutex[T]) Apply(f func(*T)) {
+	defer m.Unlock()
+	f(m.Lock())
+}
+
+// Update locks the mutex, calls the passed function with a pointer to the
+// data, and then wakes all waiters.
+func (m *CondMutex[T]) Update(f func(*T)) {
+	m.Apply(f)
+	m.Broadcast()
+}
+
+// Wait locks the mutex and waits until pred returns true, returning a pointer
+// to the data with the mutex locked. pred is called with the mutex locked
+// initially and each time a waiter is woken.
+func (m *CondMutex[T]) Wait(pred func(*T) bool) *T {
+	t, _ := m.wait(nil, nil, pred)
+	return t
+}
+
+// WaitTimeout is the same as Wait but gives up after the timeout, returning
+// false (with the mutex unlocked).
+func (m *CondMutex[T]) WaitTimeout(
+	pred func(*T) bool, timeout time.Duration,
+) (*T, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return m.wait(timer.C, nil, pred)
+}
+
+// WaitContext is the same as Wait but gives up when the context is done,
+// returning ErrCanceled (wrapping the context's error) with the mutex
+// unlocked.
+func (m *CondMutex[T]) WaitContext(
+	ctx context.Context, pred func(*T) bool,
+) (*T, error) {
+	t, ok := m.wait(nil, ctx.Done(), pred)
+	if !ok {
+		return nil, newCanceledError(ctx)
+	}
+	return t, nil
+}
+
+func (m *CondMutex[T]) wait(
+	timeout <-chan time.Time, done <-chan struct{}, pred func(*T) bool,
+) (*T, bool) {
+	m.mtx.Lock()
+	for !pred(&m.data) {
+		// Register before unlocking so that wakeups aren't missed
+		ch := make(chan struct{})
+		m.waitMtx.Lock()
+		elem := m.waiters.PushBack(ch)
+		m.waitMtx.Unlock()
+		m.mtx.Unlock()
+
+		select {
+		case <-ch:
+		case <-timeout:
+			m.cancelWait(elem, ch)
+			return nil, false
+		case <-done:
+			m.cancelWait(elem, ch)
+			return nil, false
+		}
+		m.mtx.Lock()
+	}
+	return &m.data, true
+}
+
+// cancelWait removes the waiter, passing on a signal if one was received.
+func (m *CondMutex[T]) cancelWait(elem *list.Element, ch chan struct{}) {
+	m.waitMtx.Lock()
+	select {
+	case <-ch:
+		m.waitMtx.Unlock()
+		m.Signal()
+	default:
+		m.waiters.Remove(elem)
+		m.waitMtx.Unlock()
+	}
+}
+
+// Signal wakes one waiter, if any, to recheck its condition.
+func (m *CondMutex[T]) Signal() {
+	m.waitMtx.Lock()
+	defer m.waitMtx.Unlock()
+	if front := m.waiters.Front(); front != nil {
+		close(m.waiters.Remove(front).(chan struct{}))
+	}
+}
+
+// Broadcast wakes all waiters to recheck their conditions.
+func (m *CondMutex[T]) Broadcast() {
+	m.waitMtx.Lock()
+	defer m.waitMtx.Unlock()
+	for front := m.waiters.Front(); front != nil; front = m.waiters.Front() {
+		close(m.waiters.Remove(front).(chan struct{}))
+	}
+}
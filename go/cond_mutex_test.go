@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCondMutex(t *testing.T) {
+	m := NewCondMutex([]int{})
+	const n = 100
+	var wg sync.WaitGroup
+	wg.Add(2)
+	var got []int
+	// Consumer
+	go func() {
+		defer wg.Done()
+		for len(got) < n {
+			q := m.Wait(func(q *[]int) bool { return len(*q) != 0 })
+			got = append(got, *q...)
+			*q = (*q)[:0]
+			m.Unlock()
+		}
+	}()
+	// Producer
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			m.Update(func(q *[]int) { *q = append(*q, i) })
+		}
+	}()
+	wg.Wait()
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("expected %d at %d, got %d", i, i, v)
+		}
+	}
+
+	never := func(*[]int) bool { return false }
+	if _, ok := m.WaitTimeout(never, time.Millisecond*10); ok {
+		t.Fatal("expected timeout")
+	}
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*10,
+	)
+	defer cancel()
+	if _, err := m.WaitContext(ctx, never); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	// Mutex should be unlocked after timing out
+	m.Lock()
+	m.Unlock()
+
+	// Signal wakes waiters one at a time
+	woken := make(chan Unit, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			m.Wait(func(q *[]int) bool { return len(*q) != 0 })
+			m.Unlock()
+			woken <- Unit{}
+		}()
+	}
+	time.Sleep(time.Millisecond * 10)
+	m.Apply(func(q *[]int) { *q = append(*q, 1) })
+	m.Signal()
+	<-woken
+	select {
+	case <-woken:
+		t.Fatal("second waiter woken by Signal")
+	case <-time.After(time.Millisecond * 10):
+	}
+	m.Broadcast()
+	<-woken
+}
@@ -0,0 +1,32 @@
+package utils
+
+// Signed is a constraint for signed integer types.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is a constraint for unsigned integer types (including those usable
+// with Put, Place, and Get).
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Integer is a constraint for integer types.
+type Integer interface {
+	Signed | Unsigned
+}
+
+// Float is a constraint for floating-point types.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is a constraint for integer and floating-point types.
+type Number interface {
+	Integer | Float
+}
+
+// Ordered is a constraint for types that support the ordering operators.
+type Ordered interface {
+	Integer | Float | ~string
+}
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Resolver resolves values from a Container. It is passed to constructors so
+// they can resolve their own dependencies (using Resolve).
+type Resolver interface {
+	resolve(t reflect.Type) (any, error)
+}
+
+// Container is a minimal dependency-injection container. Constructors are
+// registered per type with Provide and called (at most once per type, with
+// the result memoized) the first time the type is resolved with Resolve.
+// Dependency cycles are detected and returned as errors. It is safe for
+// concurrent use, though a cycle spanning multiple goroutines' concurrent
+// resolutions deadlocks rather than being detected.
+type Container struct {
+	mtx     sync.Mutex
+	entries map[reflect.Type]*containerEntry
+	// resolved is the entries in the order they were resolved (a dependency
+	// is always before its dependents).
+	resolved []*containerEntry
+}
+
+type containerEntry struct {
+	typ  reflect.Type
+	ctor func(Resolver) (any, error)
+	// mtx is held while the constructor is running.
+	mtx  sync.Mutex
+	done bool
+	val  any
+	// deps is the types resolved directly by the constructor.
+	deps []reflect.Type
+}
+
+// NewContainer returns a new, empty Container.
+func NewContainer() *Container {
+	return &Container{entries: make(map[reflect.Type]*containerEntry)}
+}
+
+// Provide registers the constructor for T, returning an error wrapping
+// ErrExists if T already has one. The constructor resolves any dependencies
+// from the Resolver passed to it.
+func Provide[T any](c *Container, ctor func(r Resolver) (T, error)) error {
+	return c.provide(reflect.TypeFor[T](), func(r Resolver) (any, error) {
+		return ctor(r)
+	})
+}
+
+// ProvideValue registers an already-constructed value for T.
+func ProvideValue[T any](c *Container, val T) error {
+	return Provide(c, func(Resolver) (T, error) {
+		return val, nil
+	})
+}
+
+func (c *Container) provide(
+	t reflect.Type, ctor func(Resolver) (any, error),
+) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.entries[t]; ok {
+		return fmt.Errorf("provider for %s: %w", t, ErrExists)
+	}
+	c.entries[t] = &containerEntry{typ: t, ctor: ctor}
+	return nil
+}
+
+// Resolve returns the value of type T, constructing it (and its dependencies)
+// if needed. Returns an error wrapping ErrNotFound if there is no provider for
+// T (or a dependency), or an error describing the cycle if there is a
+// dependency cycle. Constructor errors aren't memoized, so a later Resolve
+// tries again.
+func Resolve[T any](r Resolver) (T, error) {
+	val, err := r.resolve(reflect.TypeFor[T]())
+	if err != nil {
+		var t T
+		return t, err
+	}
+	// The value may be a nil interface.
+	t, _ := val.(T)
+	return t, nil
+}
+
+// MustResolve is like Resolve but panics on error.
+func MustResolve[T any](r Resolver) T {
+	return Must(Resolve[T](r))
+}
+
+func (c *Container) resolve(t reflect.Type) (any, error) {
+	return (&containerScope{c: c}).resolve(t)
+}
+
+// containerScope is the Resolver passed to a constructor, tracking the chain
+// of types being resolved (for cycle detection) and the constructor's direct
+// dependencies.
+type containerScope struct {
+	c     *Container
+	chain []reflect.Type
+	deps  []reflect.Type
+}
+
+func (s *containerScope) resolve(t reflect.Type) (any, error) {
+	s.c.mtx.Lock()
+	e, ok := s.c.entries[t]
+	s.c.mtx.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("provider for %s: %w", t, ErrNotFound)
+	}
+	for i, ct := range s.chain {
+		if ct == t {
+			return nil, fmt.Errorf(
+				"dependency cycle: %s", formatTypeChain(append(s.chain[i:], t)),
+			)
+		}
+	}
+	s.deps = append(s.deps, t)
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.done {
+		return e.val, nil
+	}
+	child := &containerScope{c: s.c, chain: append(CloneSlice(s.chain), t)}
+	val, err := e.ctor(child)
+	if err != nil {
+		return nil, fmt.Errorf("constructing %s: %w", t, err)
+	}
+	e.val, e.deps, e.done = val, child.deps, true
+	s.c.mtx.Lock()
+	s.c.resolved = append(s.c.resolved, e)
+	s.c.mtx.Unlock()
+	return val, nil
+}
+
+func formatTypeChain(chain []reflect.Type) string {
+	names := make([]string, len(chain))
+	for i, t := range chain {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// AddToSupervisor adds each resolved value that implements Starter, Runner,
+// or Stopper to the supervisor, named after its type, in the order they were
+// resolved. A component depends on the components it was constructed from,
+// including those reached only through dependencies that aren't components
+// themselves, so the supervisor starts them in dependency order.
+func (c *Container) AddToSupervisor(s *Supervisor) error {
+	c.mtx.Lock()
+	resolved := CloneSlice(c.resolved)
+	c.mtx.Unlock()
+
+	byType := make(map[reflect.Type]*containerEntry, len(resolved))
+	for _, e := range resolved {
+		byType[e.typ] = e
+	}
+	isComp := func(e *containerEntry) bool {
+		switch e.val.(type) {
+		case Starter, Runner, Stopper:
+			return true
+		}
+		return false
+	}
+	for _, e := range resolved {
+		if !isComp(e) {
+			continue
+		}
+		var deps []string
+		seen := make(map[reflect.Type]bool)
+		var collect func(types []reflect.Type)
+		collect = func(types []reflect.Type) {
+			for _, t := range types {
+				if seen[t] {
+					continue
+				}
+				seen[t] = true
+				// Types whose constructors failed (with the error handled by
+				// the dependent) were never resolved, so they're skipped.
+				if dep := byType[t]; dep == nil {
+					continue
+				} else if isComp(dep) {
+					deps = append(deps, t.String())
+				} else {
+					collect(dep.deps)
+				}
+			}
+		}
+		collect(e.deps)
+		if err := s.Add(e.typ.String(), e.val, deps...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testConfig struct{ dsn string }
+
+type testDB struct {
+	cfg    *testConfig
+	events *Mutex[[]string]
+}
+
+func (db *testDB) Start(ctx context.Context) error {
+	db.events.Apply(func(ep *[]string) { *ep = append(*ep, "start db") })
+	return nil
+}
+
+type testRepo struct{ db *testDB }
+
+type testServer struct {
+	repo   *testRepo
+	events *Mutex[[]string]
+}
+
+func (srv *testServer) Start(ctx context.Context) error {
+	srv.events.Apply(func(ep *[]string) { *ep = append(*ep, "start server") })
+	return nil
+}
+
+type testCycleA struct{}
+type testCycleB struct{}
+
+func TestContainer(t *testing.T) {
+	events := NewMutex[[]string](nil)
+	c := NewContainer()
+	ctorCalls := 0
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(ProvideValue(c, &testConfig{dsn: "mem://"}))
+	must(Provide(c, func(r Resolver) (*testDB, error) {
+		ctorCalls++
+		cfg, err := Resolve[*testConfig](r)
+		return &testDB{cfg: cfg, events: events}, err
+	}))
+	must(Provide(c, func(r Resolver) (*testRepo, error) {
+		db, err := Resolve[*testDB](r)
+		return &testRepo{db: db}, err
+	}))
+	must(Provide(c, func(r Resolver) (*testServer, error) {
+		return &testServer{repo: MustResolve[*testRepo](r), events: events}, nil
+	}))
+	err := ProvideValue(c, &testConfig{})
+	if !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+
+	srv, err := Resolve[*testServer](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.repo.db.cfg.dsn != "mem://" {
+		t.Fatal("dependencies not wired")
+	}
+	if db := MustResolve[*testDB](c); db != srv.repo.db || ctorCalls != 1 {
+		t.Fatalf("expected memoized db, got %d constructor calls", ctorCalls)
+	}
+
+	if _, err := Resolve[string](c); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// The server depends on the db through the repo, which isn't a component.
+	sup := NewSupervisor(SupervisorOpts{})
+	must(c.AddToSupervisor(sup))
+	must(sup.Start(context.Background()))
+	want := []string{"start db", "start server"}
+	if got := *events.Lock(); !SliceEq(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	events.Unlock()
+	if len(sup.States()) != 2 {
+		t.Fatalf("unexpected components: %v", sup.States())
+	}
+	must(sup.Stop(context.Background()))
+}
+
+func TestContainerErrors(t *testing.T) {
+	c := NewContainer()
+	Provide(c, func(r Resolver) (testCycleA, error) {
+		_, err := Resolve[testCycleB](r)
+		return testCycleA{}, err
+	})
+	Provide(c, func(r Resolver) (testCycleB, error) {
+		_, err := Resolve[testCycleA](r)
+		return testCycleB{}, err
+	})
+	_, err := Resolve[testCycleA](c)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+
+	attempts := 0
+	Provide(c, func(r Resolver) (int, error) {
+		if attempts++; attempts == 1 {
+			return 0, errors.New("transient")
+		}
+		return 42, nil
+	})
+	if _, err := Resolve[int](c); err == nil {
+		t.Fatal("expected constructor error")
+	}
+	if n, err := Resolve[int](c); err != nil || n != 42 {
+		t.Fatalf("expected 42, got %d, %v", n, err)
+	}
+
+	Provide(c, func(r Resolver) (error, error) { return nil, nil })
+	if err, rerr := Resolve[error](c); err != nil || rerr != nil {
+		t.Fatalf("expected nil interface value, got %v, %v", err, rerr)
+	}
+}
+
+func TestContainerFailedDep(t *testing.T) {
+	c := NewContainer()
+	Provide(c, func(r Resolver) (*testRepo, error) {
+		return nil, errors.New("no db")
+	})
+	events := NewMutex[[]string](nil)
+	Provide(c, func(r Resolver) (*testServer, error) {
+		// The repo is optional, so the error is ignored.
+		repo, _ := Resolve[*testRepo](r)
+		return &testServer{repo: repo, events: events}, nil
+	})
+	if _, err := Resolve[*testServer](c); err != nil {
+		t.Fatal(err)
+	}
+	sup := NewSupervisor(SupervisorOpts{})
+	if err := c.AddToSupervisor(sup); err != nil {
+		t.Fatal(err)
+	}
+	if len(sup.States()) != 1 {
+		t.Fatalf("unexpected components: %v", sup.States())
+	}
+}
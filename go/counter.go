@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"sort"
+)
+
+// Counter is a multiset, tracking the number of occurrences of each item.
+// Items with a count of 0 are not stored.
+type Counter[T comparable] struct {
+	m     map[T]int
+	total int
+}
+
+// CounterEntry is an item and its count in a Counter.
+type CounterEntry[T comparable] struct {
+	Item  T
+	Count int
+}
+
+// NewCounter creates a new Counter.
+func NewCounter[T comparable]() *Counter[T] {
+	return &Counter[T]{m: make(map[T]int)}
+}
+
+// CounterFromSlice creates a new Counter counting the items of the given
+// slice.
+func CounterFromSlice[T comparable](s []T) *Counter[T] {
+	c := &Counter[T]{m: make(map[T]int, len(s))}
+	for _, t := range s {
+		c.Add(t)
+	}
+	return c
+}
+
+// CounterFromMap creates a new Counter from the given map of items to counts.
+// Non-positive counts are ignored.
+func CounterFromMap[T comparable](m map[T]int) *Counter[T] {
+	c := &Counter[T]{m: make(map[T]int, len(m))}
+	for t, n := range m {
+		c.AddN(t, n)
+	}
+	return c
+}
+
+// Add adds one occurrence of the item, returning the new count.
+func (c *Counter[T]) Add(item T) int {
+	return c.AddN(item, 1)
+}
+
+// AddN adds n occurrences of the item, returning the new count. If n is
+// negative, it functions the same as RemoveN(item, -n).
+func (c *Counter[T]) AddN(item T, n int) int {
+	if n < 0 {
+		c.RemoveN(item, -n)
+		return c.m[item]
+	}
+	if n == 0 {
+		return c.m[item]
+	}
+	c.m[item] += n
+	c.total += n
+	return c.m[item]
+}
+
+// Remove removes one occurrence of the item, returning false if there were
+// none.
+func (c *Counter[T]) Remove(item T) bool {
+	return c.RemoveN(item, 1) == 1
+}
+
+// RemoveN removes up to n occurrences of the item, returning the number
+// removed.
+func (c *Counter[T]) RemoveN(item T, n int) int {
+	count := c.m[item]
+	if n <= 0 || count == 0 {
+		return 0
+	}
+	if n >= count {
+		delete(c.m, item)
+		n = count
+	} else {
+		c.m[item] = count - n
+	}
+	c.total -= n
+	return n
+}
+
+// Delete removes all occurrences of the item, returning the number removed.
+func (c *Counter[T]) Delete(item T) int {
+	return c.RemoveN(item, c.m[item])
+}
+
+// Count returns the number of occurrences of the item.
+func (c *Counter[T]) Count(item T) int {
+	return c.m[item]
+}
+
+// Contains returns whether the item occurs at least once.
+func (c *Counter[T]) Contains(item T) bool {
+	return c.m[item] > 0
+}
+
+// Total returns the total number of occurrences of all items.
+func (c *Counter[T]) Total() int {
+	return c.total
+}
+
+// Len returns the number of distinct items.
+func (c *Counter[T]) Len() int {
+	return len(c.m)
+}
+
+// MostCommon returns the n most common items, ordered from most to least
+// common. If n is negative, all items are returned. The order of items with
+// equal counts is unspecified.
+func (c *Counter[T]) MostCommon(n int) []CounterEntry[T] {
+	entries := c.Entries()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Count > entries[j].Count
+	})
+	if n >= 0 && n < len(entries) {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// Entries returns the items and their counts, in random order.
+func (c *Counter[T]) Entries() []CounterEntry[T] {
+	entries := make([]CounterEntry[T], 0, len(c.m))
+	for t, n := range c.m {
+		entries = append(entries, CounterEntry[T]{Item: t, Count: n})
+	}
+	return entries
+}
+
+// Merge adds the counts of the other Counter to this one.
+func (c *Counter[T]) Merge(other *Counter[T]) {
+	for t, n := range other.m {
+		c.AddN(t, n)
+	}
+}
+
+// Subtract subtracts the counts of the other Counter from this one. Counts
+// don't go below 0.
+func (c *Counter[T]) Subtract(other *Counter[T]) {
+	for t, n := range other.m {
+		c.RemoveN(t, n)
+	}
+}
+
+// Range iterates over each item and its count in random order, applying a
+// given function that returns whether the iterations should stop.
+func (c *Counter[T]) Range(f func(T, int) bool) {
+	for t, n := range c.m {
+		if !f(t, n) {
+			return
+		}
+	}
+}
+
+// Clear removes all items.
+func (c *Counter[T]) Clear() {
+	c.m = make(map[T]int)
+	c.total = 0
+}
+
+// Clone clones the Counter.
+func (c *Counter[T]) Clone() *Counter[T] {
+	return &Counter[T]{m: CloneMap(c.m), total: c.total}
+}
+
+// ToSlice returns a slice containing each item repeated by its count, in
+// random order (though occurrences of the same item are adjacent).
+func (c *Counter[T]) ToSlice() []T {
+	s := make([]T, 0, c.total)
+	for t, n := range c.m {
+		for i := 0; i < n; i++ {
+			s = append(s, t)
+		}
+	}
+	return s
+}
+
+// ToGoMap returns a new map of items to counts.
+func (c *Counter[T]) ToGoMap() map[T]int {
+	return CloneMap(c.m)
+}
+
+// ToSet returns a Set of the distinct items.
+func (c *Counter[T]) ToSet() *Set[T] {
+	return SetFromMapKeys(c.m)
+}
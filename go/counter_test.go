@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	c := CounterFromSlice([]string{"a", "b", "a", "c", "a", "b"})
+	if c.Count("a") != 3 || c.Count("b") != 2 || c.Count("d") != 0 {
+		t.Fatalf("unexpected counts: %v", c.ToGoMap())
+	}
+	if c.Total() != 6 || c.Len() != 3 {
+		t.Fatalf("expected total 6 and len 3, got %d and %d", c.Total(), c.Len())
+	}
+	mc := c.MostCommon(2)
+	if len(mc) != 2 || mc[0] != (CounterEntry[string]{"a", 3}) ||
+		mc[1] != (CounterEntry[string]{"b", 2}) {
+		t.Fatalf("unexpected most common: %v", mc)
+	}
+	if n := len(c.MostCommon(-1)); n != 3 {
+		t.Fatalf("expected 3 entries, got %d", n)
+	}
+
+	if !c.Remove("c") || c.Remove("c") || c.Contains("c") {
+		t.Fatal("bad Remove results")
+	}
+	if n := c.RemoveN("a", 5); n != 3 || c.Total() != 2 {
+		t.Fatalf("expected 3 removed and total 2, got %d and %d", n, c.Total())
+	}
+
+	other := CounterFromMap(map[string]int{"b": 1, "d": 4, "e": 0})
+	c.Merge(other)
+	if c.Count("b") != 3 || c.Count("d") != 4 || c.Contains("e") {
+		t.Fatalf("unexpected counts after merge: %v", c.ToGoMap())
+	}
+	c.Subtract(CounterFromMap(map[string]int{"b": 5, "d": 1}))
+	if c.Contains("b") || c.Count("d") != 3 || c.Total() != 3 {
+		t.Fatalf("unexpected counts after subtract: %v", c.ToGoMap())
+	}
+	if s := c.ToSlice(); !SliceEq(s, []string{"d", "d", "d"}) {
+		t.Fatalf("unexpected slice: %v", s)
+	}
+}
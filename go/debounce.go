@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Debouncer delays calls to a function until a duration has passed without
+// another call, at which point the function is called once with the
+// argument of the last call. The function is never run concurrently with
+// itself and must not call Flush.
+type Debouncer[T any] struct {
+	f   func(T)
+	dur time.Duration
+
+	mtx     sync.Mutex
+	runMtx  sync.Mutex
+	timer   *time.Timer
+	gen     uint64
+	pending bool
+	arg     T
+	stopped bool
+}
+
+// Debounce returns a debounced version of f (see Debouncer) along with the
+// Debouncer used to control it.
+func Debounce(f func(), dur time.Duration) (func(), *Debouncer[Unit]) {
+	d := NewDebouncer(func(Unit) { f() }, dur)
+	return func() { d.Call(Unit{}) }, d
+}
+
+// DebounceArg is the same as Debounce but for functions taking an argument.
+func DebounceArg[T any](
+	f func(T), dur time.Duration,
+) (func(T), *Debouncer[T]) {
+	d := NewDebouncer(f, dur)
+	return d.Call, d
+}
+
+// NewDebouncer creates a new Debouncer for the function.
+func NewDebouncer[T any](f func(T), dur time.Duration) *Debouncer[T] {
+	return &Debouncer[T]{f: f, dur: dur}
+}
+
+// Call schedules a call of the function with the argument, replacing any
+// pending call and restarting the wait. Does nothing if the Debouncer is
+// stopped.
+func (d *Debouncer[T]) Call(t T) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.stopped {
+		return
+	}
+	d.arg, d.pending = t, true
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.gen++
+	gen := d.gen
+	d.timer = time.AfterFunc(d.dur, func() { d.fire(gen) })
+}
+
+func (d *Debouncer[T]) fire(gen uint64) {
+	d.mtx.Lock()
+	if gen != d.gen || !d.pending {
+		// A later call (or Flush/Stop) superseded this one.
+		d.mtx.Unlock()
+		return
+	}
+	t := d.take()
+	d.run(t)
+}
+
+// take clears the pending call and returns its argument. The lock must be
+// held and is released.
+func (d *Debouncer[T]) take() T {
+	t := d.arg
+	var zero T
+	d.arg, d.pending = zero, false
+	d.timer, d.gen = nil, d.gen+1
+	d.runMtx.Lock()
+	d.mtx.Unlock()
+	return t
+}
+
+// run runs the function. The run lock must be held and is released.
+func (d *Debouncer[T]) run(t T) {
+	defer d.runMtx.Unlock()
+	d.f(t)
+}
+
+// Flush immediately runs the pending call, if any, returning whether there
+// was one.
+func (d *Debouncer[T]) Flush() bool {
+	d.mtx.Lock()
+	if !d.pending {
+		d.mtx.Unlock()
+		return false
+	}
+	d.timer.Stop()
+	d.run(d.take())
+	return true
+}
+
+// Pending returns whether there is a pending call.
+func (d *Debouncer[T]) Pending() bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.pending
+}
+
+// Stop cancels any pending call and stops the Debouncer, so future calls do
+// nothing. Returns false if it was already stopped.
+func (d *Debouncer[T]) Stop() bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.stopped {
+		return false
+	}
+	d.stopped, d.pending = true, false
+	var zero T
+	d.arg = zero
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	return true
+}
+
+// Throttler limits calls to a function to at most once per duration. The
+// first call runs right away; calls made during the following duration are
+// coalesced into a single call, with the argument of the last call, made
+// when the duration ends. The function is never run concurrently with
+// itself and must not call the Throttler's Call or Flush.
+type Throttler[T any] struct {
+	f   func(T)
+	dur time.Duration
+
+	mtx     sync.Mutex
+	runMtx  sync.Mutex
+	timer   *time.Timer
+	pending bool
+	arg     T
+	stopped bool
+}
+
+// Throttle returns a throttled version of f (see Throttler) along with the
+// Throttler used to control it.
+func Throttle(f func(), dur time.Duration) (func(), *Throttler[Unit]) {
+	th := NewThrottler(func(Unit) { f() }, dur)
+	return func() { th.Call(Unit{}) }, th
+}
+
+// ThrottleArg is the same as Throttle but for functions taking an argument.
+func ThrottleArg[T any](
+	f func(T), dur time.Duration,
+) (func(T), *Throttler[T]) {
+	th := NewThrottler(f, dur)
+	return th.Call, th
+}
+
+// NewThrottler creates a new Throttler for the function.
+func NewThrottler[T any](f func(T), dur time.Duration) *Throttler[T] {
+	return &Throttler[T]{f: f, dur: dur}
+}
+
+// Call calls the function with the argument if it hasn't been called within
+// the duration, otherwise, schedules a call for the end of the duration,
+// replacing any already scheduled. Does nothing if the Throttler is
+// stopped.
+func (th *Throttler[T]) Call(t T) {
+	th.mtx.Lock()
+	if th.stopped {
+		th.mtx.Unlock()
+		return
+	}
+	if th.timer != nil {
+		th.arg, th.pending = t, true
+		th.mtx.Unlock()
+		return
+	}
+	th.timer = time.AfterFunc(th.dur, th.windowEnd)
+	th.runMtx.Lock()
+	th.mtx.Unlock()
+	th.run(t)
+}
+
+func (th *Throttler[T]) windowEnd() {
+	th.mtx.Lock()
+	if th.stopped || !th.pending {
+		th.timer = nil
+		th.mtx.Unlock()
+		return
+	}
+	// Start a new window for the trailing call.
+	th.timer = time.AfterFunc(th.dur, th.windowEnd)
+	th.run(th.take())
+}
+
+// take clears the pending call and returns its argument. The lock must be
+// held and is released.
+func (th *Throttler[T]) take() T {
+	t := th.arg
+	var zero T
+	th.arg, th.pending = zero, false
+	th.runMtx.Lock()
+	th.mtx.Unlock()
+	return t
+}
+
+// run runs the function. The run lock must be held and is released.
+func (th *Throttler[T]) run(t T) {
+	defer th.runMtx.Unlock()
+	th.f(t)
+}
+
+// Flush immediately runs the pending call, if any, returning whether there
+// was one. The current duration isn't restarted.
+func (th *Throttler[T]) Flush() bool {
+	th.mtx.Lock()
+	if !th.pending {
+		th.mtx.Unlock()
+		return false
+	}
+	th.run(th.take())
+	return true
+}
+
+// Pending returns whether there is a pending call.
+func (th *Throttler[T]) Pending() bool {
+	th.mtx.Lock()
+	defer th.mtx.Unlock()
+	return th.pending
+}
+
+// Stop cancels any pending call and stops the Throttler, so future calls do
+// nothing. Returns false if it was already stopped.
+func (th *Throttler[T]) Stop() bool {
+	th.mtx.Lock()
+	defer th.mtx.Unlock()
+	if th.stopped {
+		return false
+	}
+	th.stopped, th.pending = true, false
+	var zero T
+	th.arg = zero
+	if th.timer != nil {
+		th.timer.Stop()
+		th.timer = nil
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	var calls atomic.Int32
+	f, d := Debounce(func() { calls.Add(1) }, time.Millisecond*20)
+	for i := 0; i < 10; i++ {
+		f()
+		time.Sleep(time.Millisecond)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("expected no calls yet, got %d", n)
+	}
+	time.Sleep(time.Millisecond * 60)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call, got %d", n)
+	}
+
+	f()
+	if !d.Pending() {
+		t.Fatal("expected pending call")
+	}
+	if !d.Flush() || calls.Load() != 2 {
+		t.Fatalf("expected flush to run call, got %d calls", calls.Load())
+	}
+	if d.Flush() {
+		t.Fatal("expected nothing to flush")
+	}
+	time.Sleep(time.Millisecond * 40)
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("flushed call ran again: %d calls", n)
+	}
+
+	f()
+	if !d.Stop() || d.Stop() {
+		t.Fatal("expected only first Stop to succeed")
+	}
+	f()
+	time.Sleep(time.Millisecond * 40)
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected no calls after Stop, got %d", n)
+	}
+}
+
+func TestDebounceArg(t *testing.T) {
+	got := make(chan int, 10)
+	f, _ := DebounceArg(func(n int) { got <- n }, time.Millisecond*10)
+	for i := 0; i < 5; i++ {
+		f(i)
+	}
+	select {
+	case n := <-got:
+		if n != 4 {
+			t.Fatalf("expected last argument 4, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("debounced call never ran")
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	var mtx sync.Mutex
+	var got []int
+	f, th := ThrottleArg(func(n int) {
+		mtx.Lock()
+		got = append(got, n)
+		mtx.Unlock()
+	}, time.Millisecond*30)
+	read := func() []int {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return CloneSlice(got)
+	}
+
+	for i := 0; i < 5; i++ {
+		f(i)
+	}
+	// The first call runs right away; the rest are coalesced.
+	if s := read(); !SliceEq(s, []int{0}) {
+		t.Fatalf("expected [0], got %v", s)
+	}
+	if !th.Pending() {
+		t.Fatal("expected pending call")
+	}
+	time.Sleep(time.Millisecond * 45)
+	if s := read(); !SliceEq(s, []int{0, 4}) {
+		t.Fatalf("expected [0 4], got %v", s)
+	}
+	// The trailing call started a new window.
+	f(5)
+	if !th.Flush() {
+		t.Fatal("expected pending call to flush")
+	}
+	if s := read(); !SliceEq(s, []int{0, 4, 5}) {
+		t.Fatalf("expected [0 4 5], got %v", s)
+	}
+
+	time.Sleep(time.Millisecond * 70)
+	f(6)
+	if s := read(); !SliceEq(s, []int{0, 4, 5, 6}) {
+		t.Fatalf("expected [0 4 5 6], got %v", s)
+	}
+	f(7)
+	th.Stop()
+	time.Sleep(time.Millisecond * 45)
+	f(8)
+	if s := read(); !SliceEq(s, []int{0, 4, 5, 6}) {
+		t.Fatalf("expected no calls after Stop, got %v", s)
+	}
+
+	var calls atomic.Int32
+	g, _ := Throttle(func() { calls.Add(1) }, time.Hour)
+	g()
+	g()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call, got %d", n)
+	}
+}
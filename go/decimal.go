@@ -0,0 +1,382 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxDecimalScale is the maximum number of digits after the decimal point a
+// Decimal can have.
+const MaxDecimalScale = 18
+
+// ErrDecimalOverflow means a Decimal operation's result doesn't fit in a
+// Decimal.
+var ErrDecimalOverflow = errors.New("decimal overflow")
+
+// Decimal is a fixed-point decimal number made up of an int64 mantissa and a
+// scale (the number of digits after the decimal point), so its value is
+// mantissa * 10^-scale. The zero value is 0.
+//
+// Unlike integers, arithmetic that overflows panics with ErrDecimalOverflow
+// rather than wrapping, since a silently wrong amount is worse than a crash.
+// Use the Checked variants of the operations to get an error instead.
+type Decimal struct {
+	m     int64
+	scale int32
+}
+
+// NewDecimal returns a new Decimal with the value mantissa * 10^-scale. Panics
+// if the scale is negative or greater than MaxDecimalScale.
+func NewDecimal(mantissa int64, scale int) Decimal {
+	if scale < 0 || scale > MaxDecimalScale {
+		panic(fmt.Sprintf("invalid decimal scale: %d", scale))
+	}
+	return Decimal{m: mantissa, scale: int32(scale)}
+}
+
+// DecimalFromInt returns a Decimal with the integer value.
+func DecimalFromInt(i int64) Decimal {
+	return Decimal{m: i}
+}
+
+// DecimalFromFloat returns a Decimal from the float rounded to the given
+// scale, using the shortest decimal representation of the float (like
+// RoundTo). Returns an error if the float is NaN, infinite, or out of range.
+func DecimalFromFloat(f float64, scale int) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("invalid decimal: %v", f)
+	}
+	if scale < 0 || scale > MaxDecimalScale {
+		return Decimal{}, fmt.Errorf("invalid decimal scale: %d", scale)
+	}
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	r.Mul(r, new(big.Rat).SetInt(bigPow10(scale)))
+	return decimalFromBig(
+		roundQuo(r.Num(), r.Denom()), int32(scale),
+	)
+}
+
+// ParseDecimal parses a decimal such as "12", "-0.05", or "1.5e3". The scale
+// of the result is the number of digits after the decimal point (after
+// applying any exponent), so "1.50" has a scale of 2.
+func ParseDecimal(s string) (Decimal, error) {
+	str := s
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i != -1 {
+		e, err := strconv.Atoi(str[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		str, exp = str[:i], e
+	}
+	neg := false
+	if str != "" && (str[0] == '-' || str[0] == '+') {
+		neg, str = str[0] == '-', str[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(str, ".")
+	digits := intPart + fracPart
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool {
+		return r < '0' || r > '9'
+	}) != -1 {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	b, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		b.Neg(b)
+	}
+	scale := len(fracPart) - exp
+	if scale < 0 {
+		// Any power of 10 beyond what fits in an int64 overflows a non-zero
+		// mantissa, so it's never computed (which could take arbitrarily long
+		// for large exponents). Zero stays zero no matter the exponent.
+		if -scale > len(pow10Int64) && b.Sign() != 0 {
+			return Decimal{}, fmt.Errorf(
+				"invalid decimal %q: %w", s, ErrDecimalOverflow,
+			)
+		}
+		if b.Sign() != 0 {
+			b.Mul(b, bigPow10(-scale))
+		}
+		scale = 0
+	} else if scale > MaxDecimalScale {
+		return Decimal{}, fmt.Errorf(
+			"invalid decimal %q: more than %d decimal places",
+			s, MaxDecimalScale,
+		)
+	}
+	d, err := decimalFromBig(b, int32(scale))
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: %w", s, err)
+	}
+	return d, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on error.
+func MustParseDecimal(s string) Decimal {
+	return Must(ParseDecimal(s))
+}
+
+// Mantissa returns the mantissa of the decimal.
+func (d Decimal) Mantissa() int64 {
+	return d.m
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return int(d.scale)
+}
+
+// Sign returns -1, 0, or 1 depending on the sign of the decimal.
+func (d Decimal) Sign() int {
+	switch {
+	case d.m < 0:
+		return -1
+	case d.m > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero returns whether the decimal is 0.
+func (d Decimal) IsZero() bool {
+	return d.m == 0
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return mustDecimal(d.CheckedMul(DecimalFromInt(-1)))
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	if d.m < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Cmp compares the values of the decimals, returning -1 if d < e, 0 if d == e,
+// and 1 if d > e. Decimals with different scales can be equal (e.g., 1.5 and
+// 1.50).
+func (d Decimal) Cmp(e Decimal) int {
+	scale := max(d.scale, e.scale)
+	return d.big(scale).Cmp(e.big(scale))
+}
+
+// Equal returns whether the values of the decimals are equal.
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Add returns d + e, with the larger of the 2 scales. Panics with
+// ErrDecimalOverflow on overflow.
+func (d Decimal) Add(e Decimal) Decimal {
+	return mustDecimal(d.CheckedAdd(e))
+}
+
+// CheckedAdd is like Add but returns ErrDecimalOverflow on overflow.
+func (d Decimal) CheckedAdd(e Decimal) (Decimal, error) {
+	scale := max(d.scale, e.scale)
+	return decimalFromBig(d.big(scale).Add(d.big(scale), e.big(scale)), scale)
+}
+
+// Sub returns d - e, with the larger of the 2 scales. Panics with
+// ErrDecimalOverflow on overflow.
+func (d Decimal) Sub(e Decimal) Decimal {
+	return mustDecimal(d.CheckedSub(e))
+}
+
+// CheckedSub is like Sub but returns ErrDecimalOverflow on overflow.
+func (d Decimal) CheckedSub(e Decimal) (Decimal, error) {
+	scale := max(d.scale, e.scale)
+	return decimalFromBig(d.big(scale).Sub(d.big(scale), e.big(scale)), scale)
+}
+
+// Mul returns d * e, with the sum of the 2 scales (rounded to
+// MaxDecimalScale if greater). Panics with ErrDecimalOverflow on overflow.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return mustDecimal(d.CheckedMul(e))
+}
+
+// CheckedMul is like Mul but returns ErrDecimalOverflow on overflow.
+func (d Decimal) CheckedMul(e Decimal) (Decimal, error) {
+	b := new(big.Int).Mul(big.NewInt(d.m), big.NewInt(e.m))
+	scale := d.scale + e.scale
+	if scale > MaxDecimalScale {
+		b = roundQuo(b, bigPow10(int(scale-MaxDecimalScale)))
+		scale = MaxDecimalScale
+	}
+	return decimalFromBig(b, scale)
+}
+
+// Quo returns d / e rounded (halves away from zero) to the given scale.
+// Panics if e is 0, or with ErrDecimalOverflow on overflow.
+func (d Decimal) Quo(e Decimal, scale int) Decimal {
+	return mustDecimal(d.CheckedQuo(e, scale))
+}
+
+// CheckedQuo is like Quo but returns an error rather than panicking.
+func (d Decimal) CheckedQuo(e Decimal, scale int) (Decimal, error) {
+	if e.m == 0 {
+		return Decimal{}, errors.New("decimal division by zero")
+	}
+	if scale < 0 || scale > MaxDecimalScale {
+		return Decimal{}, fmt.Errorf("invalid decimal scale: %d", scale)
+	}
+	// d/e = (dm * 10^-ds) / (em * 10^-es), so to get a mantissa with the
+	// given scale, multiply the numerator by 10^(scale + es - ds).
+	num, den := big.NewInt(d.m), big.NewInt(e.m)
+	if shift := scale + int(e.scale) - int(d.scale); shift >= 0 {
+		num.Mul(num, bigPow10(shift))
+	} else {
+		den.Mul(den, bigPow10(-shift))
+	}
+	return decimalFromBig(roundQuo(num, den), int32(scale))
+}
+
+// Round returns d rounded (halves away from zero) to the given scale. If the
+// scale is greater than d's, trailing zeros are added. Panics with
+// ErrDecimalOverflow on overflow.
+func (d Decimal) Round(scale int) Decimal {
+	return mustDecimal(d.rescale(scale, true))
+}
+
+// Trunc returns d truncated (toward zero) to the given scale. If the scale is
+// greater than d's, trailing zeros are added. Panics with ErrDecimalOverflow
+// on overflow.
+func (d Decimal) Trunc(scale int) Decimal {
+	return mustDecimal(d.rescale(scale, false))
+}
+
+func (d Decimal) rescale(scale int, round bool) (Decimal, error) {
+	if scale < 0 || scale > MaxDecimalScale {
+		panic(fmt.Sprintf("invalid decimal scale: %d", scale))
+	}
+	if int32(scale) >= d.scale {
+		return decimalFromBig(d.big(int32(scale)), int32(scale))
+	}
+	den := bigPow10(int(d.scale) - scale)
+	if round {
+		return decimalFromBig(roundQuo(big.NewInt(d.m), den), int32(scale))
+	}
+	return decimalFromBig(new(big.Int).Quo(big.NewInt(d.m), den), int32(scale))
+}
+
+// Int64 returns the integer part of the decimal (truncated toward zero).
+func (d Decimal) Int64() int64 {
+	return d.m / pow10Int64[d.scale]
+}
+
+// Float64 returns the closest float64 to the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats the decimal with exactly Scale digits after the decimal
+// point (e.g., "-1.50").
+func (d Decimal) String() string {
+	s := strconv.FormatInt(d.m, 10)
+	if d.scale == 0 {
+		return s
+	}
+	neg := d.m < 0
+	if neg {
+		s = s[1:]
+	}
+	if pad := int(d.scale) + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	i := len(s) - int(d.scale)
+	s = s[:i] + "." + s[i:]
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalText implements encoding.TextMarshaler, using String.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, using ParseDecimal.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	dec, err := ParseDecimal(string(text))
+	if err == nil {
+		*d = dec
+	}
+	return err
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either a number or a
+// string. Numbers are parsed from their text, so no precision is lost to
+// float64.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return d.UnmarshalText([]byte(s))
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid decimal: %s", data)
+	}
+	return d.UnmarshalText([]byte(n))
+}
+
+// big returns the mantissa of d at the given scale, which must be at least
+// d's scale.
+func (d Decimal) big(scale int32) *big.Int {
+	b := big.NewInt(d.m)
+	if scale > d.scale {
+		b.Mul(b, bigPow10(int(scale-d.scale)))
+	}
+	return b
+}
+
+func decimalFromBig(b *big.Int, scale int32) (Decimal, error) {
+	if !b.IsInt64() {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return Decimal{m: b.Int64(), scale: scale}, nil
+}
+
+func mustDecimal(d Decimal, err error) Decimal {
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// roundQuo returns num / den rounded with halves away from zero.
+func roundQuo(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Lsh(r.Abs(r), 1).Cmp(new(big.Int).Abs(den)) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign()*den.Sign())))
+	}
+	return q
+}
+
+var pow10Int64 = func() (res [MaxDecimalScale + 1]int64) {
+	res[0] = 1
+	for i := 1; i < len(res); i++ {
+		res[i] = res[i-1] * 10
+	}
+	return
+}()
+
+func bigPow10(n int) *big.Int {
+	if n < len(pow10Int64) {
+		return big.NewInt(pow10Int64[n])
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
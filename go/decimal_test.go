@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]string{
+		"12":        "12",
+		"-0.05":     "-0.05",
+		"+1.50":     "1.50",
+		".5":        "0.5",
+		"7.":        "7",
+		"1.5e3":     "1500",
+		"1.5e-3":    "0.0015",
+		"-12.345E1": "-123.45",
+		"0e100":     "0",
+		// Mustn't compute 10^200000000.
+		"0e200000000":      "0",
+		"-0.000e200000000": "0",
+		"0.00000001":       "0.00000001",
+	}
+	for s, want := range tests {
+		d, err := ParseDecimal(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		} else if got := d.String(); got != want {
+			t.Fatalf("%s: expected %s, got %s", s, want, got)
+		}
+	}
+	for _, s := range []string{
+		"", "-", ".", "1.2.3", "abc", "1e", "1e999999999",
+		"0.1234567890123456789", "99999999999999999999",
+	} {
+		if _, err := ParseDecimal(s); err == nil {
+			t.Fatalf("%s: expected error", s)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := MustParseDecimal
+	if got := d("0.1").Add(d("0.2")); !got.Equal(d("0.3")) {
+		t.Fatalf("expected 0.3, got %s", got)
+	}
+	if got := d("1.5").Sub(d("2.25")); got.String() != "-0.75" {
+		t.Fatalf("expected -0.75, got %s", got)
+	}
+	if got := d("1.25").Mul(d("-0.2")); got.String() != "-0.250" {
+		t.Fatalf("expected -0.250, got %s", got)
+	}
+	if got := d("10").Quo(d("3"), 4); got.String() != "3.3333" {
+		t.Fatalf("expected 3.3333, got %s", got)
+	}
+	if got := d("-2").Quo(d("3"), 2); got.String() != "-0.67" {
+		t.Fatalf("expected -0.67, got %s", got)
+	}
+	if got := d("1.2345").Round(2); got.String() != "1.23" {
+		t.Fatalf("expected 1.23, got %s", got)
+	}
+	if got := d("-1.235").Round(2); got.String() != "-1.24" {
+		t.Fatalf("expected -1.24, got %s", got)
+	}
+	if got := d("-1.239").Trunc(2); got.String() != "-1.23" {
+		t.Fatalf("expected -1.23, got %s", got)
+	}
+	if got := d("1.5").Round(3); got.String() != "1.500" {
+		t.Fatalf("expected 1.500, got %s", got)
+	}
+	if d("1.5").Cmp(d("1.50")) != 0 || d("-1").Cmp(d("0.1")) != -1 {
+		t.Fatal("bad Cmp results")
+	}
+	if got := d("-3.99"); got.Int64() != -3 || got.Abs().String() != "3.99" ||
+		got.Sign() != -1 || got.Float64() != -3.99 {
+		t.Fatalf("unexpected conversions for %s", got)
+	}
+
+	big := DecimalFromInt(math.MaxInt64)
+	if _, err := big.CheckedAdd(d("1")); !errors.Is(err, ErrDecimalOverflow) {
+		t.Fatalf("expected ErrDecimalOverflow, got %v", err)
+	}
+	if _, err := d("1").CheckedQuo(Decimal{}, 2); err == nil {
+		t.Fatal("expected division by zero error")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != ErrDecimalOverflow {
+				t.Fatalf("expected ErrDecimalOverflow panic, got %v", r)
+			}
+		}()
+		big.Mul(d("2"))
+	}()
+
+	f, err := DecimalFromFloat(1.005, 2)
+	if err != nil {
+		t.Fatal(err)
+	} else if f.String() != "1.01" {
+		t.Fatalf("expected 1.01, got %s", f)
+	}
+	if _, err := DecimalFromFloat(math.NaN(), 2); err == nil {
+		t.Fatal("expected error for NaN")
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		A, B, C Decimal
+	}
+	data := `{"A":"19.99","B":0.100000000000000001,"C":null}`
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "19.99" || v.B.String() != "0.100000000000000001" ||
+		!v.C.IsZero() {
+		t.Fatalf("unexpected decimals: %v", v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"A":"19.99","B":"0.100000000000000001","C":"0"}`
+	if string(b) != want {
+		t.Fatalf("expected %s, got %s", want, b)
+	}
+
+	var z Decimal
+	if err := json.Unmarshal([]byte(`"0e200000000"`), &z); err != nil {
+		t.Fatal(err)
+	} else if !z.IsZero() {
+		t.Fatalf("expected 0, got %s", z)
+	}
+}
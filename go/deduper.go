@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DeduperOpts are options for a Deduper.
+type DeduperOpts struct {
+	// Window is how long keys are remembered after they are first seen.
+	Window time.Duration
+	// MaxKeys is the maximum number of keys remembered, the oldest being
+	// forgotten first once reached. If 0, there is no limit.
+	MaxKeys int
+}
+
+// Deduper detects duplicate keys (e.g., event IDs from an at-least-once
+// source) seen within a sliding time window. It is safe for concurrent use.
+type Deduper[K comparable] struct {
+	opts DeduperOpts
+
+	mtx  sync.Mutex
+	seen map[K]time.Time
+	// Keys in the order first seen, used for expiration
+	order []dedupEntry[K]
+	// Number of entries in order for keys that were forgotten
+	stale int
+}
+
+type dedupEntry[K comparable] struct {
+	key K
+	at  time.Time
+}
+
+// NewDeduper creates a new Deduper.
+func NewDeduper[K comparable](opts DeduperOpts) *Deduper[K] {
+	return &Deduper[K]{opts: opts, seen: make(map[K]time.Time)}
+}
+
+// Seen returns whether the key has been seen within the window, recording it
+// as seen if it hasn't. The window isn't extended for keys already seen.
+func (d *Deduper[K]) Seen(key K) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	now := time.Now()
+	d.expire(now)
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	d.seen[key] = now
+	d.order = append(d.order, dedupEntry[K]{key: key, at: now})
+	if d.opts.MaxKeys > 0 {
+		for len(d.seen) > d.opts.MaxKeys {
+			d.popOldest()
+		}
+	}
+	return false
+}
+
+// Contains returns whether the key has been seen within the window without
+// recording it.
+func (d *Deduper[K]) Contains(key K) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.expire(time.Now())
+	_, ok := d.seen[key]
+	return ok
+}
+
+// Forget forgets the key so that it is no longer considered seen.
+func (d *Deduper[K]) Forget(key K) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.seen[key]; !ok {
+		return
+	}
+	// The entry in order is skipped once popped since it won't match, but
+	// without a window or max keys it may never be popped, so stale entries
+	// are removed once they make up half of order.
+	delete(d.seen, key)
+	if d.stale++; d.stale > len(d.order)/2 {
+		d.order = slices.DeleteFunc(d.order, func(e dedupEntry[K]) bool {
+			at, ok := d.seen[e.key]
+			return !ok || !at.Equal(e.at)
+		})
+		d.stale = 0
+	}
+}
+
+// Len returns the number of keys remembered.
+func (d *Deduper[K]) Len() int {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.expire(time.Now())
+	return len(d.seen)
+}
+
+// expire forgets keys outside of the window. The lock must be held.
+func (d *Deduper[K]) expire(now time.Time) {
+	if d.opts.Window <= 0 {
+		return
+	}
+	cutoff := now.Add(-d.opts.Window)
+	for len(d.order) != 0 && !d.order[0].at.After(cutoff) {
+		d.popOldest()
+	}
+}
+
+// popOldest removes the oldest entry. The lock must be held.
+func (d *Deduper[K]) popOldest() {
+	e := d.order[0]
+	d.order[0] = dedupEntry[K]{}
+	d.order = d.order[1:]
+	if at, ok := d.seen[e.key]; ok && at.Equal(e.at) {
+		delete(d.seen, e.key)
+	} else if d.stale > 0 {
+		d.stale--
+	}
+	// Reclaim space from the front of the slice once it's mostly unused
+	if cap(d.order) > 64 && len(d.order) < cap(d.order)/4 {
+		d.order = append([]dedupEntry[K](nil), d.order...)
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeduper(t *testing.T) {
+	d := NewDeduper[string](DeduperOpts{Window: time.Millisecond * 30})
+	if d.Seen("a") || !d.Seen("a") || d.Seen("b") {
+		t.Fatal("bad Seen results")
+	}
+	time.Sleep(time.Millisecond * 20)
+	d.Seen("c")
+	time.Sleep(time.Millisecond * 15)
+	if d.Contains("a") || d.Contains("b") || !d.Contains("c") {
+		t.Fatal("expected a and b to expire")
+	}
+	if n := d.Len(); n != 1 {
+		t.Fatalf("expected 1 key, got %d", n)
+	}
+	d.Forget("c")
+	if d.Seen("c") {
+		t.Fatal("expected c to be forgotten")
+	}
+
+	d = NewDeduper[string](DeduperOpts{MaxKeys: 2})
+	d.Seen("a")
+	d.Seen("b")
+	d.Seen("c")
+	if d.Contains("a") || !d.Contains("b") || !d.Contains("c") {
+		t.Fatal("expected oldest key to be evicted")
+	}
+
+	// Forgotten keys don't accumulate without a window or max keys.
+	d = NewDeduper[string](DeduperOpts{})
+	d.Seen("keep")
+	for i := 0; i < 1000; i++ {
+		d.Seen("x")
+		d.Forget("x")
+	}
+	if len(d.order) > 2 || !d.Contains("keep") || d.Contains("x") {
+		t.Fatalf("expected forgotten keys removed, got %d entries", len(d.order))
+	}
+}
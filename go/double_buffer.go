@@ -0,0 +1,75 @@
+package utils
+
+import "sync"
+
+// DoubleBuffer is an accumulator where writers append to an active buffer
+// while a reader periodically swaps the active buffer with a spare one to
+// consume a consistent batch of values. Since the buffers are reused, writers
+// only contend for the duration of an append and no allocations are needed
+// once the buffers have grown to their working sizes.
+type DoubleBuffer[T any] struct {
+	mtx    sync.Mutex
+	active []T
+
+	swapMtx sync.Mutex
+	spare   []T
+}
+
+// NewDoubleBuffer creates a new DoubleBuffer with buffers of the given
+// initial capacity.
+func NewDoubleBuffer[T any](cap int) *DoubleBuffer[T] {
+	return &DoubleBuffer[T]{
+		active: make([]T, 0, cap),
+		spare:  make([]T, 0, cap),
+	}
+}
+
+// Append appends the values to the active buffer.
+func (db *DoubleBuffer[T]) Append(ts ...T) {
+	db.mtx.Lock()
+	db.active = append(db.active, ts...)
+	db.mtx.Unlock()
+}
+
+// Len returns the number of values in the active buffer.
+func (db *DoubleBuffer[T]) Len() int {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	return len(db.active)
+}
+
+// SwapAndGet swaps the active buffer with the spare buffer, returning the
+// values that were in the active buffer. The returned slice is reused as the
+// active buffer on the next call to SwapAndGet, so it should no longer be
+// used by then.
+func (db *DoubleBuffer[T]) SwapAndGet() []T {
+	db.swapMtx.Lock()
+	defer db.swapMtx.Unlock()
+	// Clear the old values so they can be garbage collected
+	var zero T
+	for i := range db.spare {
+		db.spare[i] = zero
+	}
+	db.mtx.Lock()
+	res := db.active
+	db.active = db.spare[:0]
+	db.mtx.Unlock()
+	db.spare = res
+	return res
+}
+
+// SwapAndCopy is the same as SwapAndGet but returns a copy of the values,
+// which can be used indefinitely.
+func (db *DoubleBuffer[T]) SwapAndCopy() []T {
+	db.swapMtx.Lock()
+	defer db.swapMtx.Unlock()
+	db.mtx.Lock()
+	res := CloneSlice(db.active)
+	var zero T
+	for i := range db.active {
+		db.active[i] = zero
+	}
+	db.active = db.active[:0]
+	db.mtx.Unlock()
+	return res
+}
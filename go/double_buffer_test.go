@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDoubleBuffer(t *testing.T) {
+	db := NewDoubleBuffer[int](16)
+	const goroutines, iters = 4, 10000
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iters; j++ {
+				db.Append(1)
+			}
+		}()
+	}
+	total := 0
+	done := make(chan Unit)
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+SwapLoop:
+	for {
+		select {
+		case <-done:
+			break SwapLoop
+		default:
+		}
+		for _, n := range db.SwapAndGet() {
+			total += n
+		}
+	}
+	for _, n := range db.SwapAndCopy() {
+		total += n
+	}
+	if total != goroutines*iters {
+		t.Fatalf("expected total of %d, got %d", goroutines*iters, total)
+	}
+
+	db.Append(1, 2, 3)
+	if l := db.Len(); l != 3 {
+		t.Fatalf("expected length of 3, got %d", l)
+	}
+	if got := db.SwapAndGet(); !SliceEq(got, []int{1, 2, 3}) {
+		t.Fatalf("expected [1 2 3], got %v", got)
+	}
+	if got := db.SwapAndGet(); len(got) != 0 {
+		t.Fatalf("expected empty batch, got %v", got)
+	}
+}
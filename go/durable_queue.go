@@ -0,0 +1,317 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// DefaultDurableQueueChanLen is the default chan length of a DurableQueue's
+// UChan.
+const DefaultDurableQueueChanLen = 16
+
+// DurableQueueOpts are options for a DurableQueue.
+type DurableQueueOpts struct {
+	// Sync is whether the log file is synced to disk after every write. Without
+	// it, sends survive process crashes but not necessarily OS crashes.
+	Sync bool
+	// ChanLen is the chan length of the underlying UChan. If less than 1,
+	// DefaultDurableQueueChanLen is used.
+	ChanLen int
+}
+
+// DurableMsg is a message received from a DurableQueue.
+type DurableMsg[T any] struct {
+	// ID is the ID of the message, used to acknowledge it.
+	ID uint64
+	// Value is the value sent.
+	Value T
+}
+
+// DurableQueue is a UChan whose messages are persisted to an append-only log
+// file before Send returns. Received messages must be acknowledged with Ack;
+// messages not acknowledged before the process exits (or crashes) are
+// redelivered when the queue is reopened. Values are encoded as JSON.
+type DurableQueue[T any] struct {
+	opts DurableQueueOpts
+	uc   *UChan[DurableMsg[T]]
+
+	mtx      sync.Mutex
+	path     string
+	f        *os.File
+	w        *bufio.Writer
+	nextID   uint64
+	pending  map[uint64]T
+	isClosed bool
+}
+
+type durableRecord struct {
+	Op  string          `json:"op"`
+	ID  uint64          `json:"id"`
+	Val json.RawMessage `json:"val,omitempty"`
+}
+
+const (
+	durableOpSend = "send"
+	durableOpAck  = "ack"
+	// durableOpNext records the next ID (in the record's ID) so that IDs
+	// aren't reused after compacting away the records of acked messages.
+	durableOpNext = "next"
+)
+
+// OpenDurableQueue opens (creating if necessary) the DurableQueue with the
+// log file at the given path. Unacknowledged messages from the log are
+// delivered first, in the order they were sent.
+func OpenDurableQueue[T any](
+	path string, opts DurableQueueOpts,
+) (*DurableQueue[T], error) {
+	if opts.ChanLen < 1 {
+		opts.ChanLen = DefaultDurableQueueChanLen
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	q := &DurableQueue[T]{
+		opts:    opts,
+		uc:      NewUChan[DurableMsg[T]](opts.ChanLen),
+		path:    path,
+		f:       f,
+		pending: make(map[uint64]T),
+	}
+	if err := q.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	q.w = bufio.NewWriter(f)
+	for _, id := range q.pendingIDs() {
+		q.uc.Send(DurableMsg[T]{ID: id, Value: q.pending[id]})
+	}
+	return q, nil
+}
+
+// replay reads the log, rebuilding the pending messages. A torn (incomplete)
+// last record, such as from a crash during a write, is truncated.
+func (q *DurableQueue[T]) replay() error {
+	r := bufio.NewReader(q.f)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) != 0 {
+				// Torn write
+				if err := q.f.Truncate(offset); err != nil {
+					return err
+				}
+			}
+			break
+		} else if err != nil {
+			return err
+		}
+		var rec durableRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("bad record at offset %d: %w", offset, err)
+		}
+		switch rec.Op {
+		case durableOpSend:
+			var t T
+			if err := json.Unmarshal(rec.Val, &t); err != nil {
+				return fmt.Errorf("bad value at offset %d: %w", offset, err)
+			}
+			q.pending[rec.ID] = t
+			if rec.ID >= q.nextID {
+				q.nextID = rec.ID + 1
+			}
+		case durableOpAck:
+			delete(q.pending, rec.ID)
+		case durableOpNext:
+			q.nextID = max(q.nextID, rec.ID)
+		default:
+			return fmt.Errorf("bad record op at offset %d: %q", offset, rec.Op)
+		}
+		offset += int64(len(line))
+	}
+	_, err := q.f.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (q *DurableQueue[T]) pendingIDs() []uint64 {
+	ids := make([]uint64, 0, len(q.pending))
+	for id := range q.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// writeRecord writes the record to the log. If writing fails, the log is
+// truncated to remove any partially written record and the writer is reset,
+// since a bufio.Writer keeps returning the first error it got. The lock must
+// be held.
+func (q *DurableQueue[T]) writeRecord(rec durableRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// The writer is always flushed, so this is where the record starts.
+	offset, err := q.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	q.w.Write(b)
+	q.w.WriteByte('\n')
+	err = q.w.Flush()
+	if err == nil && q.opts.Sync {
+		err = q.f.Sync()
+	}
+	if err != nil {
+		q.w.Reset(q.f)
+		if q.f.Truncate(offset) == nil {
+			q.f.Seek(offset, io.SeekStart)
+		}
+	}
+	return err
+}
+
+// Send persists the value and then sends it, returning its ID. Returns
+// ErrClosed if the queue is closed.
+func (q *DurableQueue[T]) Send(t T) (uint64, error) {
+	val, err := json.Marshal(t)
+	if err != nil {
+		return 0, err
+	}
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.isClosed {
+		return 0, ErrClosed
+	}
+	id := q.nextID
+	rec := durableRecord{Op: durableOpSend, ID: id, Val: val}
+	if err := q.writeRecord(rec); err != nil {
+		return 0, err
+	}
+	q.nextID++
+	q.pending[id] = t
+	q.uc.Send(DurableMsg[T]{ID: id, Value: t})
+	return id, nil
+}
+
+// Recv receives a message, returning false if the queue is closed (and all
+// messages have been received). The message should be acknowledged with Ack
+// once processed.
+func (q *DurableQueue[T]) Recv() (DurableMsg[T], bool) {
+	return q.uc.Recv()
+}
+
+// RecvContext is the same as Recv but returns ErrCanceled (wrapping the
+// context's error) if the context is done first, or ErrClosed if the queue is
+// closed.
+func (q *DurableQueue[T]) RecvContext(
+	ctx context.Context,
+) (DurableMsg[T], error) {
+	return q.uc.RecvContext(ctx)
+}
+
+// Ack acknowledges the message with the given ID so that it is never
+// redelivered. Returns an error if there is no pending message with the ID.
+func (q *DurableQueue[T]) Ack(id uint64) error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.isClosed {
+		return ErrClosed
+	}
+	if _, ok := q.pending[id]; !ok {
+		return fmt.Errorf("no pending message with ID %d", id)
+	}
+	err := q.writeRecord(durableRecord{Op: durableOpAck, ID: id})
+	if err != nil {
+		return err
+	}
+	delete(q.pending, id)
+	return nil
+}
+
+// Pending returns the number of unacknowledged messages (including those not
+// yet received).
+func (q *DurableQueue[T]) Pending() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return len(q.pending)
+}
+
+// Compact rewrites the log file with only the unacknowledged messages (and
+// the next ID, so IDs aren't reused) so that it doesn't grow indefinitely.
+func (q *DurableQueue[T]) Compact() error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.isClosed {
+		return ErrClosed
+	}
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(
+		tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644,
+	)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	err = func() error {
+		b, err := json.Marshal(durableRecord{Op: durableOpNext, ID: q.nextID})
+		if err != nil {
+			return err
+		}
+		w.Write(b)
+		w.WriteByte('\n')
+		for _, id := range q.pendingIDs() {
+			val, err := json.Marshal(q.pending[id])
+			if err != nil {
+				return err
+			}
+			b, err := json.Marshal(durableRecord{
+				Op: durableOpSend, ID: id, Val: val,
+			})
+			if err != nil {
+				return err
+			}
+			w.Write(b)
+			w.WriteByte('\n')
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return tmp.Sync()
+	}()
+	if err == nil {
+		err = os.Rename(tmpPath, q.path)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	q.f.Close()
+	q.f, q.w = tmp, bufio.NewWriter(tmp)
+	return nil
+}
+
+// Close closes the queue and its log file. Messages already sent can still
+// be received, but not acknowledged.
+func (q *DurableQueue[T]) Close() error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.isClosed {
+		return ErrClosed
+	}
+	q.isClosed = true
+	q.uc.Close()
+	err := q.w.Flush()
+	if closeErr := q.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDurableQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	q, err := OpenDurableQueue[string](path, DurableQueueOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"a", "b", "c", "d"} {
+		if _, err := q.Send(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		msg, ok := q.Recv()
+		if !ok {
+			t.Fatal("queue unexpectedly closed")
+		}
+		if err := q.Ack(msg.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Received but not acked
+	if msg, _ := q.Recv(); msg.Value != "c" {
+		t.Fatalf("expected c, got %q", msg.Value)
+	}
+	if err := q.Ack(100); err == nil {
+		t.Fatal("expected error acking unknown ID")
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a torn write
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"send","id":9`)
+	f.Close()
+
+	q, err = OpenDurableQueue[string](path, DurableQueueOpts{Sync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := q.Pending(); n != 2 {
+		t.Fatalf("expected 2 pending, got %d", n)
+	}
+	id, err := q.Send("e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 {
+		t.Fatalf("expected ID 4, got %d", id)
+	}
+	if err := q.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i := 0; i < 3; i++ {
+		msg, _ := q.Recv()
+		got = append(got, msg.Value)
+		if msg.Value != "d" {
+			q.Ack(msg.ID)
+		}
+	}
+	if len(got) != 3 || got[0] != "c" || got[1] != "d" || got[2] != "e" {
+		t.Fatalf("expected [c d e], got %v", got)
+	}
+	q.Close()
+
+	q, err = OpenDurableQueue[string](path, DurableQueueOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := q.Recv()
+	if msg.Value != "d" || q.Pending() != 1 {
+		t.Fatalf("expected only d redelivered, got %q", msg.Value)
+	}
+
+	// IDs aren't reused after compacting away all the messages.
+	if err := q.Ack(msg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+	q, err = OpenDurableQueue[string](path, DurableQueueOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if n := q.Pending(); n != 0 {
+		t.Fatalf("expected 0 pending, got %d", n)
+	}
+	if id, err := q.Send("f"); err != nil {
+		t.Fatal(err)
+	} else if id != 5 {
+		t.Fatalf("expected ID 5, got %d", id)
+	}
+}
+
+func TestDurableQueueWriteError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	q, err := OpenDurableQueue[string](path, DurableQueueOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	// A failed flush shouldn't break later sends.
+	failErr := errors.New("write failed")
+	q.w = bufio.NewWriter(testFailWriter{failErr})
+	if _, err := q.Send("a"); err != failErr {
+		t.Fatalf("expected %v, got %v", failErr, err)
+	}
+	if _, err := q.Send("b"); err != nil {
+		t.Fatal(err)
+	}
+	if msg, _ := q.Recv(); msg.Value != "b" {
+		t.Fatalf("expected b, got %q", msg.Value)
+	}
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrUndefinedEnv means a variable referenced in a string being expanded is
+// not defined.
+var ErrUndefinedEnv = errors.New("undefined variable")
+
+// ExpandEnvStrict is like os.ExpandEnv, but returns an error if a referenced
+// variable is undefined (rather than expanding it to an empty string). See
+// ExpandStrict for the supported syntax.
+func ExpandEnvStrict(s string) (string, error) {
+	return ExpandStrict(s, os.LookupEnv)
+}
+
+// ExpandMapStrict is like ExpandEnvStrict, but looks up variables in the given
+// map rather than the environment.
+func ExpandMapStrict(s string, m ReadOnlyMap[string, string]) (string, error) {
+	return ExpandStrict(s, m.GetOk)
+}
+
+// ExpandStrict replaces variable references in the string with the values
+// returned by lookup. The supported syntax is:
+//
+//	$VAR or ${VAR}  the value of VAR; an error if VAR is undefined
+//	${VAR:-default} the value of VAR, or default if VAR is undefined or empty
+//	${VAR-default}  the value of VAR, or default if VAR is undefined
+//	$$              a literal $
+//
+// Defaults are expanded as well, so they may contain references. Variable
+// names consist of letters, digits, and underscores, and can't start with a
+// digit. Any other use of $ is an error. Errors for undefined variables wrap
+// ErrUndefinedEnv.
+func ExpandStrict(
+	s string, lookup func(string) (string, bool),
+) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != '$' {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		if i+1 == len(s) {
+			return "", errors.New("trailing $")
+		}
+		switch s[i+1] {
+		case '$':
+			sb.WriteByte('$')
+			i += 2
+		case '{':
+			end := matchingBrace(s, i+2)
+			if end == -1 {
+				return "", fmt.Errorf("unterminated ${ at %d", i)
+			}
+			val, err := expandBraced(s[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(val)
+			i = end + 1
+		default:
+			name := s[i+1 : i+1+envNameLen(s[i+1:])]
+			if name == "" {
+				return "", fmt.Errorf("invalid variable reference at %d", i)
+			}
+			val, ok := lookup(name)
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrUndefinedEnv, name)
+			}
+			sb.WriteString(val)
+			i += 1 + len(name)
+		}
+	}
+	return sb.String(), nil
+}
+
+func expandBraced(
+	expr string, lookup func(string) (string, bool),
+) (string, error) {
+	name := expr[:envNameLen(expr)]
+	if name == "" {
+		return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+	}
+	val, ok := lookup(name)
+	switch rest := expr[len(name):]; {
+	case rest == "":
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUndefinedEnv, name)
+		}
+		return val, nil
+	case strings.HasPrefix(rest, ":-"):
+		if !ok || val == "" {
+			return ExpandStrict(rest[2:], lookup)
+		}
+		return val, nil
+	case strings.HasPrefix(rest, "-"):
+		if !ok {
+			return ExpandStrict(rest[1:], lookup)
+		}
+		return val, nil
+	default:
+		return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+	}
+}
+
+// envNameLen returns the length of the variable name at the start of s.
+func envNameLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		isLetter := c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+		if !isLetter && (i == 0 || c < '0' || c > '9') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// matchingBrace returns the index of the brace closing the one opened just
+// before start, or -1 if there is none.
+func matchingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
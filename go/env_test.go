@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpandStrict(t *testing.T) {
+	vars := MapFromMap(map[string]string{
+		"HOST":  "localhost",
+		"PORT":  "8080",
+		"EMPTY": "",
+		"_x1":   "y",
+	})
+	tests := map[string]string{
+		"no vars":                    "no vars",
+		"$HOST:$PORT":                "localhost:8080",
+		"http://${HOST}/$_x1":        "http://localhost/y",
+		"${MISSING:-default}":        "default",
+		"${EMPTY:-default}":          "default",
+		"${EMPTY-default}":           "",
+		"${MISSING-}":                "",
+		"${MISSING:-${HOST}:$PORT}":  "localhost:8080",
+		"${MISSING:-${NOPE:-deep}}!": "deep!",
+		"cost: $$5":                  "cost: $5",
+		"$PORT.0":                    "8080.0",
+	}
+	for s, want := range tests {
+		got, err := ExpandMapStrict(s, vars)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		} else if got != want {
+			t.Fatalf("%s: expected %q, got %q", s, want, got)
+		}
+	}
+
+	for _, s := range []string{"$MISSING", "${MISSING}", "${X:-$MISSING}"} {
+		_, err := ExpandMapStrict(s, vars)
+		if !errors.Is(err, ErrUndefinedEnv) {
+			t.Fatalf("%s: expected ErrUndefinedEnv, got %v", s, err)
+		}
+	}
+	for _, s := range []string{
+		"$", "a$", "$5", "${", "${HOST", "${}", "${HOST?}",
+	} {
+		_, err := ExpandMapStrict(s, vars)
+		if err == nil || errors.Is(err, ErrUndefinedEnv) {
+			t.Fatalf("%s: expected syntax error, got %v", s, err)
+		}
+	}
+
+	t.Setenv("UTILS_TEST_EXPAND", "value")
+	if got, err := ExpandEnvStrict("[$UTILS_TEST_EXPAND]"); err != nil {
+		t.Fatal(err)
+	} else if got != "[value]" {
+		t.Fatalf("expected [value], got %q", got)
+	}
+}
@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"iter"
+	"slices"
+	"time"
+)
+
+// WindowKind is the kind of windows an EventWindower groups items into.
+type WindowKind uint8
+
+const (
+	// TumblingWindow is fixed-size, non-overlapping windows aligned to Size.
+	TumblingWindow WindowKind = iota
+	// SlidingWindow is fixed-size windows starting every Slide, so an item can
+	// be in multiple windows.
+	SlidingWindow
+	// SessionWindow is windows of activity, closed once no items arrive within
+	// Gap (of event time) of the last item.
+	SessionWindow
+)
+
+// WindowOpts are options for an EventWindower.
+type WindowOpts[T any] struct {
+	// Kind is the kind of windows.
+	Kind WindowKind
+	// Size is the length of tumbling and sliding windows.
+	Size time.Duration
+	// Slide is how often sliding windows start. If not positive, Size is used
+	// (making them the same as tumbling windows).
+	Slide time.Duration
+	// Gap is the inactivity gap that ends a session window.
+	Gap time.Duration
+	// EventTime extracts the event timestamp from an item. Required.
+	EventTime func(T) time.Time
+	// AllowedLateness is how far behind the latest event time seen the
+	// watermark is kept. Windows are emitted once their end is at or before
+	// the watermark, so a larger value tolerates more out-of-order items at
+	// the cost of emitting windows later.
+	AllowedLateness time.Duration
+	// OnLate, if not nil, is called with items that arrive after all the
+	// windows they belong to were emitted. Otherwise, such items are dropped.
+	OnLate func(T)
+}
+
+// Window is a window of items emitted by an EventWindower. Items are in the
+// order they were added, except in sessions that were merged, where they're
+// grouped by the session they were first added to.
+type Window[T any] struct {
+	Start time.Time
+	End   time.Time
+	Items []T
+}
+
+// EventWindower groups items into windows by their event time, rather than
+// when they're received, so out-of-order items are put in the correct window.
+// Windows are emitted once the watermark (the latest event time seen minus
+// AllowedLateness) passes their end. It isn't safe for concurrent use. See
+// WindowSeq to window a sequence.
+type EventWindower[T any] struct {
+	opts      WindowOpts[T]
+	maxTime   time.Time
+	watermark time.Time
+	// Open tumbling/sliding windows, by start.
+	windows map[time.Time]*Window[T]
+	// Open sessions, in no particular order.
+	sessions []*Window[T]
+	late     uint64
+}
+
+// NewEventWindower creates a new EventWindower. Panics if EventTime is nil or
+// the size (or gap, for sessions) isn't positive.
+func NewEventWindower[T any](opts WindowOpts[T]) *EventWindower[T] {
+	if opts.EventTime == nil {
+		panic("utils: EventWindower requires EventTime")
+	}
+	if opts.Kind == SessionWindow {
+		if opts.Gap <= 0 {
+			panic("utils: EventWindower session gap must be positive")
+		}
+	} else if opts.Size <= 0 {
+		panic("utils: EventWindower size must be positive")
+	}
+	if opts.Slide <= 0 || opts.Kind == TumblingWindow {
+		opts.Slide = opts.Size
+	}
+	return &EventWindower[T]{
+		opts:    opts,
+		windows: make(map[time.Time]*Window[T]),
+	}
+}
+
+// Add adds an item, returning any windows closed by the watermark advancing,
+// ordered by end then start.
+func (ew *EventWindower[T]) Add(item T) []Window[T] {
+	t := ew.opts.EventTime(item)
+	if ew.opts.Kind == SessionWindow {
+		ew.addSession(item, t)
+	} else {
+		ew.addFixed(item, t)
+	}
+	if t.After(ew.maxTime) {
+		ew.maxTime = t
+		if wm := t.Add(-ew.opts.AllowedLateness); wm.After(ew.watermark) {
+			ew.watermark = wm
+			return ew.closeUpTo(wm)
+		}
+	}
+	return nil
+}
+
+func (ew *EventWindower[T]) addFixed(item T, t time.Time) {
+	size, slide := ew.opts.Size, ew.opts.Slide
+	added, late := false, false
+	// Go through each window containing t, from the latest start back. Starts
+	// are in UTC since they're used as map keys.
+	start := t.UTC().Truncate(slide)
+	for ; start.Add(size).After(t); start = start.Add(-slide) {
+		end := start.Add(size)
+		if !end.After(ew.watermark) {
+			// This and all earlier windows were already emitted.
+			late = true
+			break
+		}
+		w := ew.windows[start]
+		if w == nil {
+			w = &Window[T]{Start: start, End: end}
+			ew.windows[start] = w
+		}
+		w.Items = append(w.Items, item)
+		added = true
+	}
+	// If the item wasn't added and isn't late, it's in a gap between sliding
+	// windows (Slide > Size), so it's dropped.
+	if !added && late {
+		ew.lateItem(item)
+	}
+}
+
+func (ew *EventWindower[T]) addSession(item T, t time.Time) {
+	end := t.Add(ew.opts.Gap)
+	if !end.After(ew.watermark) {
+		ew.lateItem(item)
+		return
+	}
+	merged := &Window[T]{Start: t, End: end}
+	// Merge all the sessions overlapping the item's.
+	ew.sessions = slices.DeleteFunc(ew.sessions, func(s *Window[T]) bool {
+		if !(s.Start.Before(merged.End) && merged.Start.Before(s.End)) {
+			return false
+		}
+		if s.Start.Before(merged.Start) {
+			merged.Start = s.Start
+		}
+		if s.End.After(merged.End) {
+			merged.End = s.End
+		}
+		merged.Items = append(merged.Items, s.Items...)
+		return true
+	})
+	merged.Items = append(merged.Items, item)
+	ew.sessions = append(ew.sessions, merged)
+}
+
+func (ew *EventWindower[T]) lateItem(item T) {
+	ew.late++
+	if ew.opts.OnLate != nil {
+		ew.opts.OnLate(item)
+	}
+}
+
+// closeUpTo removes and returns the windows ending at or before wm.
+func (ew *EventWindower[T]) closeUpTo(wm time.Time) []Window[T] {
+	var closed []Window[T]
+	for start, w := range ew.windows {
+		if !w.End.After(wm) {
+			closed = append(closed, *w)
+			delete(ew.windows, start)
+		}
+	}
+	ew.sessions = slices.DeleteFunc(ew.sessions, func(s *Window[T]) bool {
+		if s.End.After(wm) {
+			return false
+		}
+		closed = append(closed, *s)
+		return true
+	})
+	sortWindows(closed)
+	return closed
+}
+
+// Flush removes and returns all open windows, such as when the input ends,
+// ordered by end then start. Items for the flushed windows that are added
+// later are treated as late.
+func (ew *EventWindower[T]) Flush() []Window[T] {
+	var last time.Time
+	for _, w := range ew.windows {
+		if w.End.After(last) {
+			last = w.End
+		}
+	}
+	for _, s := range ew.sessions {
+		if s.End.After(last) {
+			last = s.End
+		}
+	}
+	if last.After(ew.watermark) {
+		ew.watermark = last
+	}
+	return ew.closeUpTo(ew.watermark)
+}
+
+// Watermark returns the current watermark. Windows ending at or before it have
+// been emitted.
+func (ew *EventWindower[T]) Watermark() time.Time {
+	return ew.watermark
+}
+
+// Open returns the number of open windows.
+func (ew *EventWindower[T]) Open() int {
+	return len(ew.windows) + len(ew.sessions)
+}
+
+// Late returns the number of late items seen.
+func (ew *EventWindower[T]) Late() uint64 {
+	return ew.late
+}
+
+func sortWindows[T any](ws []Window[T]) {
+	slices.SortFunc(ws, func(a, b Window[T]) int {
+		if c := a.End.Compare(b.End); c != 0 {
+			return c
+		}
+		return a.Start.Compare(b.Start)
+	})
+}
+
+// WindowSeq returns a sequence of the windows of the items in seq, as emitted
+// by an EventWindower with the given options. The remaining open windows are
+// flushed when seq ends. To window the values of a UChan, use it with
+// SeqFromUChan (and UChanFromSeq to send the windows downstream over a
+// UChan).
+func WindowSeq[T any](
+	seq iter.Seq[T], opts WindowOpts[T],
+) iter.Seq[Window[T]] {
+	return func(yield func(Window[T]) bool) {
+		ew := NewEventWindower(opts)
+		for t := range seq {
+			for _, w := range ew.Add(t) {
+				if !yield(w) {
+					return
+				}
+			}
+		}
+		for _, w := range ew.Flush() {
+			if !yield(w) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+type windowEvent struct {
+	id int
+	at time.Duration
+}
+
+var windowEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func windowEvents(ws []Window[windowEvent]) [][]int {
+	res := make([][]int, len(ws))
+	for i, w := range ws {
+		for _, e := range w.Items {
+			res[i] = append(res[i], e.id)
+		}
+	}
+	return res
+}
+
+func checkWindows(t *testing.T, got []Window[windowEvent], want [][]int) {
+	t.Helper()
+	ids := windowEvents(got)
+	if len(ids) != len(want) {
+		t.Fatalf("expected windows %v, got %v", want, ids)
+	}
+	for i := range want {
+		if !SliceEq(ids[i], want[i]) {
+			t.Fatalf("expected windows %v, got %v", want, ids)
+		}
+	}
+}
+
+func windowOpts(kind WindowKind) WindowOpts[windowEvent] {
+	return WindowOpts[windowEvent]{
+		Kind: kind,
+		EventTime: func(e windowEvent) time.Time {
+			return windowEpoch.Add(e.at)
+		},
+	}
+}
+
+func TestTumblingWindow(t *testing.T) {
+	var late []int
+	opts := windowOpts(TumblingWindow)
+	opts.Size = 10 * time.Second
+	opts.AllowedLateness = 5 * time.Second
+	opts.OnLate = func(e windowEvent) { late = append(late, e.id) }
+	ew := NewEventWindower(opts)
+
+	var got []Window[windowEvent]
+	for _, e := range []windowEvent{
+		{1, 1 * time.Second},
+		{2, 12 * time.Second},
+		{3, 8 * time.Second},  // Out of order, but within lateness.
+		{4, 16 * time.Second}, // Watermark 11s closes [0s, 10s).
+		{5, 9 * time.Second},  // Late.
+		{6, 25 * time.Second}, // Watermark 20s closes [10s, 20s).
+	} {
+		got = append(got, ew.Add(e)...)
+	}
+	checkWindows(t, got, [][]int{{1, 3}, {2, 4}})
+	if !got[0].Start.Equal(windowEpoch) ||
+		!got[0].End.Equal(windowEpoch.Add(10*time.Second)) {
+		t.Fatalf("unexpected bounds: %v-%v", got[0].Start, got[0].End)
+	}
+	if !SliceEq(late, []int{5}) || ew.Late() != 1 {
+		t.Fatalf("expected 5 to be late, got %v", late)
+	}
+	if !ew.Watermark().Equal(windowEpoch.Add(20 * time.Second)) {
+		t.Fatalf("unexpected watermark: %v", ew.Watermark())
+	}
+	checkWindows(t, ew.Flush(), [][]int{{6}})
+	if ew.Open() != 0 {
+		t.Fatalf("expected no open windows, got %d", ew.Open())
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	opts := windowOpts(SlidingWindow)
+	opts.Size, opts.Slide = 10*time.Second, 5*time.Second
+	events := []windowEvent{
+		{1, 1 * time.Second},
+		{2, 7 * time.Second},
+		{3, 12 * time.Second},
+	}
+	got := CollectSeq(WindowSeq(SeqFromSlice(events), opts))
+	// Windows: [-5,5) [0,10) [5,15) [10,20)
+	checkWindows(t, got, [][]int{{1}, {1, 2}, {2, 3}, {3}})
+}
+
+func TestSessionWindow(t *testing.T) {
+	opts := windowOpts(SessionWindow)
+	opts.Gap = 5 * time.Second
+	opts.AllowedLateness = 10 * time.Second
+	events := []windowEvent{
+		{1, 0},
+		{2, 3 * time.Second},
+		{3, 20 * time.Second}, // Closes the first session.
+		{4, 30 * time.Second},
+		{5, 38 * time.Second}, // Closes the session of 3.
+		{6, 34 * time.Second}, // Merges the sessions of 4 and 5.
+	}
+	got := CollectSeq(WindowSeq(SeqFromSlice(events), opts))
+	checkWindows(t, got, [][]int{{1, 2}, {3}, {4, 5, 6}})
+	if !got[0].End.Equal(windowEpoch.Add(8 * time.Second)) {
+		t.Fatalf("unexpected end: %v", got[0].End)
+	}
+	if got[2].Start.Sub(windowEpoch) != 30*time.Second ||
+		got[2].End.Sub(windowEpoch) != 43*time.Second {
+		t.Fatalf("unexpected bounds: %v-%v", got[2].Start, got[2].End)
+	}
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrNotFound means something being looked up doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+// Factory builds a T from its (JSON) config.
+type Factory[T any] func(cfg json.RawMessage) (T, error)
+
+// TypedFactory returns a Factory that unmarshals the config into a C before
+// passing it to f. An empty (or null) config results in the zero C.
+func TypedFactory[T, C any](f func(cfg C) (T, error)) Factory[T] {
+	return func(raw json.RawMessage) (T, error) {
+		var cfg C
+		if len(raw) != 0 {
+			if err := json.Unmarshal(raw, &cfg); err != nil {
+				var t T
+				return t, fmt.Errorf("invalid config: %w", err)
+			}
+		}
+		return f(cfg)
+	}
+}
+
+// Factories is a registry of named factories, used to make implementations of
+// T pluggable (e.g., selecting a codec by name from a config file). It is
+// safe for concurrent use.
+type Factories[T any] struct {
+	mtx       sync.RWMutex
+	factories map[string]Factory[T]
+}
+
+// NewFactories returns a new, empty Factories.
+func NewFactories[T any]() *Factories[T] {
+	return &Factories[T]{factories: make(map[string]Factory[T])}
+}
+
+// Register registers the factory under the given name, returning an error
+// wrapping ErrExists if the name is already registered.
+func (fs *Factories[T]) Register(name string, f Factory[T]) error {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	if _, ok := fs.factories[name]; ok {
+		return fmt.Errorf("factory %q: %w", name, ErrExists)
+	}
+	fs.factories[name] = f
+	return nil
+}
+
+// MustRegister is like Register but panics on error. Useful in init
+// functions.
+func (fs *Factories[T]) MustRegister(name string, f Factory[T]) {
+	if err := fs.Register(name, f); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes the factory with the given name, returning false if
+// there was none.
+func (fs *Factories[T]) Unregister(name string) bool {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	_, ok := fs.factories[name]
+	delete(fs.factories, name)
+	return ok
+}
+
+// Has returns whether a factory with the given name is registered.
+func (fs *Factories[T]) Has(name string) bool {
+	fs.mtx.RLock()
+	defer fs.mtx.RUnlock()
+	_, ok := fs.factories[name]
+	return ok
+}
+
+// Names returns the names of the registered factories in sorted order.
+func (fs *Factories[T]) Names() []string {
+	fs.mtx.RLock()
+	names := make([]string, 0, len(fs.factories))
+	for name := range fs.factories {
+		names = append(names, name)
+	}
+	fs.mtx.RUnlock()
+	slices.Sort(names)
+	return names
+}
+
+// Build builds a T using the factory with the given name and config. If no
+// such factory is registered, the returned error wraps ErrNotFound (and
+// suggests a close name, if any). Errors from the factory are wrapped with
+// its name.
+func (fs *Factories[T]) Build(name string, cfg json.RawMessage) (T, error) {
+	fs.mtx.RLock()
+	f, ok := fs.factories[name]
+	fs.mtx.RUnlock()
+	if !ok {
+		var t T
+		return t, fmt.Errorf(
+			"factory %q: %w%s", name, ErrNotFound, didYouMean(name, fs.Names()),
+		)
+	}
+	t, err := f(cfg)
+	if err != nil {
+		return t, fmt.Errorf("factory %q: %w", name, err)
+	}
+	return t, nil
+}
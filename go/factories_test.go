@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testCodec interface {
+	Name() string
+}
+
+type testGzipCodec struct{ level int }
+
+func (c testGzipCodec) Name() string { return "gzip" }
+
+type testRawCodec struct{}
+
+func (testRawCodec) Name() string { return "raw" }
+
+func TestFactories(t *testing.T) {
+	fs := NewFactories[testCodec]()
+	fs.MustRegister("gzip", TypedFactory(func(cfg struct {
+		Level int `json:"level"`
+	}) (testCodec, error) {
+		if cfg.Level < 0 || cfg.Level > 9 {
+			return nil, errors.New("bad level")
+		}
+		return testGzipCodec{level: cfg.Level}, nil
+	}))
+	fs.MustRegister("raw", func(json.RawMessage) (testCodec, error) {
+		return testRawCodec{}, nil
+	})
+	err := fs.Register("raw", nil)
+	if !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if names := fs.Names(); !SliceEq(names, []string{"gzip", "raw"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+
+	c, err := fs.Build("gzip", json.RawMessage(`{"level":5}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if gc, ok := c.(testGzipCodec); !ok || gc.level != 5 {
+		t.Fatalf("unexpected codec: %#v", c)
+	}
+	c, err = fs.Build("gzip", nil)
+	if err != nil || c.(testGzipCodec).level != 0 {
+		t.Fatalf("unexpected codec or error: %#v, %v", c, err)
+	}
+	if _, err := fs.Build("gzip", json.RawMessage(`{"level":10}`)); err == nil ||
+		!strings.Contains(err.Error(), `factory "gzip": bad level`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := fs.Build("gzip", json.RawMessage(`[]`)); err == nil {
+		t.Fatal("expected config error")
+	}
+
+	_, err = fs.Build("gzp", nil)
+	if !errors.Is(err, ErrNotFound) ||
+		!strings.Contains(err.Error(), `did you mean "gzip"?`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !fs.Unregister("raw") || fs.Unregister("raw") || fs.Has("raw") {
+		t.Fatal("bad Unregister results")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		fs.MustRegister("gzip", nil)
+	}()
+}
@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// FairSchedulerOpts are options for a FairScheduler.
+type FairSchedulerOpts struct {
+	// Workers is the number of workers. If less than 1, runtime.GOMAXPROCS(0)
+	// is used.
+	Workers int
+	// DefaultWeight is the weight of queues without one set with SetWeight.
+	// If less than 1, 1 is used.
+	DefaultWeight int
+	// OnError, if not nil, is called with the queue name and error of each
+	// job that fails. If the job panicked, the error is a *PanicError.
+	OnError func(queue string, err error)
+}
+
+// QueueStats are the metrics for a FairScheduler queue.
+type QueueStats struct {
+	// Weight is the queue's weight.
+	Weight int
+	// Pending is the number of jobs waiting to run.
+	Pending int
+	// Running is the number of jobs currently running.
+	Running int
+	// Submitted is the total number of jobs submitted.
+	Submitted uint64
+	// Completed is the total number of jobs that finished without error.
+	Completed uint64
+	// Failed is the total number of jobs that returned an error or panicked.
+	Failed uint64
+	// TotalWait is the total time finished jobs spent waiting to run.
+	TotalWait time.Duration
+	// TotalRun is the total time finished jobs spent running.
+	TotalRun time.Duration
+}
+
+// FairScheduler runs jobs from multiple named queues (e.g., one per tenant) on
+// a fixed number of workers, interleaving the queues with weighted
+// round-robin so that a large backlog in one queue can't starve the others.
+// Each turn, a queue can start up to its weight in jobs before the next queue
+// with pending jobs gets a turn.
+type FairScheduler struct {
+	opts   FairSchedulerOpts
+	mtx    sync.Mutex
+	cond   *sync.Cond
+	queues map[string]*fairQueue
+	// active is the queues with pending jobs, in round-robin order.
+	active []*fairQueue
+	next   int
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	done   chan struct{}
+}
+
+type fairQueue struct {
+	name    string
+	jobs    []fairJob
+	credits int
+	stats   QueueStats
+}
+
+type fairJob struct {
+	f         func(context.Context) error
+	submitted time.Time
+}
+
+// NewFairScheduler creates and starts a new FairScheduler.
+func NewFairScheduler(opts FairSchedulerOpts) *FairScheduler {
+	if opts.Workers < 1 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	if opts.DefaultWeight < 1 {
+		opts.DefaultWeight = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	fs := &FairScheduler{
+		opts:   opts,
+		queues: make(map[string]*fairQueue),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	fs.cond = sync.NewCond(&fs.mtx)
+	fs.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go fs.work()
+	}
+	go func() {
+		fs.wg.Wait()
+		close(fs.done)
+	}()
+	return fs
+}
+
+// queue returns the queue with the given name, creating it if needed. The
+// lock must be held.
+func (fs *FairScheduler) queue(name string) *fairQueue {
+	q, ok := fs.queues[name]
+	if !ok {
+		q = &fairQueue{name: name}
+		q.stats.Weight = fs.opts.DefaultWeight
+		fs.queues[name] = q
+	}
+	return q
+}
+
+// SetWeight sets the weight of the queue (at least 1), creating the queue if
+// needed.
+func (fs *FairScheduler) SetWeight(queue string, weight int) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	fs.queue(queue).stats.Weight = max(weight, 1)
+}
+
+// Submit adds a job to the queue, creating the queue if needed. The context
+// passed to the job is canceled if the scheduler is stopped. Returns
+// ErrClosed if the scheduler is closed.
+func (fs *FairScheduler) Submit(
+	queue string, job func(ctx context.Context) error,
+) error {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	if fs.closed {
+		return ErrClosed
+	}
+	q := fs.queue(queue)
+	q.jobs = append(q.jobs, fairJob{f: job, submitted: time.Now()})
+	q.stats.Submitted++
+	if len(q.jobs) == 1 {
+		fs.active = append(fs.active, q)
+	}
+	fs.cond.Signal()
+	return nil
+}
+
+// Stats returns the metrics for the queue, returning false if there is no
+// such queue.
+func (fs *FairScheduler) Stats(queue string) (QueueStats, bool) {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	q, ok := fs.queues[queue]
+	if !ok {
+		return QueueStats{}, false
+	}
+	stats := q.stats
+	stats.Pending = len(q.jobs)
+	return stats, true
+}
+
+// AllStats returns the metrics for all queues.
+func (fs *FairScheduler) AllStats() map[string]QueueStats {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	all := make(map[string]QueueStats, len(fs.queues))
+	for name, q := range fs.queues {
+		stats := q.stats
+		stats.Pending = len(q.jobs)
+		all[name] = stats
+	}
+	return all
+}
+
+// nextJob returns the next job using weighted round-robin. The lock must be
+// held and there must be an active queue.
+func (fs *FairScheduler) nextJob() (*fairQueue, fairJob) {
+	if fs.next >= len(fs.active) {
+		fs.next = 0
+	}
+	q := fs.active[fs.next]
+	if q.credits == 0 {
+		q.credits = q.stats.Weight
+	}
+	job := q.jobs[0]
+	q.jobs[0] = fairJob{}
+	q.jobs = q.jobs[1:]
+	q.credits--
+	if len(q.jobs) == 0 {
+		q.jobs, q.credits = nil, 0
+		fs.active = append(fs.active[:fs.next], fs.active[fs.next+1:]...)
+	} else if q.credits == 0 {
+		fs.next++
+	}
+	return q, job
+}
+
+func (fs *FairScheduler) work() {
+	defer fs.wg.Done()
+	for {
+		fs.mtx.Lock()
+		for len(fs.active) == 0 && !fs.closed {
+			fs.cond.Wait()
+		}
+		if len(fs.active) == 0 {
+			fs.mtx.Unlock()
+			return
+		}
+		q, job := fs.nextJob()
+		q.stats.Running++
+		fs.mtx.Unlock()
+
+		start := time.Now()
+		var err error
+		if perr := Safe(func() { err = job.f(fs.ctx) }); perr != nil {
+			err = perr
+		}
+		end := time.Now()
+
+		fs.mtx.Lock()
+		q.stats.Running--
+		q.stats.TotalWait += start.Sub(job.submitted)
+		q.stats.TotalRun += end.Sub(start)
+		if err != nil {
+			q.stats.Failed++
+		} else {
+			q.stats.Completed++
+		}
+		fs.mtx.Unlock()
+		if err != nil && fs.opts.OnError != nil {
+			fs.opts.OnError(q.name, err)
+		}
+	}
+}
+
+// Close stops the scheduler from accepting new jobs. Jobs already submitted
+// are still run. Returns false if the scheduler was already closed.
+func (fs *FairScheduler) Close() bool {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+	if fs.closed {
+		return false
+	}
+	fs.closed = true
+	fs.cond.Broadcast()
+	return true
+}
+
+// Wait waits for the scheduler to be closed and all jobs to finish.
+func (fs *FairScheduler) Wait() {
+	<-fs.done
+}
+
+// Done returns a chan that is closed once the scheduler is closed and all
+// jobs have finished.
+func (fs *FairScheduler) Done() <-chan struct{} {
+	return fs.done
+}
+
+// Shutdown closes the scheduler and waits for all submitted jobs to finish.
+// If the context is done first, the scheduler is stopped (see Stop) and
+// ErrCanceled (wrapping the context's error) is returned.
+func (fs *FairScheduler) Shutdown(ctx context.Context) error {
+	fs.Close()
+	select {
+	case <-fs.done:
+		return nil
+	case <-ctx.Done():
+		fs.Stop()
+		return newCanceledError(ctx)
+	}
+}
+
+// Stop closes the scheduler, cancels the contexts of running jobs, and drops
+// all pending jobs.
+func (fs *FairScheduler) Stop() {
+	fs.mtx.Lock()
+	fs.closed = true
+	for _, q := range fs.active {
+		q.jobs, q.credits = nil, 0
+	}
+	fs.active = nil
+	fs.cond.Broadcast()
+	fs.mtx.Unlock()
+	fs.cancel()
+}
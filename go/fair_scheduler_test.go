@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockFairScheduler submits a job that occupies the single worker until the
+// returned func is called.
+func blockFairScheduler(t *testing.T, fs *FairScheduler) func() {
+	started, release := make(chan struct{}), make(chan struct{})
+	err := fs.Submit("block", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	<-started
+	return func() { close(release) }
+}
+
+func TestFairSchedulerOrder(t *testing.T) {
+	fs := NewFairScheduler(FairSchedulerOpts{Workers: 1})
+	release := blockFairScheduler(t, fs)
+
+	var mtx sync.Mutex
+	var order []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mtx.Lock()
+			order = append(order, name)
+			mtx.Unlock()
+			return nil
+		}
+	}
+	fs.SetWeight("a", 3)
+	for i := 0; i < 9; i++ {
+		fs.Submit("a", record("a"))
+	}
+	for i := 0; i < 3; i++ {
+		fs.Submit("b", record("b"))
+	}
+	release()
+	fs.Close()
+	fs.Wait()
+
+	got := strings.Join(order, "")
+	if want := "aaabaaabaaab"; got != want {
+		t.Fatalf("expected order %s, got %s", want, got)
+	}
+}
+
+func TestFairSchedulerNoStarvation(t *testing.T) {
+	fs := NewFairScheduler(FairSchedulerOpts{Workers: 1})
+	release := blockFairScheduler(t, fs)
+
+	var mtx sync.Mutex
+	var order []string
+	for i := 0; i < 100; i++ {
+		fs.Submit("big", func(context.Context) error {
+			mtx.Lock()
+			order = append(order, "big")
+			mtx.Unlock()
+			return nil
+		})
+	}
+	for i := 0; i < 2; i++ {
+		fs.Submit("small", func(context.Context) error {
+			mtx.Lock()
+			order = append(order, "small")
+			mtx.Unlock()
+			return nil
+		})
+	}
+	release()
+	if err := fs.Shutdown(context.Background()); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+
+	smalls := 0
+	for _, name := range order[:4] {
+		if name == "small" {
+			smalls++
+		}
+	}
+	if smalls != 2 {
+		t.Fatalf("small jobs starved: first jobs were %v", order[:4])
+	}
+	stats, ok := fs.Stats("big")
+	if !ok {
+		t.Fatal("missing stats for big")
+	}
+	if stats.Submitted != 100 || stats.Completed != 100 || stats.Pending != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestFairSchedulerErrors(t *testing.T) {
+	var mtx sync.Mutex
+	errs := map[string]error{}
+	fs := NewFairScheduler(FairSchedulerOpts{
+		Workers: 2,
+		OnError: func(queue string, err error) {
+			mtx.Lock()
+			errs[queue] = err
+			mtx.Unlock()
+		},
+	})
+	errTest := errors.New("test")
+	fs.Submit("err", func(context.Context) error { return errTest })
+	fs.Submit("panic", func(context.Context) error { panic("oops") })
+	fs.Submit("ok", func(context.Context) error { return nil })
+	fs.Close()
+	fs.Wait()
+
+	if err := fs.Submit("ok", nil); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if !errors.Is(errs["err"], errTest) {
+		t.Fatalf("expected test error, got %v", errs["err"])
+	}
+	var pe *PanicError
+	if !errors.As(errs["panic"], &pe) || pe.Value != "oops" {
+		t.Fatalf("expected PanicError, got %v", errs["panic"])
+	}
+	all := fs.AllStats()
+	if len(all) != 3 {
+		t.Fatalf("expected 3 queues, got %d", len(all))
+	}
+	if all["err"].Failed != 1 || all["panic"].Failed != 1 {
+		t.Fatalf("unexpected stats: %+v", all)
+	}
+	if all["ok"].Completed != 1 || all["ok"].Failed != 0 {
+		t.Fatalf("unexpected stats: %+v", all["ok"])
+	}
+}
+
+func TestFairSchedulerStop(t *testing.T) {
+	fs := NewFairScheduler(FairSchedulerOpts{Workers: 1})
+	started := make(chan struct{})
+	fs.Submit("a", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ran := false
+	fs.Submit("a", func(context.Context) error {
+		ran = true
+		return nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*10,
+	)
+	defer cancel()
+	err := fs.Shutdown(ctx)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	fs.Wait()
+	if ran {
+		t.Fatal("pending job ran after Stop")
+	}
+	if stats, _ := fs.Stats("a"); stats.Failed != 1 || stats.Pending != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
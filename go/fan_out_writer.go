@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SinkID identifies a sink in a FanOutWriter.
+type SinkID uint64
+
+// SinkError is the error for a single sink in a FanOutWriter write.
+type SinkError struct {
+	ID  SinkID
+	Err error
+}
+
+// Error implements the error interface.
+func (se *SinkError) Error() string {
+	return fmt.Sprintf("sink %d: %v", se.ID, se.Err)
+}
+
+// Unwrap returns the sink's error.
+func (se *SinkError) Unwrap() error {
+	return se.Err
+}
+
+// FanOutWriter writes to multiple sinks. Unlike io.MultiWriter, a failing
+// sink doesn't stop the write to the others; the errors from each are
+// collected and returned together. Sinks can be added and removed at any
+// time. Each Write is delivered to all sinks before the next begins.
+type FanOutWriter struct {
+	mtx    sync.Mutex
+	sinks  []*fanOutSink
+	nextID SinkID
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type fanOutSink struct {
+	id SinkID
+	w  io.Writer
+	// ch is non-nil for async sinks.
+	ch *UChan[[]byte]
+}
+
+// NewFanOutWriter returns a new FanOutWriter with the given (synchronous)
+// sinks.
+func NewFanOutWriter(ws ...io.Writer) *FanOutWriter {
+	fw := &FanOutWriter{}
+	for _, w := range ws {
+		fw.AddSink(w)
+	}
+	return fw
+}
+
+// AddSink adds a sink that is written to synchronously, returning its ID.
+// Returns 0 if the writer is closed.
+func (fw *FanOutWriter) AddSink(w io.Writer) SinkID {
+	return fw.addSink(&fanOutSink{w: w})
+}
+
+// AddAsyncSink adds a sink that is written to asynchronously, returning its
+// ID. Writes are copied and queued in an unbounded channel (UChan) with the
+// given chan length, then written in order by a separate goroutine, so a slow
+// sink never slows down Write. Since errors can't be returned from Write,
+// they are passed to onErr (if not nil). Returns 0 if the writer is closed.
+func (fw *FanOutWriter) AddAsyncSink(
+	w io.Writer, chanLen int, onErr func(error),
+) SinkID {
+	sink := &fanOutSink{w: w, ch: NewUChan[[]byte](chanLen)}
+	id := fw.addSink(sink)
+	if id == 0 {
+		return 0
+	}
+	fw.wg.Add(1)
+	go func() {
+		defer fw.wg.Done()
+		for {
+			p, ok := sink.ch.Recv()
+			if !ok {
+				return
+			}
+			if _, err := WriteAll(w, p); err != nil && onErr != nil {
+				onErr(&SinkError{ID: id, Err: err})
+			}
+		}
+	}()
+	return id
+}
+
+func (fw *FanOutWriter) addSink(sink *fanOutSink) SinkID {
+	fw.mtx.Lock()
+	defer fw.mtx.Unlock()
+	if fw.closed {
+		return 0
+	}
+	fw.nextID++
+	sink.id = fw.nextID
+	fw.sinks = append(fw.sinks, sink)
+	return sink.id
+}
+
+// RemoveSink removes the sink with the given ID, returning false if there is
+// no such sink. Data already queued for an async sink is still written.
+func (fw *FanOutWriter) RemoveSink(id SinkID) bool {
+	fw.mtx.Lock()
+	defer fw.mtx.Unlock()
+	for i, sink := range fw.sinks {
+		if sink.id == id {
+			fw.sinks = append(fw.sinks[:i], fw.sinks[i+1:]...)
+			if sink.ch != nil {
+				sink.ch.Close()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of sinks.
+func (fw *FanOutWriter) Len() int {
+	fw.mtx.Lock()
+	defer fw.mtx.Unlock()
+	return len(fw.sinks)
+}
+
+// Write writes p to each sink. All sinks are written to regardless of any
+// errors, and len(p) is always returned. The returned error (if any) joins a
+// *SinkError for each failed sink. Returns ErrClosed if the writer is closed.
+func (fw *FanOutWriter) Write(p []byte) (int, error) {
+	fw.mtx.Lock()
+	defer fw.mtx.Unlock()
+	if fw.closed {
+		return 0, ErrClosed
+	}
+	var errs []error
+	var pc []byte
+	for _, sink := range fw.sinks {
+		if sink.ch != nil {
+			if pc == nil {
+				pc = CloneSlice(p)
+			}
+			sink.ch.Send(pc)
+			continue
+		}
+		if _, err := WriteAll(sink.w, p); err != nil {
+			errs = append(errs, &SinkError{ID: sink.id, Err: err})
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+// Close removes all sinks and waits for the async sinks to finish writing
+// their queued data. The sinks themselves aren't closed. Subsequent writes
+// return ErrClosed.
+func (fw *FanOutWriter) Close() error {
+	fw.mtx.Lock()
+	if fw.closed {
+		fw.mtx.Unlock()
+		return ErrClosed
+	}
+	fw.closed = true
+	for _, sink := range fw.sinks {
+		if sink.ch != nil {
+			sink.ch.Close()
+		}
+	}
+	fw.sinks = nil
+	fw.mtx.Unlock()
+	fw.wg.Wait()
+	return nil
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testFailWriter struct{ err error }
+
+func (fw testFailWriter) Write(p []byte) (int, error) {
+	return 0, fw.err
+}
+
+type testSlowWriter struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sw *testSlowWriter) Write(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	sw.mtx.Lock()
+	defer sw.mtx.Unlock()
+	return sw.buf.Write(p)
+}
+
+func TestFanOutWriter(t *testing.T) {
+	var a, b bytes.Buffer
+	errFail := errors.New("fail")
+	fw := NewFanOutWriter(&a)
+	failID := fw.AddSink(testFailWriter{errFail})
+	bID := fw.AddSink(&b)
+	if fw.Len() != 3 {
+		t.Fatalf("expected 3 sinks, got %d", fw.Len())
+	}
+
+	n, err := fw.Write([]byte("hello"))
+	if n != 5 || !errors.Is(err, errFail) {
+		t.Fatalf("expected 5 and errFail, got %d, %v", n, err)
+	}
+	var se *SinkError
+	if !errors.As(err, &se) || se.ID != failID {
+		t.Fatalf("expected SinkError for %d, got %v", failID, err)
+	}
+	if a.String() != "hello" || b.String() != "hello" {
+		t.Fatalf("unexpected sink data: %q, %q", a.String(), b.String())
+	}
+
+	if !fw.RemoveSink(failID) || fw.RemoveSink(failID) {
+		t.Fatal("bad RemoveSink results")
+	}
+	if _, err := fw.Write([]byte("!")); err != nil {
+		t.Fatal(err)
+	}
+	fw.RemoveSink(bID)
+	fw.Write([]byte("?"))
+	if a.String() != "hello!?" || b.String() != "hello!" {
+		t.Fatalf("unexpected sink data: %q, %q", a.String(), b.String())
+	}
+
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte("x")); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if fw.AddSink(&a) != 0 {
+		t.Fatal("expected AddSink to fail after close")
+	}
+}
+
+func TestFanOutWriterAsync(t *testing.T) {
+	var fast bytes.Buffer
+	slow := &testSlowWriter{}
+	var errs []error
+	var errsMtx sync.Mutex
+	onErr := func(err error) {
+		errsMtx.Lock()
+		errs = append(errs, err)
+		errsMtx.Unlock()
+	}
+	fw := NewFanOutWriter(&fast)
+	fw.AddAsyncSink(slow, 4, nil)
+	failID := fw.AddAsyncSink(testFailWriter{errors.New("fail")}, 4, onErr)
+
+	start := time.Now()
+	buf := []byte("x")
+	for i := 0; i < 50; i++ {
+		buf[0] = byte('a' + i%26)
+		if _, err := fw.Write(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Fatalf("writes blocked on slow sink for %v", elapsed)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if fast.String() != slow.buf.String() || fast.Len() != 50 {
+		t.Fatalf("unexpected data: %q, %q", fast.String(), slow.buf.String())
+	}
+	var se *SinkError
+	if len(errs) != 50 || !errors.As(errs[0], &se) || se.ID != failID {
+		t.Fatalf("unexpected errors: %d, %v", len(errs), errs[0])
+	}
+}
@@ -1,6 +1,11 @@
 package utils
 
-import "strings"
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
 
 // BoolMapFlag is a map that holds whether various values were passed. This is
 // intended to be used in cases such as passing a flag multiple times with
@@ -45,3 +50,83 @@ func (bm BoolMapFlag) Set(s string) error {
 	}
 	return nil
 }
+
+// FlagBinder wraps a flag.FlagSet, falling back to environment variables for
+// flags that aren't passed on the command line.
+type FlagBinder struct {
+	*flag.FlagSet
+	// EnvPrefix, if not empty, is used to derive environment variable names for
+	// flags without an explicitly bound variable. The name is the prefix
+	// followed by the flag name in uppercase, with dashes and dots replaced by
+	// underscores (e.g., with a prefix of "APP_", "log-level" becomes
+	// "APP_LOG_LEVEL").
+	EnvPrefix string
+	envs      map[string]string
+}
+
+// NewFlagBinder creates a new FlagBinder wrapping the given FlagSet.
+func NewFlagBinder(fs *flag.FlagSet, envPrefix string) *FlagBinder {
+	return &FlagBinder{
+		FlagSet:   fs,
+		EnvPrefix: envPrefix,
+		envs:      make(map[string]string),
+	}
+}
+
+// BindEnv binds the flag with the given name to the given environment
+// variable, overriding the name derived from EnvPrefix.
+func (fb *FlagBinder) BindEnv(flagName, envName string) {
+	fb.envs[flagName] = envName
+}
+
+// EnvName returns the environment variable name used for the flag with the
+// given name, returning an empty string if there is none.
+func (fb *FlagBinder) EnvName(flagName string) string {
+	if envName, ok := fb.envs[flagName]; ok {
+		return envName
+	}
+	if fb.EnvPrefix == "" {
+		return ""
+	}
+	name := strings.NewReplacer("-", "_", ".", "_").Replace(flagName)
+	return fb.EnvPrefix + strings.ToUpper(name)
+}
+
+// Parse parses the arguments using the underlying FlagSet and then applies the
+// environment variable fallbacks.
+func (fb *FlagBinder) Parse(args []string) error {
+	if err := fb.FlagSet.Parse(args); err != nil {
+		return err
+	}
+	return fb.ApplyEnv()
+}
+
+// ApplyEnv sets all flags that weren't passed on the command line from their
+// environment variables, if set.
+func (fb *FlagBinder) ApplyEnv() error {
+	passed := make(map[string]bool)
+	fb.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+	var err error
+	fb.VisitAll(func(f *flag.Flag) {
+		if err != nil || passed[f.Name] {
+			return
+		}
+		envName := fb.EnvName(f.Name)
+		if envName == "" {
+			return
+		}
+		val, ok := os.LookupEnv(envName)
+		if !ok {
+			return
+		}
+		if setErr := fb.Set(f.Name, val); setErr != nil {
+			err = fmt.Errorf(
+				"invalid value %q for flag -%s from %s: %w",
+				val, f.Name, envName, setErr,
+			)
+		}
+	})
+	return err
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// AlmostEq returns whether a and b differ by at most eps.
+func AlmostEq[F Float](a, b, eps F) bool {
+	if a == b {
+		return true
+	}
+	return math.Abs(float64(a)-float64(b)) <= float64(eps)
+}
+
+// RelEq returns whether a and b differ by at most rel times the larger of
+// their magnitudes (e.g., 1e-9 for 9 significant digits).
+func RelEq[F Float](a, b, rel F) bool {
+	if a == b {
+		return true
+	}
+	fa, fb := float64(a), float64(b)
+	diff := math.Abs(fa - fb)
+	return diff <= float64(rel)*math.Max(math.Abs(fa), math.Abs(fb))
+}
+
+// RoundTo rounds f to the given number of decimal places, with halves rounded
+// away from zero. Negative places round to the left of the decimal point
+// (e.g., -2 rounds to the nearest hundred). Rounding is done on the shortest
+// decimal representation of f, so RoundTo(1.005, 2) is 1.01, not the 1.00
+// that scaling with math.Round would give. NaNs and infinities are returned
+// as is.
+func RoundTo(f float64, places int) float64 {
+	return scaleDecimal(f, places, true)
+}
+
+// TruncTo truncates f (toward zero) to the given number of decimal places.
+// Like RoundTo, this uses the shortest decimal representation of f, so
+// TruncTo(0.29, 2) is 0.29.
+func TruncTo(f float64, places int) float64 {
+	return scaleDecimal(f, places, false)
+}
+
+func scaleDecimal(f float64, places int, round bool) float64 {
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	n := int64(places)
+	if n < 0 {
+		n = -n
+	}
+	scale := new(big.Rat).SetInt(
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil),
+	)
+	if places >= 0 {
+		r.Mul(r, scale)
+	} else {
+		r.Quo(r, scale)
+	}
+	if round {
+		r.SetInt(roundQuo(r.Num(), r.Denom()))
+	} else {
+		r.SetInt(new(big.Int).Quo(r.Num(), r.Denom()))
+	}
+	if places >= 0 {
+		r.Quo(r, scale)
+	} else {
+		r.Mul(r, scale)
+	}
+	res, _ := r.Float64()
+	if res == 0 {
+		return math.Copysign(0, f)
+	}
+	return res
+}
+
+// FloatToInt converts f to an integer, truncating toward zero. Returns false
+// if f is NaN or out of the range of the integer type.
+func FloatToInt[I Integer, F Float](f F) (I, bool) {
+	t := math.Trunc(float64(f))
+	if math.IsNaN(t) {
+		return 0, false
+	}
+	var zero I
+	bits := sizeOfInteger[I]()
+	lo, hi := 0.0, math.Ldexp(1, bits)
+	if zero-1 < zero {
+		lo, hi = -math.Ldexp(1, bits-1), math.Ldexp(1, bits-1)
+	}
+	if t < lo || t >= hi {
+		return 0, false
+	}
+	return I(t), true
+}
+
+// RoundToInt rounds f to the nearest integer (halves away from zero). Returns
+// false if f is NaN or out of the range of the integer type.
+func RoundToInt[I Integer, F Float](f F) (I, bool) {
+	return FloatToInt[I](math.Round(float64(f)))
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAlmostEq(t *testing.T) {
+	if !AlmostEq(0.1+0.2, 0.3, 1e-12) {
+		t.Fatal("expected 0.1+0.2 to almost equal 0.3")
+	}
+	if AlmostEq(1.0, 1.1, 0.05) {
+		t.Fatal("expected 1.0 and 1.1 to not be almost equal")
+	}
+	if !AlmostEq(math.Inf(1), math.Inf(1), 0) {
+		t.Fatal("expected infinities to be equal")
+	}
+	if AlmostEq(math.NaN(), math.NaN(), 1) {
+		t.Fatal("expected NaNs to not be equal")
+	}
+	if !RelEq(1e20, 1e20+1e10, 1e-9) || RelEq(1.0, 1.01, 1e-3) {
+		t.Fatal("bad RelEq results")
+	}
+	if !AlmostEq[float32](1, 1.0001, 0.001) {
+		t.Fatal("expected float32s to be almost equal")
+	}
+}
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		f            float64
+		places       int
+		round, trunc float64
+	}{
+		{1.005, 2, 1.01, 1.0},
+		{0.29, 2, 0.29, 0.29},
+		{-2.675, 2, -2.68, -2.67},
+		{1234.5, -2, 1200, 1200},
+		{1250, -2, 1300, 1200},
+		{2.5, 0, 3, 2},
+		{-0.004, 2, -0, -0},
+		{123.456, 10, 123.456, 123.456},
+	}
+	for _, test := range tests {
+		if got := RoundTo(test.f, test.places); got != test.round {
+			t.Errorf(
+				"RoundTo(%v, %d): expected %v, got %v",
+				test.f, test.places, test.round, got,
+			)
+		}
+		if got := TruncTo(test.f, test.places); got != test.trunc {
+			t.Errorf(
+				"TruncTo(%v, %d): expected %v, got %v",
+				test.f, test.places, test.trunc, got,
+			)
+		}
+	}
+	if got := RoundTo(-0.004, 2); !math.Signbit(got) {
+		t.Errorf("expected negative zero, got %v", got)
+	}
+	if got := RoundTo(math.Inf(-1), 2); !math.IsInf(got, -1) {
+		t.Errorf("expected -Inf, got %v", got)
+	}
+}
+
+func TestFloatToInt(t *testing.T) {
+	if i, ok := FloatToInt[int8](127.9); !ok || i != 127 {
+		t.Fatalf("expected 127, got %d (%v)", i, ok)
+	}
+	if i, ok := FloatToInt[int8](-128.5); !ok || i != -128 {
+		t.Fatalf("expected -128, got %d (%v)", i, ok)
+	}
+	if _, ok := FloatToInt[int8](128.0); ok {
+		t.Fatal("expected 128 to be out of range")
+	}
+	if _, ok := FloatToInt[uint8](-1.0); ok {
+		t.Fatal("expected -1 to be out of range")
+	}
+	if i, ok := FloatToInt[uint8](-0.5); !ok || i != 0 {
+		t.Fatalf("expected 0, got %d (%v)", i, ok)
+	}
+	if _, ok := FloatToInt[int64](math.Ldexp(1, 63)); ok {
+		t.Fatal("expected 2^63 to be out of range")
+	}
+	if i, ok := FloatToInt[uint64](math.Ldexp(1, 63)); !ok || i != 1<<63 {
+		t.Fatalf("expected 2^63, got %d (%v)", i, ok)
+	}
+	if _, ok := FloatToInt[int](math.NaN()); ok {
+		t.Fatal("expected NaN to fail")
+	}
+	if _, ok := FloatToInt[int](math.Inf(1)); ok {
+		t.Fatal("expected Inf to fail")
+	}
+	if i, ok := RoundToInt[int](float32(-2.5)); !ok || i != -3 {
+		t.Fatalf("expected -3, got %d (%v)", i, ok)
+	}
+}
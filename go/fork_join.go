@@ -0,0 +1,297 @@
+package utils
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ForkJoinPool is a work-stealing executor meant for recursive (divide and
+// conquer) tasks. Each worker has its own deque of tasks; tasks spawned by a
+// worker are pushed onto (and popped from) the back of its deque while idle
+// workers steal from the front of other workers' deques.
+type ForkJoinPool struct {
+	workers []*ForkJoinWorker
+	inject  fjDeque
+
+	pending atomic.Int64
+	idle    atomic.Int64
+	mtx     sync.Mutex
+	cond    *sync.Cond
+
+	isClosed atomic.Bool
+	wg       sync.WaitGroup
+}
+
+// NewForkJoinPool creates and starts a new ForkJoinPool with the given number
+// of workers. If n is less than 1, runtime.GOMAXPROCS(0) workers are used.
+func NewForkJoinPool(n int) *ForkJoinPool {
+	if n < 1 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	p := &ForkJoinPool{workers: make([]*ForkJoinWorker, n)}
+	p.cond = sync.NewCond(&p.mtx)
+	for i := range p.workers {
+		p.workers[i] = &ForkJoinWorker{
+			pool: p,
+			id:   i,
+			seed: uint64(i)*0x9E3779B97F4A7C15 + 1,
+		}
+	}
+	p.wg.Add(n)
+	for _, w := range p.workers {
+		go w.run()
+	}
+	return p
+}
+
+// NumWorkers returns the number of workers in the pool.
+func (p *ForkJoinPool) NumWorkers() int {
+	return len(p.workers)
+}
+
+// Close closes the pool, waiting for all queued tasks to finish and the
+// workers to exit. Returns false if the pool was already closed.
+func (p *ForkJoinPool) Close() bool {
+	if p.isClosed.Swap(true) {
+		return false
+	}
+	p.mtx.Lock()
+	p.cond.Broadcast()
+	p.mtx.Unlock()
+	p.wg.Wait()
+	return true
+}
+
+// IsClosed returns whether the pool is closed.
+func (p *ForkJoinPool) IsClosed() bool {
+	return p.isClosed.Load()
+}
+
+func (p *ForkJoinPool) push(d *fjDeque, t fjTask) {
+	p.pending.Add(1)
+	d.pushBack(t)
+	if p.idle.Load() > 0 {
+		p.mtx.Lock()
+		p.cond.Signal()
+		p.mtx.Unlock()
+	}
+}
+
+// park waits until there is a pending task or the pool is closed, returning
+// false if the pool is closed and there are no pending tasks.
+func (p *ForkJoinPool) park() bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.idle.Add(1)
+	for p.pending.Load() == 0 && !p.IsClosed() {
+		p.cond.Wait()
+	}
+	p.idle.Add(-1)
+	return p.pending.Load() != 0
+}
+
+// ForkJoinWorker is a worker of a ForkJoinPool. It is passed to each task
+// executed by the pool and is used to spawn and join subtasks.
+type ForkJoinWorker struct {
+	pool  *ForkJoinPool
+	id    int
+	seed  uint64
+	deque fjDeque
+}
+
+// Pool returns the pool the worker belongs to.
+func (w *ForkJoinWorker) Pool() *ForkJoinPool {
+	return w.pool
+}
+
+// ID returns the index of the worker in its pool.
+func (w *ForkJoinWorker) ID() int {
+	return w.id
+}
+
+func (w *ForkJoinWorker) run() {
+	defer w.pool.wg.Done()
+	for {
+		if t := w.next(); t != nil {
+			t.run(w)
+		} else if !w.pool.park() {
+			return
+		}
+	}
+}
+
+// next gets the next task to run, first from the worker's own deque, then
+// from the pool's submissions, then by stealing from other workers.
+func (w *ForkJoinWorker) next() fjTask {
+	p := w.pool
+	if t := w.deque.popBack(); t != nil {
+		p.pending.Add(-1)
+		return t
+	}
+	if t := p.inject.popFront(); t != nil {
+		p.pending.Add(-1)
+		return t
+	}
+	n := len(p.workers)
+	start := int(w.rand() % uint64(n))
+	for i := 0; i < n; i++ {
+		other := p.workers[(start+i)%n]
+		if other == w {
+			continue
+		}
+		if t := other.deque.popFront(); t != nil {
+			p.pending.Add(-1)
+			return t
+		}
+	}
+	return nil
+}
+
+func (w *ForkJoinWorker) rand() uint64 {
+	// xorshift64
+	w.seed ^= w.seed << 13
+	w.seed ^= w.seed >> 7
+	w.seed ^= w.seed << 17
+	return w.seed
+}
+
+// ForkJoinTask is a task executed by a ForkJoinPool that produces a value.
+type ForkJoinTask[T any] struct {
+	f        func(*ForkJoinWorker) T
+	res      T
+	panicVal any
+	panicked bool
+	done     chan struct{}
+}
+
+func newForkJoinTask[T any](f func(*ForkJoinWorker) T) *ForkJoinTask[T] {
+	return &ForkJoinTask[T]{f: f, done: make(chan struct{})}
+}
+
+func (t *ForkJoinTask[T]) run(w *ForkJoinWorker) {
+	defer close(t.done)
+	defer func() {
+		if r := recover(); r != nil {
+			t.panicVal, t.panicked = r, true
+		}
+	}()
+	t.res = t.f(w)
+}
+
+// IsDone returns whether the task has finished.
+func (t *ForkJoinTask[T]) IsDone() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Join waits for the task to finish from within another task, returning its
+// result. While waiting, the worker executes other pending tasks rather than
+// blocking. If the task panicked, the panic is propagated to the caller.
+func (t *ForkJoinTask[T]) Join(w *ForkJoinWorker) T {
+	for !t.IsDone() {
+		if other := w.next(); other != nil {
+			other.run(w)
+			continue
+		}
+		// Nothing left to help with, the task must be being run by another
+		// worker.
+		<-t.done
+	}
+	return t.result()
+}
+
+// Wait blocks until the task is finished, returning its result. This should
+// be used outside of the pool's workers (use Join inside of tasks). If the
+// task panicked, the panic is propagated to the caller.
+func (t *ForkJoinTask[T]) Wait() T {
+	<-t.done
+	return t.result()
+}
+
+func (t *ForkJoinTask[T]) result() T {
+	if t.panicked {
+		panic(t.panicVal)
+	}
+	return t.res
+}
+
+// Spawn spawns a subtask onto the given worker's deque, returning the task
+// which can be joined using ForkJoinTask.Join.
+func Spawn[T any](
+	w *ForkJoinWorker, f func(*ForkJoinWorker) T,
+) *ForkJoinTask[T] {
+	t := newForkJoinTask(f)
+	w.pool.push(&w.deque, t)
+	return t
+}
+
+// Submit submits a task to the pool from outside of the pool, returning the
+// task which can be waited on using ForkJoinTask.Wait. Returns nil if the
+// pool is closed.
+func Submit[T any](
+	p *ForkJoinPool, f func(*ForkJoinWorker) T,
+) *ForkJoinTask[T] {
+	if p.IsClosed() {
+		return nil
+	}
+	t := newForkJoinTask(f)
+	p.push(&p.inject, t)
+	return t
+}
+
+// Invoke submits the task to the pool and waits for its result. Panics if the
+// pool is closed.
+func Invoke[T any](p *ForkJoinPool, f func(*ForkJoinWorker) T) T {
+	t := Submit(p, f)
+	if t == nil {
+		panic(ErrClosed)
+	}
+	return t.Wait()
+}
+
+type fjTask interface {
+	run(*ForkJoinWorker)
+}
+
+// fjDeque is a simple locked double-ended queue of tasks.
+type fjDeque struct {
+	mtx   sync.Mutex
+	tasks []fjTask
+	head  int
+}
+
+func (d *fjDeque) pushBack(t fjTask) {
+	d.mtx.Lock()
+	if d.head != 0 && d.head == len(d.tasks) {
+		d.tasks, d.head = d.tasks[:0], 0
+	}
+	d.tasks = append(d.tasks, t)
+	d.mtx.Unlock()
+}
+
+func (d *fjDeque) popBack() (t fjTask) {
+	d.mtx.Lock()
+	if l := len(d.tasks); l > d.head {
+		t = d.tasks[l-1]
+		d.tasks[l-1] = nil
+		d.tasks = d.tasks[:l-1]
+	}
+	d.mtx.Unlock()
+	return
+}
+
+func (d *fjDeque) popFront() (t fjTask) {
+	d.mtx.Lock()
+	if d.head < len(d.tasks) {
+		t = d.tasks[d.head]
+		d.tasks[d.head] = nil
+		d.head++
+	}
+	d.mtx.Unlock()
+	return
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"testing"
+)
+
+func fjFib(w *ForkJoinWorker, n int) int {
+	if n < 2 {
+		return n
+	}
+	t := Spawn(w, func(w *ForkJoinWorker) int {
+		return fjFib(w, n-1)
+	})
+	res := fjFib(w, n-2)
+	return res + t.Join(w)
+}
+
+func TestForkJoinPool(t *testing.T) {
+	p := NewForkJoinPool(4)
+	defer p.Close()
+
+	got := Invoke(p, func(w *ForkJoinWorker) int { return fjFib(w, 20) })
+	if got != 6765 {
+		t.Fatalf("expected %d, got %d", 6765, got)
+	}
+
+	s := ShuffleSlice(RangeSlice(10000), nil)
+	var sum func(w *ForkJoinWorker, s []int) int
+	sum = func(w *ForkJoinWorker, s []int) int {
+		if len(s) <= 1000 {
+			total := 0
+			for _, n := range s {
+				total += n
+			}
+			return total
+		}
+		mid := len(s) / 2
+		left := Spawn(w, func(w *ForkJoinWorker) int { return sum(w, s[:mid]) })
+		right := sum(w, s[mid:])
+		return left.Join(w) + right
+	}
+	tasks := make([]*ForkJoinTask[int], 10)
+	for i := range tasks {
+		tasks[i] = Submit(p, func(w *ForkJoinWorker) int { return sum(w, s) })
+	}
+	want := 10000 * 9999 / 2
+	for i, task := range tasks {
+		if got := task.Wait(); got != want {
+			t.Errorf("task %d: expected %d, got %d", i, want, got)
+		}
+	}
+}
+
+func TestForkJoinPoolPanic(t *testing.T) {
+	p := NewForkJoinPool(2)
+	defer p.Close()
+
+	task := Submit(p, func(w *ForkJoinWorker) int {
+		sub := Spawn(w, func(*ForkJoinWorker) int { panic("test panic") })
+		return sub.Join(w)
+	})
+	defer func() {
+		if r := recover(); r != "test panic" {
+			t.Fatalf("expected panic, got %v", r)
+		}
+	}()
+	task.Wait()
+}
+
+func TestForkJoinPoolClose(t *testing.T) {
+	p := NewForkJoinPool(2)
+	task := Submit(p, func(*ForkJoinWorker) int { return 1 })
+	if !p.Close() {
+		t.Fatal("pool unexpectedly closed")
+	}
+	if !task.IsDone() {
+		t.Fatal("expected queued task to finish before close")
+	}
+	if p.Close() {
+		t.Fatal("pool not closed")
+	}
+	if Submit(p, func(*ForkJoinWorker) int { return 1 }) != nil {
+		t.Fatal("expected nil task from closed pool")
+	}
+}
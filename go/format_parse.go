@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,3 +37,151 @@ func FormatTime3339(u int64) string {
 	t := int64(u)
 	return time.Unix(t/1000000000, t%1000000000).Format(time.RFC3339Nano)
 }
+
+// ParseBytes parses a byte size such as "1.5GiB" or "10 MB" into a number of
+// bytes. See ParseByteSize for the accepted units.
+func ParseBytes(s string) (int64, error) {
+	b, err := ParseByteSize(s)
+	return int64(b), err
+}
+
+// FormatBytes formats a number of bytes using binary units (e.g., "1.5GiB").
+// See ByteSize.String.
+func FormatBytes(n int64) string {
+	return ByteSize(n).String()
+}
+
+const (
+	// Day is 24 hours. It doesn't account for DST or leap seconds.
+	Day = 24 * time.Hour
+	// Week is 7 days.
+	Week = 7 * Day
+)
+
+// ParseDurationExt parses a duration the same as time.ParseDuration, but also
+// accepts days ("d") and weeks ("w"), such as "2d4h" or "1.5w". Days are always
+// 24 hours.
+func ParseDurationExt(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	neg := false
+	if str[0] == '-' || str[0] == '+' {
+		neg = str[0] == '-'
+		str = str[1:]
+	}
+	if str == "0" {
+		return 0, nil
+	}
+	var total time.Duration
+	for str != "" {
+		// Split off the next number and unit.
+		i := strings.IndexFunc(str, func(r rune) bool {
+			return !('0' <= r && r <= '9' || r == '.')
+		})
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		j := strings.IndexFunc(str[i:], func(r rune) bool {
+			return '0' <= r && r <= '9' || r == '.'
+		})
+		if j == -1 {
+			j = len(str)
+		} else {
+			j += i
+		}
+		num, unit := str[:i], str[i:j]
+		str = str[j:]
+
+		var mult time.Duration = 1
+		switch unit {
+		case "d":
+			unit, mult = "h", 24
+		case "w":
+			unit, mult = "h", 24*7
+		}
+		d, err := time.ParseDuration(num + unit)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		if d > math.MaxInt64/mult || total > math.MaxInt64-d*mult {
+			return 0, fmt.Errorf("invalid duration %q: out of range", s)
+		}
+		total += d * mult
+	}
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
+
+// FormatDurationExt formats a duration like time.Duration.String, but uses
+// weeks and days for long durations and omits zero units (e.g., "2d4h"
+// instead of "52h0m0s"). The result can be parsed with ParseDurationExt.
+func FormatDurationExt(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	var sb strings.Builder
+	if d < 0 {
+		sb.WriteByte('-')
+		if d == math.MinInt64 {
+			// Can't be negated; format the first week separately.
+			sb.WriteString("1w")
+			d += Week
+		}
+		d = -d
+	}
+	units := []struct {
+		unit time.Duration
+		name string
+	}{{Week, "w"}, {Day, "d"}, {time.Hour, "h"}, {time.Minute, "m"}}
+	for _, u := range units {
+		if d >= u.unit {
+			sb.WriteString(strconv.FormatInt(int64(d/u.unit), 10))
+			sb.WriteString(u.name)
+			d %= u.unit
+		}
+	}
+	if d != 0 {
+		// Seconds and smaller, such as "1.5s" or "250ms".
+		sb.WriteString(d.String())
+	}
+	return sb.String()
+}
+
+// DurationExt is a time.Duration that is parsed with ParseDurationExt and
+// formatted with FormatDurationExt. It implements flag.Value and
+// encoding.TextMarshaler/TextUnmarshaler, so it can be used for command line
+// flags and config values.
+type DurationExt time.Duration
+
+// Duration returns the time.Duration.
+func (d DurationExt) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// String implements the flag.Value interface, using FormatDurationExt.
+func (d DurationExt) String() string {
+	return FormatDurationExt(time.Duration(d))
+}
+
+// Set implements the flag.Value interface, using ParseDurationExt.
+func (d *DurationExt) Set(s string) error {
+	dur, err := ParseDurationExt(s)
+	if err == nil {
+		*d = DurationExt(dur)
+	}
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler, using String.
+func (d DurationExt) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, using ParseDurationExt.
+func (d *DurationExt) UnmarshalText(text []byte) error {
+	return d.Set(string(text))
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestParseBytes(t *testing.T) {
+	if n, err := ParseBytes("1.5GiB"); err != nil || n != 3*int64(GiB)/2 {
+		t.Fatalf("expected %d, got %d, %v", 3*int64(GiB)/2, n, err)
+	}
+	if _, err := ParseBytes("5 parsecs"); err == nil {
+		t.Fatal("expected error")
+	}
+	if s := FormatBytes(1536); s != "1.5KiB" {
+		t.Fatalf("expected 1.5KiB, got %s", s)
+	}
+}
+
+func TestParseDurationExt(t *testing.T) {
+	tests := map[string]time.Duration{
+		"0":          0,
+		"1h30m":      90 * time.Minute,
+		"2d4h":       2*Day + 4*time.Hour,
+		"1w":         Week,
+		"1.5d":       36 * time.Hour,
+		"-1w2d":      -(Week + 2*Day),
+		" 3d ":       3 * Day,
+		"1d250ms":    Day + 250*time.Millisecond,
+		"1w1d1h1m1s": Week + Day + time.Hour + time.Minute + time.Second,
+	}
+	for s, want := range tests {
+		if got, err := ParseDurationExt(s); err != nil {
+			t.Errorf("%q: unexpected error: %v", s, err)
+		} else if got != want {
+			t.Errorf("%q: expected %v, got %v", s, want, got)
+		}
+	}
+	for _, s := range []string{"", "d", "5", "2x", "1d-2h", "100000000w"} {
+		if _, err := ParseDurationExt(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestFormatDurationExt(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                              "0s",
+		250 * time.Millisecond:         "250ms",
+		90 * time.Minute:               "1h30m",
+		2*Day + 4*time.Hour:            "2d4h",
+		Week + 90*time.Second:          "1w1m30s",
+		-(Day + 1500*time.Millisecond): "-1d1.5s",
+	}
+	for d, want := range tests {
+		got := FormatDurationExt(d)
+		if got != want {
+			t.Errorf("%v: expected %q, got %q", time.Duration(d), want, got)
+		}
+		if back, err := ParseDurationExt(got); err != nil || back != d {
+			t.Errorf("%q: round trip got %v, %v", got, back, err)
+		}
+	}
+}
+
+func TestDurationExtFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var d DurationExt
+	fs.Var(&d, "ttl", "")
+	if err := fs.Parse([]string{"-ttl", "2w"}); err != nil {
+		t.Fatal(err)
+	}
+	if d.Duration() != 2*Week || d.String() != "2w" {
+		t.Fatalf("expected 2w, got %v", d)
+	}
+	if err := d.UnmarshalText([]byte("bad")); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// Future is a value (or error) that will be available at some point in the
+// future. A Future is completed through its Promise.
+type Future[T any] struct {
+	once sync.Once
+	done chan struct{}
+	val  T
+	err  error
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+// complete completes the future, returning false if it was already completed.
+func (f *Future[T]) complete(t T, err error) (completed bool) {
+	f.once.Do(func() {
+		f.val, f.err = t, err
+		close(f.done)
+		completed = true
+	})
+	return
+}
+
+// Await waits for the future to complete, returning its value and error. If
+// the context is done first, ErrCanceled (wrapping the context's error) is
+// returned.
+func (f *Future[T]) Await(ctx context.Context) (t T, err error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	default:
+	}
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		return t, newCanceledError(ctx)
+	}
+}
+
+// Wait waits for the future to complete, returning its value and error.
+func (f *Future[T]) Wait() (T, error) {
+	<-f.done
+	return f.val, f.err
+}
+
+// TryGet returns the value and error of the future if it is completed,
+// otherwise, false is returned.
+func (f *Future[T]) TryGet() (t T, err error, ok bool) {
+	select {
+	case <-f.done:
+		return f.val, f.err, true
+	default:
+		return
+	}
+}
+
+// Done returns a chan that is closed when the future is completed.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// IsDone returns whether the future is completed.
+func (f *Future[T]) IsDone() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Promise is used to complete a Future.
+type Promise[T any] struct {
+	f *Future[T]
+}
+
+// NewPromise creates a new Promise (and its Future).
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{f: newFuture[T]()}
+}
+
+// Future returns the promise's future.
+func (p *Promise[T]) Future() *Future[T] {
+	return p.f
+}
+
+// Resolve completes the future with the given value. Returns false if the
+// future was already completed.
+func (p *Promise[T]) Resolve(t T) bool {
+	return p.f.complete(t, nil)
+}
+
+// Reject completes the future with the given error. Returns false if the
+// future was already completed.
+func (p *Promise[T]) Reject(err error) bool {
+	var t T
+	return p.f.complete(t, err)
+}
+
+// Complete completes the future with the given value and error. Returns false
+// if the future was already completed.
+func (p *Promise[T]) Complete(t T, err error) bool {
+	return p.f.complete(t, err)
+}
+
+// ResolvedFuture returns a future completed with the given value.
+func ResolvedFuture[T any](t T) *Future[T] {
+	f := newFuture[T]()
+	f.complete(t, nil)
+	return f
+}
+
+// RejectedFuture returns a future completed with the given error.
+func RejectedFuture[T any](err error) *Future[T] {
+	var t T
+	f := newFuture[T]()
+	f.complete(t, err)
+	return f
+}
+
+// GoFuture runs the function in a new goroutine, returning a future completed
+// with its results.
+func GoFuture[T any](fn func() (T, error)) *Future[T] {
+	f := newFuture[T]()
+	go func() {
+		f.complete(fn())
+	}()
+	return f
+}
+
+// ThenFuture returns a future that is completed with the result of calling fn
+// with the value of the given future once it completes. If the given future
+// completes with an error, fn is not called and the returned future is
+// completed with the error.
+func ThenFuture[T, U any](f *Future[T], fn func(T) (U, error)) *Future[U] {
+	res := newFuture[U]()
+	go func() {
+		t, err := f.Wait()
+		if err != nil {
+			var u U
+			res.complete(u, err)
+			return
+		}
+		res.complete(fn(t))
+	}()
+	return res
+}
+
+// AllFutures returns a future completed with the values of all the given
+// futures (in order) once they all complete successfully, or with the first
+// error encountered.
+func AllFutures[T any](fs ...*Future[T]) *Future[[]T] {
+	res := newFuture[[]T]()
+	go func() {
+		vals := make([]T, len(fs))
+		errCh := make(chan error, 1)
+		var wg sync.WaitGroup
+		wg.Add(len(fs))
+		for i, f := range fs {
+			i, f := i, f
+			go func() {
+				defer wg.Done()
+				t, err := f.Wait()
+				if err != nil {
+					select {
+					case errCh <- err:
+					default:
+					}
+					return
+				}
+				vals[i] = t
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(errCh)
+		}()
+		if err := <-errCh; err != nil {
+			res.complete(nil, err)
+			return
+		}
+		res.complete(vals, nil)
+	}()
+	return res
+}
+
+// AnyFuture returns a future completed with the value of the first of the
+// given futures to complete successfully. If all of the futures fail, the
+// returned future is completed with the error of the last to fail.
+func AnyFuture[T any](fs ...*Future[T]) *Future[T] {
+	res := newFuture[T]()
+	if len(fs) == 0 {
+		var t T
+		res.complete(t, ErrEmpty)
+		return res
+	}
+	go func() {
+		type result struct {
+			t   T
+			err error
+		}
+		ch := make(chan result, len(fs))
+		for _, f := range fs {
+			f := f
+			go func() {
+				t, err := f.Wait()
+				ch <- result{t, err}
+			}()
+		}
+		var r result
+		for range fs {
+			if r = <-ch; r.err == nil {
+				break
+			}
+		}
+		res.complete(r.t, r.err)
+	}()
+	return res
+}
+
+// RaceFutures returns a future completed with the result (value or error) of
+// the first of the given futures to complete.
+func RaceFutures[T any](fs ...*Future[T]) *Future[T] {
+	res := newFuture[T]()
+	if len(fs) == 0 {
+		var t T
+		res.complete(t, ErrEmpty)
+		return res
+	}
+	for _, f := range fs {
+		f := f
+		go func() {
+			select {
+			case <-f.done:
+				res.complete(f.val, f.err)
+			case <-res.done:
+			}
+		}()
+	}
+	return res
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFuture(t *testing.T) {
+	p := NewPromise[int]()
+	f := p.Future()
+	if _, _, ok := f.TryGet(); ok {
+		t.Fatal("future unexpectedly done")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := f.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	s := ThenFuture(f, func(n int) (string, error) {
+		return strconv.Itoa(n), nil
+	})
+	if !p.Resolve(123) {
+		t.Fatal("future unexpectedly completed")
+	}
+	if p.Reject(errors.New("error")) {
+		t.Fatal("future not completed")
+	}
+	if n, err, ok := f.TryGet(); !ok || err != nil || n != 123 {
+		t.Fatalf("expected 123, nil, true, got %d, %v, %v", n, err, ok)
+	}
+	if got, err := s.Await(context.Background()); err != nil {
+		t.Fatal("unexpected error: ", err)
+	} else if got != "123" {
+		t.Fatalf("expected \"123\", got %q", got)
+	}
+
+	testErr := errors.New("test error")
+	called := false
+	s = ThenFuture(RejectedFuture[int](testErr), func(n int) (string, error) {
+		called = true
+		return "", nil
+	})
+	if _, err := s.Wait(); err != testErr {
+		t.Fatalf("expected test error, got %v", err)
+	} else if called {
+		t.Fatal("then func unexpectedly called")
+	}
+}
+
+func TestFutureCombinators(t *testing.T) {
+	testErr := errors.New("test error")
+	delayed := func(n int, err error, dur time.Duration) *Future[int] {
+		return GoFuture(func() (int, error) {
+			time.Sleep(dur)
+			return n, err
+		})
+	}
+
+	all, err := AllFutures(
+		delayed(1, nil, time.Millisecond*10),
+		ResolvedFuture(2),
+		delayed(3, nil, time.Millisecond),
+	).Wait()
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	} else if !SliceEq(all, []int{1, 2, 3}) {
+		t.Fatalf("expected [1 2 3], got %v", all)
+	}
+	_, err = AllFutures(
+		delayed(1, nil, time.Second),
+		RejectedFuture[int](testErr),
+	).Wait()
+	if err != testErr {
+		t.Fatalf("expected test error, got %v", err)
+	}
+
+	n, err := AnyFuture(
+		RejectedFuture[int](testErr),
+		delayed(1, nil, time.Millisecond*10),
+	).Wait()
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1, nil, got %d, %v", n, err)
+	}
+	_, err = AnyFuture(RejectedFuture[int](testErr)).Wait()
+	if err != testErr {
+		t.Fatalf("expected test error, got %v", err)
+	}
+
+	n, err = RaceFutures(
+		delayed(1, nil, time.Second),
+		delayed(2, testErr, time.Millisecond),
+	).Wait()
+	if err != testErr || n != 2 {
+		t.Fatalf("expected 2, test error, got %d, %v", n, err)
+	}
+	if _, err := RaceFutures[int]().Wait(); err != ErrEmpty {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strings"
+)
+
+// EditDistance returns the Levenshtein distance between the 2 strings (the
+// minimum number of single-rune insertions, deletions, and substitutions
+// needed to turn one into the other).
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	prev, curr := make([]int, len(rb)+1), make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Similarity returns how similar the 2 strings are, from 0 (nothing in
+// common) to 1 (equal), based on their edit distance relative to the length
+// of the longer string.
+func Similarity(a, b string) float64 {
+	n := max(len([]rune(a)), len([]rune(b)))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(EditDistance(a, b))/float64(n)
+}
+
+// ClosestMatch returns the candidate closest to the input (ignoring case),
+// for use in "did you mean" suggestions. Returns false if no candidate is
+// close enough to be a plausible typo: the edit distance must be at most a
+// third of the input's length (but at least 2) and less than the input's
+// length. Ties go to the earliest candidate.
+func ClosestMatch(input string, candidates []string) (string, bool) {
+	lower := strings.ToLower(input)
+	n := len([]rune(input))
+	maxDist := min(max(2, n/3), n-1)
+	best, bestDist := "", -1
+	for _, cand := range candidates {
+		dist := EditDistance(lower, strings.ToLower(cand))
+		if dist <= maxDist && (bestDist == -1 || dist < bestDist) {
+			best, bestDist = cand, dist
+		}
+	}
+	return best, bestDist != -1
+}
+
+// didYouMean returns a suggestion suffix for an error message (e.g., `; did
+// you mean "status"?`), or an empty string if there is no close match.
+func didYouMean(input string, candidates []string) string {
+	if match, ok := ClosestMatch(input, candidates); ok {
+		return "; did you mean \"" + match + "\"?"
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"status", "stauts", 2},
+		{"héllo", "hello", 1},
+		{"same", "same", 0},
+	}
+	for _, test := range tests {
+		if got := EditDistance(test.a, test.b); got != test.want {
+			t.Errorf(
+				"EditDistance(%q, %q): expected %d, got %d",
+				test.a, test.b, test.want, got,
+			)
+		}
+		if got := EditDistance(test.b, test.a); got != test.want {
+			t.Errorf("EditDistance not symmetric for %q, %q", test.a, test.b)
+		}
+	}
+	if s := Similarity("", ""); s != 1 {
+		t.Errorf("expected 1, got %f", s)
+	}
+	if s := Similarity("abcd", "abcf"); s != 0.75 {
+		t.Errorf("expected 0.75, got %f", s)
+	}
+	if s := Similarity("abc", "xyz"); s != 0 {
+		t.Errorf("expected 0, got %f", s)
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	cands := []string{"status", "start", "stop", "restart"}
+	tests := map[string]string{
+		"stauts":  "status",
+		"STATUS":  "status",
+		"strat":   "start",
+		"stp":     "stop",
+		"restrat": "restart",
+	}
+	for input, want := range tests {
+		if got, ok := ClosestMatch(input, cands); !ok || got != want {
+			t.Errorf("%s: expected %q, got %q (%v)", input, want, got, ok)
+		}
+	}
+	for _, input := range []string{"", "x", "deploy", "zz"} {
+		if got, ok := ClosestMatch(input, cands); ok {
+			t.Errorf("%s: expected no match, got %q", input, got)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// HandoffFDEnv is the environment variable used to pass the file descriptor
+// of the state handoff pipe to a child process.
+const HandoffFDEnv = "UTILS_HANDOFF_FD"
+
+// DefaultMaxHandoffSnapshotSize is the default maximum size of a single
+// snapshot read by StateHandoff.ReadFrom.
+const DefaultMaxHandoffSnapshotSize = 256 * MiB
+
+var (
+	// ErrBadHandoff means handoff data is malformed.
+	ErrBadHandoff = errors.New("malformed handoff data")
+
+	handoffMagic = []byte("UHND")
+)
+
+// Snapshotter is implemented by types whose state can be saved and later
+// restored (possibly in another process).
+type Snapshotter interface {
+	// Snapshot returns the serialized state.
+	Snapshot() ([]byte, error)
+	// Restore restores the state from the output of Snapshot.
+	Restore([]byte) error
+}
+
+// JSONSnapshotter returns a Snapshotter for a value that can be marshaled to
+// and unmarshaled from JSON (e.g., Mutex, AValue, or Slice). The value should
+// be a pointer.
+func JSONSnapshotter(v any) Snapshotter {
+	return jsonSnapshotter{v: v}
+}
+
+type jsonSnapshotter struct {
+	v any
+}
+
+func (js jsonSnapshotter) Snapshot() ([]byte, error) {
+	return json.Marshal(js.v)
+}
+
+func (js jsonSnapshotter) Restore(b []byte) error {
+	return json.Unmarshal(b, js.v)
+}
+
+// StateHandoff serializes registered Snapshotters so that their state can be
+// handed off to another process, e.g., a new instance of a daemon during a
+// zero-downtime restart. The new process registers the same names and
+// restores the state.
+type StateHandoff struct {
+	names   []string
+	snaps   map[string]Snapshotter
+	maxSize ByteSize
+}
+
+// NewStateHandoff creates a new StateHandoff.
+func NewStateHandoff() *StateHandoff {
+	return &StateHandoff{
+		snaps:   make(map[string]Snapshotter),
+		maxSize: DefaultMaxHandoffSnapshotSize,
+	}
+}
+
+// SetMaxSnapshotSize sets the maximum size of a single snapshot read by
+// ReadFrom, which defaults to DefaultMaxHandoffSnapshotSize. Larger snapshots
+// are treated as malformed data rather than allocated.
+func (h *StateHandoff) SetMaxSnapshotSize(size ByteSize) {
+	h.maxSize = size
+}
+
+// Register registers the Snapshotter under the given name. Returns ErrExists
+// if the name is already registered.
+func (h *StateHandoff) Register(name string, s Snapshotter) error {
+	if _, ok := h.snaps[name]; ok {
+		return ErrExists
+	}
+	if len(name) > 0xFFFF {
+		return fmt.Errorf("name too long: %d bytes", len(name))
+	}
+	h.names = append(h.names, name)
+	h.snaps[name] = s
+	return nil
+}
+
+// WriteTo writes the snapshots of all registered Snapshotters (in registration
+// order) to the writer. Implements io.WriterTo.
+func (h *StateHandoff) WriteTo(w io.Writer) (n int64, err error) {
+	write := func(b []byte) bool {
+		var nw int64
+		nw, err = WriteAll(w, b)
+		n += nw
+		return err == nil
+	}
+	if !write(handoffMagic) || !write(Put4(uint32(len(h.names)))) {
+		return
+	}
+	for _, name := range h.names {
+		b, snapErr := h.snaps[name].Snapshot()
+		if snapErr != nil {
+			return n, fmt.Errorf("error snapshotting %q: %w", name, snapErr)
+		}
+		if !write(Put2(uint16(len(name)))) ||
+			!write([]byte(name)) ||
+			!write(Put8(uint64(len(b)))) ||
+			!write(b) {
+			return
+		}
+	}
+	return
+}
+
+// ReadFrom reads snapshots from the reader, restoring the Snapshotters
+// registered with the same names. Snapshots with names that aren't registered
+// are skipped. Returns ErrBadHandoff if the data is malformed, including if a
+// snapshot is larger than the max snapshot size. Implements io.ReaderFrom.
+func (h *StateHandoff) ReadFrom(r io.Reader) (n int64, err error) {
+	read := func(b []byte) bool {
+		var nr int
+		nr, err = io.ReadFull(r, b)
+		n += int64(nr)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrBadHandoff
+		}
+		return err == nil
+	}
+	var header [8]byte
+	if !read(header[:]) {
+		return
+	}
+	if string(header[:4]) != string(handoffMagic) {
+		return n, ErrBadHandoff
+	}
+	count := Get4(header[4:])
+	for i := uint32(0); i < count; i++ {
+		var lenBuf [8]byte
+		if !read(lenBuf[:2]) {
+			return
+		}
+		name := make([]byte, Get2(lenBuf[:2]))
+		if !read(name) || !read(lenBuf[:]) {
+			return
+		}
+		size := Get8(lenBuf[:])
+		if size > uint64(h.maxSize) {
+			return n, fmt.Errorf(
+				"%w: snapshot %q is %d bytes (max %s)",
+				ErrBadHandoff, name, size, h.maxSize,
+			)
+		}
+		s, ok := h.snaps[string(name)]
+		if !ok {
+			var nc int64
+			nc, err = io.CopyN(io.Discard, r, int64(size))
+			n += nc
+			if err != nil {
+				return n, ErrBadHandoff
+			}
+			continue
+		}
+		b := make([]byte, size)
+		if !read(b) {
+			return
+		}
+		if err = s.Restore(b); err != nil {
+			return n, fmt.Errorf("error restoring %q: %w", name, err)
+		}
+	}
+	return
+}
+
+// HandoffPipe creates a pipe used to hand off state to the child process run
+// by the given command. The read end is added to cmd.ExtraFiles and its file
+// descriptor is passed to the child through the HandoffFDEnv environment
+// variable. The returned write end should be passed to StateHandoff.WriteTo
+// (after the command is started) and then closed. cmd.Env should be set
+// before calling this (if it is nil, the current environment is used).
+func HandoffPipe(cmd *exec.Cmd) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, r)
+	// ExtraFiles start at fd 3 in the child
+	fd := 2 + len(cmd.ExtraFiles)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, HandoffFDEnv+"="+strconv.Itoa(fd))
+	return w, nil
+}
+
+// HandoffFile returns the file passed by the parent process using
+// HandoffPipe, returning false if there was none.
+func HandoffFile() (*os.File, bool) {
+	fdStr := os.Getenv(HandoffFDEnv)
+	if fdStr == "" {
+		return nil, false
+	}
+	fd, err := strconv.ParseUint(fdStr, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	f := os.NewFile(uintptr(fd), "handoff")
+	return f, f != nil
+}
+
+// RestoreFromParent restores the state handed off by the parent process using
+// HandoffPipe, closing the handoff file afterwards. Returns false if no state
+// was handed off.
+func (h *StateHandoff) RestoreFromParent() (bool, error) {
+	f, ok := HandoffFile()
+	if !ok {
+		return false, nil
+	}
+	defer f.Close()
+	_, err := h.ReadFrom(f)
+	return true, err
+}
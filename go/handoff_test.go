@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestStateHandoff(t *testing.T) {
+	counts := NewMutex(map[string]int{"a": 1, "b": 2})
+	val := NewAValue("value")
+	skipped := NewAValue(123)
+
+	h := NewStateHandoff()
+	if err := h.Register("counts", JSONSnapshotter(counts)); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if err := h.Register("counts", JSONSnapshotter(counts)); err != ErrExists {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	h.Register("skipped", JSONSnapshotter(skipped))
+	h.Register("val", JSONSnapshotter(val))
+
+	var buf bytes.Buffer
+	n, err := h.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	} else if n != int64(buf.Len()) {
+		t.Fatalf("expected %d bytes written, got %d", buf.Len(), n)
+	}
+	data := buf.Bytes()
+
+	newCounts := NewMutex(map[string]int(nil))
+	newVal := &AValue[string]{}
+	h2 := NewStateHandoff()
+	h2.Register("val", JSONSnapshotter(newVal))
+	h2.Register("counts", JSONSnapshotter(newCounts))
+	if _, err := h2.ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if got := *newCounts.Lock(); got["a"] != 1 || got["b"] != 2 || len(got) != 2 {
+		t.Fatalf("unexpected counts: %v", got)
+	}
+	newCounts.Unlock()
+	if got := newVal.Load(); got != "value" {
+		t.Fatalf("expected \"value\", got %q", got)
+	}
+
+	_, err = h2.ReadFrom(bytes.NewReader(data[:len(data)-1]))
+	if err != ErrBadHandoff {
+		t.Fatalf("expected ErrBadHandoff, got %v", err)
+	}
+	_, err = h2.ReadFrom(bytes.NewReader([]byte("nope1234")))
+	if err != ErrBadHandoff {
+		t.Fatalf("expected ErrBadHandoff, got %v", err)
+	}
+}
+
+func TestStateHandoffMaxSize(t *testing.T) {
+	// A snapshot claiming to be huge shouldn't be allocated.
+	data := []byte("UHND")
+	data = append(data, Put4(1)...)
+	data = append(data, Put2(1)...)
+	data = append(data, 'a')
+	data = append(data, Put8(1<<62)...)
+	h := NewStateHandoff()
+	h.Register("a", JSONSnapshotter(NewAValue(12345)))
+	_, err := h.ReadFrom(bytes.NewReader(data))
+	if !errors.Is(err, ErrBadHandoff) {
+		t.Fatalf("expected ErrBadHandoff, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	h.WriteTo(&buf)
+	h.SetMaxSnapshotSize(1)
+	_, err = h.ReadFrom(bytes.NewReader(buf.Bytes()))
+	if !errors.Is(err, ErrBadHandoff) {
+		t.Fatalf("expected ErrBadHandoff, got %v", err)
+	}
+	h.SetMaxSnapshotSize(KiB)
+	if _, err := h.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package utils
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestStateHandoffFile(t *testing.T) {
+	h := NewStateHandoff()
+	if ok, err := h.RestoreFromParent(); ok || err != nil {
+		t.Fatalf("expected false, nil, got %v, %v", ok, err)
+	}
+
+	val := NewAValue(123)
+	h.Register("val", JSONSnapshotter(val))
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal("error creating pipe: ", err)
+	}
+	go func() {
+		h.WriteTo(w)
+		w.Close()
+	}()
+	// RestoreFromParent takes ownership of (and closes) the fd, so pass a
+	// duplicate to keep r's finalizer from closing it again after the fd number
+	// may have been reused
+	fd, err := syscall.Dup(int(r.Fd()))
+	if err != nil {
+		t.Fatal("error duplicating fd: ", err)
+	}
+	r.Close()
+	t.Setenv(HandoffFDEnv, strconv.Itoa(fd))
+
+	newVal := &AValue[int]{}
+	h2 := NewStateHandoff()
+	h2.Register("val", JSONSnapshotter(newVal))
+	if ok, err := h2.RestoreFromParent(); !ok || err != nil {
+		t.Fatalf("expected true, nil, got %v, %v", ok, err)
+	}
+	if got := newVal.Load(); got != 123 {
+		t.Fatalf("expected 123, got %d", got)
+	}
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// DefaultRingReplicas is the default number of points per node on a
+// HashRing.
+const DefaultRingReplicas = 100
+
+// HashRing is a consistent-hash ring mapping hashes to nodes. Each node is
+// placed at multiple points (replicas) on the ring so load is spread evenly,
+// and adding or removing a node only moves the hashes nearest its points.
+// Node placement is deterministic, so rings with the same nodes agree across
+// processes. A HashRing isn't safe for concurrent use.
+type HashRing struct {
+	replicas int
+	points   []ringPoint
+	nodes    map[string]Unit
+}
+
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+// NewHashRing creates a new HashRing with the given number of replicas per
+// node. If replicas is less than 1, DefaultRingReplicas is used.
+func NewHashRing(replicas int) *HashRing {
+	if replicas < 1 {
+		replicas = DefaultRingReplicas
+	}
+	return &HashRing{replicas: replicas, nodes: make(map[string]Unit)}
+}
+
+// Add adds the nodes to the ring. Nodes already on the ring are ignored.
+func (r *HashRing) Add(nodes ...string) {
+	for _, node := range nodes {
+		if _, ok := r.nodes[node]; ok {
+			continue
+		}
+		r.nodes[node] = Unit{}
+		for i := 0; i < r.replicas; i++ {
+			r.points = append(r.points, ringPoint{
+				hash: hashRingString(node + "#" + strconv.Itoa(i)),
+				node: node,
+			})
+		}
+	}
+	slices.SortFunc(r.points, func(a, b ringPoint) int {
+		// Break ties deterministically.
+		return cmp.Or(cmp.Compare(a.hash, b.hash), cmp.Compare(a.node, b.node))
+	})
+}
+
+// Remove removes the node from the ring, returning false if it wasn't on the
+// ring.
+func (r *HashRing) Remove(node string) bool {
+	if _, ok := r.nodes[node]; !ok {
+		return false
+	}
+	delete(r.nodes, node)
+	r.points = slices.DeleteFunc(r.points, func(p ringPoint) bool {
+		return p.node == node
+	})
+	return true
+}
+
+// Has returns whether the node is on the ring.
+func (r *HashRing) Has(node string) bool {
+	_, ok := r.nodes[node]
+	return ok
+}
+
+// Nodes returns the nodes on the ring, sorted.
+func (r *HashRing) Nodes() []string {
+	nodes := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	slices.Sort(nodes)
+	return nodes
+}
+
+// Len returns the number of nodes on the ring.
+func (r *HashRing) Len() int {
+	return len(r.nodes)
+}
+
+// Get returns the node owning the hash (the node of the first point at or
+// after it, wrapping around), returning false if the ring is empty.
+func (r *HashRing) Get(hash uint64) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+	i, _ := slices.BinarySearchFunc(
+		r.points, hash,
+		func(p ringPoint, h uint64) int { return cmp.Compare(p.hash, h) },
+	)
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].node, true
+}
+
+// GetString returns the node owning the string, returning false if the ring
+// is empty.
+func (r *HashRing) GetString(s string) (string, bool) {
+	return r.Get(hashRingString(s))
+}
+
+func hashRingString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return mixHash(h.Sum64())
+}
+
+// mixHash is the splitmix64 finalizer, used to spread out similar hashes.
+func mixHash(u uint64) uint64 {
+	u ^= u >> 30
+	u *= 0xbf58476d1ce4e5b9
+	u ^= u >> 27
+	u *= 0x94d049bb133111eb
+	return u ^ (u >> 31)
+}
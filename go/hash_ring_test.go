@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestHashRing(t *testing.T) {
+	r := NewHashRing(0)
+	if _, ok := r.GetString("key"); ok {
+		t.Fatal("expected empty ring to own nothing")
+	}
+	r.Add("a", "b", "c", "a")
+	if r.Len() != 3 || !SliceEq(r.Nodes(), []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected nodes: %v", r.Nodes())
+	}
+
+	const n = 3000
+	owners := make(map[string]string, n)
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		key := "key" + strconv.Itoa(i)
+		node, _ := r.GetString(key)
+		owners[key] = node
+		counts[node]++
+	}
+	for node, count := range counts {
+		if count < n/6 {
+			t.Fatalf("node %s got too few keys: %v", node, counts)
+		}
+	}
+
+	// Same nodes in a different order give the same placement.
+	other := NewHashRing(0)
+	other.Add("c", "b", "a")
+	for key, node := range owners {
+		if got, _ := other.GetString(key); got != node {
+			t.Fatalf("expected %s for %s, got %s", node, key, got)
+		}
+	}
+
+	// Removing a node only moves its keys.
+	if !r.Remove("b") || r.Remove("b") || r.Has("b") {
+		t.Fatal("unexpected Remove result")
+	}
+	for key, node := range owners {
+		got, _ := r.GetString(key)
+		if node != "b" && got != node {
+			t.Fatalf("key %s moved from %s to %s", key, node, got)
+		} else if got == "b" {
+			t.Fatalf("key %s still owned by removed node", key)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// Hedge calls primary and, if it hasn't finished within the delay, also
+// calls backup, returning the result of whichever succeeds first. The
+// context of the other call is then canceled. If primary fails before the
+// delay, backup is started right away. If both fail, a MultiError of both
+// errors (primary's first) is returned. If the context is done first,
+// ErrCanceled (wrapping the context's error) is returned. Panics are
+// returned as *PanicError.
+func Hedge[T any](
+	ctx context.Context,
+	primary, backup func(context.Context) (T, error),
+	delay time.Duration,
+) (t T, err error) {
+	type result struct {
+		t      T
+		err    error
+		backup bool
+	}
+	// Cancel whichever call is still running on return.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch := make(chan result, 2)
+	run := func(f func(context.Context) (T, error), isBackup bool) {
+		go func() {
+			r := result{backup: isBackup}
+			if perr := Safe(func() { r.t, r.err = f(ctx) }); perr != nil {
+				r.err = perr
+			}
+			ch <- r
+		}()
+	}
+
+	run(primary, false)
+	pending, timer := 1, time.NewTimer(delay)
+	defer timer.Stop()
+	startBackup := func() {
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+			run(backup, true)
+			pending++
+		}
+	}
+	var errs MultiError = make([]error, 2)
+	for pending > 0 {
+		var timerC <-chan time.Time
+		if timer != nil {
+			timerC = timer.C
+		}
+		select {
+		case r := <-ch:
+			pending--
+			if r.err == nil {
+				return r.t, nil
+			} else if r.backup {
+				errs[1] = r.err
+			} else {
+				errs[0] = r.err
+			}
+			startBackup()
+		case <-timerC:
+			startBackup()
+		case <-ctx.Done():
+			return t, newCanceledError(ctx)
+		}
+	}
+	return t, errs
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	ctx := context.Background()
+	backupCalled := false
+	n, err := Hedge(
+		ctx,
+		func(context.Context) (int, error) { return 1, nil },
+		func(context.Context) (int, error) {
+			backupCalled = true
+			return 2, nil
+		},
+		time.Second,
+	)
+	if n != 1 || err != nil {
+		t.Fatalf("expected 1, nil, got %d, %v", n, err)
+	} else if backupCalled {
+		t.Fatal("backup unexpectedly called")
+	}
+
+	// Slow primary; backup wins and the primary is canceled.
+	canceled := make(chan struct{})
+	n, err = Hedge(
+		ctx,
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			close(canceled)
+			return 0, ctx.Err()
+		},
+		func(context.Context) (int, error) { return 2, nil },
+		time.Millisecond,
+	)
+	if n != 2 || err != nil {
+		t.Fatalf("expected 2, nil, got %d, %v", n, err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("primary not canceled")
+	}
+
+	// Failed primary starts the backup without waiting for the delay.
+	start := time.Now()
+	n, err = Hedge(
+		ctx,
+		func(context.Context) (int, error) { return 0, ErrEmpty },
+		func(context.Context) (int, error) { return 3, nil },
+		time.Second,
+	)
+	if n != 3 || err != nil {
+		t.Fatalf("expected 3, nil, got %d, %v", n, err)
+	} else if time.Since(start) >= time.Second {
+		t.Fatal("backup waited for delay after primary failed")
+	}
+}
+
+func TestHedgeErrors(t *testing.T) {
+	_, err := Hedge(
+		context.Background(),
+		func(context.Context) (int, error) { return 0, ErrEmpty },
+		func(context.Context) (int, error) { panic("boom") },
+		time.Millisecond,
+	)
+	var me MultiError
+	if !errors.As(err, &me) || me.Len() != 2 {
+		t.Fatalf("expected MultiError of 2 errors, got %v", err)
+	}
+	if me[0] != ErrEmpty || !ErrAs[*PanicError](me[1]) {
+		t.Fatalf("unexpected errors: %v", me)
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*10,
+	)
+	defer cancel()
+	block := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		time.Sleep(time.Millisecond * 10)
+		return 0, ctx.Err()
+	}
+	_, err = Hedge(ctx, block, block, time.Millisecond)
+	if !errors.Is(err, ErrCanceled) ||
+		!errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+}
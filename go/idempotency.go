@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyGuard tracks operations by idempotency key so that retried
+// operations return the original result rather than being performed again.
+// Completed results are kept for a TTL. It implements Snapshotter (if the keys
+// and results can be encoded as JSON) so that completed results can be
+// persisted or handed off.
+type IdempotencyGuard[K comparable, R any] struct {
+	ttl time.Duration
+	m   *Mutex[map[K]*idemEntry[R]]
+}
+
+type idemEntry[R any] struct {
+	// Closed when completed or aborted
+	done      chan struct{}
+	res       R
+	completed bool
+	expires   time.Time
+}
+
+// NewIdempotencyGuard creates a new IdempotencyGuard keeping completed
+// results for the given TTL. A TTL of 0 means results never expire.
+func NewIdempotencyGuard[K comparable, R any](
+	ttl time.Duration,
+) *IdempotencyGuard[K, R] {
+	return &IdempotencyGuard[K, R]{
+		ttl: ttl,
+		m:   NewMutex(make(map[K]*idemEntry[R])),
+	}
+}
+
+// Begin begins the operation with the given key. If the operation already
+// completed (and hasn't expired), its result is returned with done being
+// true. If the operation is in progress, this waits for it to finish.
+// Otherwise, done is false and the caller should perform the operation and
+// then call release with the result (or call Abort if the result shouldn't be
+// kept, e.g., on a retryable error).
+func (g *IdempotencyGuard[K, R]) Begin(
+	key K,
+) (res R, done bool, release func(R)) {
+	res, done, release, _ = g.BeginContext(context.Background(), key)
+	return
+}
+
+// BeginContext is the same as Begin but returns ErrCanceled (wrapping the
+// context's error) if the context is done while waiting for an in-progress
+// operation.
+func (g *IdempotencyGuard[K, R]) BeginContext(
+	ctx context.Context, key K,
+) (res R, done bool, release func(R), err error) {
+	for {
+		m := g.m.Lock()
+		e, ok := (*m)[key]
+		if ok && e.completed && g.expired(e) {
+			delete(*m, key)
+			ok = false
+		}
+		if !ok {
+			e = &idemEntry[R]{done: make(chan struct{})}
+			(*m)[key] = e
+			g.m.Unlock()
+			return res, false, func(r R) { g.complete(key, e, r) }, nil
+		}
+		completed, res := e.completed, e.res
+		g.m.Unlock()
+		if completed {
+			return res, true, nil, nil
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return res, false, nil, newCanceledError(ctx)
+		}
+		// Either completed or aborted; check again
+	}
+}
+
+func (g *IdempotencyGuard[K, R]) expired(e *idemEntry[R]) bool {
+	return !e.expires.IsZero() && !time.Now().Before(e.expires)
+}
+
+func (g *IdempotencyGuard[K, R]) complete(key K, e *idemEntry[R], res R) {
+	m := g.m.Lock()
+	defer g.m.Unlock()
+	if (*m)[key] != e || e.completed {
+		// Aborted or already released
+		return
+	}
+	e.res, e.completed = res, true
+	if g.ttl > 0 {
+		e.expires = time.Now().Add(g.ttl)
+	}
+	close(e.done)
+}
+
+// Abort aborts the in-progress operation with the given key without keeping
+// a result. One of the callers waiting on the operation (if any) will begin
+// it instead. Returns false if there is no in-progress operation with the key.
+func (g *IdempotencyGuard[K, R]) Abort(key K) bool {
+	m := g.m.Lock()
+	defer g.m.Unlock()
+	e, ok := (*m)[key]
+	if !ok || e.completed {
+		return false
+	}
+	delete(*m, key)
+	close(e.done)
+	return true
+}
+
+// Forget removes the completed result for the key so the operation can be
+// performed again. Returns false if there was no completed result.
+func (g *IdempotencyGuard[K, R]) Forget(key K) bool {
+	m := g.m.Lock()
+	defer g.m.Unlock()
+	e, ok := (*m)[key]
+	if !ok || !e.completed {
+		return false
+	}
+	delete(*m, key)
+	return true
+}
+
+// Purge removes all expired results, returning the number removed. Expired
+// results are also removed when their keys are begun.
+func (g *IdempotencyGuard[K, R]) Purge() int {
+	m := g.m.Lock()
+	defer g.m.Unlock()
+	n := 0
+	for key, e := range *m {
+		if e.completed && g.expired(e) {
+			delete(*m, key)
+			n++
+		}
+	}
+	return n
+}
+
+// Len returns the number of tracked operations (in progress and completed).
+func (g *IdempotencyGuard[K, R]) Len() int {
+	m := g.m.Lock()
+	defer g.m.Unlock()
+	return len(*m)
+}
+
+type idemSnapshotEntry[K, R any] struct {
+	Key     K         `json:"key"`
+	Result  R         `json:"result"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// Snapshot returns the completed, unexpired results encoded as JSON.
+// Implements Snapshotter.
+func (g *IdempotencyGuard[K, R]) Snapshot() ([]byte, error) {
+	m := g.m.Lock()
+	entries := make([]idemSnapshotEntry[K, R], 0, len(*m))
+	for key, e := range *m {
+		if e.completed && !g.expired(e) {
+			entries = append(entries, idemSnapshotEntry[K, R]{
+				Key: key, Result: e.res, Expires: e.expires,
+			})
+		}
+	}
+	g.m.Unlock()
+	return json.Marshal(entries)
+}
+
+// Restore adds the (unexpired) results from the output of Snapshot, replacing
+// existing results with the same keys. Implements Snapshotter.
+func (g *IdempotencyGuard[K, R]) Restore(b []byte) error {
+	var entries []idemSnapshotEntry[K, R]
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+	m := g.m.Lock()
+	defer g.m.Unlock()
+	for _, se := range entries {
+		e := &idemEntry[R]{
+			done:      make(chan struct{}),
+			res:       se.Result,
+			completed: true,
+			expires:   se.Expires,
+		}
+		close(e.done)
+		if g.expired(e) {
+			continue
+		}
+		if old, ok := (*m)[se.Key]; ok && !old.completed {
+			// Leave in-progress operations alone
+			continue
+		}
+		(*m)[se.Key] = e
+	}
+	return nil
+}
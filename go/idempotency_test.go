@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyGuard(t *testing.T) {
+	g := NewIdempotencyGuard[string, int](time.Millisecond * 50)
+	var performed atomic.Int32
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, done, release := g.Begin("op")
+			if !done {
+				performed.Add(1)
+				time.Sleep(time.Millisecond * 5)
+				res = 42
+				release(res)
+			}
+			results[i] = res
+		}(i)
+	}
+	wg.Wait()
+	if n := performed.Load(); n != 1 {
+		t.Fatalf("expected operation performed once, got %d", n)
+	}
+	for _, res := range results {
+		if res != 42 {
+			t.Fatalf("expected 42, got %d", res)
+		}
+	}
+
+	// Abort lets a retry perform the operation
+	_, done, _ := g.Begin("abort")
+	if done || !g.Abort("abort") {
+		t.Fatal("failed to abort")
+	}
+	if _, done, release := g.Begin("abort"); done {
+		t.Fatal("aborted operation completed")
+	} else {
+		release(1)
+	}
+
+	b, err := g.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2 := NewIdempotencyGuard[string, int](time.Minute)
+	if err := g2.Restore(b); err != nil {
+		t.Fatal(err)
+	}
+	if res, done, _ := g2.Begin("op"); !done || res != 42 {
+		t.Fatalf("expected restored result, got %d %v", res, done)
+	}
+
+	time.Sleep(time.Millisecond * 60)
+	if n := g.Purge(); n != 2 {
+		t.Fatalf("expected 2 purged, got %d", n)
+	}
+	if _, done, _ := g.Begin("op"); done {
+		t.Fatal("expected expired result to be gone")
+	}
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KVEncoder encodes key=value (logfmt-style) lines. Values are quoted (with
+// Go escaping) when they are empty or contain spaces, quotes, '=', or
+// non-printable characters. The output is parsed by ParseKVLine.
+type KVEncoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewKVEncoder creates a new KVEncoder writing lines to the writer. The writer
+// may be nil if only String is used.
+func NewKVEncoder(w io.Writer) *KVEncoder {
+	return &KVEncoder{w: w}
+}
+
+// Add adds a key/value pair to the current line. Values are formatted using
+// fmt.Sprint, except for times, which are formatted using RFC3339Nano, and
+// nil, which is formatted as an empty string. Invalid characters in the key
+// (spaces, quotes, '=', and non-printable characters) are replaced with '_'.
+func (e *KVEncoder) Add(key string, value any) *KVEncoder {
+	if len(e.buf) != 0 {
+		e.buf = append(e.buf, ' ')
+	}
+	e.buf = appendKVKey(e.buf, key)
+	e.buf = append(e.buf, '=')
+	e.buf = appendKVValue(e.buf, formatKVValue(value))
+	return e
+}
+
+// AddPairs adds alternating keys and values to the current line. Keys that
+// aren't strings are formatted using fmt.Sprint. If there is an odd number of
+// arguments, the last key is given an empty value.
+func (e *KVEncoder) AddPairs(kvs ...any) *KVEncoder {
+	for i := 0; i < len(kvs); i += 2 {
+		key, ok := kvs[i].(string)
+		if !ok {
+			key = fmt.Sprint(kvs[i])
+		}
+		var val any
+		if i+1 < len(kvs) {
+			val = kvs[i+1]
+		}
+		e.Add(key, val)
+	}
+	return e
+}
+
+// String returns the current line (without a newline).
+func (e *KVEncoder) String() string {
+	return string(e.buf)
+}
+
+// Reset clears the current line.
+func (e *KVEncoder) Reset() {
+	e.buf = e.buf[:0]
+}
+
+// WriteLine writes the current line, followed by a newline, to the writer and
+// then resets the line.
+func (e *KVEncoder) WriteLine() error {
+	e.buf = append(e.buf, '\n')
+	_, err := WriteAll(e.w, e.buf)
+	e.Reset()
+	return err
+}
+
+// FormatKV formats alternating keys and values as a key=value line. See
+// KVEncoder.AddPairs.
+func FormatKV(kvs ...any) string {
+	return NewKVEncoder(nil).AddPairs(kvs...).String()
+}
+
+func formatKVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func isKVSpecial(r rune) bool {
+	return r == ' ' || r == '=' || r == '"' || r == '\\' ||
+		!unicode.IsPrint(r) || r == utf8.RuneError
+}
+
+func appendKVKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	for _, r := range key {
+		if isKVSpecial(r) {
+			r = '_'
+		}
+		buf = utf8.AppendRune(buf, r)
+	}
+	return buf
+}
+
+func appendKVValue(buf []byte, val string) []byte {
+	if val == "" || strings.IndexFunc(val, isKVSpecial) != -1 {
+		return strconv.AppendQuote(buf, val)
+	}
+	return append(buf, val...)
+}
+
+// ParseKVLine parses a key=value (logfmt-style) line, such as those produced
+// by KVEncoder. Quoted values are unquoted using Go escaping rules. Keys
+// without a value (no '=') are given empty values. If a key appears multiple
+// times, the last value is used. Parsing is lenient: malformed quoted values
+// are taken verbatim up to the end of the line.
+func ParseKVLine(s string) map[string]string {
+	m := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if s == "" {
+			return m
+		}
+		end := strings.IndexAny(s, "= \t\r\n")
+		if end == -1 {
+			m[s] = ""
+			return m
+		}
+		key := s[:end]
+		if s[end] != '=' {
+			m[key] = ""
+			s = s[end:]
+			continue
+		}
+		s = s[end+1:]
+		var val string
+		val, s = parseKVValue(s)
+		m[key] = val
+	}
+}
+
+// parseKVValue parses a value from the start of the string, returning it and
+// the rest of the string.
+func parseKVValue(s string) (string, string) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexAny(s, " \t\r\n")
+		if end == -1 {
+			return s, ""
+		}
+		return s[:end], s[end:]
+	}
+	// Find the closing quote, skipping escaped characters
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			if val, err := strconv.Unquote(s[:i+1]); err == nil {
+				return val, s[i+1:]
+			}
+			return s[1:i], s[i+1:]
+		}
+	}
+	return s[1:], ""
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKVLine(t *testing.T) {
+	var sb strings.Builder
+	enc := NewKVEncoder(&sb)
+	enc.Add("level", "info").
+		Add("msg", `said "hi"`).
+		Add("empty", "").
+		Add("n", 5).
+		Add("err", errors.New("a=b\nc")).
+		Add("bad key", "x")
+	want := `level=info msg="said \"hi\"" empty="" n=5 err="a=b\nc" bad_key=x`
+	if got := enc.String(); got != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, got)
+	}
+	if err := enc.WriteLine(); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != want+"\n" || enc.String() != "" {
+		t.Fatalf("bad write: %q", sb.String())
+	}
+
+	m := ParseKVLine(want + " flag  trailing=")
+	expected := map[string]string{
+		"level":    "info",
+		"msg":      `said "hi"`,
+		"empty":    "",
+		"n":        "5",
+		"err":      "a=b\nc",
+		"bad_key":  "x",
+		"flag":     "",
+		"trailing": "",
+	}
+	if len(m) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, m)
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Fatalf("key %q: expected %q, got %q", k, v, m[k])
+		}
+	}
+
+	if got := FormatKV("a", 1, 2, "b", "c"); got != `a=1 2=b c=""` {
+		t.Fatalf("unexpected FormatKV result: %s", got)
+	}
+	if m := ParseKVLine(`a="unterminated`); m["a"] != "unterminated" {
+		t.Fatalf("unexpected lenient parse: %v", m)
+	}
+}
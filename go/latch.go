@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Latch is a value that is set once and can then be read any number of
+// times. Waiters are released when the value is set. The zero value is
+// ready to use.
+type Latch[T any] struct {
+	once  sync.Once
+	done  chan struct{}
+	isSet atomic.Bool
+	mtx   sync.Mutex
+	val   T
+}
+
+// Signal is a Latch without a value, used to signal that something has
+// happened (e.g., readiness).
+type Signal = Latch[Unit]
+
+// NewLatch creates a new, unset Latch.
+func NewLatch[T any]() *Latch[T] {
+	return &Latch[T]{}
+}
+
+func (l *Latch[T]) init() {
+	l.once.Do(func() { l.done = make(chan struct{}) })
+}
+
+// Set sets the value and releases all waiters, returning false if the value
+// was already set (in which case it isn't changed).
+func (l *Latch[T]) Set(t T) bool {
+	l.init()
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.isSet.Load() {
+		return false
+	}
+	l.val = t
+	l.isSet.Store(true)
+	close(l.done)
+	return true
+}
+
+// Fire sets the latch with the zero value. Intended for use with Signal.
+func (l *Latch[T]) Fire() bool {
+	var t T
+	return l.Set(t)
+}
+
+// Wait waits for the value to be set and returns it.
+func (l *Latch[T]) Wait() T {
+	<-l.Done()
+	return l.val
+}
+
+// WaitCtx waits for the value to be set, returning it. If the context is done
+// first, ErrCanceled (wrapping the context's error) is returned.
+func (l *Latch[T]) WaitCtx(ctx context.Context) (t T, err error) {
+	done := l.Done()
+	select {
+	case <-done:
+		return l.val, nil
+	default:
+	}
+	select {
+	case <-done:
+		return l.val, nil
+	case <-ctx.Done():
+		return t, newCanceledError(ctx)
+	}
+}
+
+// Done returns a chan that is closed when the value is set.
+func (l *Latch[T]) Done() <-chan struct{} {
+	l.init()
+	return l.done
+}
+
+// Value returns the value and true if it's set, or the zero value and false
+// otherwise.
+func (l *Latch[T]) Value() (t T, ok bool) {
+	if !l.isSet.Load() {
+		return
+	}
+	return l.val, true
+}
+
+// IsSet returns whether the value is set.
+func (l *Latch[T]) IsSet() bool {
+	return l.isSet.Load()
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLatch(t *testing.T) {
+	var l Latch[string]
+	if _, ok := l.Value(); ok || l.IsSet() {
+		t.Fatal("latch unexpectedly set")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v := l.Wait(); v != "ready" {
+				t.Errorf("expected ready, got %q", v)
+			}
+		}()
+	}
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond,
+	)
+	defer cancel()
+	if _, err := l.WaitCtx(ctx); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+
+	if !l.Set("ready") {
+		t.Fatal("expected first Set to succeed")
+	}
+	if l.Set("again") {
+		t.Fatal("expected second Set to fail")
+	}
+	wg.Wait()
+	select {
+	case <-l.Done():
+	default:
+		t.Fatal("Done not closed")
+	}
+	if v, ok := l.Value(); !ok || v != "ready" {
+		t.Fatalf("expected ready, true, got %q, %v", v, ok)
+	}
+	// The value is returned even with a done context once set.
+	if v, err := l.WaitCtx(ctx); err != nil || v != "ready" {
+		t.Fatalf("expected ready, nil, got %q, %v", v, err)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	s := NewLatch[Unit]()
+	go func() {
+		time.Sleep(time.Millisecond)
+		s.Fire()
+	}()
+	var sig *Signal = s
+	select {
+	case <-sig.Done():
+	case <-time.After(time.Second):
+		t.Fatal("signal not fired")
+	}
+	if sig.Fire() {
+		t.Fatal("signal fired twice")
+	}
+}
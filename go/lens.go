@@ -0,0 +1,107 @@
+package utils
+
+// Lens focuses on a part, A, of a whole, S, allowing the part to be read and
+// updated without modifying the original whole. Lenses compose (see
+// ComposeLens), making nested updates that copy only what changes easy to
+// write.
+type Lens[S, A any] struct {
+	// Get returns the part of the whole.
+	Get func(S) A
+	// Set returns a copy of the whole with the part replaced. The given whole
+	// shouldn't be modified.
+	Set func(S, A) S
+}
+
+// NewLens creates a new Lens from a getter and setter.
+func NewLens[S, A any](get func(S) A, set func(S, A) S) Lens[S, A] {
+	return Lens[S, A]{Get: get, Set: set}
+}
+
+// Modify returns a copy of the whole with the part replaced by the result of
+// applying f to it.
+func (l Lens[S, A]) Modify(s S, f func(A) A) S {
+	return l.Set(s, f(l.Get(s)))
+}
+
+// ComposeLens composes two lenses, focusing on the part, B, of the part, A,
+// of the whole, S.
+func ComposeLens[S, A, B any](outer Lens[S, A], inner Lens[A, B]) Lens[S, B] {
+	return Lens[S, B]{
+		Get: func(s S) B {
+			return inner.Get(outer.Get(s))
+		},
+		Set: func(s S, b B) S {
+			return outer.Set(s, inner.Set(outer.Get(s), b))
+		},
+	}
+}
+
+// MapKeyLens returns a Lens focusing on the value of the given key of a map.
+// Getting a missing key returns the default value. Setting copies the map
+// (creating it if nil).
+func MapKeyLens[K comparable, V any](key K) Lens[map[K]V, V] {
+	return Lens[map[K]V, V]{
+		Get: func(m map[K]V) V {
+			return m[key]
+		},
+		Set: func(m map[K]V, v V) map[K]V {
+			res := make(map[K]V, len(m)+1)
+			CloneMapInto(res, m)
+			res[key] = v
+			return res
+		},
+	}
+}
+
+// SliceIndexLens returns a Lens focusing on the element at the given index of
+// a slice. Setting copies the slice. Both panic if the index is out of
+// bounds.
+func SliceIndexLens[T any](i int) Lens[[]T, T] {
+	return Lens[[]T, T]{
+		Get: func(s []T) T {
+			return s[i]
+		},
+		Set: func(s []T, t T) []T {
+			res := CloneSlice(s)
+			res[i] = t
+			return res
+		},
+	}
+}
+
+// PtrLens returns a Lens focusing on the value pointed to by a pointer.
+// Setting returns a new pointer. Getting a nil pointer returns the default
+// value.
+func PtrLens[T any]() Lens[*T, T] {
+	return Lens[*T, T]{
+		Get: func(p *T) T {
+			return ValOrDefault(p)
+		},
+		Set: func(_ *T, t T) *T {
+			return &t
+		},
+	}
+}
+
+// StructPtrLens returns a Lens focusing on a part of the struct pointed to by
+// a pointer, using the given functions to get the part and set it on the
+// struct. Setting copies the struct, calls set on the copy, and returns a
+// pointer to the copy. A nil pointer is treated as a pointer to the zero
+// value.
+func StructPtrLens[S, A any](
+	get func(*S) A, set func(*S, A),
+) Lens[*S, A] {
+	return Lens[*S, A]{
+		Get: func(p *S) A {
+			if p == nil {
+				p = new(S)
+			}
+			return get(p)
+		},
+		Set: func(p *S, a A) *S {
+			s := ValOrDefault(p)
+			set(&s, a)
+			return &s
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+)
+
+type lensUser struct {
+	Name string
+	Tags []string
+}
+
+type lensConfig struct {
+	Users map[string]*lensUser
+}
+
+func TestLens(t *testing.T) {
+	cfg := &lensConfig{
+		Users: map[string]*lensUser{
+			"a": {Name: "A", Tags: []string{"x", "y"}},
+		},
+	}
+	users := StructPtrLens(
+		func(c *lensConfig) map[string]*lensUser { return c.Users },
+		func(c *lensConfig, u map[string]*lensUser) { c.Users = u },
+	)
+	tags := StructPtrLens(
+		func(u *lensUser) []string { return u.Tags },
+		func(u *lensUser, tags []string) { u.Tags = tags },
+	)
+	secondTag := ComposeLens(
+		ComposeLens(
+			ComposeLens(users, MapKeyLens[string, *lensUser]("a")),
+			tags,
+		),
+		SliceIndexLens[string](1),
+	)
+
+	if got := secondTag.Get(cfg); got != "y" {
+		t.Fatalf("expected y, got %q", got)
+	}
+	newCfg := secondTag.Modify(cfg, func(s string) string { return s + s })
+	if got := secondTag.Get(newCfg); got != "yy" {
+		t.Fatalf("expected yy, got %q", got)
+	}
+	if got := cfg.Users["a"].Tags[1]; got != "y" {
+		t.Fatalf("original modified: %q", got)
+	}
+
+	// Missing key
+	name := ComposeLens(
+		ComposeLens(users, MapKeyLens[string, *lensUser]("b")),
+		StructPtrLens(
+			func(u *lensUser) string { return u.Name },
+			func(u *lensUser, name string) { u.Name = name },
+		),
+	)
+	newCfg = name.Set(newCfg, "B")
+	if len(cfg.Users) != 1 || newCfg.Users["b"].Name != "B" {
+		t.Fatal("unexpected users after set")
+	}
+
+	p := PtrLens[int]()
+	one := 1
+	if two := p.Modify(&one, func(i int) int { return i + 1 }); *two != 2 ||
+		one != 1 {
+		t.Fatalf("unexpected PtrLens results: %d, %d", *two, one)
+	}
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// LogRingOpts are options for a LogRing. At least one of the limits should be
+// set.
+type LogRingOpts struct {
+	// MaxBytes is the maximum number of bytes kept. If 0, there is no limit.
+	MaxBytes int
+	// MaxLines is the maximum number of lines kept. If 0, there is no limit.
+	MaxLines int
+}
+
+// LogRing is a writer that keeps the last lines written to it in memory,
+// capped by bytes and/or lines, so that recent logs can be dumped on demand
+// (e.g., on a crash) without verbose persistent logging. It is safe for
+// concurrent use.
+type LogRing struct {
+	opts LogRingOpts
+
+	mtx sync.Mutex
+	// Complete lines (including their newlines), oldest first
+	lines [][]byte
+	// Incomplete last line
+	partial []byte
+	size    int
+}
+
+// NewLogRing creates a new LogRing.
+func NewLogRing(opts LogRingOpts) *LogRing {
+	return &LogRing{opts: opts}
+}
+
+// Write implements the io.Writer interface. It never fails.
+func (r *LogRing) Write(p []byte) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	n := len(p)
+	for len(p) != 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			r.partial = append(r.partial, p...)
+			r.size += len(p)
+			break
+		}
+		line := append(r.partial, p[:i+1]...)
+		r.partial = nil
+		r.size += i + 1
+		r.lines = append(r.lines, line)
+		p = p[i+1:]
+	}
+	r.evict()
+	return n, nil
+}
+
+// evict removes old data until the limits are satisfied. The lock must be
+// held.
+func (r *LogRing) evict() {
+	maxBytes, maxLines := r.opts.MaxBytes, r.opts.MaxLines
+	for len(r.lines) != 0 &&
+		((maxLines > 0 && len(r.lines) > maxLines) ||
+			(maxBytes > 0 && r.size > maxBytes)) {
+		r.size -= len(r.lines[0])
+		r.lines[0] = nil
+		r.lines = r.lines[1:]
+	}
+	if maxBytes > 0 && r.size > maxBytes {
+		// Only the partial line is left and it's too long, so keep its end
+		r.partial = r.partial[len(r.partial)-maxBytes:]
+		r.size = maxBytes
+	}
+	// Reclaim space from the front of the slice once it's mostly unused
+	if cap(r.lines) > 64 && len(r.lines) < cap(r.lines)/4 {
+		r.lines = append([][]byte(nil), r.lines...)
+	}
+}
+
+// Dump writes the contents of the ring to the writer.
+func (r *LogRing) Dump(w io.Writer) error {
+	_, err := WriteAll(w, r.Bytes())
+	return err
+}
+
+// Bytes returns a copy of the contents of the ring.
+func (r *LogRing) Bytes() []byte {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	b := make([]byte, 0, r.size)
+	for _, line := range r.lines {
+		b = append(b, line...)
+	}
+	return append(b, r.partial...)
+}
+
+// Lines returns a copy of the lines in the ring (without newlines), including
+// the last line even if it is incomplete.
+func (r *LogRing) Lines() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	lines := make([]string, 0, len(r.lines)+1)
+	for _, line := range r.lines {
+		lines = append(lines, string(line[:len(line)-1]))
+	}
+	if len(r.partial) != 0 {
+		lines = append(lines, string(r.partial))
+	}
+	return lines
+}
+
+// Len returns the number of bytes in the ring.
+func (r *LogRing) Len() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.size
+}
+
+// Reset clears the ring.
+func (r *LogRing) Reset() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.lines, r.partial, r.size = nil, nil, 0
+}
+
+// DumpOnPanic dumps the ring to the writer if there is a panic and then
+// re-panics with the same value. It must be deferred directly, e.g.,
+// "defer ring.DumpOnPanic(os.Stderr)".
+func (r *LogRing) DumpOnPanic(w io.Writer) {
+	if v := recover(); v != nil {
+		fmt.Fprintf(w, "---- last %d bytes of logs ----\n", r.Len())
+		r.Dump(w)
+		panic(v)
+	}
+}
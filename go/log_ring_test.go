@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLogRing(t *testing.T) {
+	r := NewLogRing(LogRingOpts{MaxLines: 3})
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(r, "line %d\n", i)
+	}
+	// Partial lines don't count towards MaxLines
+	r.Write([]byte("part"))
+	r.Write([]byte("ial"))
+	want := []string{"line 7", "line 8", "line 9", "partial"}
+	if got := r.Lines(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	var sb strings.Builder
+	if err := r.Dump(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != "line 7\nline 8\nline 9\npartial" {
+		t.Fatalf("unexpected dump: %q", sb.String())
+	}
+
+	r = NewLogRing(LogRingOpts{MaxBytes: 10})
+	r.Write([]byte("aaaa\nbbbb\ncccc\n"))
+	if got := string(r.Bytes()); got != "bbbb\ncccc\n" {
+		t.Fatalf("unexpected bytes: %q", got)
+	}
+	r.Write([]byte("0123456789abcdef"))
+	if got := string(r.Bytes()); got != "6789abcdef" || r.Len() != 10 {
+		t.Fatalf("unexpected bytes: %q", got)
+	}
+
+	sb.Reset()
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("expected re-panic with boom, got %v", v)
+			}
+		}()
+		defer r.DumpOnPanic(&sb)
+		panic("boom")
+	}()
+	if !strings.HasSuffix(sb.String(), "6789abcdef") {
+		t.Fatalf("unexpected panic dump: %q", sb.String())
+	}
+}
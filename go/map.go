@@ -1,8 +1,11 @@
 package utils
 
+import "context"
+
 // Map is a wrapper for a map[K]V
 type Map[K comparable, V any] struct {
-	m map[K]V
+	m    map[K]V
+	feed *changeFeed[K, V]
 }
 
 // NewMap creates a new Map.
@@ -23,8 +26,22 @@ func MapFromMap[K comparable, V any](m map[K]V) *Map[K, V] {
 	return &Map[K, V]{m: m}
 }
 
+// MapFromPairs returns a new map with the given key-value pairs. Later pairs
+// overwrite earlier ones with the same key.
+func MapFromPairs[K comparable, V any](ps []Pair[K, V]) *Map[K, V] {
+	m := MapWithLen[K, V](len(ps))
+	for _, p := range ps {
+		m.m[p.First] = p.Second
+	}
+	return m
+}
+
 // Set sets the key to the value.
 func (m *Map[K, V]) Set(key K, value V) {
+	if m.feed.active() {
+		m.Insert(key, value)
+		return
+	}
 	m.m[key] = value
 }
 
@@ -33,6 +50,9 @@ func (m *Map[K, V]) Set(key K, value V) {
 func (m *Map[K, V]) Insert(key K, value V) (old V, inserted bool) {
 	old, inserted = m.m[key]
 	m.m[key] = value
+	if m.feed.active() {
+		m.notifySet(key, old, inserted, value)
+	}
 	return
 }
 
@@ -42,6 +62,10 @@ func (m *Map[K, V]) TrySet(key K, value V) bool {
 	if _, ok := m.m[key]; ok {
 		return false
 	}
+	m.m[key] = value
+	if m.feed.active() {
+		m.notifySet(key, value, false, value)
+	}
 	return true
 }
 
@@ -95,6 +119,10 @@ func (m *Map[K, V]) ContainsValue(pred func(V) bool) bool {
 
 // Delete deletes the value from the map for the given key.
 func (m *Map[K, V]) Delete(key K) {
+	if m.feed.active() {
+		m.GetDelete(key)
+		return
+	}
 	delete(m.m, key)
 }
 
@@ -104,6 +132,9 @@ func (m *Map[K, V]) GetDelete(key K) (V, bool) {
 	val, ok := m.m[key]
 	if ok {
 		delete(m.m, key)
+		if m.feed.active() {
+			m.notifyDelete(key, val)
+		}
 	}
 	return val, ok
 }
@@ -121,9 +152,13 @@ func (m *Map[K, V]) Range(f func(K, V) bool) {
 // Filter iterates over each key/value pair in random order, removing items not
 // satisfying the given predicate.
 func (m *Map[K, V]) Filter(f func(K, V) bool) {
+	watched := m.feed.active()
 	for k, v := range m.m {
 		if !f(k, v) {
 			delete(m.m, k)
+			if watched {
+				m.notifyDelete(k, v)
+			}
 		}
 	}
 }
@@ -131,47 +166,49 @@ func (m *Map[K, V]) Filter(f func(K, V) bool) {
 // FilterKeys iterates over each key in random order, removing items not
 // satisfying the given predicate.
 func (m *Map[K, V]) FilterKeys(f func(K) bool) {
-	for k := range m.m {
-		if !f(k) {
-			delete(m.m, k)
-		}
-	}
+	m.Filter(func(k K, _ V) bool { return f(k) })
 }
 
 // FilterValues iterates over each value in random order, removing items not
 // satisfying the given predicate.
 func (m *Map[K, V]) FilterValues(f func(V) bool) {
-	for k, v := range m.m {
-		if !f(v) {
-			delete(m.m, k)
-		}
-	}
+	m.Filter(func(_ K, v V) bool { return f(v) })
 }
 
 // Map iterates over each key/value pair in random order, mapping the values to
 // the the new values.
 func (m *Map[K, V]) Map(f func(K, V) V) {
+	watched := m.feed.active()
 	for k, v := range m.m {
-		m.m[k] = f(k, v)
+		v2 := f(k, v)
+		m.m[k] = v2
+		if watched {
+			m.notifySet(k, v, true, v2)
+		}
 	}
 }
 
 // MapValues iterates over each value in random order, mapping the values to
 // the the new values.
 func (m *Map[K, V]) MapValues(f func(V) V) {
-	for k, v := range m.m {
-		m.m[k] = f(v)
-	}
+	m.Map(func(_ K, v V) V { return f(v) })
 }
 
 // FilterMap iterates over each key/value pair in random order, retaining and
 // mapping the values that satisfy the predicate.
 func (m *Map[K, V]) FilterMap(f func(K, V) (V, bool)) {
+	watched := m.feed.active()
 	for k, v := range m.m {
 		if v2, ok := f(k, v); ok {
 			m.m[k] = v2
+			if watched {
+				m.notifySet(k, v, true, v2)
+			}
 		} else {
 			delete(m.m, k)
+			if watched {
+				m.notifyDelete(k, v)
+			}
 		}
 	}
 }
@@ -179,13 +216,7 @@ func (m *Map[K, V]) FilterMap(f func(K, V) (V, bool)) {
 // FilterMapValues iterates over each value in random order, retaining and
 // mapping the values that satisfy the predicate.
 func (m *Map[K, V]) FilterMapValues(f func(V) (V, bool)) {
-	for k, v := range m.m {
-		if v2, ok := f(v); ok {
-			m.m[k] = v2
-		} else {
-			delete(m.m, k)
-		}
-	}
+	m.FilterMap(func(_ K, v V) (V, bool) { return f(v) })
 }
 
 // Clone clones the Map. If it is a set of pointers/interfaces, it does not
@@ -199,11 +230,58 @@ func (m *Map[K, V]) ToGoMap() map[K]V {
 	return CloneMap(m.m)
 }
 
-// Inner returns the inner go map.
+// Pairs returns the key-value pairs of the map in random order.
+func (m *Map[K, V]) Pairs() []Pair[K, V] {
+	res := make([]Pair[K, V], 0, len(m.m))
+	for k, v := range m.m {
+		res = append(res, Pair[K, V]{First: k, Second: v})
+	}
+	return res
+}
+
+// Freeze returns a FrozenMap with a copy of the Map's contents.
+func (m *Map[K, V]) Freeze() *FrozenMap[K, V] {
+	return NewFrozenMap(m.m)
+}
+
+// Inner returns the inner go map. Changes made directly to it aren't seen by
+// watchers.
 func (m *Map[K, V]) Inner() map[K]V {
 	return m.m
 }
 
+// WatchChanges returns a chan that receives an event for every insert,
+// update, and delete made through the Map's methods until the context is
+// done, at which point the chan is closed. Events are buffered in a UChan, so
+// mutations never block on slow watchers. Like the rest of the Map, it must
+// not be called concurrently with other methods.
+func (m *Map[K, V]) WatchChanges(ctx context.Context) <-chan ChangeEvent[K, V] {
+	return m.WatchChangesOpts(ctx, WatchOpts{})
+}
+
+// WatchChangesOpts is the same as WatchChanges, but with options, such as
+// coalescing events.
+func (m *Map[K, V]) WatchChangesOpts(
+	ctx context.Context, opts WatchOpts,
+) <-chan ChangeEvent[K, V] {
+	if m.feed == nil {
+		m.feed = &changeFeed[K, V]{}
+	}
+	return m.feed.watch(ctx, opts)
+}
+
+func (m *Map[K, V]) notifySet(key K, old V, existed bool, value V) {
+	ev := ChangeEvent[K, V]{Kind: ChangeInsert, Key: key, New: value}
+	if existed {
+		ev.Kind, ev.Old = ChangeUpdate, old
+	}
+	m.feed.emit(ev)
+}
+
+func (m *Map[K, V]) notifyDelete(key K, old V) {
+	m.feed.emit(ChangeEvent[K, V]{Kind: ChangeDelete, Key: key, Old: old})
+}
+
 // CloneMap clonse a map.
 func CloneMap[K comparable, V any](m map[K]V) map[K]V {
 	nm := make(map[K]V, len(m))
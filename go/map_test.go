@@ -0,0 +1,22 @@
+package utils
+
+import "testing"
+
+func TestMapTrySet(t *testing.T) {
+	m := NewMap[string, int]()
+	if !m.TrySet("a", 1) {
+		t.Fatal("expected TrySet to succeed for a new key")
+	}
+	if v, ok := m.GetOk("a"); !ok || v != 1 {
+		t.Fatalf("expected 1, got %d %v", v, ok)
+	}
+	if m.TrySet("a", 2) {
+		t.Fatal("expected TrySet to fail for an existing key")
+	}
+	if v := m.Get("a"); v != 1 {
+		t.Fatalf("expected 1, got %d", v)
+	}
+	if n := m.Len(); n != 1 {
+		t.Fatalf("expected 1 entry, got %d", n)
+	}
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"errors"
+	"slices"
+)
+
+var (
+	// ErrTxnConflict means a transaction couldn't be committed because data it
+	// used was changed after it was read.
+	ErrTxnConflict = errors.New("transaction conflict")
+	// ErrTxnDone means a transaction was already committed or rolled back.
+	ErrTxnDone = errors.New("transaction already done")
+)
+
+// txnBuffer holds the pending writes of a transaction, in the order the keys
+// were first written.
+type txnBuffer[K comparable, V any] struct {
+	writes map[K]txnWrite[V]
+	order  []K
+	done   bool
+}
+
+type txnWrite[V any] struct {
+	value   V
+	deleted bool
+}
+
+func (tb *txnBuffer[K, V]) get(key K) (v V, ok, buffered bool) {
+	w, buffered := tb.writes[key]
+	if buffered && !w.deleted {
+		v, ok = w.value, true
+	}
+	return
+}
+
+func (tb *txnBuffer[K, V]) put(key K, w txnWrite[V]) {
+	if tb.done {
+		return
+	}
+	if tb.writes == nil {
+		tb.writes = make(map[K]txnWrite[V])
+	}
+	if _, ok := tb.writes[key]; !ok {
+		tb.order = append(tb.order, key)
+	}
+	tb.writes[key] = w
+}
+
+// finish marks the buffer done, returning false if it already was.
+func (tb *txnBuffer[K, V]) finish() bool {
+	if tb.done {
+		return false
+	}
+	tb.done = true
+	return true
+}
+
+// MapTxn is a transaction over a Map, returned by Map.BeginTxn. Sets and
+// deletes are buffered and only applied to the Map on Commit. Reads through
+// the transaction see its own pending writes. A MapTxn isn't safe for
+// concurrent use.
+type MapTxn[K comparable, V any] struct {
+	m   *Map[K, V]
+	buf txnBuffer[K, V]
+}
+
+// BeginTxn starts a transaction over the Map.
+func (m *Map[K, V]) BeginTxn() *MapTxn[K, V] {
+	return &MapTxn[K, V]{m: m}
+}
+
+// Get gets the value for the key or returns the default.
+func (tx *MapTxn[K, V]) Get(key K) V {
+	v, _ := tx.GetOk(key)
+	return v
+}
+
+// GetOk gets the value for the key, returning false if it doesn't exist.
+func (tx *MapTxn[K, V]) GetOk(key K) (V, bool) {
+	if v, ok, buffered := tx.buf.get(key); buffered {
+		return v, ok
+	}
+	return tx.m.GetOk(key)
+}
+
+// Set sets the key to the value when the transaction is committed.
+func (tx *MapTxn[K, V]) Set(key K, value V) {
+	tx.buf.put(key, txnWrite[V]{value: value})
+}
+
+// Delete deletes the key when the transaction is committed.
+func (tx *MapTxn[K, V]) Delete(key K) {
+	tx.buf.put(key, txnWrite[V]{deleted: true})
+}
+
+// Commit applies the pending writes to the Map. Returns ErrTxnDone if the
+// transaction was already committed or rolled back.
+func (tx *MapTxn[K, V]) Commit() error {
+	if !tx.buf.finish() {
+		return ErrTxnDone
+	}
+	for _, k := range tx.buf.order {
+		if w := tx.buf.writes[k]; w.deleted {
+			tx.m.Delete(k)
+		} else {
+			tx.m.Set(k, w.value)
+		}
+	}
+	return nil
+}
+
+// Rollback discards the pending writes. Returns ErrTxnDone if the transaction
+// was already committed or rolled back.
+func (tx *MapTxn[K, V]) Rollback() error {
+	if !tx.buf.finish() {
+		return ErrTxnDone
+	}
+	tx.buf.writes, tx.buf.order = nil, nil
+	return nil
+}
+
+// ShardedMapTxn is an optimistic transaction over a ShardedMap, returned by
+// ShardedMap.BeginTxn. Sets and deletes are buffered and applied atomically on
+// Commit, which fails with ErrTxnConflict if any shard the transaction read
+// from or wrote to was changed since it was first used. Conflicts are
+// detected per shard, so a write to an unrelated key in the same shard also
+// causes a conflict; the transaction should then be retried (see
+// ShardedMap.RunTxn). A ShardedMapTxn isn't safe for concurrent use.
+type ShardedMapTxn[K comparable, V any] struct {
+	sm  *ShardedMap[K, V]
+	buf txnBuffer[K, V]
+	// The version of each shard used, by shard index, when first used.
+	vers map[int]uint64
+}
+
+// BeginTxn starts an optimistic transaction over the map.
+func (sm *ShardedMap[K, V]) BeginTxn() *ShardedMapTxn[K, V] {
+	return &ShardedMapTxn[K, V]{sm: sm, vers: make(map[int]uint64)}
+}
+
+// RunTxn runs f in a transaction, committing it if f returns nil and rolling
+// it back otherwise. If the commit conflicts, f is retried with a new
+// transaction, up to maxRetries times (negative means no limit). Returns the
+// error from f or ErrTxnConflict if it's retried too many times.
+func (sm *ShardedMap[K, V]) RunTxn(
+	maxRetries int, f func(*ShardedMapTxn[K, V]) error,
+) error {
+	for i := 0; ; i++ {
+		tx := sm.BeginTxn()
+		if err := f(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		err := tx.Commit()
+		if err != ErrTxnConflict || (maxRetries >= 0 && i >= maxRetries) {
+			return err
+		}
+	}
+}
+
+// use records the version of the key's shard if it hasn't been used yet. If
+// read is true, the key's value is also read under the same lock.
+func (tx *ShardedMapTxn[K, V]) use(key K, read bool) (v V, ok bool) {
+	idx := int(tx.sm.hash(key) & tx.sm.mask)
+	s := &tx.sm.shards[idx]
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if _, seen := tx.vers[idx]; !seen {
+		tx.vers[idx] = s.ver
+	}
+	if read {
+		v, ok = s.m[key]
+	}
+	return
+}
+
+// Get gets the value for the key or returns the default.
+func (tx *ShardedMapTxn[K, V]) Get(key K) V {
+	v, _ := tx.GetOk(key)
+	return v
+}
+
+// GetOk gets the value for the key, returning false if it doesn't exist.
+func (tx *ShardedMapTxn[K, V]) GetOk(key K) (V, bool) {
+	if v, ok, buffered := tx.buf.get(key); buffered {
+		return v, ok
+	}
+	return tx.use(key, true)
+}
+
+// Set sets the key to the value when the transaction is committed.
+func (tx *ShardedMapTxn[K, V]) Set(key K, value V) {
+	if !tx.buf.done {
+		tx.use(key, false)
+	}
+	tx.buf.put(key, txnWrite[V]{value: value})
+}
+
+// Delete deletes the key when the transaction is committed.
+func (tx *ShardedMapTxn[K, V]) Delete(key K) {
+	if !tx.buf.done {
+		tx.use(key, false)
+	}
+	tx.buf.put(key, txnWrite[V]{deleted: true})
+}
+
+// Commit atomically applies the pending writes, returning ErrTxnConflict if
+// any shard used was changed since the transaction first used it. Other
+// readers and writers of the used shards are blocked while the writes are
+// applied, so none see a partially applied transaction. Returns ErrTxnDone if
+// the transaction was already committed or rolled back (including after a
+// conflict).
+func (tx *ShardedMapTxn[K, V]) Commit() error {
+	if !tx.buf.finish() {
+		return ErrTxnDone
+	}
+	idxs := make([]int, 0, len(tx.vers))
+	for idx := range tx.vers {
+		idxs = append(idxs, idx)
+	}
+	// Lock in a consistent order to avoid deadlocks with other commits.
+	slices.Sort(idxs)
+	for _, idx := range idxs {
+		tx.sm.shards[idx].mtx.Lock()
+	}
+	defer func() {
+		for _, idx := range idxs {
+			tx.sm.shards[idx].mtx.Unlock()
+		}
+	}()
+	for _, idx := range idxs {
+		if tx.sm.shards[idx].ver != tx.vers[idx] {
+			return ErrTxnConflict
+		}
+	}
+	for _, k := range tx.buf.order {
+		s := tx.sm.shard(k)
+		if w := tx.buf.writes[k]; w.deleted {
+			delete(s.m, k)
+		} else {
+			s.m[k] = w.value
+		}
+		s.ver++
+	}
+	return nil
+}
+
+// Rollback discards the pending writes. Returns ErrTxnDone if the transaction
+// was already committed or rolled back.
+func (tx *ShardedMapTxn[K, V]) Rollback() error {
+	if !tx.buf.finish() {
+		return ErrTxnDone
+	}
+	tx.buf.writes, tx.buf.order = nil, nil
+	return nil
+}
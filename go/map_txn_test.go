@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestMapTxn(t *testing.T) {
+	m := MapFromMap(map[string]int{"a": 1, "b": 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := m.WatchChanges(ctx)
+
+	tx := m.BeginTxn()
+	tx.Set("a", 10)
+	tx.Delete("b")
+	tx.Set("c", 3)
+	if v := tx.Get("a"); v != 10 {
+		t.Fatalf("expected txn to see 10, got %d", v)
+	}
+	if _, ok := tx.GetOk("b"); ok {
+		t.Fatal("expected txn to see b deleted")
+	}
+	if m.Get("a") != 1 || !m.ContainsKey("b") || m.ContainsKey("c") {
+		t.Fatal("map changed before commit")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("a") != 10 || m.ContainsKey("b") || m.Get("c") != 3 {
+		t.Fatalf("unexpected map after commit: %v", m.Inner())
+	}
+	evs := recvChanges(t, ch, 3)
+	if evs[0].Key != "a" || evs[1].Key != "b" || evs[2].Key != "c" {
+		t.Fatalf("expected events in write order, got %v", evs)
+	}
+	if err := tx.Commit(); err != ErrTxnDone {
+		t.Fatalf("expected ErrTxnDone, got %v", err)
+	}
+
+	tx = m.BeginTxn()
+	tx.Set("a", 100)
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("a") != 10 {
+		t.Fatal("rolled back write applied")
+	}
+	if err := tx.Commit(); err != ErrTxnDone {
+		t.Fatalf("expected ErrTxnDone, got %v", err)
+	}
+}
+
+func TestShardedMapTxn(t *testing.T) {
+	sm := NewShardedMap[int, int](ShardedMapOpts[int]{Shards: 4})
+	sm.Set(1, 1)
+
+	tx := sm.BeginTxn()
+	tx.Set(1, tx.Get(1)+1)
+	tx.Set(2, 2)
+	if sm.ContainsKey(2) {
+		t.Fatal("map changed before commit")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if sm.Get(1) != 2 || sm.Get(2) != 2 {
+		t.Fatalf("unexpected map after commit: %v", sm.ToGoMap())
+	}
+
+	// A write after the txn reads causes a conflict.
+	tx = sm.BeginTxn()
+	_ = tx.Get(1)
+	tx.Delete(2)
+	sm.Set(1, 50)
+	if err := tx.Commit(); err != ErrTxnConflict {
+		t.Fatalf("expected ErrTxnConflict, got %v", err)
+	}
+	if sm.Get(1) != 50 || !sm.ContainsKey(2) {
+		t.Fatal("conflicting txn was applied")
+	}
+
+	// Concurrent increments are serialized by RunTxn.
+	const n, iters = 8, 100
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iters; j++ {
+				err := sm.RunTxn(-1, func(tx *ShardedMapTxn[int, int]) error {
+					tx.Set(1, tx.Get(1)+1)
+					tx.Set(3, tx.Get(3)+1)
+					return nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := sm.Get(1); got != 50+n*iters {
+		t.Fatalf("expected %d, got %d", 50+n*iters, got)
+	}
+	if got := sm.Get(3); got != n*iters {
+		t.Fatalf("expected %d, got %d", n*iters, got)
+	}
+
+	sm.Set(1, 0)
+	err := sm.RunTxn(0, func(tx *ShardedMapTxn[int, int]) error {
+		tx.Set(1, tx.Get(1)+1)
+		// Simulate a concurrent writer.
+		sm.Set(1, 100)
+		return nil
+	})
+	if err != ErrTxnConflict {
+		t.Fatalf("expected ErrTxnConflict, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package utils
+
+// Make2D returns a rows x cols 2D slice. The rows share a single contiguous
+// backing array (for cache locality and a single allocation), with each row's
+// capacity limited to cols so that appending to one row never overwrites the
+// next.
+func Make2D[T any](rows, cols int) [][]T {
+	data := make([]T, rows*cols)
+	res := make([][]T, rows)
+	for r := range res {
+		res[r] = data[r*cols : (r+1)*cols : (r+1)*cols]
+	}
+	return res
+}
+
+// Clone2D returns a copy of the 2D slice with a contiguous backing array (see
+// Make2D). Rows may have different lengths.
+func Clone2D[T any](s [][]T) [][]T {
+	data := Flatten2D(s)
+	res := make([][]T, len(s))
+	i := 0
+	for r, row := range s {
+		res[r] = data[i : i+len(row) : i+len(row)]
+		i += len(row)
+	}
+	return res
+}
+
+// Flatten2D returns the elements of the 2D slice in row order. Rows may have
+// different lengths.
+func Flatten2D[T any](s [][]T) []T {
+	res := make([]T, 0, len2D(s))
+	for _, row := range s {
+		res = append(res, row...)
+	}
+	return res
+}
+
+// Transpose returns the transpose of the 2D slice (with a contiguous backing
+// array). The number of columns is taken from the first row; panics if any
+// row is shorter.
+func Transpose[T any](s [][]T) [][]T {
+	if len(s) == 0 {
+		return [][]T{}
+	}
+	res := Make2D[T](len(s[0]), len(s))
+	for r, row := range res {
+		for c := range row {
+			row[c] = s[c][r]
+		}
+	}
+	return res
+}
+
+// Map2D returns a new 2D slice (with a contiguous backing array) with f
+// applied to each element. Rows may have different lengths.
+func Map2D[T, U any](s [][]T, f func(T) U) [][]U {
+	res := make([][]U, len(s))
+	data := make([]U, 0, len2D(s))
+	for r, row := range s {
+		start := len(data)
+		for _, t := range row {
+			data = append(data, f(t))
+		}
+		res[r] = data[start:len(data):len(data)]
+	}
+	return res
+}
+
+// Column returns a copy of the column of the 2D slice. Panics if any row is
+// too short.
+func Column[T any](s [][]T, c int) []T {
+	res := make([]T, len(s))
+	for r, row := range s {
+		res[r] = row[c]
+	}
+	return res
+}
+
+// Matrix is a rows x cols matrix stored contiguously in row-major order.
+type Matrix[T any] struct {
+	rows, cols int
+	data       []T
+}
+
+// NewMatrix returns a new rows x cols Matrix of zero values.
+func NewMatrix[T any](rows, cols int) *Matrix[T] {
+	return &Matrix[T]{rows: rows, cols: cols, data: make([]T, rows*cols)}
+}
+
+// MatrixFromSlices returns a new Matrix with a copy of the values in the 2D
+// slice. The number of columns is taken from the first row; panics if the
+// rows have different lengths.
+func MatrixFromSlices[T any](s [][]T) *Matrix[T] {
+	cols := 0
+	if len(s) != 0 {
+		cols = len(s[0])
+	}
+	for _, row := range s {
+		if len(row) != cols {
+			panic("MatrixFromSlices: rows have different lengths")
+		}
+	}
+	return &Matrix[T]{rows: len(s), cols: cols, data: Flatten2D(s)}
+}
+
+// Rows returns the number of rows.
+func (m *Matrix[T]) Rows() int {
+	return m.rows
+}
+
+// Cols returns the number of columns.
+func (m *Matrix[T]) Cols() int {
+	return m.cols
+}
+
+// At returns the value at the given row and column.
+func (m *Matrix[T]) At(r, c int) T {
+	return m.data[m.index(r, c)]
+}
+
+// Ptr returns a pointer to the value at the given row and column.
+func (m *Matrix[T]) Ptr(r, c int) *T {
+	return &m.data[m.index(r, c)]
+}
+
+// Set sets the value at the given row and column.
+func (m *Matrix[T]) Set(r, c int, t T) {
+	m.data[m.index(r, c)] = t
+}
+
+// Row returns the row. The returned slice shares the matrix's memory, so
+// changes to it are reflected in the matrix.
+func (m *Matrix[T]) Row(r int) []T {
+	if r < 0 || r >= m.rows {
+		panic("Matrix: row index out of range")
+	}
+	return m.data[r*m.cols : (r+1)*m.cols : (r+1)*m.cols]
+}
+
+// Col returns a copy of the column.
+func (m *Matrix[T]) Col(c int) []T {
+	res := make([]T, m.rows)
+	for r := range res {
+		res[r] = m.At(r, c)
+	}
+	return res
+}
+
+// Data returns the underlying data in row-major order.
+func (m *Matrix[T]) Data() []T {
+	return m.data
+}
+
+// ToSlices returns the matrix as a 2D slice. The rows share the matrix's
+// memory.
+func (m *Matrix[T]) ToSlices() [][]T {
+	res := make([][]T, m.rows)
+	for r := range res {
+		res[r] = m.Row(r)
+	}
+	return res
+}
+
+// Transpose returns a new matrix that is the transpose of the matrix.
+func (m *Matrix[T]) Transpose() *Matrix[T] {
+	res := NewMatrix[T](m.cols, m.rows)
+	for r := 0; r < m.rows; r++ {
+		for c := 0; c < m.cols; c++ {
+			res.data[c*m.rows+r] = m.data[r*m.cols+c]
+		}
+	}
+	return res
+}
+
+// Clone returns a copy of the matrix.
+func (m *Matrix[T]) Clone() *Matrix[T] {
+	return &Matrix[T]{rows: m.rows, cols: m.cols, data: CloneSlice(m.data)}
+}
+
+func len2D[T any](s [][]T) int {
+	n := 0
+	for _, row := range s {
+		n += len(row)
+	}
+	return n
+}
+
+func (m *Matrix[T]) index(r, c int) int {
+	if r < 0 || r >= m.rows || c < 0 || c >= m.cols {
+		panic("Matrix: index out of range")
+	}
+	return r*m.cols + c
+}
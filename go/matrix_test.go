@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMake2D(t *testing.T) {
+	s := Make2D[int](3, 4)
+	if len(s) != 3 || len(s[0]) != 4 || cap(s[0]) != 4 {
+		t.Fatalf("unexpected dimensions: %d x %d", len(s), len(s[0]))
+	}
+	for r, row := range s {
+		for c := range row {
+			row[c] = r*10 + c
+		}
+	}
+	s[0] = append(s[0], 99)
+	if s[1][0] != 10 {
+		t.Fatal("appending to a row overwrote the next row")
+	}
+	s[0] = s[0][:4]
+
+	tr := Transpose(s)
+	if len(tr) != 4 || len(tr[0]) != 3 {
+		t.Fatalf("unexpected transpose dimensions: %d x %d", len(tr), len(tr[0]))
+	}
+	if !SliceEq(tr[2], []int{2, 12, 22}) || !SliceEq(Column(s, 2), tr[2]) {
+		t.Fatalf("unexpected transpose row: %v", tr[2])
+	}
+	if len(Transpose[int](nil)) != 0 {
+		t.Fatal("expected empty transpose")
+	}
+
+	ragged := [][]int{{1, 2}, {}, {3}}
+	if got := Flatten2D(ragged); !SliceEq(got, []int{1, 2, 3}) {
+		t.Fatalf("unexpected flattened slice: %v", got)
+	}
+	c := Clone2D(ragged)
+	c[0][0] = 100
+	if ragged[0][0] != 1 || len(c[1]) != 0 || c[2][0] != 3 {
+		t.Fatalf("unexpected clone: %v", c)
+	}
+	m := Map2D(ragged, strconv.Itoa)
+	if len(m) != 3 || m[0][1] != "2" || len(m[1]) != 0 || m[2][0] != "3" {
+		t.Fatalf("unexpected mapped slice: %v", m)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	m := MatrixFromSlices([][]int{{1, 2, 3}, {4, 5, 6}})
+	if m.Rows() != 2 || m.Cols() != 3 || m.At(1, 2) != 6 {
+		t.Fatal("unexpected matrix")
+	}
+	m.Set(0, 1, 20)
+	*m.Ptr(1, 0) *= 10
+	m.Row(1)[1] = 50
+	if !SliceEq(m.Data(), []int{1, 20, 3, 40, 50, 6}) {
+		t.Fatalf("unexpected data: %v", m.Data())
+	}
+	if !SliceEq(m.Col(1), []int{20, 50}) {
+		t.Fatalf("unexpected column: %v", m.Col(1))
+	}
+
+	tr := m.Transpose()
+	if tr.Rows() != 3 || tr.Cols() != 2 || !SliceEq(tr.Row(2), []int{3, 6}) {
+		t.Fatalf("unexpected transpose: %v", tr.ToSlices())
+	}
+	c := m.Clone()
+	c.Set(0, 0, -1)
+	if m.At(0, 0) != 1 {
+		t.Fatal("clone shares memory")
+	}
+	if s := m.ToSlices(); len(s) != 2 || !SliceEq(s[0], []int{1, 20, 3}) {
+		t.Fatalf("unexpected slices: %v", s)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		m.At(0, 3)
+	}()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		MatrixFromSlices([][]int{{1}, {2, 3}})
+	}()
+}
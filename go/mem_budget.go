@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrOverBudget means acquiring memory from a MemBudget would exceed its
+// limit.
+var ErrOverBudget = errors.New("over memory budget")
+
+// MemBudget tracks the bytes in use across components that share a memory
+// limit (e.g., UChan buffers, batchers, and caches), so they can be bounded
+// collectively rather than each on its own. Components acquire bytes before
+// buffering data and release them once it's freed. It's built on a
+// Semaphore, so blocked acquires are served in FIFO order.
+type MemBudget struct {
+	sem       *Semaphore
+	inUse     atomic.Int64
+	highWater atomic.Int64
+	acquires  atomic.Uint64
+	waits     atomic.Uint64
+	rejects   atomic.Uint64
+}
+
+// MemBudgetStats are stats for a MemBudget.
+type MemBudgetStats struct {
+	// Limit is the total number of bytes that can be in use.
+	Limit ByteSize
+	// InUse is the number of bytes currently acquired.
+	InUse ByteSize
+	// HighWater is the most bytes that have been in use at once.
+	HighWater ByteSize
+	// Acquires is the number of successful acquires.
+	Acquires uint64
+	// Waits is the number of acquires that had to block.
+	Waits uint64
+	// Rejects is the number of acquires that failed, either because they
+	// would've exceeded the budget or their context was done.
+	Rejects uint64
+}
+
+// String returns a summary of the stats.
+func (s MemBudgetStats) String() string {
+	return fmt.Sprintf(
+		"%s/%s in use (high water %s), acquires=%d waits=%d rejects=%d",
+		s.InUse, s.Limit, s.HighWater, s.Acquires, s.Waits, s.Rejects,
+	)
+}
+
+// NewMemBudget creates a new MemBudget with the given limit in bytes.
+func NewMemBudget(limit ByteSize) *MemBudget {
+	return &MemBudget{sem: NewSemaphore(int64(limit))}
+}
+
+// Acquire acquires n bytes, blocking until they're available. Returns
+// ErrOverBudget immediately if n is greater than the limit, since it could
+// never be acquired, or ErrCanceled if the context is done first. Returns an
+// error if n is negative.
+func (mb *MemBudget) Acquire(ctx context.Context, n int64) error {
+	if n < 0 {
+		return fmt.Errorf("negative memory budget size: %d", n)
+	}
+	if n > mb.sem.Size() {
+		mb.rejects.Add(1)
+		return fmt.Errorf(
+			"%w: %s is more than the limit of %s",
+			ErrOverBudget, ByteSize(n), ByteSize(mb.sem.Size()),
+		)
+	}
+	if !mb.sem.TryAcquire(n) {
+		mb.waits.Add(1)
+		if err := mb.sem.Acquire(ctx, n); err != nil {
+			mb.rejects.Add(1)
+			return err
+		}
+	}
+	mb.acquired(n)
+	return nil
+}
+
+// TryAcquire acquires n bytes without blocking, returning ErrOverBudget if
+// they aren't available. Returns an error if n is negative.
+func (mb *MemBudget) TryAcquire(n int64) error {
+	if n < 0 {
+		return fmt.Errorf("negative memory budget size: %d", n)
+	}
+	if !mb.sem.TryAcquire(n) {
+		mb.rejects.Add(1)
+		return ErrOverBudget
+	}
+	mb.acquired(n)
+	return nil
+}
+
+func (mb *MemBudget) acquired(n int64) {
+	mb.acquires.Add(1)
+	cur := mb.inUse.Add(n)
+	for {
+		hw := mb.highWater.Load()
+		if cur <= hw || mb.highWater.CompareAndSwap(hw, cur) {
+			return
+		}
+	}
+}
+
+// Release releases n bytes, unblocking waiting acquires if possible. Panics if
+// n is negative or more is released than is in use.
+func (mb *MemBudget) Release(n int64) {
+	if n < 0 {
+		panic("utils: memory budget released negative size")
+	}
+	// The semaphore panics if too much is released, so it's released first
+	// to leave the stats untouched in that case.
+	mb.sem.Release(n)
+	mb.inUse.Add(-n)
+}
+
+// Resize acquires or releases the difference between the old and new sizes of
+// something already accounted for, such as a buffer that grew. Growing blocks
+// the same as Acquire.
+func (mb *MemBudget) Resize(ctx context.Context, old, new int64) error {
+	if new > old {
+		return mb.Acquire(ctx, new-old)
+	} else if new < old {
+		mb.Release(old - new)
+	}
+	return nil
+}
+
+// Limit returns the limit in bytes.
+func (mb *MemBudget) Limit() int64 {
+	return mb.sem.Size()
+}
+
+// InUse returns the number of bytes currently acquired.
+func (mb *MemBudget) InUse() int64 {
+	return mb.inUse.Load()
+}
+
+// Available returns the number of bytes that can currently be acquired,
+// ignoring any blocked acquires.
+func (mb *MemBudget) Available() int64 {
+	return max(mb.Limit()-mb.InUse(), 0)
+}
+
+// Stats returns the budget's stats.
+func (mb *MemBudget) Stats() MemBudgetStats {
+	return MemBudgetStats{
+		Limit:     ByteSize(mb.Limit()),
+		InUse:     ByteSize(mb.InUse()),
+		HighWater: ByteSize(mb.highWater.Load()),
+		Acquires:  mb.acquires.Load(),
+		Waits:     mb.waits.Load(),
+		Rejects:   mb.rejects.Load(),
+	}
+}
+
+// ResetHighWater resets the high-water mark to the bytes currently in use,
+// returning the old high-water mark.
+func (mb *MemBudget) ResetHighWater() int64 {
+	return mb.highWater.Swap(mb.inUse.Load())
+}
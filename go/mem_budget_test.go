@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemBudget(t *testing.T) {
+	mb := NewMemBudget(100)
+	ctx := context.Background()
+
+	if err := mb.Acquire(ctx, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := mb.TryAcquire(50); !errors.Is(err, ErrOverBudget) {
+		t.Fatalf("expected ErrOverBudget, got %v", err)
+	}
+	if err := mb.Acquire(ctx, 101); !errors.Is(err, ErrOverBudget) {
+		t.Fatalf("expected ErrOverBudget, got %v", err)
+	}
+	if err := mb.TryAcquire(40); err != nil {
+		t.Fatal(err)
+	}
+	if mb.InUse() != 100 || mb.Available() != 0 {
+		t.Fatalf("expected 100 in use, got %d", mb.InUse())
+	}
+
+	// Blocks until released.
+	done := make(chan error, 1)
+	go func() { done <- mb.Acquire(ctx, 30) }()
+	select {
+	case err := <-done:
+		t.Fatalf("acquire didn't block: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	mb.Release(40)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := mb.Acquire(cctx, 50); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+
+	if err := mb.Resize(ctx, 30, 10); err != nil {
+		t.Fatal(err)
+	}
+	stats := mb.Stats()
+	want := MemBudgetStats{
+		Limit: 100, InUse: 70, HighWater: 100,
+		Acquires: 3, Waits: 2, Rejects: 3,
+	}
+	if stats != want {
+		t.Fatalf("expected %+v, got %+v", want, stats)
+	}
+	if hw := mb.ResetHighWater(); hw != 100 {
+		t.Fatalf("expected old high water of 100, got %d", hw)
+	}
+	if s := mb.Stats(); s.HighWater != 70 {
+		t.Fatalf("expected high water of 70, got %d", s.HighWater)
+	}
+	if s := stats.String(); s !=
+		"70B/100B in use (high water 100B), acquires=3 waits=2 rejects=3" {
+		t.Fatalf("unexpected string: %s", s)
+	}
+
+	if err := mb.Acquire(ctx, -1); err == nil {
+		t.Fatal("expected error for negative size")
+	}
+	if err := mb.TryAcquire(-1); err == nil {
+		t.Fatal("expected error for negative size")
+	}
+	// Failed releases leave the stats alone.
+	for _, n := range []int64{-1, 71} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic releasing %d", n)
+				}
+			}()
+			mb.Release(n)
+		}()
+	}
+	if s := mb.Stats(); s.InUse != 70 || mb.Available() != 30 {
+		t.Fatalf("expected 70 in use, got %d", s.InUse)
+	}
+	if err := mb.TryAcquire(31); !errors.Is(err, ErrOverBudget) {
+		t.Fatalf("expected ErrOverBudget, got %v", err)
+	}
+}
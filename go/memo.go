@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MemoOpts are options for a Memo.
+type MemoOpts struct {
+	// MaxSize is the maximum number of cached values. Once reached, the least
+	// recently used value is evicted. If less than 1, there is no limit.
+	MaxSize int
+	// TTL is how long a value is cached. If 0, values don't expire.
+	TTL time.Duration
+}
+
+// Memo memoizes a function, caching its results by key. For a given key, the
+// function is run at most once at a time; concurrent callers wait for and
+// share its result. Errors aren't cached. Without a MaxSize, values are kept
+// in a SyncMap, otherwise, in an LRU cache.
+type Memo[K comparable, V any] struct {
+	f    func(K) (V, error)
+	opts MemoOpts
+
+	// Used without a MaxSize
+	sm *SyncMap[K, *memoEntry[V]]
+
+	// Used with a MaxSize
+	mtx      sync.Mutex
+	ll       *list.List
+	items    map[K]*list.Element
+	inflight map[K]*syncMapCompute[V]
+}
+
+type memoEntry[V any] struct {
+	val     V
+	expires time.Time
+}
+
+type memoItem[K comparable, V any] struct {
+	key   K
+	entry memoEntry[V]
+}
+
+// NewMemo creates a new Memo for the function.
+func NewMemo[K comparable, V any](
+	f func(K) (V, error), opts MemoOpts,
+) *Memo[K, V] {
+	m := &Memo[K, V]{f: f, opts: opts}
+	if opts.MaxSize < 1 {
+		m.sm = NewSyncMap[K, *memoEntry[V]]()
+	} else {
+		m.ll = list.New()
+		m.items = make(map[K]*list.Element)
+		m.inflight = make(map[K]*syncMapCompute[V])
+	}
+	return m
+}
+
+func (m *Memo[K, V]) newEntry(val V) memoEntry[V] {
+	e := memoEntry[V]{val: val}
+	if m.opts.TTL > 0 {
+		e.expires = time.Now().Add(m.opts.TTL)
+	}
+	return e
+}
+
+func (e *memoEntry[V]) expired() bool {
+	return !e.expires.IsZero() && !time.Now().Before(e.expires)
+}
+
+// Get returns the cached value for the key, calling the function if there is
+// none (or it expired). If the function panics, the panic is propagated to
+// the caller that ran it and other callers waiting on it get a *PanicError.
+func (m *Memo[K, V]) Get(key K) (V, error) {
+	if m.sm == nil {
+		return m.getLRU(key)
+	}
+	for {
+		e, _, err := m.sm.LoadOrComputeErr(
+			key,
+			func() (*memoEntry[V], error) {
+				val, err := m.f(key)
+				if err != nil {
+					return nil, err
+				}
+				e := m.newEntry(val)
+				return &e, nil
+			},
+		)
+		if err != nil {
+			var zero V
+			return zero, err
+		}
+		if !e.expired() {
+			return e.val, nil
+		}
+		m.sm.CompareAndDelete(key, e)
+	}
+}
+
+func (m *Memo[K, V]) getLRU(key K) (val V, err error) {
+	m.mtx.Lock()
+	if el, ok := m.items[key]; ok {
+		item := el.Value.(*memoItem[K, V])
+		if !item.entry.expired() {
+			m.ll.MoveToFront(el)
+			m.mtx.Unlock()
+			return item.entry.val, nil
+		}
+		m.ll.Remove(el)
+		delete(m.items, key)
+	}
+	if call, ok := m.inflight[key]; ok {
+		m.mtx.Unlock()
+		<-call.done
+		return call.val, call.err
+	}
+	call := &syncMapCompute[V]{done: make(chan struct{})}
+	m.inflight[key] = call
+	m.mtx.Unlock()
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			call.err = &PanicError{Value: r}
+		} else {
+			call.val, call.err = val, err
+		}
+		m.mtx.Lock()
+		if m.inflight[key] == call {
+			delete(m.inflight, key)
+		}
+		if call.err == nil {
+			m.storeLRU(key, val)
+		}
+		m.mtx.Unlock()
+		close(call.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+	return m.f(key)
+}
+
+// storeLRU stores the value, evicting the least recently used values if
+// needed. The lock must be held.
+func (m *Memo[K, V]) storeLRU(key K, val V) {
+	item := &memoItem[K, V]{key: key, entry: m.newEntry(val)}
+	if el, ok := m.items[key]; ok {
+		el.Value = item
+		m.ll.MoveToFront(el)
+		return
+	}
+	m.items[key] = m.ll.PushFront(item)
+	for m.ll.Len() > m.opts.MaxSize {
+		back := m.ll.Back()
+		m.ll.Remove(back)
+		delete(m.items, back.Value.(*memoItem[K, V]).key)
+	}
+}
+
+// Invalidate removes the cached value for the key, returning false if there
+// was none. A call of the function in progress for the key isn't affected,
+// so its result may still be cached.
+func (m *Memo[K, V]) Invalidate(key K) bool {
+	if m.sm != nil {
+		_, ok := m.sm.LoadAndDelete(key)
+		return ok
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	el, ok := m.items[key]
+	if ok {
+		m.ll.Remove(el)
+		delete(m.items, key)
+	}
+	return ok
+}
+
+// Reset removes all cached values. As with Invalidate, calls in progress
+// aren't affected.
+func (m *Memo[K, V]) Reset() {
+	if m.sm != nil {
+		m.sm.Clear()
+		return
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.ll.Init()
+	clear(m.items)
+}
+
+// Len returns the number of cached values (including expired values that
+// haven't been removed yet).
+func (m *Memo[K, V]) Len() int {
+	if m.sm != nil {
+		return m.sm.Len()
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.ll.Len()
+}
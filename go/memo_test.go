@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testMemo(t *testing.T, opts MemoOpts) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	errTest := errors.New("test")
+	m := NewMemo(func(n int) (int, error) {
+		calls.Add(1)
+		if n == 0 {
+			<-release
+		} else if n < 0 {
+			return 0, errTest
+		}
+		return n * 2, nil
+	}, opts)
+
+	// Concurrent callers share a single call.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := m.Get(0); v != 0 || err != nil {
+				t.Errorf("expected 0, nil, got %d, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond * 10)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call, got %d", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		if v, err := m.Get(5); v != 10 || err != nil {
+			t.Fatalf("expected 10, nil, got %d, %v", v, err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 calls, got %d", n)
+	}
+
+	// Errors aren't cached.
+	m.Get(-1)
+	if _, err := m.Get(-1); err != errTest {
+		t.Fatalf("expected test error, got %v", err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("expected 4 calls, got %d", n)
+	}
+
+	if !m.Invalidate(5) || m.Invalidate(5) {
+		t.Fatal("unexpected Invalidate result")
+	}
+	m.Get(5)
+	if n := calls.Load(); n != 5 {
+		t.Fatalf("expected 5 calls, got %d", n)
+	}
+	if l := m.Len(); l != 2 {
+		t.Fatalf("expected 2 cached values, got %d", l)
+	}
+	m.Reset()
+	if l := m.Len(); l != 0 {
+		t.Fatalf("expected 0 cached values, got %d", l)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected panic to propagate")
+			}
+		}()
+		NewMemo(func(int) (int, error) { panic("boom") }, opts).Get(1)
+	}()
+}
+
+func TestMemo(t *testing.T) {
+	testMemo(t, MemoOpts{})
+}
+
+func TestMemoLRU(t *testing.T) {
+	testMemo(t, MemoOpts{MaxSize: 10})
+
+	var calls atomic.Int32
+	m := NewMemo(func(n int) (int, error) {
+		calls.Add(1)
+		return n, nil
+	}, MemoOpts{MaxSize: 2})
+	m.Get(1)
+	m.Get(2)
+	m.Get(1)
+	// Evicts 2, the least recently used
+	m.Get(3)
+	if m.Len() != 2 {
+		t.Fatalf("expected 2 cached values, got %d", m.Len())
+	}
+	m.Get(1)
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected 1 to stay cached, got %d calls", n)
+	}
+	m.Get(2)
+	if n := calls.Load(); n != 4 {
+		t.Fatalf("expected 2 to be evicted, got %d calls", n)
+	}
+}
+
+func TestMemoTTL(t *testing.T) {
+	for _, size := range []int{0, 10} {
+		var calls atomic.Int32
+		m := NewMemo(func(n int) (int, error) {
+			calls.Add(1)
+			return n, nil
+		}, MemoOpts{MaxSize: size, TTL: time.Millisecond * 10})
+		m.Get(1)
+		m.Get(1)
+		if n := calls.Load(); n != 1 {
+			t.Fatalf("size %d: expected 1 call, got %d", size, n)
+		}
+		time.Sleep(time.Millisecond * 15)
+		m.Get(1)
+		if n := calls.Load(); n != 2 {
+			t.Fatalf("size %d: expected expiry, got %d calls", size, n)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// MergeSorted lazily merges sequences, each sorted according to cmp, into a
+// single sorted sequence. cmp should return a negative number when a < b, a
+// positive number when a > b, and 0 when they're equal (e.g., cmp.Compare).
+// Equal items are yielded in the order of the sequences they came from.
+func MergeSorted[T any](cmp func(a, b T) int, seqs ...iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		h := &seqMergeHeap[T]{cmp: cmp}
+		for i, seq := range seqs {
+			next, stop := iter.Pull(seq)
+			defer stop()
+			if t, ok := next(); ok {
+				h.items = append(h.items, seqMergeItem[T]{val: t, src: i, next: next})
+			}
+		}
+		heap.Init(h)
+		for h.Len() != 0 {
+			item := &h.items[0]
+			if !yield(item.val) {
+				return
+			}
+			if t, ok := item.next(); ok {
+				item.val = t
+				heap.Fix(h, 0)
+			} else {
+				heap.Pop(h)
+			}
+		}
+	}
+}
+
+// MergeSortedUChan is the same as MergeSorted but each sequence is consumed
+// in its own goroutine (so slow sequences, e.g., ones reading from disk, are
+// read concurrently) with the merged items sent over the returned UChan,
+// which is closed once all items are sent. Closing the UChan early stops the
+// merge, though sequences blocked producing an item aren't interrupted.
+// bufLen is the number of items read ahead from each sequence.
+func MergeSortedUChan[T any](
+	cmp func(a, b T) int, bufLen int, seqs ...iter.Seq[T],
+) *UChan[T] {
+	if bufLen < 1 {
+		bufLen = 1
+	}
+	uc := NewUChan[T](bufLen)
+	done := make(chan Unit)
+	chanSeqs := make([]iter.Seq[T], len(seqs))
+	for i, seq := range seqs {
+		ch := make(chan T, bufLen)
+		go func() {
+			defer close(ch)
+			for t := range seq {
+				select {
+				case ch <- t:
+				case <-done:
+					return
+				}
+			}
+		}()
+		chanSeqs[i] = func(yield func(T) bool) {
+			for t := range ch {
+				if !yield(t) {
+					return
+				}
+			}
+		}
+	}
+	go func() {
+		defer close(done)
+		for t := range MergeSorted(cmp, chanSeqs...) {
+			if !uc.Send(t) {
+				return
+			}
+		}
+		uc.Close()
+	}()
+	return uc
+}
+
+type seqMergeItem[T any] struct {
+	val  T
+	src  int
+	next func() (T, bool)
+}
+
+type seqMergeHeap[T any] struct {
+	items []seqMergeItem[T]
+	cmp   func(a, b T) int
+}
+
+func (h *seqMergeHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *seqMergeHeap[T]) Less(i, j int) bool {
+	if c := h.cmp(h.items[i].val, h.items[j].val); c != 0 {
+		return c < 0
+	}
+	return h.items[i].src < h.items[j].src
+}
+
+func (h *seqMergeHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *seqMergeHeap[T]) Push(x any) {
+	h.items = append(h.items, x.(seqMergeItem[T]))
+}
+
+func (h *seqMergeHeap[T]) Pop() any {
+	n := len(h.items) - 1
+	item := h.items[n]
+	h.items[n] = seqMergeItem[T]{}
+	h.items = h.items[:n]
+	return item
+}
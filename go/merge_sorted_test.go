@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+func TestMergeSorted(t *testing.T) {
+	a := []int{1, 4, 7, 10}
+	b := []int{2, 5, 8}
+	c := []int{0, 3, 6, 9, 11, 12}
+	want := RangeSlice(13)
+
+	got := slices.Collect(MergeSorted(
+		cmp.Compare[int], slices.Values(a), slices.Values(b), slices.Values(c),
+	))
+	if !SliceEq(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// Early stop
+	got = nil
+	seq := MergeSorted(cmp.Compare[int], slices.Values(a), slices.Values(c))
+	for n := range seq {
+		if n > 4 {
+			break
+		}
+		got = append(got, n)
+	}
+	if !SliceEq(got, []int{0, 1, 3, 4}) {
+		t.Fatalf("unexpected values: %v", got)
+	}
+
+	// Stable for equal items
+	type item struct{ key, src int }
+	cmpItem := func(x, y item) int { return cmp.Compare(x.key, y.key) }
+	items := slices.Collect(MergeSorted(
+		cmpItem,
+		slices.Values([]item{{1, 0}, {2, 0}}),
+		slices.Values([]item{{1, 1}, {2, 1}}),
+	))
+	if !SliceEq(items, []item{{1, 0}, {1, 1}, {2, 0}, {2, 1}}) {
+		t.Fatalf("unexpected order: %v", items)
+	}
+
+	uc := MergeSortedUChan(
+		cmp.Compare[int], 2,
+		slices.Values(a), slices.Values(b), slices.Values(c),
+	)
+	got = nil
+	for {
+		n, ok := uc.Recv()
+		if !ok {
+			break
+		}
+		got = append(got, n)
+	}
+	if !SliceEq(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
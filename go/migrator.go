@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Migration is a single versioned migration of persisted state.
+type Migration struct {
+	// Version is the version of the migration. Migrations are run in order of
+	// increasing version and each version must be unique and positive.
+	Version int
+	// Name is a short description of the migration.
+	Name string
+	// Up runs the migration. When dryRun is true, it should check that the
+	// migration can be run without modifying anything.
+	Up func(ctx context.Context, dryRun bool) error
+}
+
+// AppliedMigration is a record of a migration that was applied.
+type AppliedMigration struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// MigrateOpts are options for Migrator.Migrate.
+type MigrateOpts struct {
+	// DryRun is whether the pending migrations are only checked (by calling
+	// them with dryRun set to true), without being recorded as applied.
+	DryRun bool
+	// To is the version to migrate up to (inclusive). If 0, all pending
+	// migrations are run.
+	To int
+}
+
+// Migrator runs ordered, versioned migrations over persisted state (e.g.,
+// Snapshotter snapshots or DurableQueue logs), recording the applied versions
+// in a JSON file so that each migration is only run once. It is safe for
+// concurrent use, though migrations are always run one at a time.
+type Migrator struct {
+	path string
+
+	mtx        sync.Mutex
+	migrations []Migration
+}
+
+// NewMigrator creates a new Migrator recording applied versions in the file
+// at the given path.
+func NewMigrator(path string) *Migrator {
+	return &Migrator{path: path}
+}
+
+// Add adds migrations. Returns an error wrapping ErrExists if a version was
+// already added, in which case no migrations are added.
+func (m *Migrator) Add(migrations ...Migration) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	versions := make(map[int]bool, len(m.migrations)+len(migrations))
+	for _, mig := range m.migrations {
+		versions[mig.Version] = true
+	}
+	for _, mig := range migrations {
+		if mig.Version < 1 {
+			return fmt.Errorf("invalid migration version: %d", mig.Version)
+		} else if mig.Up == nil {
+			return fmt.Errorf("migration %d: missing Up", mig.Version)
+		} else if versions[mig.Version] {
+			return fmt.Errorf("migration %d: %w", mig.Version, ErrExists)
+		}
+		versions[mig.Version] = true
+	}
+	m.migrations = append(m.migrations, migrations...)
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	return nil
+}
+
+// Applied returns the records of applied migrations, in order of version.
+func (m *Migrator) Applied() ([]AppliedMigration, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.readApplied()
+}
+
+// Version returns the highest applied version, or 0 if none have been.
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.Applied()
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Pending returns the migrations that haven't been applied, in order.
+func (m *Migrator) Pending() ([]Migration, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	applied, err := m.readApplied()
+	if err != nil {
+		return nil, err
+	}
+	return m.pending(applied, 0)
+}
+
+// Migrate runs the pending migrations in order, recording each as applied
+// after it succeeds, and returns the migrations that were run. If a migration
+// fails, the ones before it remain applied. Returns an error if the state has
+// migrations applied that are unknown to the Migrator and newer than every
+// known one, since that means the state was written by a newer version of
+// the program.
+func (m *Migrator) Migrate(
+	ctx context.Context, opts MigrateOpts,
+) ([]Migration, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	applied, err := m.readApplied()
+	if err != nil {
+		return nil, err
+	}
+	pending, err := m.pending(applied, opts.To)
+	if err != nil {
+		return nil, err
+	}
+	for i, mig := range pending {
+		if err := ctx.Err(); err != nil {
+			return pending[:i], newCanceledError(ctx)
+		}
+		if err := mig.Up(ctx, opts.DryRun); err != nil {
+			return pending[:i], fmt.Errorf(
+				"migration %d (%s): %w", mig.Version, mig.Name, err,
+			)
+		}
+		if opts.DryRun {
+			continue
+		}
+		applied = append(applied, AppliedMigration{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			AppliedAt: time.Now(),
+		})
+		if err := m.writeApplied(applied); err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+// pending returns the unapplied migrations up to the given version. The lock
+// must be held.
+func (m *Migrator) pending(
+	applied []AppliedMigration, to int,
+) ([]Migration, error) {
+	done := make(map[int]bool, len(applied))
+	maxApplied := 0
+	for _, a := range applied {
+		done[a.Version] = true
+		if a.Version > maxApplied {
+			maxApplied = a.Version
+		}
+	}
+	maxKnown := 0
+	if n := len(m.migrations); n != 0 {
+		maxKnown = m.migrations[n-1].Version
+	}
+	if maxApplied > maxKnown {
+		return nil, fmt.Errorf(
+			"applied migration version %d is newer than latest known version %d",
+			maxApplied, maxKnown,
+		)
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if to > 0 && mig.Version > to {
+			break
+		}
+		if !done[mig.Version] {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) readApplied() ([]AppliedMigration, error) {
+	b, err := os.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var applied []AppliedMigration
+	if err := json.Unmarshal(b, &applied); err != nil {
+		return nil, err
+	}
+	sort.Slice(applied, func(i, j int) bool {
+		return applied[i].Version < applied[j].Version
+	})
+	return applied, nil
+}
+
+// writeApplied writes the applied migrations to a temporary file and renames
+// it so that the record is never partially written.
+func (m *Migrator) writeApplied(applied []AppliedMigration) error {
+	b, err := json.MarshalIndent(applied, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(m.path, b, 0600)
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrations.json")
+	var ran []int
+	var dryRan []int
+	fail := false
+	mig := func(v int) Migration {
+		return Migration{
+			Version: v,
+			Name:    "test",
+			Up: func(_ context.Context, dryRun bool) error {
+				if dryRun {
+					dryRan = append(dryRan, v)
+					return nil
+				}
+				if fail && v == 3 {
+					return errors.New("failed")
+				}
+				ran = append(ran, v)
+				return nil
+			},
+		}
+	}
+
+	m := NewMigrator(path)
+	if err := m.Add(mig(2), mig(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add(mig(1)); !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := m.Migrate(ctx, MigrateOpts{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !SliceEq(dryRan, []int{1, 2}) || len(ran) != 0 {
+		t.Fatalf("unexpected dry run: %v %v", dryRan, ran)
+	}
+	if v, _ := m.Version(); v != 0 {
+		t.Fatalf("expected version 0 after dry run, got %d", v)
+	}
+
+	if _, err := m.Migrate(ctx, MigrateOpts{To: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := m.Migrate(ctx, MigrateOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("unexpected migrations run: %v", done)
+	}
+	if !SliceEq(ran, []int{1, 2}) {
+		t.Fatalf("unexpected migrations run: %v", ran)
+	}
+
+	// Reopen with more migrations, one failing
+	m = NewMigrator(path)
+	m.Add(mig(1), mig(2), mig(3), mig(4))
+	fail = true
+	if _, err := m.Migrate(ctx, MigrateOpts{}); err == nil {
+		t.Fatal("expected error")
+	}
+	fail = false
+	if _, err := m.Migrate(ctx, MigrateOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if !SliceEq(ran, []int{1, 2, 3, 4}) {
+		t.Fatalf("unexpected migrations run: %v", ran)
+	}
+
+	// Older program
+	m = NewMigrator(path)
+	m.Add(mig(1))
+	if _, err := m.Migrate(ctx, MigrateOpts{}); err == nil {
+		t.Fatal("expected error for newer state")
+	}
+}
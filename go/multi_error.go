@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MultiError is a collection of errors that is itself an error. It supports
+// errors.Is and errors.As through Unwrap() []error. A MultiError shouldn't be
+// returned directly when empty; use ErrorOrNil.
+type MultiError []error
+
+// AppendErrors appends the non-nil errors in errs to err, returning the
+// resulting MultiError (or nil if there are no errors). If err or any of
+// errs is a MultiError, its errors are added individually rather than
+// nested.
+func AppendErrors(err error, errs ...error) error {
+	var me MultiError
+	if m, ok := err.(MultiError); ok {
+		me = m
+	} else {
+		me = me.Append(err)
+	}
+	return me.Append(errs...).ErrorOrNil()
+}
+
+// Append returns the MultiError with the non-nil errors in errs appended.
+// MultiErrors in errs are flattened. Like the builtin append, the returned
+// value should be used in place of the receiver.
+func (me MultiError) Append(errs ...error) MultiError {
+	for _, err := range errs {
+		switch e := err.(type) {
+		case nil:
+		case MultiError:
+			me = me.Append(e...)
+		case *MultiError:
+			if e != nil {
+				me = me.Append(*e...)
+			}
+		default:
+			me = append(me, err)
+		}
+	}
+	return me
+}
+
+// ErrorOrNil returns nil if there are no errors, or the MultiError
+// otherwise.
+func (me MultiError) ErrorOrNil() error {
+	if len(me) == 0 {
+		return nil
+	}
+	return me
+}
+
+// Len returns the number of errors.
+func (me MultiError) Len() int {
+	return len(me)
+}
+
+// Error implements the error interface. A single error is formatted as is,
+// while multiple errors are formatted as a count followed by a bulleted list
+// of the errors.
+func (me MultiError) Error() string {
+	return me.format("%v")
+}
+
+// Unwrap returns the errors, implementing errors.Is and errors.As.
+func (me MultiError) Unwrap() []error {
+	return me
+}
+
+// Format implements fmt.Formatter. The %+v verb formats each sub-error with
+// %+v (useful for errors that include stack traces or other detail); other
+// verbs format the same as Error.
+func (me MultiError) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprint(f, me.format("%+v"))
+	case verb == 'q':
+		fmt.Fprint(f, strconv.Quote(me.Error()))
+	default:
+		fmt.Fprint(f, me.Error())
+	}
+}
+
+func (me MultiError) format(verb string) string {
+	switch len(me) {
+	case 0:
+		return "no errors"
+	case 1:
+		return fmt.Sprintf(verb, me[0])
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d errors occurred:", len(me))
+	for _, err := range me {
+		msg := fmt.Sprintf(verb, err)
+		// Indent continuation lines so nested lists stay readable.
+		msg = strings.ReplaceAll(msg, "\n", "\n  ")
+		sb.WriteString("\n  * ")
+		sb.WriteString(msg)
+	}
+	return sb.String()
+}
+
+// SyncMultiError is a MultiError that is safe for concurrent use. The zero
+// value is ready to use.
+type SyncMultiError struct {
+	errs Mutex[MultiError]
+}
+
+// NewSyncMultiError creates a new SyncMultiError.
+func NewSyncMultiError() *SyncMultiError {
+	return &SyncMultiError{}
+}
+
+// Append appends the non-nil errors, flattening MultiErrors.
+func (sme *SyncMultiError) Append(errs ...error) {
+	sme.errs.Apply(func(mp *MultiError) {
+		*mp = mp.Append(errs...)
+	})
+}
+
+// Len returns the number of errors.
+func (sme *SyncMultiError) Len() int {
+	defer sme.errs.Unlock()
+	return len(*sme.errs.Lock())
+}
+
+// Errors returns a copy of the errors collected so far.
+func (sme *SyncMultiError) Errors() MultiError {
+	defer sme.errs.Unlock()
+	return CloneSlice(*sme.errs.Lock())
+}
+
+// ErrorOrNil returns nil if there are no errors, or a copy of the collected
+// errors as a MultiError otherwise.
+func (sme *SyncMultiError) ErrorOrNil() error {
+	return sme.Errors().ErrorOrNil()
+}
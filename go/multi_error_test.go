@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestMultiError(t *testing.T) {
+	var me MultiError
+	if me.ErrorOrNil() != nil {
+		t.Fatal("expected nil error")
+	}
+	if AppendErrors(nil, nil, nil) != nil {
+		t.Fatal("expected nil error")
+	}
+
+	err := AppendErrors(nil, io.EOF)
+	if got, want := err.Error(), "EOF"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	inner := MultiError{ErrClosed, ErrEmpty}
+	err = AppendErrors(err, nil, inner, ErrFull)
+	me, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("expected MultiError, got %T", err)
+	}
+	if me.Len() != 4 {
+		t.Fatalf("expected 4 flattened errors, got %d: %v", me.Len(), me)
+	}
+	for _, target := range []error{io.EOF, ErrClosed, ErrEmpty, ErrFull} {
+		if !errors.Is(err, target) {
+			t.Fatalf("expected errors.Is to match %v", target)
+		}
+	}
+	if errors.Is(err, ErrTimedOut) {
+		t.Fatal("unexpected errors.Is match")
+	}
+	if !ErrAs[MultiError](fmt.Errorf("wrapped: %w", err)) {
+		t.Fatal("expected errors.As to find MultiError")
+	}
+
+	want := "4 errors occurred:\n  * EOF\n  * closed\n  * empty\n  * full"
+	if got := err.Error(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := fmt.Sprintf("%v", err); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	nested := MultiError{
+		io.EOF,
+		fmt.Errorf("wrapped: %w", MultiError{ErrClosed, ErrEmpty}),
+	}
+	want = "2 errors occurred:\n  * EOF\n  * wrapped: 2 errors occurred:" +
+		"\n    * closed\n    * empty"
+	if got := nested.Error(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+type detailErr struct{}
+
+func (detailErr) Error() string { return "short" }
+
+func (detailErr) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		fmt.Fprint(f, "detailed")
+		return
+	}
+	fmt.Fprint(f, "short")
+}
+
+func TestMultiErrorFormat(t *testing.T) {
+	me := MultiError{detailErr{}, io.EOF}
+	want := "2 errors occurred:\n  * detailed\n  * EOF"
+	if got := fmt.Sprintf("%+v", me); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := fmt.Sprintf("%q", MultiError{io.EOF}); got != `"EOF"` {
+		t.Fatalf("unexpected quoted value: %s", got)
+	}
+}
+
+func TestSyncMultiError(t *testing.T) {
+	var sme SyncMultiError
+	if sme.ErrorOrNil() != nil {
+		t.Fatal("expected nil error")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				sme.Append(fmt.Errorf("error %d", i))
+			} else {
+				sme.Append(nil)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if l := sme.Len(); l != 50 {
+		t.Fatalf("expected 50 errors, got %d", l)
+	}
+	errs := sme.Errors()
+	sme.Append(io.EOF)
+	if errs.Len() != 50 {
+		t.Fatal("Errors didn't return a copy")
+	}
+	if err := sme.ErrorOrNil(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF in %v", err)
+	}
+}
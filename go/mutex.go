@@ -3,7 +3,9 @@ package utils
 import (
 	"encoding/json"
 	"reflect"
+	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
 // Locker represents an object that can be locked, attempted to be locked, and
@@ -228,3 +230,102 @@ func (m *RWMutex[T]) UnmarshalJSON(data []byte) (err error) {
 	err = json.Unmarshal(data, valPtr.Interface())
 	return
 }
+
+const (
+	// spinMutexSpins is the number of attempts a SpinMutex makes to lock before
+	// parking.
+	spinMutexSpins = 32
+	// spinMutexYieldAfter is the number of attempts a SpinMutex makes before
+	// yielding the processor between attempts.
+	spinMutexYieldAfter = 16
+)
+
+// SpinMutex is a mutex meant for extremely short critical sections that owns
+// its data like Mutex. Locking spins briefly before falling back to parking
+// the goroutine until the mutex is unlocked. It makes no fairness guarantees.
+type SpinMutex[T any] struct {
+	data    T
+	state   atomic.Int32
+	waiters atomic.Int32
+	wake    atomic.Pointer[chan struct{}]
+}
+
+// NewSpinMutex creates a new SpinMutex.
+func NewSpinMutex[T any](t T) *SpinMutex[T] {
+	return &SpinMutex[T]{data: t}
+}
+
+// Lock locks the mutex, returning a pointer to data.
+func (m *SpinMutex[T]) Lock() *T {
+	for i := 0; i < spinMutexSpins; i++ {
+		if m.state.CompareAndSwap(0, 1) {
+			return &m.data
+		}
+		if i >= spinMutexYieldAfter {
+			runtime.Gosched()
+		}
+	}
+	m.waiters.Add(1)
+	for !m.state.CompareAndSwap(0, 1) {
+		<-m.wakeChan()
+	}
+	m.waiters.Add(-1)
+	return &m.data
+}
+
+// TryLock attempts to lock the mutex, returning a pointer to the data and true
+// if successful.
+func (m *SpinMutex[T]) TryLock() (*T, bool) {
+	if m.state.CompareAndSwap(0, 1) {
+		return &m.data, true
+	}
+	return nil, false
+}
+
+// Unlock unlocks the mutex. The data should no longer be used.
+func (m *SpinMutex[T]) Unlock() {
+	if m.state.Swap(0) == 0 {
+		panic("unlock of unlocked SpinMutex")
+	}
+	if m.waiters.Load() > 0 {
+		select {
+		case m.wakeChan() <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (m *SpinMutex[T]) wakeChan() chan struct{} {
+	if ch := m.wake.Load(); ch != nil {
+		return *ch
+	}
+	ch := make(chan struct{}, 1)
+	if !m.wake.CompareAndSwap(nil, &ch) {
+		return *m.wake.Load()
+	}
+	return ch
+}
+
+// Apply locks the mutex and calls the passed function with a pointer to the
+// data.
+func (m *SpinMutex[T]) Apply(f func(*T)) {
+	defer m.Unlock()
+	f(m.Lock())
+}
+
+// TryApply attempts to lock the mutex and call the passed function with a
+// pointer to the data, returning true if successful.
+func (m *SpinMutex[T]) TryApply(f func(*T)) bool {
+	data, locked := m.TryLock()
+	if locked {
+		defer m.Unlock()
+		f(data)
+	}
+	return locked
+}
+
+func (m *SpinMutex[T]) MarshalJSON() ([]byte, error) {
+	m.Lock()
+	defer m.Unlock()
+	return json.Marshal(m.data)
+}
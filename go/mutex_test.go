@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"sync"
 	"testing"
 )
 
@@ -157,3 +158,74 @@ func TestRWMutexJSON(t *testing.T) {
 		t.Fatalf("bytes not equal: %v != %v", b2, b)
 	}
 }
+
+func TestSpinMutex(t *testing.T) {
+	var _ Locker[int] = &SpinMutex[int]{}
+
+	const goroutines, iters = 8, 10000
+	m := &SpinMutex[int]{}
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iters; j++ {
+				m.Apply(func(n *int) { *n++ })
+			}
+		}()
+	}
+	wg.Wait()
+	if got := *m.Lock(); got != goroutines*iters {
+		t.Fatalf("expected %d, got %d", goroutines*iters, got)
+	}
+	if _, locked := m.TryLock(); locked {
+		t.Fatal("expected TryLock to fail")
+	}
+	if m.TryApply(func(*int) {}) {
+		t.Fatal("expected TryApply to fail")
+	}
+	m.Unlock()
+	if n, locked := m.TryLock(); !locked {
+		t.Fatal("expected TryLock to succeed")
+	} else if *n != goroutines*iters {
+		t.Fatalf("expected %d, got %d", goroutines*iters, *n)
+	}
+	m.Unlock()
+
+	if b := Must(json.Marshal(m)); string(b) != "80000" {
+		t.Fatalf("expected 80000, got %s", b)
+	}
+}
+
+func benchmarkLocker(b *testing.B, l Locker[int]) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			*l.Lock()++
+			l.Unlock()
+		}
+	})
+}
+
+func BenchmarkMutexCounter(b *testing.B) {
+	benchmarkLocker(b, NewMutex(0))
+}
+
+func BenchmarkSpinMutexCounter(b *testing.B) {
+	benchmarkLocker(b, NewSpinMutex(0))
+}
+
+func BenchmarkMutexCounterUncontended(b *testing.B) {
+	m := NewMutex(0)
+	for i := 0; i < b.N; i++ {
+		*m.Lock()++
+		m.Unlock()
+	}
+}
+
+func BenchmarkSpinMutexCounterUncontended(b *testing.B) {
+	m := NewSpinMutex(0)
+	for i := 0; i < b.N; i++ {
+		*m.Lock()++
+		m.Unlock()
+	}
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// LocalPipe returns a connected pair of in-memory net.Conns. Writes on one
+// end block until read from the other (see net.Pipe), so reads and writes
+// should happen in separate goroutines.
+func LocalPipe() (net.Conn, net.Conn) {
+	return net.Pipe()
+}
+
+// ListenUnixSafe is ListenUnixMode with a mode of 0600 (only accessible by
+// the owner).
+func ListenUnixSafe(path string) (*net.UnixListener, error) {
+	return ListenUnixMode(path, 0600)
+}
+
+// ListenUnixMode listens on the unix socket at the given path, setting the
+// socket file's permissions to the given mode. If a socket file already
+// exists at the path and nothing is listening on it (i.e., it was left by a
+// process that didn't clean up), it is removed. If something is listening,
+// an error wrapping ErrExists is returned, and if the file isn't a socket, it
+// is left alone and an error is returned. The socket file is removed when the
+// listener is closed.
+//
+// The mode is set after binding, so the socket file briefly has the default
+// permissions. If that matters, the socket should be put in a directory only
+// accessible by the owner.
+func ListenUnixMode(
+	path string, mode os.FileMode,
+) (*net.UnixListener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use: %w", path, ErrExists)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// FreePort returns a TCP port on the loopback interface that is currently
+// free. The port could be taken by another process before it is used.
+func FreePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// WaitForPort waits until a TCP connection can be made to the address,
+// retrying periodically. If the context is done first, ErrCanceled (wrapping
+// the context's error) is returned.
+func WaitForPort(ctx context.Context, addr string) error {
+	var dialer net.Dialer
+	delay := time.Millisecond * 10
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return newCanceledError(ctx)
+		}
+		if delay < time.Millisecond*500 {
+			delay *= 2
+		}
+	}
+}
+
+// SplitHostPortDefault splits the address into its host and port, using the
+// default port if the address doesn't have one. Bare and bracketed IPv6
+// addresses without ports (e.g., "::1" and "[::1]") are handled.
+func SplitHostPortDefault(addr, defPort string) (host, port string, err error) {
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		return addr[1 : len(addr)-1], defPort, nil
+	}
+	if strings.Count(addr, ":") > 1 && !strings.HasPrefix(addr, "[") {
+		// Bare IPv6 address
+		return addr, defPort, nil
+	}
+	if !strings.Contains(addr, ":") {
+		return addr, defPort, nil
+	}
+	host, port, err = net.SplitHostPort(addr)
+	if err == nil && port == "" {
+		port = defPort
+	}
+	return
+}
+
+// IsLoopback returns whether the address (with or without a port) refers to
+// the loopback interface. The host "localhost" is considered a loopback
+// address; other hostnames are not resolved.
+func IsLoopback(addr string) bool {
+	host, _, err := SplitHostPortDefault(addr, "")
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	// Strip IPv6 zones
+	if i := strings.IndexByte(host, '%'); i != -1 {
+		host = host[:i]
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
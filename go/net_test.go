@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLocalPipe(t *testing.T) {
+	c1, c2 := LocalPipe()
+	defer c1.Close()
+	defer c2.Close()
+	go c1.Write([]byte("hello"))
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(c2, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "hello" {
+		t.Fatalf("expected hello, got %q", buf)
+	}
+}
+
+func TestPortUtils(t *testing.T) {
+	port, err := FreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := "127.0.0.1:" + strconv.Itoa(port)
+	lnCh := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Error(err)
+			close(lnCh)
+			return
+		}
+		lnCh <- ln
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := WaitForPort(ctx, addr); err != nil {
+		t.Fatal(err)
+	}
+	if ln, ok := <-lnCh; ok {
+		ln.Close()
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	port, _ = FreePort()
+	badAddr := "127.0.0.1:" + strconv.Itoa(port)
+	if err := WaitForPort(ctx, badAddr); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+
+	for _, test := range []struct {
+		addr, host, port string
+	}{
+		{"example.com", "example.com", "80"},
+		{"example.com:8080", "example.com", "8080"},
+		{"example.com:", "example.com", "80"},
+		{"::1", "::1", "80"},
+		{"[::1]", "::1", "80"},
+		{"[::1]:443", "::1", "443"},
+	} {
+		host, port, err := SplitHostPortDefault(test.addr, "80")
+		if err != nil || host != test.host || port != test.port {
+			t.Errorf(
+				"%s: expected %s %s, got %s %s (%v)",
+				test.addr, test.host, test.port, host, port, err,
+			)
+		}
+	}
+
+	for addr, want := range map[string]bool{
+		"localhost:80":  true,
+		"127.0.0.5":     true,
+		"[::1]:22":      true,
+		"::1":           true,
+		"10.0.0.1:80":   false,
+		"example.com":   false,
+		"LOCALHOST":     true,
+		"fe80::1%lo0":   false,
+		"bad:addr:x:80": false,
+	} {
+		if got := IsLoopback(addr); got != want {
+			t.Errorf("%s: expected %v, got %v", addr, want, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+//go:build unix
+
+package utils
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixSafe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+
+	// Create a stale socket file
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Skipf("unix sockets unsupported: %v", err)
+	}
+	ln.SetUnlinkOnClose(false)
+	ln.Close()
+
+	ln, err = ListenUnixSafe(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("expected mode 0600, got %o", perm)
+	}
+
+	// Socket is in use
+	if _, err := ListenUnixSafe(path); !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+
+	// Not a socket
+	filePath := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(filePath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListenUnixSafe(filePath); err == nil {
+		t.Fatal("expected error for non-socket file")
+	}
+}
+
+func TestListenUnixMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := ListenUnixMode(path, 0640)
+	if err != nil {
+		t.Skipf("unix sockets unsupported: %v", err)
+	}
+	defer ln.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0640 {
+		t.Fatalf("expected mode 0640, got %o", perm)
+	}
+}
@@ -100,10 +100,84 @@ func MapPtr[T, U any](t *T, f func(*T) *U) *U {
 }
 
 // CurrentDay returns the current time with the hours, minutes, and seconds
-// removed.
+// removed, in the local time zone. Use CurrentDayIn to specify the location.
 func CurrentDay() time.Time {
-	t := time.Now()
-	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	return CurrentDayIn(time.Local)
+}
+
+// CurrentDayIn returns the start of the current day in the given location. A
+// nil location is treated as time.Local.
+func CurrentDayIn(loc *time.Location) time.Time {
+	return StartOfDay(time.Now(), loc)
+}
+
+// StartOfDay returns midnight of the day t falls on in loc. A nil location
+// uses t's location.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = timeIn(t, loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek returns the start of the week (Sunday, matching time.Weekday)
+// that t falls in, in loc. A nil location uses t's location.
+func StartOfWeek(t time.Time, loc *time.Location) time.Time {
+	return StartOfWeekOn(t, loc, time.Sunday)
+}
+
+// StartOfWeekOn is the same as StartOfWeek but weeks begin on the given day.
+func StartOfWeekOn(
+	t time.Time, loc *time.Location, start time.Weekday,
+) time.Time {
+	day := StartOfDay(t, loc)
+	back := (int(day.Weekday()) - int(start) + 7) % 7
+	return day.AddDate(0, 0, -back)
+}
+
+// StartOfMonth returns the first day of the month t falls in, in loc. A nil
+// location uses t's location.
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = timeIn(t, loc)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// StartOfYear returns the first day of the year t falls in, in loc. A nil
+// location uses t's location.
+func StartOfYear(t time.Time, loc *time.Location) time.Time {
+	t = timeIn(t, loc)
+	return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+}
+
+// SameDay returns true if a and b fall on the same calendar day in loc. A nil
+// location uses a's location for both.
+func SameDay(a, b time.Time, loc *time.Location) bool {
+	if loc == nil {
+		loc = a.Location()
+	}
+	a, b = a.In(loc), b.In(loc)
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// DayRange returns the start of every day from from's day through to's day,
+// inclusive, in from's location. Days are stepped by calendar day so DST
+// changes are handled. Returns nil if to is before from's day.
+func DayRange(from, to time.Time) []time.Time {
+	day := StartOfDay(from, nil)
+	to = to.In(day.Location())
+	var days []time.Time
+	for !day.After(to) {
+		days = append(days, day)
+		day = day.AddDate(0, 0, 1)
+	}
+	return days
+}
+
+func timeIn(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		return t
+	}
+	return t.In(loc)
 }
 
 const (
@@ -114,23 +188,60 @@ const (
 )
 
 // TimestampToDay takes a timestamp with second-precision and returns a
-// timestamp of the beginning of the day.
+// timestamp of the beginning of the day, in UTC. Use TimestampToDayIn for
+// other locations.
 func TimestampToDay(i int64) int64 {
-	return i - i%SecsInDay
+	return floorTimestamp(i, SecsInDay)
+}
+
+// TimestampToDayIn takes a timestamp with second-precision and returns a
+// timestamp of the beginning of the day in the given location. A nil location
+// is treated as UTC.
+func TimestampToDayIn(i int64, loc *time.Location) int64 {
+	if loc == nil {
+		return TimestampToDay(i)
+	}
+	return StartOfDay(time.Unix(i, 0), loc).Unix()
+}
+
+// TimestampToHour takes a timestamp with second-precision and returns a
+// timestamp of the beginning of the hour.
+func TimestampToHour(i int64) int64 {
+	return floorTimestamp(i, 3600)
+}
+
+// TimestampToMinute takes a timestamp with second-precision and returns a
+// timestamp of the beginning of the minute.
+func TimestampToMinute(i int64) int64 {
+	return floorTimestamp(i, 60)
 }
 
 // TimestampNanoToDay takes a timestamp with nanosecond-precision and returns a
 // timestamp of the beginning of the day.
 func TimestampNanoToDay(i int64) int64 {
-	return i - i%NanosInDay
+	return floorTimestamp(i, NanosInDay)
+}
+
+// floorTimestamp rounds i down to a multiple of unit, including for
+// timestamps before the epoch.
+func floorTimestamp(i, unit int64) int64 {
+	r := i % unit
+	if r < 0 {
+		r += unit
+	}
+	return i - r
 }
 
 // First discards the second value and returns the first.
+//
+// Deprecated: Use NewPair(t, u).First, or the Pair type to keep both values.
 func First[T any, U any](t T, u U) T {
 	return t
 }
 
 // Second discards the first value and returns the second.
+//
+// Deprecated: Use NewPair(t, u).Second, or the Pair type to keep both values.
 func Second[T any, U any](t T, u U) U {
 	return u
 }
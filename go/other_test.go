@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 type testErr Unit
@@ -49,3 +50,77 @@ func TestValOr(t *testing.T) {
 		t.Errorf("expected %d, got %d", def, got)
 	}
 }
+
+func TestTimeHelpers(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("missing tzdata: %v", err)
+	}
+	// Wednesday, 2024-03-13 02:30 UTC, which is Tuesday 22:30 in New York.
+	tm := time.Date(2024, 3, 13, 2, 30, 15, 0, time.UTC)
+	day := func(y int, m time.Month, d int, loc *time.Location) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	check := func(name string, got, want time.Time) {
+		t.Helper()
+		if !got.Equal(want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+	check("StartOfDay", StartOfDay(tm, nil), day(2024, 3, 13, time.UTC))
+	check("StartOfDay ny", StartOfDay(tm, ny), day(2024, 3, 12, ny))
+	check("StartOfWeek", StartOfWeek(tm, nil), day(2024, 3, 10, time.UTC))
+	check(
+		"StartOfWeekOn",
+		StartOfWeekOn(tm, nil, time.Monday),
+		day(2024, 3, 11, time.UTC),
+	)
+	check("StartOfMonth", StartOfMonth(tm, nil), day(2024, 3, 1, time.UTC))
+	check("StartOfYear", StartOfYear(tm, nil), day(2024, 1, 1, time.UTC))
+
+	if !SameDay(tm, tm.Add(2*time.Hour), nil) {
+		t.Error("expected same day in UTC")
+	}
+	if SameDay(tm, tm.Add(2*time.Hour), ny) {
+		t.Error("expected different days in New York")
+	}
+
+	ts := tm.Unix()
+	if got, want := TimestampToDay(ts), StartOfDay(tm, nil).Unix(); got != want {
+		t.Errorf("TimestampToDay: expected %d, got %d", want, got)
+	}
+	want := StartOfDay(tm, ny).Unix()
+	if got := TimestampToDayIn(ts, ny); got != want {
+		t.Errorf("TimestampToDayIn: expected %d, got %d", want, got)
+	}
+	if got, want := TimestampToHour(ts), ts-30*60-15; got != want {
+		t.Errorf("TimestampToHour: expected %d, got %d", want, got)
+	}
+	if got, want := TimestampToMinute(ts), ts-15; got != want {
+		t.Errorf("TimestampToMinute: expected %d, got %d", want, got)
+	}
+	if got := TimestampToMinute(-1); got != -60 {
+		t.Errorf("TimestampToMinute(-1): expected -60, got %d", got)
+	}
+	if got := TimestampToDay(-1); got != -SecsInDay {
+		t.Errorf("TimestampToDay(-1): expected %d, got %d", -SecsInDay, got)
+	}
+	if got := TimestampNanoToDay(-1); got != -NanosInDay {
+		t.Errorf(
+			"TimestampNanoToDay(-1): expected %d, got %d", -NanosInDay, got,
+		)
+	}
+
+	// Spans the 2024-03-10 DST change in New York.
+	from := time.Date(2024, 3, 9, 15, 0, 0, 0, ny)
+	days := DayRange(from, time.Date(2024, 3, 11, 1, 0, 0, 0, ny))
+	if len(days) != 3 {
+		t.Fatalf("expected 3 days, got %v", days)
+	}
+	for i, d := range days {
+		check("DayRange", d, day(2024, 3, 9+i, ny))
+	}
+	if days := DayRange(from, from.AddDate(0, 0, -1)); days != nil {
+		t.Errorf("expected no days, got %v", days)
+	}
+}
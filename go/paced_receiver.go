@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PacedReceiverOpts are options for a PacedReceiver.
+type PacedReceiverOpts struct {
+	// Interval is the time between values at the steady rate (e.g., 100ms for
+	// 10 values per second).
+	Interval time.Duration
+	// Burst is the number of values that can be received back-to-back after
+	// being idle. If less than 1, 1 is used (no bursts).
+	Burst int
+}
+
+// PacedReceiver receives from a UChan at a steady rate (a leaky bucket),
+// allowing bursts after idle periods. It is safe for use by multiple
+// consumers, which share the rate.
+type PacedReceiver[T any] struct {
+	uc   *UChan[T]
+	opts PacedReceiverOpts
+
+	mtx sync.Mutex
+	// Number of values that can be received immediately
+	tokens float64
+	last   time.Time
+}
+
+// NewPacedReceiver creates a new PacedReceiver receiving from the UChan. It
+// starts with a full burst allowance.
+func NewPacedReceiver[T any](
+	uc *UChan[T], opts PacedReceiverOpts,
+) *PacedReceiver[T] {
+	if opts.Burst < 1 {
+		opts.Burst = 1
+	}
+	return &PacedReceiver[T]{
+		uc:     uc,
+		opts:   opts,
+		tokens: float64(opts.Burst),
+		last:   time.Now(),
+	}
+}
+
+// Recv receives a value once allowed by the rate, returning false if the
+// UChan is closed.
+func (pr *PacedReceiver[T]) Recv() (T, bool) {
+	t, err := pr.RecvContext(context.Background())
+	return t, err == nil
+}
+
+// RecvContext receives a value once allowed by the rate. Returns ErrClosed if
+// the UChan is closed, or ErrCanceled (wrapping the context's error) if the
+// context is done first.
+func (pr *PacedReceiver[T]) RecvContext(ctx context.Context) (t T, err error) {
+	if err = pr.wait(ctx); err != nil {
+		return
+	}
+	t, err = pr.uc.RecvContext(ctx)
+	if err != nil {
+		// Nothing was received, so give back the allowance
+		pr.refund()
+	}
+	return
+}
+
+// wait waits until a value can be received, taking a token.
+func (pr *PacedReceiver[T]) wait(ctx context.Context) error {
+	for {
+		pr.mtx.Lock()
+		now := time.Now()
+		pr.refill(now)
+		if pr.tokens >= 1 {
+			pr.tokens--
+			pr.mtx.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - pr.tokens) * float64(pr.opts.Interval))
+		pr.mtx.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return newCanceledError(ctx)
+		}
+	}
+}
+
+// refill adds the tokens accrued since the last refill. The lock must be
+// held.
+func (pr *PacedReceiver[T]) refill(now time.Time) {
+	if pr.opts.Interval <= 0 {
+		pr.tokens = float64(pr.opts.Burst)
+		return
+	}
+	elapsed := now.Sub(pr.last)
+	pr.last = now
+	pr.tokens += float64(elapsed) / float64(pr.opts.Interval)
+	if max := float64(pr.opts.Burst); pr.tokens > max {
+		pr.tokens = max
+	}
+}
+
+func (pr *PacedReceiver[T]) refund() {
+	pr.mtx.Lock()
+	defer pr.mtx.Unlock()
+	pr.tokens++
+	if max := float64(pr.opts.Burst); pr.tokens > max {
+		pr.tokens = max
+	}
+}
+
+// UChan returns the underlying UChan.
+func (pr *PacedReceiver[T]) UChan() *UChan[T] {
+	return pr.uc
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPacedReceiver(t *testing.T) {
+	uc := NewUChan[int](20)
+	for i := 0; i < 8; i++ {
+		uc.Send(i)
+	}
+	pr := NewPacedReceiver(uc, PacedReceiverOpts{
+		Interval: time.Millisecond * 20,
+		Burst:    3,
+	})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if v, ok := pr.Recv(); !ok || v != i {
+			t.Fatalf("expected %d, got %d", i, v)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Millisecond*15 {
+		t.Fatalf("burst took too long: %v", elapsed)
+	}
+	for i := 3; i < 8; i++ {
+		if v, ok := pr.Recv(); !ok || v != i {
+			t.Fatalf("expected %d, got %d", i, v)
+		}
+	}
+	// 5 values after the burst at 20ms each
+	if elapsed := time.Since(start); elapsed < time.Millisecond*95 {
+		t.Fatalf("values not paced: %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*10,
+	)
+	defer cancel()
+	if _, err := pr.RecvContext(ctx); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	uc.Close()
+	time.Sleep(time.Millisecond * 20)
+	if _, err := pr.RecvContext(context.Background()); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+)
+
+// ErrBadCursor means a pagination cursor is malformed or was produced by a
+// different kind of pagination.
+var ErrBadCursor = errors.New("bad cursor")
+
+// Page is a page of items from a paginated container.
+type Page[T any] struct {
+	// Items are the items in the page.
+	Items []T
+	// Next is the opaque cursor used to get the next page. It is empty if
+	// there are no more pages.
+	Next string
+}
+
+const (
+	cursorOffset byte = 'o'
+	cursorKey    byte = 'k'
+)
+
+// Paginate returns the page of at most limit items, starting at the cursor
+// (an empty cursor starts at the beginning), from the sequence of items
+// produced by the given range function (e.g., Set.Range). Cursors are
+// offsets, so they are only stable if the sequence's order is (e.g., not for
+// Go maps). If limit is less than 1, all remaining items are returned.
+func Paginate[T any](
+	rangeFunc func(func(T) bool), cursor string, limit int,
+) (Page[T], error) {
+	page := Page[T]{}
+	offset, err := decodeOffsetCursor(cursor)
+	if err != nil {
+		return page, err
+	}
+	i, more := uint64(0), false
+	rangeFunc(func(t T) bool {
+		if i < offset {
+			i++
+			return true
+		}
+		if limit > 0 && len(page.Items) == limit {
+			more = true
+			return false
+		}
+		page.Items = append(page.Items, t)
+		i++
+		return true
+	})
+	if more {
+		page.Next = encodeOffsetCursor(i)
+	}
+	return page, nil
+}
+
+// PaginateSlice is Paginate for slices, without needing to iterate to the
+// cursor.
+func PaginateSlice[T any](s []T, cursor string, limit int) (Page[T], error) {
+	page := Page[T]{}
+	offset, err := decodeOffsetCursor(cursor)
+	if err != nil {
+		return page, err
+	}
+	if offset >= uint64(len(s)) {
+		return page, nil
+	}
+	s = s[offset:]
+	if limit > 0 && limit < len(s) {
+		page.Next = encodeOffsetCursor(offset + uint64(limit))
+		s = s[:limit]
+	}
+	page.Items = CloneSlice(s)
+	return page, nil
+}
+
+// PaginateMap returns the page of at most limit key-value pairs of the map,
+// in order of key, starting after the key encoded in the cursor (an empty
+// cursor starts at the beginning). Since the cursor holds the last key rather
+// than an offset, it stays stable when entries are added or removed between
+// pages. The keys are sorted on each call, so each page is O(n log n). If
+// limit is less than 1, all remaining entries are returned.
+func PaginateMap[K Ordered, V any](
+	m ReadOnlyMap[K, V], cursor string, limit int,
+) (Page[Pair[K, V]], error) {
+	page := Page[Pair[K, V]]{}
+	var after K
+	hasAfter := cursor != ""
+	if hasAfter {
+		var err error
+		if after, err = decodeKeyCursor[K](cursor); err != nil {
+			return page, err
+		}
+	}
+	keys := make([]K, 0, m.Len())
+	m.Range(func(k K, _ V) bool {
+		if !hasAfter || k > after {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	if limit > 0 && limit < len(keys) {
+		keys = keys[:limit]
+		page.Next = encodeKeyCursor(keys[limit-1])
+	}
+	page.Items = make([]Pair[K, V], len(keys))
+	for i, k := range keys {
+		page.Items[i] = Pair[K, V]{First: k, Second: m.Get(k)}
+	}
+	return page, nil
+}
+
+func encodeOffsetCursor(offset uint64) string {
+	b := append([]byte{cursorOffset}, Put8(offset)...)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeOffsetCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) != 9 || b[0] != cursorOffset {
+		return 0, ErrBadCursor
+	}
+	return Get8(b[1:]), nil
+}
+
+type keyCursor[K any] struct {
+	Key K
+}
+
+func encodeKeyCursor[K any](key K) string {
+	// Ordered types are always encodable
+	b, _ := EncodeBinaryStruct(keyCursor[K]{Key: key}, 0)
+	return base64.RawURLEncoding.EncodeToString(append([]byte{cursorKey}, b...))
+}
+
+func decodeKeyCursor[K any](cursor string) (K, error) {
+	var kc keyCursor[K]
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) == 0 || b[0] != cursorKey {
+		return kc.Key, ErrBadCursor
+	}
+	if _, err := DecodeBinaryStruct(b[1:], &kc); err != nil {
+		return kc.Key, ErrBadCursor
+	}
+	return kc.Key, nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	s := RangeSlice(10)
+	var all []int
+	cursor, pages := "", 0
+	for {
+		page, err := PaginateSlice(s, cursor, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, page.Items...)
+		pages++
+		if cursor = page.Next; cursor == "" {
+			break
+		}
+	}
+	if pages != 3 || !SliceEq(all, s) {
+		t.Fatalf("unexpected pages (%d): %v", pages, all)
+	}
+
+	sp := NewSlice(s)
+	rangeFunc := func(f func(int) bool) {
+		sp.Range(func(_ int, n int) bool { return f(n) })
+	}
+	page, err := Paginate(rangeFunc, "", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err = Paginate(rangeFunc, page.Next, 5)
+	if err != nil {
+		t.Fatal(err)
+	} else if !SliceEq(page.Items, s[5:]) || page.Next != "" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	m := MapFromMap(map[string]int{"a": 1, "b": 2, "c": 3, "d": 4})
+	mp, err := PaginateMap[string, int](m, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Pair[string, int]{{"a", 1}, {"b", 2}}
+	if !SliceEq(mp.Items, want) {
+		t.Fatalf("expected %v, got %v", want, mp.Items)
+	}
+	// Cursors are stable across modifications
+	m.Delete("a")
+	m.Set("bb", 22)
+	mp, err = PaginateMap[string, int](m, mp.Next, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Pair[string, int]{{"bb", 22}, {"c", 3}}
+	if !SliceEq(mp.Items, want) {
+		t.Fatalf("expected %v, got %v", want, mp.Items)
+	}
+
+	if _, err := PaginateSlice(s, mp.Next, 2); err != ErrBadCursor {
+		t.Fatalf("expected ErrBadCursor, got %v", err)
+	}
+	if _, err := PaginateMap[string, int](m, "!!", 2); err != ErrBadCursor {
+		t.Fatalf("expected ErrBadCursor, got %v", err)
+	}
+}
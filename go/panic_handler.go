@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// PanicHandlerOpts are options for the panic handler.
+type PanicHandlerOpts struct {
+	// AllGoroutines is whether the stacks of all goroutines are included in
+	// the report, rather than just the panicking goroutine.
+	AllGoroutines bool
+	// Redact is called, in order, on the full report before it is written.
+	// Used to remove secrets (e.g., tokens in context values or logs).
+	Redact []func(report string) string
+	// Dumpers are called to add sections to the report (e.g., LogRing.Dump),
+	// in order of their names.
+	Dumpers map[string]func(w io.Writer) error
+	// Exit is whether to exit the program with ExitCode after writing the
+	// report, rather than re-panicking.
+	Exit bool
+	// ExitCode is the exit code used if Exit is true. If 0, 2 is used (the
+	// same as an unrecovered panic).
+	ExitCode int
+}
+
+type panicHandler struct {
+	lw   *LockedWriter
+	opts PanicHandlerOpts
+}
+
+var (
+	installedPanicHandler AValue[*panicHandler]
+	panicContext          = NewMutex(make(map[string]any))
+)
+
+// InstallPanicHandler installs the panic handler, which writes panic reports
+// to the writer when HandlePanic recovers a panic. The writer is wrapped in a
+// LockedWriter (unless it is one) so reports from concurrent panics aren't
+// interleaved. Replaces any previously installed handler.
+func InstallPanicHandler(w io.Writer, opts PanicHandlerOpts) {
+	lw, ok := w.(*LockedWriter)
+	if !ok {
+		lw = NewLockedWriter(w)
+	}
+	installedPanicHandler.Store(&panicHandler{lw: lw, opts: opts})
+}
+
+// HandlePanic recovers a panic, writes a report using the installed panic
+// handler, and then re-panics (or exits, depending on the options). It must
+// be deferred directly (e.g., "defer utils.HandlePanic()") at the top of main
+// and of any goroutine whose panics should be reported. If no handler is
+// installed, reports are written to os.Stderr.
+func HandlePanic() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	h, ok := installedPanicHandler.LoadSafe()
+	if !ok {
+		h = &panicHandler{lw: NewLockedWriter(os.Stderr)}
+	}
+	report := FormatPanicReport(v, debug.Stack(), h.opts)
+	h.lw.WriteAll(report)
+	if h.opts.Exit {
+		code := h.opts.ExitCode
+		if code == 0 {
+			code = 2
+		}
+		os.Exit(code)
+	}
+	panic(v)
+}
+
+// SetPanicContext registers a value included in panic reports under the
+// given key (e.g., a request ID or build version). Values are formatted when
+// the report is written.
+func SetPanicContext(key string, value any) {
+	m := panicContext.Lock()
+	defer panicContext.Unlock()
+	(*m)[key] = value
+}
+
+// DeletePanicContext removes a value registered with SetPanicContext.
+func DeletePanicContext(key string) {
+	m := panicContext.Lock()
+	defer panicContext.Unlock()
+	delete(*m, key)
+}
+
+// FormatPanicReport formats a report for the panic value and stack (of the
+// panicking goroutine), including the registered panic context values and
+// the sections from the options' dumpers, with redactions applied.
+func FormatPanicReport(v any, stack []byte, opts PanicHandlerOpts) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "panic: %v\n", v)
+	if pe, ok := v.(*PanicError); ok && pe.Stack != nil {
+		// Report the original stack of a re-thrown panic
+		stack = pe.Stack
+	}
+	fmt.Fprintf(&buf, "time: %s\n", time.Now().Format(time.RFC3339Nano))
+
+	m := panicContext.Lock()
+	keys := make([]string, 0, len(*m))
+	for key := range *m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) != 0 {
+		enc := NewKVEncoder(nil)
+		for _, key := range keys {
+			enc.Add(key, (*m)[key])
+		}
+		fmt.Fprintf(&buf, "context: %s\n", enc)
+	}
+	panicContext.Unlock()
+
+	buf.WriteString("\n")
+	buf.Write(stack)
+	if opts.AllGoroutines {
+		buf.WriteString("\nall goroutines:\n")
+		buf.Write(allGoroutineStacks())
+	}
+
+	names := make([]string, 0, len(opts.Dumpers))
+	for name := range opts.Dumpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&buf, "\n---- %s ----\n", name)
+		if err := opts.Dumpers[name](&buf); err != nil {
+			fmt.Fprintf(&buf, "\n(error dumping %s: %v)", name, err)
+		}
+		buf.WriteByte('\n')
+	}
+
+	if len(opts.Redact) == 0 {
+		return buf.Bytes()
+	}
+	report := buf.String()
+	for _, redact := range opts.Redact {
+		report = redact(report)
+	}
+	return []byte(report)
+}
+
+func allGoroutineStacks() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// Safe calls f, converting a panic into a *PanicError (with the stack of the
+// panicking goroutine) rather than letting it propagate.
+func Safe(f func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	f()
+	return nil
+}
+
+// SafeValue calls f, returning its value, or a *PanicError if f panicked.
+func SafeValue[T any](f func() T) (t T, err error) {
+	err = Safe(func() { t = f() })
+	return
+}
+
+// SafeGo runs f in a new goroutine, recovering any panic. The panic is
+// passed to onErr as a *PanicError. If onErr is nil, a report of the panic is
+// written using the installed panic handler (see InstallPanicHandler), but
+// the program neither re-panics nor exits.
+func SafeGo(f func(), onErr func(error)) {
+	go func() {
+		err := Safe(f)
+		if err == nil {
+			return
+		}
+		if onErr != nil {
+			onErr(err)
+			return
+		}
+		pe := err.(*PanicError)
+		h, ok := installedPanicHandler.LoadSafe()
+		if !ok {
+			h = &panicHandler{lw: NewLockedWriter(os.Stderr)}
+		}
+		h.lw.WriteAll(FormatPanicReport(pe.Value, pe.Stack, h.opts))
+	}()
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPanicHandler(t *testing.T) {
+	var buf bytes.Buffer
+	ring := NewLogRing(LogRingOpts{MaxLines: 10})
+	ring.Write([]byte("token=secret123 did a thing\n"))
+	InstallPanicHandler(&buf, PanicHandlerOpts{
+		Redact: []func(string) string{
+			func(s string) string {
+				return strings.ReplaceAll(s, "secret123", "[REDACTED]")
+			},
+		},
+		Dumpers: map[string]func(io.Writer) error{"logs": ring.Dump},
+	})
+	defer installedPanicHandler.Store(&panicHandler{
+		lw: NewLockedWriter(io.Discard),
+	})
+	SetPanicContext("request_id", "abc")
+	defer DeletePanicContext("request_id")
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("expected re-panic with boom, got %v", v)
+			}
+		}()
+		defer HandlePanic()
+		panic("boom")
+	}()
+
+	report := buf.String()
+	for _, want := range []string{
+		"panic: boom\n",
+		"context: request_id=abc\n",
+		"TestPanicHandler",
+		"---- logs ----\ntoken=[REDACTED] did a thing\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "secret123") {
+		t.Fatalf("report not redacted:\n%s", report)
+	}
+}
+
+func TestSafe(t *testing.T) {
+	if err := Safe(func() {}); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	err := Safe(func() { panic(io.EOF) })
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected PanicError, got %v", err)
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Fatal("expected PanicError to unwrap to io.EOF")
+	}
+	if !strings.Contains(string(pe.Stack), "TestSafe") {
+		t.Fatalf("stack missing caller:\n%s", pe.Stack)
+	}
+
+	if n, err := SafeValue(func() int { return 5 }); n != 5 || err != nil {
+		t.Fatalf("expected 5, nil, got %d, %v", n, err)
+	}
+	n, err := SafeValue(func() int { panic("boom") })
+	if n != 0 || !ErrAs[*PanicError](err) {
+		t.Fatalf("expected 0, PanicError, got %d, %v", n, err)
+	}
+}
+
+func TestSafeGo(t *testing.T) {
+	errs := make(chan error, 1)
+	SafeGo(func() { panic("boom") }, func(err error) { errs <- err })
+	if err := <-errs; err.Error() != "panic: boom" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	lw := NewLockedWriter(&buf)
+	InstallPanicHandler(lw, PanicHandlerOpts{})
+	defer installedPanicHandler.Store(&panicHandler{
+		lw: NewLockedWriter(io.Discard),
+	})
+	done := make(chan struct{})
+	SafeGo(func() {
+		defer close(done)
+		panic("unhandled")
+	}, nil)
+	<-done
+	// The report is written after the deferred close.
+	deadline := time.Now().Add(time.Second)
+	for {
+		lw.Lock()
+		report := buf.String()
+		lw.Unlock()
+		if strings.Contains(report, "panic: unhandled\n") {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("report not written:\n%s", report)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
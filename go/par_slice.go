@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"container/heap"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultParSortCutoff is the default length at or below which ParSortSlice
+// sorts sequentially.
+const DefaultParSortCutoff = 1 << 12
+
+// ParSortOpts are options for ParSortSlice.
+type ParSortOpts struct {
+	// Cutoff is the length at or below which (sub)slices are sorted
+	// sequentially. If less than 1, DefaultParSortCutoff is used.
+	Cutoff int
+	// Pool is the pool used to sort. If nil, a new pool is created (and
+	// closed) for the call.
+	Pool *ForkJoinPool
+}
+
+// ParSortSlice sorts the slice in place using a parallel (stable) merge sort
+// run on a ForkJoinPool.
+func ParSortSlice[T any](s []T, less func(a, b T) bool, opts ParSortOpts) {
+	cutoff := opts.Cutoff
+	if cutoff < 1 {
+		cutoff = DefaultParSortCutoff
+	}
+	if len(s) <= cutoff {
+		sortSliceStable(s, less)
+		return
+	}
+	p := opts.Pool
+	if p == nil {
+		p = NewForkJoinPool(0)
+		defer p.Close()
+	}
+	tmp := make([]T, len(s))
+	Invoke(p, func(w *ForkJoinWorker) Unit {
+		parMergeSort(w, s, tmp, less, cutoff)
+		return Unit{}
+	})
+}
+
+func sortSliceStable[T any](s []T, less func(a, b T) bool) {
+	sort.SliceStable(s, func(i, j int) bool { return less(s[i], s[j]) })
+}
+
+// parMergeSort sorts s using tmp (which must be the same length as s) as
+// scratch space.
+func parMergeSort[T any](
+	w *ForkJoinWorker, s, tmp []T, less func(a, b T) bool, cutoff int,
+) {
+	if len(s) <= cutoff {
+		sortSliceStable(s, less)
+		return
+	}
+	mid := len(s) / 2
+	left := Spawn(w, func(w *ForkJoinWorker) Unit {
+		parMergeSort(w, s[:mid], tmp[:mid], less, cutoff)
+		return Unit{}
+	})
+	parMergeSort(w, s[mid:], tmp[mid:], less, cutoff)
+	left.Join(w)
+	parMerge(w, s[:mid], s[mid:], tmp, less, cutoff)
+	copy(s, tmp)
+}
+
+// parMerge stably merges the sorted slices a and b into dst, which must have
+// a length of len(a)+len(b).
+func parMerge[T any](
+	w *ForkJoinWorker, a, b, dst []T, less func(a, b T) bool, cutoff int,
+) {
+	if len(a)+len(b) <= cutoff {
+		mergeSorted(a, b, dst, less)
+		return
+	}
+	var leftA, leftB, rightA, rightB []T
+	var pivot T
+	if len(a) >= len(b) {
+		// Elements in b equal to the pivot must come after it
+		m := len(a) / 2
+		pivot = a[m]
+		j := sort.Search(len(b), func(j int) bool { return !less(b[j], pivot) })
+		leftA, leftB, rightA, rightB = a[:m], b[:j], a[m+1:], b[j:]
+	} else {
+		// Elements in a equal to the pivot must come before it
+		m := len(b) / 2
+		pivot = b[m]
+		i := sort.Search(len(a), func(i int) bool { return less(pivot, a[i]) })
+		leftA, leftB, rightA, rightB = a[:i], b[:m], a[i:], b[m+1:]
+	}
+	p := len(leftA) + len(leftB)
+	dst[p] = pivot
+	left := Spawn(w, func(w *ForkJoinWorker) Unit {
+		parMerge(w, leftA, leftB, dst[:p], less, cutoff)
+		return Unit{}
+	})
+	parMerge(w, rightA, rightB, dst[p+1:], less, cutoff)
+	left.Join(w)
+}
+
+// mergeSorted stably merges the sorted slices a and b into dst, which must
+// have a length of len(a)+len(b).
+func mergeSorted[T any](a, b, dst []T, less func(a, b T) bool) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if less(b[j], a[i]) {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
+
+// MergeSortedSlices merges the given sorted slices into a new sorted slice.
+// The merge is stable; equal elements are ordered by the position of the slice
+// they came from in the arguments.
+func MergeSortedSlices[T any](less func(a, b T) bool, ss ...[]T) []T {
+	total := 0
+	for _, s := range ss {
+		total += len(s)
+	}
+	res := make([]T, total)
+	switch len(ss) {
+	case 0:
+		return res
+	case 1:
+		copy(res, ss[0])
+		return res
+	case 2:
+		mergeSorted(ss[0], ss[1], res, less)
+		return res
+	}
+	h := &mergeHeap[T]{less: less}
+	for i, s := range ss {
+		if len(s) != 0 {
+			h.items = append(h.items, mergeHeapItem{slice: i})
+		}
+	}
+	h.ss = ss
+	heap.Init(h)
+	for k := 0; h.Len() != 0; k++ {
+		item := &h.items[0]
+		res[k] = ss[item.slice][item.index]
+		item.index++
+		if item.index == len(ss[item.slice]) {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+	return res
+}
+
+type mergeHeapItem struct {
+	slice, index int
+}
+
+type mergeHeap[T any] struct {
+	ss    [][]T
+	items []mergeHeapItem
+	less  func(a, b T) bool
+}
+
+func (h *mergeHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	ta, tb := h.ss[a.slice][a.index], h.ss[b.slice][b.index]
+	if h.less(ta, tb) {
+		return true
+	} else if h.less(tb, ta) {
+		return false
+	}
+	return a.slice < b.slice
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *mergeHeap[T]) Push(x any) {
+	h.items = append(h.items, x.(mergeHeapItem))
+}
+
+func (h *mergeHeap[T]) Pop() any {
+	l := len(h.items)
+	item := h.items[l-1]
+	h.items = h.items[:l-1]
+	return item
+}
+
+// MapReduceOpts are options for MapReduceSlice.
+type MapReduceOpts struct {
+	// ChunkSize is the maximum number of elements passed to each call of the
+	// mapper. If less than 1, the slice is split evenly between the workers.
+	ChunkSize int
+	// Workers is the number of goroutines used to map chunks. If less than 1,
+	// runtime.GOMAXPROCS(0) is used.
+	Workers int
+}
+
+// MapReduceSlice partitions the slice into chunks, concurrently passing each
+// chunk to the mapper, then reduces the results of the mapper, starting with
+// the zero value of R. The reduction is done in chunk order (regardless of the
+// order the chunks finish) so the result is deterministic.
+func MapReduceSlice[T, M, R any](
+	s []T, mapper func([]T) M, reducer func(R, M) R, opts MapReduceOpts,
+) (res R) {
+	if len(s) == 0 {
+		return
+	}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize < 1 {
+		chunkSize = (len(s) + workers - 1) / workers
+	}
+	numChunks := (len(s) + chunkSize - 1) / chunkSize
+	if workers > numChunks {
+		workers = numChunks
+	}
+
+	results := make([]M, numChunks)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				c := int(next.Add(1) - 1)
+				if c >= numChunks {
+					return
+				}
+				start, end := c*chunkSize, (c+1)*chunkSize
+				if end > len(s) {
+					end = len(s)
+				}
+				results[c] = mapper(s[start:end])
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, m := range results {
+		res = reducer(res, m)
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestParSortSlice(t *testing.T) {
+	type pair struct {
+		key, index int
+	}
+	const l = 100000
+	s := make([]pair, l)
+	for i := range s {
+		s[i] = pair{key: rand.Intn(l / 10), index: i}
+	}
+	want := CloneSlice(s)
+	sort.SliceStable(want, func(i, j int) bool {
+		return want[i].key < want[j].key
+	})
+
+	less := func(a, b pair) bool { return a.key < b.key }
+	for _, cutoff := range []int{0, 1, 100} {
+		got := CloneSlice(s)
+		ParSortSlice(got, less, ParSortOpts{Cutoff: cutoff})
+		if i := SliceCompare(got, want); i != -1 {
+			t.Fatalf(
+				"cutoff %d: index %d: expected %v, got %v",
+				cutoff, i, want[i], got[i],
+			)
+		}
+	}
+
+	p := NewForkJoinPool(3)
+	defer p.Close()
+	got := ShuffleSlice(RangeSlice(l), nil)
+	ParSortSlice(got, func(a, b int) bool { return a < b }, ParSortOpts{
+		Cutoff: 64,
+		Pool:   p,
+	})
+	if i := SliceCompare(got, RangeSlice(l)); i != -1 {
+		t.Fatalf("index %d: expected %d, got %d", i, i, got[i])
+	}
+}
+
+func TestMergeSortedSlices(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	if got := MergeSortedSlices(less); len(got) != 0 {
+		t.Fatalf("expected empty slice, got %v", got)
+	}
+
+	ss := [][]int{{0, 3, 6, 9}, {}, {1, 4, 7}, {2, 5, 8, 10, 11}}
+	want := RangeSlice(12)
+	if got := MergeSortedSlices(less, ss...); !SliceEq(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := MergeSortedSlices(less, ss[0], ss[2]); !SliceEq(
+		got, []int{0, 1, 3, 4, 6, 7, 9},
+	) {
+		t.Fatalf("unexpected result: %v", got)
+	}
+
+	// Stability
+	type pair struct {
+		key, slice int
+	}
+	pss := [][]pair{{{1, 0}, {2, 0}}, {{1, 1}, {2, 1}}, {{1, 2}}}
+	pairLess := func(a, b pair) bool { return a.key < b.key }
+	got := MergeSortedSlices(pairLess, pss...)
+	wantPairs := []pair{{1, 0}, {1, 1}, {1, 2}, {2, 0}, {2, 1}}
+	if !SliceEq(got, wantPairs) {
+		t.Fatalf("expected %v, got %v", wantPairs, got)
+	}
+}
+
+func TestMapReduceSlice(t *testing.T) {
+	s := RangeSlice(10000)
+	sum := func(s []int) int {
+		total := 0
+		for _, n := range s {
+			total += n
+		}
+		return total
+	}
+	add := func(a, b int) int { return a + b }
+	want := 10000 * 9999 / 2
+	for _, opts := range []MapReduceOpts{
+		{},
+		{ChunkSize: 1000},
+		{ChunkSize: 999, Workers: 3},
+		{ChunkSize: 1000000, Workers: 100},
+	} {
+		if got := MapReduceSlice(s, sum, add, opts); got != want {
+			t.Errorf("%+v: expected %d, got %d", opts, want, got)
+		}
+	}
+
+	// Reduction should happen in chunk order.
+	firsts := MapReduceSlice(
+		s,
+		func(s []int) int { return s[0] },
+		func(res []int, first int) []int { return append(res, first) },
+		MapReduceOpts{ChunkSize: 1000},
+	)
+	want2 := []int{0, 1000, 2000, 3000, 4000, 5000, 6000, 7000, 8000, 9000}
+	if !SliceEq(firsts, want2) {
+		t.Errorf("expected %v, got %v", want2, firsts)
+	}
+
+	if got := MapReduceSlice([]int{}, sum, add, MapReduceOpts{}); got != 0 {
+		t.Errorf("expected 0, got %d", got)
+	}
+}
@@ -0,0 +1,76 @@
+package utils
+
+// PluckSlice returns a new slice of the results of f applied to each element
+// of s. It's the same as MapSlice but writes by index rather than appending,
+// which is faster for hot paths.
+func PluckSlice[T, U any](s []T, f func(T) U) []U {
+	return PluckSliceInto(make([]U, len(s)), s, f)
+}
+
+// PluckSliceInto is the same as PluckSlice but writes the results into dst,
+// reusing its capacity if it's large enough, and returns the resulting
+// slice.
+func PluckSliceInto[T, U any](dst []U, s []T, f func(T) U) []U {
+	dst = resizeSlice(dst, len(s))
+	for i := range s {
+		dst[i] = f(s[i])
+	}
+	return dst
+}
+
+// PluckPtr is the same as PluckSlice but passes a pointer to each element to
+// f, avoiding a copy of each element. Use it for slices of large structs.
+func PluckPtr[T, U any](s []T, f func(*T) U) []U {
+	res := make([]U, len(s))
+	for i := range s {
+		res[i] = f(&s[i])
+	}
+	return res
+}
+
+// PluckField returns the values of a field of each element of s, where field
+// returns a pointer to the field of the element passed to it. For example:
+//
+//	ages := PluckField(people, func(p *Person) *int { return &p.Age })
+func PluckField[T, U any](s []T, field func(*T) *U) []U {
+	return PluckFieldInto(make([]U, len(s)), s, field)
+}
+
+// PluckFieldInto is the same as PluckField but writes the values into dst,
+// reusing its capacity if it's large enough, and returns the resulting
+// slice.
+func PluckFieldInto[T, U any](dst []U, s []T, field func(*T) *U) []U {
+	dst = resizeSlice(dst, len(s))
+	for i := range s {
+		dst[i] = *field(&s[i])
+	}
+	return dst
+}
+
+// AssignField sets a field of each element of s to the value at the same
+// index in vals, where field is the same as for PluckField. Only the first
+// min(len(s), len(vals)) elements are set.
+func AssignField[T, U any](s []T, field func(*T) *U, vals []U) {
+	n := min(len(s), len(vals))
+	s, vals = s[:n], vals[:n]
+	for i := range s {
+		*field(&s[i]) = vals[i]
+	}
+}
+
+// FillField sets a field of each element of s to the value, where field is
+// the same as for PluckField.
+func FillField[T, U any](s []T, field func(*T) *U, val U) {
+	for i := range s {
+		*field(&s[i]) = val
+	}
+}
+
+// resizeSlice returns s with length n, reallocating if its capacity is too
+// small.
+func resizeSlice[T any](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
+	}
+	return s[:n]
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"testing"
+)
+
+type pluckTest struct {
+	ID    int
+	Name  string
+	Score float64
+	_     [64]byte
+}
+
+func makePluckTests(n int) []pluckTest {
+	s := make([]pluckTest, n)
+	for i := range s {
+		s[i].ID, s[i].Score = i, float64(i)/2
+	}
+	return s
+}
+
+func TestPluck(t *testing.T) {
+	s := makePluckTests(10)
+	ids := PluckSlice(s, func(p pluckTest) int { return p.ID })
+	if !SliceEq(ids, RangeSlice(10)) {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+	ptrIDs := PluckPtr(s, func(p *pluckTest) int { return p.ID })
+	if !SliceEq(ptrIDs, ids) {
+		t.Fatalf("unexpected ids: %v", ptrIDs)
+	}
+	id := func(p *pluckTest) *int { return &p.ID }
+	if got := PluckField(s, id); !SliceEq(got, ids) {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+
+	// Reuses dst when it's big enough
+	dst := make([]int, 0, 20)
+	got := PluckFieldInto(dst, s, id)
+	if &got[0] != &dst[:1][0] || !SliceEq(got, ids) {
+		t.Fatalf("expected dst to be reused, got %v", got)
+	}
+	got = PluckSliceInto(
+		make([]int, 2), s, func(p pluckTest) int { return p.ID },
+	)
+	if !SliceEq(got, ids) {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+
+	name := func(p *pluckTest) *string { return &p.Name }
+	AssignField(s, name, []string{"a", "b", "c"})
+	FillField(s[3:], id, -1)
+	for i, p := range s {
+		wantName, wantID := "", -1
+		if i < 3 {
+			wantName, wantID = string(rune('a'+i)), i
+		}
+		if p.Name != wantName || p.ID != wantID {
+			t.Fatalf("unexpected element %d: %+v", i, p)
+		}
+	}
+
+	got = PluckSlice(nil, func(p pluckTest) int { return p.ID })
+	if len(got) != 0 {
+		t.Fatalf("expected empty slice, got %v", got)
+	}
+}
+
+var pluckSink []float64
+
+func BenchmarkMapSliceField(b *testing.B) {
+	s := makePluckTests(1 << 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pluckSink = MapSlice(s, func(p pluckTest) float64 { return p.Score })
+	}
+}
+
+func BenchmarkPluckSlice(b *testing.B) {
+	s := makePluckTests(1 << 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pluckSink = PluckSlice(s, func(p pluckTest) float64 { return p.Score })
+	}
+}
+
+func BenchmarkPluckField(b *testing.B) {
+	s := makePluckTests(1 << 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pluckSink = PluckField(s, func(p *pluckTest) *float64 { return &p.Score })
+	}
+}
+
+func BenchmarkPluckFieldInto(b *testing.B) {
+	s := makePluckTests(1 << 16)
+	dst := make([]float64, len(s))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst = PluckFieldInto(
+			dst, s, func(p *pluckTest) *float64 { return &p.Score },
+		)
+	}
+	pluckSink = dst
+}
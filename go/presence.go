@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PresenceEventKind is the kind of a PresenceEvent.
+type PresenceEventKind int
+
+const (
+	// PresenceJoin means a peer joined (its first heartbeat, or its first
+	// since leaving).
+	PresenceJoin PresenceEventKind = iota
+	// PresenceLeave means a peer left, either explicitly or by expiring.
+	PresenceLeave
+)
+
+// String returns the name of the kind.
+func (k PresenceEventKind) String() string {
+	switch k {
+	case PresenceJoin:
+		return "join"
+	case PresenceLeave:
+		return "leave"
+	default:
+		return fmt.Sprintf("PresenceEventKind(%d)", int(k))
+	}
+}
+
+// PresenceEvent is a change in the membership of a PresenceTracker.
+type PresenceEvent[ID comparable] struct {
+	Kind PresenceEventKind
+	ID   ID
+	// Time is when the change happened (according to the tracker's clock).
+	Time time.Time
+	// Expired is whether a leave was caused by the peer's TTL expiring.
+	Expired bool
+	// Version is the membership version after the change.
+	Version uint64
+}
+
+// Peer is a peer in a PresenceTracker.
+type Peer[ID comparable] struct {
+	ID ID
+	// Joined is when the peer joined.
+	Joined time.Time
+	// LastSeen is when the peer was last touched.
+	LastSeen time.Time
+}
+
+// PresenceSnapshot is the membership of a PresenceTracker at a point in
+// time.
+type PresenceSnapshot[ID comparable] struct {
+	// Peers are the present peers, by ID.
+	Peers map[ID]Peer[ID]
+	// Version is incremented on every join and leave, so it matches the
+	// Version of the last event emitted before the snapshot.
+	Version uint64
+}
+
+// PresenceOpts are options for a PresenceTracker.
+type PresenceOpts struct {
+	// TTL is how long a peer stays present after its last heartbeat. Must be
+	// positive.
+	TTL time.Duration
+	// SweepInterval is how often expired peers are removed. If 0, TTL/2 is
+	// used. If negative, expired peers are only removed by calling Sweep.
+	SweepInterval time.Duration
+	// Now returns the current time. If nil, time.Now is used. Mostly useful
+	// for tests (along with a negative SweepInterval).
+	Now func() time.Time
+	// EventsChanLen is the length of the chan underlying the events UChan.
+	// If less than 1, 16 is used.
+	EventsChanLen int
+}
+
+// PresenceTracker tracks which peers are present based on heartbeats. Peers
+// call Touch (directly or through some transport) and are removed once they
+// haven't been touched for the TTL. Joins and leaves are sent as events,
+// in the order they happen, over a UChan.
+type PresenceTracker[ID comparable] struct {
+	opts    PresenceOpts
+	mtx     sync.Mutex
+	peers   map[ID]*Peer[ID]
+	version uint64
+	events  *UChan[PresenceEvent[ID]]
+	stop    chan struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// NewPresenceTracker creates a new PresenceTracker, starting its sweeper (if
+// enabled). Panics if the TTL isn't positive.
+func NewPresenceTracker[ID comparable](
+	opts PresenceOpts,
+) *PresenceTracker[ID] {
+	if opts.TTL <= 0 {
+		panic("utils: non-positive TTL for NewPresenceTracker")
+	}
+	if opts.SweepInterval == 0 {
+		opts.SweepInterval = opts.TTL / 2
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.EventsChanLen < 1 {
+		opts.EventsChanLen = 16
+	}
+	pt := &PresenceTracker[ID]{
+		opts:   opts,
+		peers:  make(map[ID]*Peer[ID]),
+		events: NewUChan[PresenceEvent[ID]](opts.EventsChanLen),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if opts.SweepInterval > 0 {
+		go pt.sweeper()
+	} else {
+		close(pt.done)
+	}
+	return pt
+}
+
+func (pt *PresenceTracker[ID]) sweeper() {
+	defer close(pt.done)
+	ticker := time.NewTicker(pt.opts.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			pt.Sweep()
+		case <-pt.stop:
+			return
+		}
+	}
+}
+
+// Touch records a heartbeat from the peer, returning true if the peer
+// joined. Does nothing if the tracker is closed.
+func (pt *PresenceTracker[ID]) Touch(id ID) bool {
+	pt.mtx.Lock()
+	defer pt.mtx.Unlock()
+	if pt.closed {
+		return false
+	}
+	now := pt.opts.Now()
+	if p, ok := pt.peers[id]; ok {
+		p.LastSeen = now
+		return false
+	}
+	pt.peers[id] = &Peer[ID]{ID: id, Joined: now, LastSeen: now}
+	pt.emit(PresenceJoin, id, now, false)
+	return true
+}
+
+// Leave removes the peer, returning false if it wasn't present.
+func (pt *PresenceTracker[ID]) Leave(id ID) bool {
+	pt.mtx.Lock()
+	defer pt.mtx.Unlock()
+	if _, ok := pt.peers[id]; !ok || pt.closed {
+		return false
+	}
+	delete(pt.peers, id)
+	pt.emit(PresenceLeave, id, pt.opts.Now(), false)
+	return true
+}
+
+// Sweep removes peers that haven't been touched within the TTL, returning
+// their IDs.
+func (pt *PresenceTracker[ID]) Sweep() []ID {
+	pt.mtx.Lock()
+	defer pt.mtx.Unlock()
+	if pt.closed {
+		return nil
+	}
+	now := pt.opts.Now()
+	var expired []ID
+	for id, p := range pt.peers {
+		if now.Sub(p.LastSeen) >= pt.opts.TTL {
+			delete(pt.peers, id)
+			pt.emit(PresenceLeave, id, now, true)
+			expired = append(expired, id)
+		}
+	}
+	return expired
+}
+
+// emit sends an event, incrementing the version. The lock must be held.
+func (pt *PresenceTracker[ID]) emit(
+	kind PresenceEventKind, id ID, t time.Time, expired bool,
+) {
+	pt.version++
+	pt.events.Send(PresenceEvent[ID]{
+		Kind:    kind,
+		ID:      id,
+		Time:    t,
+		Expired: expired,
+		Version: pt.version,
+	})
+}
+
+// IsPresent returns whether the peer is present. A peer whose TTL has passed
+// but hasn't been swept yet is still considered present.
+func (pt *PresenceTracker[ID]) IsPresent(id ID) bool {
+	pt.mtx.Lock()
+	defer pt.mtx.Unlock()
+	_, ok := pt.peers[id]
+	return ok
+}
+
+// Len returns the number of present peers.
+func (pt *PresenceTracker[ID]) Len() int {
+	pt.mtx.Lock()
+	defer pt.mtx.Unlock()
+	return len(pt.peers)
+}
+
+// Snapshot returns the current membership.
+func (pt *PresenceTracker[ID]) Snapshot() PresenceSnapshot[ID] {
+	pt.mtx.Lock()
+	defer pt.mtx.Unlock()
+	peers := make(map[ID]Peer[ID], len(pt.peers))
+	for id, p := range pt.peers {
+		peers[id] = *p
+	}
+	return PresenceSnapshot[ID]{Peers: peers, Version: pt.version}
+}
+
+// Events returns the UChan that join and leave events are sent over. It's
+// closed when the tracker is closed.
+func (pt *PresenceTracker[ID]) Events() *UChan[PresenceEvent[ID]] {
+	return pt.events
+}
+
+// Close stops the tracker's sweeper and closes the events UChan. Present
+// peers aren't sent leave events. Returns false if already closed.
+func (pt *PresenceTracker[ID]) Close() bool {
+	pt.mtx.Lock()
+	if pt.closed {
+		pt.mtx.Unlock()
+		return false
+	}
+	pt.closed = true
+	pt.mtx.Unlock()
+	close(pt.stop)
+	<-pt.done
+	pt.events.Close()
+	return true
+}
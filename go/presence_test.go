@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPresenceTracker(t *testing.T) {
+	now := time.Unix(1000, 0)
+	pt := NewPresenceTracker[string](PresenceOpts{
+		TTL:           time.Second * 10,
+		SweepInterval: -1,
+		Now:           func() time.Time { return now },
+	})
+	defer pt.Close()
+	events := pt.Events()
+	expectEvent := func(kind PresenceEventKind, id string, expired bool) {
+		t.Helper()
+		ev, err := events.TryRecv()
+		if err != nil {
+			t.Fatal("expected event, got ", err)
+		}
+		if ev.Kind != kind || ev.ID != id || ev.Expired != expired {
+			t.Fatalf("expected %s of %s, got %+v", kind, id, ev)
+		}
+	}
+
+	if !pt.Touch("a") || !pt.Touch("b") || pt.Touch("a") {
+		t.Fatal("unexpected Touch result")
+	}
+	expectEvent(PresenceJoin, "a", false)
+	expectEvent(PresenceJoin, "b", false)
+	if _, err := events.TryRecv(); err != ErrEmpty {
+		t.Fatal("unexpected event for repeated touch")
+	}
+
+	now = now.Add(time.Second * 6)
+	pt.Touch("a")
+	now = now.Add(time.Second * 5)
+	if expired := pt.Sweep(); !SliceEq(expired, []string{"b"}) {
+		t.Fatalf("expected b to expire, got %v", expired)
+	}
+	expectEvent(PresenceLeave, "b", true)
+
+	snap := pt.Snapshot()
+	if len(snap.Peers) != 1 || snap.Version != 3 {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+	peer := snap.Peers["a"]
+	if !peer.Joined.Equal(time.Unix(1000, 0)) ||
+		!peer.LastSeen.Equal(time.Unix(1006, 0)) {
+		t.Fatalf("unexpected peer: %+v", peer)
+	}
+
+	if !pt.Leave("a") || pt.Leave("a") || pt.IsPresent("a") {
+		t.Fatal("unexpected Leave result")
+	}
+	expectEvent(PresenceLeave, "a", false)
+	if pt.Touch("b"); pt.Len() != 1 {
+		t.Fatalf("expected 1 peer, got %d", pt.Len())
+	}
+	expectEvent(PresenceJoin, "b", false)
+}
+
+func TestPresenceTrackerSweeper(t *testing.T) {
+	pt := NewPresenceTracker[int](PresenceOpts{TTL: time.Millisecond * 10})
+	pt.Touch(1)
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+	for _, want := range []PresenceEventKind{PresenceJoin, PresenceLeave} {
+		select {
+		case ev := <-pt.Events().RecvChan().Chan():
+			if ev.Kind != want || ev.ID != 1 {
+				t.Fatalf("expected %s of 1, got %+v", want, ev)
+			}
+		case <-timer.C:
+			t.Fatal("timed out waiting for events")
+		}
+	}
+	if !pt.Close() || pt.Close() {
+		t.Fatal("expected only first Close to succeed")
+	}
+	if _, ok := pt.Events().Recv(); ok {
+		t.Fatal("expected events to be closed")
+	}
+	if pt.Touch(2) {
+		t.Fatal("expected Touch to do nothing after Close")
+	}
+}
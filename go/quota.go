@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded means a quota would be exceeded.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError is returned when acquiring from a QuotaManager would exceed a
+// limit. It matches ErrQuotaExceeded using errors.Is.
+type QuotaError struct {
+	// Key is the key whose limit would be exceeded. It is empty for the global
+	// limit.
+	Key string
+	// Limit is the limit of the key.
+	Limit int64
+	// Used is the amount used at the time of the request.
+	Used int64
+	// Requested is the amount requested.
+	Requested int64
+}
+
+// Error implements the error interface.
+func (qe *QuotaError) Error() string {
+	key := qe.Key
+	if key == "" {
+		key = "(global)"
+	}
+	return fmt.Sprintf(
+		"quota exceeded for %s: requested %d with %d of %d used",
+		key, qe.Requested, qe.Used, qe.Limit,
+	)
+}
+
+// Unwrap returns ErrQuotaExceeded.
+func (qe *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// QuotaOpts are options for a QuotaManager.
+type QuotaOpts struct {
+	// DefaultLimit is the limit for keys without one set. If 0, keys without a
+	// limit set are unlimited (but still count towards their parents' and the
+	// global limits).
+	DefaultLimit int64
+	// GlobalLimit is the limit across all keys. If 0, there is no global limit.
+	GlobalLimit int64
+	// ResetEvery is how often usage is reset, for rate-style quotas (e.g.,
+	// requests per minute). Each key's window starts when it is first used. If
+	// 0, usage is never reset and must be released, for concurrency-style
+	// quotas.
+	ResetEvery time.Duration
+}
+
+// QuotaManager tracks usage of named counters against limits. Keys can have
+// parent keys, in which case acquiring from a key also acquires from all of
+// its ancestors (and the global limit), succeeding only if no limit is
+// exceeded.
+type QuotaManager struct {
+	opts QuotaOpts
+
+	mtx    sync.Mutex
+	keys   map[string]*quotaCounter
+	global quotaCounter
+}
+
+type quotaCounter struct {
+	key      string
+	parent   string
+	limit    int64
+	hasLimit bool
+	used     int64
+	resetAt  time.Time
+}
+
+// QuotaUsage is the usage of a key in a QuotaManager.
+type QuotaUsage struct {
+	// Key is the key. It is empty for the global usage.
+	Key string
+	// Parent is the key's parent, if any.
+	Parent string
+	// Used is the amount used.
+	Used int64
+	// Limit is the key's limit, 0 meaning no limit.
+	Limit int64
+	// ResetAt is when the usage will be reset, if resets are enabled and the
+	// key has been used.
+	ResetAt time.Time
+}
+
+// NewQuotaManager creates a new QuotaManager.
+func NewQuotaManager(opts QuotaOpts) *QuotaManager {
+	return &QuotaManager{
+		opts:   opts,
+		keys:   make(map[string]*quotaCounter),
+		global: quotaCounter{limit: opts.GlobalLimit, hasLimit: true},
+	}
+}
+
+// counter gets (creating if necessary) the counter for the key. The lock must
+// be held.
+func (qm *QuotaManager) counter(key string) *quotaCounter {
+	c, ok := qm.keys[key]
+	if !ok {
+		c = &quotaCounter{key: key}
+		qm.keys[key] = c
+	}
+	return c
+}
+
+// SetLimit sets the limit for the key. A limit of 0 means unlimited.
+func (qm *QuotaManager) SetLimit(key string, limit int64) {
+	qm.mtx.Lock()
+	defer qm.mtx.Unlock()
+	c := qm.counter(key)
+	c.limit, c.hasLimit = limit, true
+}
+
+// SetParent sets the parent of the key. Returns an error if this would create
+// a cycle.
+func (qm *QuotaManager) SetParent(key, parent string) error {
+	qm.mtx.Lock()
+	defer qm.mtx.Unlock()
+	for p := parent; p != ""; p = qm.counter(p).parent {
+		if p == key {
+			return fmt.Errorf(
+				"setting parent of %q to %q creates a cycle", key, parent,
+			)
+		}
+	}
+	qm.counter(key).parent = parent
+	return nil
+}
+
+func (qm *QuotaManager) limitOf(c *quotaCounter) int64 {
+	if c.hasLimit {
+		return c.limit
+	}
+	return qm.opts.DefaultLimit
+}
+
+// maybeReset resets the counter's usage if its window has passed. The lock
+// must be held.
+func (qm *QuotaManager) maybeReset(c *quotaCounter, now time.Time) {
+	if qm.opts.ResetEvery <= 0 || c.resetAt.IsZero() {
+		return
+	}
+	if !now.Before(c.resetAt) {
+		c.used, c.resetAt = 0, time.Time{}
+	}
+}
+
+// chain returns the key's counter and those of its ancestors, followed by
+// the global counter. The lock must be held.
+func (qm *QuotaManager) chain(key string) []*quotaCounter {
+	var cs []*quotaCounter
+	for k := key; k != ""; {
+		c := qm.counter(k)
+		cs = append(cs, c)
+		k = c.parent
+	}
+	return append(cs, &qm.global)
+}
+
+// Acquire acquires n from the key, its ancestors, and the global limit.
+// Returns a *QuotaError (matching ErrQuotaExceeded) for the first limit that
+// would be exceeded, in which case nothing is acquired.
+func (qm *QuotaManager) Acquire(key string, n int64) error {
+	qm.mtx.Lock()
+	defer qm.mtx.Unlock()
+	now := time.Now()
+	cs := qm.chain(key)
+	for _, c := range cs {
+		qm.maybeReset(c, now)
+		if limit := qm.limitOf(c); limit > 0 && c.used+n > limit {
+			return &QuotaError{
+				Key: c.key, Limit: limit, Used: c.used, Requested: n,
+			}
+		}
+	}
+	for _, c := range cs {
+		c.used += n
+		if qm.opts.ResetEvery > 0 && c.resetAt.IsZero() {
+			c.resetAt = now.Add(qm.opts.ResetEvery)
+		}
+	}
+	return nil
+}
+
+// Release releases n from the key, its ancestors, and the global usage.
+// Usage doesn't go below 0 (e.g., if it was reset in the meantime).
+func (qm *QuotaManager) Release(key string, n int64) {
+	qm.mtx.Lock()
+	defer qm.mtx.Unlock()
+	for _, c := range qm.chain(key) {
+		c.used -= n
+		if c.used < 0 {
+			c.used = 0
+		}
+	}
+}
+
+// Usage returns the usage of the key.
+func (qm *QuotaManager) Usage(key string) QuotaUsage {
+	qm.mtx.Lock()
+	defer qm.mtx.Unlock()
+	now := time.Now()
+	if key == "" {
+		qm.maybeReset(&qm.global, now)
+		return qm.usage(&qm.global)
+	}
+	c, ok := qm.keys[key]
+	if !ok {
+		return QuotaUsage{Key: key, Limit: qm.opts.DefaultLimit}
+	}
+	qm.maybeReset(c, now)
+	return qm.usage(c)
+}
+
+func (qm *QuotaManager) usage(c *quotaCounter) QuotaUsage {
+	return QuotaUsage{
+		Key:     c.key,
+		Parent:  c.parent,
+		Used:    c.used,
+		Limit:   qm.limitOf(c),
+		ResetAt: c.resetAt,
+	}
+}
+
+// Snapshot returns the global usage followed by the usage of every known key,
+// sorted by key.
+func (qm *QuotaManager) Snapshot() []QuotaUsage {
+	qm.mtx.Lock()
+	defer qm.mtx.Unlock()
+	now := time.Now()
+	qm.maybeReset(&qm.global, now)
+	usages := make([]QuotaUsage, 0, len(qm.keys)+1)
+	usages = append(usages, qm.usage(&qm.global))
+	for _, c := range qm.keys {
+		qm.maybeReset(c, now)
+		usages = append(usages, qm.usage(c))
+	}
+	sort.Slice(usages[1:], func(i, j int) bool {
+		return usages[i+1].Key < usages[j+1].Key
+	})
+	return usages
+}
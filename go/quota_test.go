@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuotaManager(t *testing.T) {
+	qm := NewQuotaManager(QuotaOpts{DefaultLimit: 5, GlobalLimit: 12})
+	qm.SetLimit("tenant", 8)
+	qm.SetLimit("unlimited", 0)
+	if err := qm.SetParent("a", "tenant"); err != nil {
+		t.Fatal(err)
+	}
+	qm.SetParent("b", "tenant")
+	if err := qm.SetParent("tenant", "a"); err == nil {
+		t.Fatal("expected cycle error")
+	}
+
+	if err := qm.Acquire("a", 5); err != nil {
+		t.Fatal(err)
+	}
+	var qe *QuotaError
+	err := qm.Acquire("a", 1)
+	if !errors.Is(err, ErrQuotaExceeded) || !errors.As(err, &qe) {
+		t.Fatalf("expected QuotaError, got %v", err)
+	}
+	if qe.Key != "a" || qe.Used != 5 || qe.Limit != 5 {
+		t.Fatalf("unexpected error: %+v", qe)
+	}
+	// Parent limit
+	if err := qm.Acquire("b", 4); !errors.As(err, &qe) || qe.Key != "tenant" {
+		t.Fatalf("expected tenant limit error, got %v", err)
+	}
+	if err := qm.Acquire("b", 3); err != nil {
+		t.Fatal(err)
+	}
+	// Global limit
+	if err := qm.Acquire("unlimited", 5); !errors.As(err, &qe) || qe.Key != "" {
+		t.Fatalf("expected global limit error, got %v", err)
+	}
+	qm.Release("a", 5)
+	if err := qm.Acquire("unlimited", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	snap := qm.Snapshot()
+	want := []QuotaUsage{
+		{Key: "", Used: 8, Limit: 12},
+		{Key: "a", Parent: "tenant", Used: 0, Limit: 5},
+		{Key: "b", Parent: "tenant", Used: 3, Limit: 5},
+		{Key: "tenant", Used: 3, Limit: 8},
+		{Key: "unlimited", Used: 5, Limit: 0},
+	}
+	if len(snap) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, snap)
+	}
+	for i := range want {
+		if snap[i] != want[i] {
+			t.Fatalf("%d: expected %+v, got %+v", i, want[i], snap[i])
+		}
+	}
+}
+
+func TestQuotaManagerReset(t *testing.T) {
+	qm := NewQuotaManager(QuotaOpts{
+		DefaultLimit: 2,
+		ResetEvery:   time.Millisecond * 20,
+	})
+	qm.Acquire("k", 2)
+	if err := qm.Acquire("k", 1); err == nil {
+		t.Fatal("expected quota exceeded")
+	}
+	if u := qm.Usage("k"); u.ResetAt.IsZero() {
+		t.Fatal("expected reset time")
+	}
+	time.Sleep(time.Millisecond * 25)
+	if err := qm.Acquire("k", 2); err != nil {
+		t.Fatal(err)
+	}
+}
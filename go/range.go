@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"iter"
+)
+
+// Range returns a sequence of the integers from start (inclusive) to end
+// (exclusive), incrementing by step. If step is negative, the sequence counts
+// down, stopping once the values are less than or equal to end. Panics if step
+// is 0.
+func Range(start, end, step int) iter.Seq[int] {
+	if step == 0 {
+		panic("Range step cannot be 0")
+	}
+	// The loops stop before stepping past end rather than checking after, so
+	// they can't overflow. The distances to end are computed as uints since
+	// they can be greater than math.MaxInt.
+	return func(yield func(int) bool) {
+		if step > 0 {
+			for i := start; i < end; i += step {
+				if !yield(i) || uint(end)-uint(i) <= uint(step) {
+					return
+				}
+			}
+		} else {
+			for i := start; i > end; i += step {
+				if !yield(i) || uint(i)-uint(end) <= uint(-step) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// RangeSlice returns a slice of the integers from 0 to n (exclusive).
+func RangeSlice(n int) []int {
+	s := make([]int, max(n, 0))
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
+
+// Span is a closed interval of values, [Start, End]. A span with a Start
+// greater than its End is empty.
+type Span[T Ordered] struct {
+	Start T
+	End   T
+}
+
+// NewSpan returns a new Span of the 2 values, ordering them so the span is
+// never empty.
+func NewSpan[T Ordered](a, b T) Span[T] {
+	if b < a {
+		a, b = b, a
+	}
+	return Span[T]{Start: a, End: b}
+}
+
+// IsEmpty returns whether the span contains no values (Start > End).
+func (s Span[T]) IsEmpty() bool {
+	return s.Start > s.End
+}
+
+// Contains returns whether the value is within the span.
+func (s Span[T]) Contains(t T) bool {
+	return s.Start <= t && t <= s.End
+}
+
+// ContainsSpan returns whether the other span is entirely within the span.
+// An empty span is contained by every span.
+func (s Span[T]) ContainsSpan(other Span[T]) bool {
+	return other.IsEmpty() || (s.Start <= other.Start && other.End <= s.End)
+}
+
+// Overlaps returns whether the spans have any values in common.
+func (s Span[T]) Overlaps(other Span[T]) bool {
+	return !s.IsEmpty() && !other.IsEmpty() &&
+		s.Start <= other.End && other.Start <= s.End
+}
+
+// Intersect returns the span of values in both spans, returning false if the
+// spans don't overlap.
+func (s Span[T]) Intersect(other Span[T]) (Span[T], bool) {
+	if !s.Overlaps(other) {
+		return Span[T]{}, false
+	}
+	return Span[T]{Start: max(s.Start, other.Start), End: min(s.End, other.End)},
+		true
+}
+
+// Clamp returns the value clamped to the span. The span must not be empty.
+func (s Span[T]) Clamp(t T) T {
+	return min(max(t, s.Start), s.End)
+}
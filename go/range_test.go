@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		start, end, step int
+		want             []int
+	}{
+		{0, 5, 1, []int{0, 1, 2, 3, 4}},
+		{1, 10, 3, []int{1, 4, 7}},
+		{5, 0, -2, []int{5, 3, 1}},
+		{3, 3, 1, nil},
+		{3, 0, 1, nil},
+		{0, 3, -1, nil},
+		// Stepping past end would overflow
+		{math.MaxInt - 1, math.MaxInt, 2, []int{math.MaxInt - 1}},
+		{math.MaxInt - 4, math.MaxInt, 2, []int{
+			math.MaxInt - 4, math.MaxInt - 2,
+		}},
+		{math.MinInt + 1, math.MinInt, -2, []int{math.MinInt + 1}},
+		{math.MinInt + 2, math.MinInt, -1, []int{
+			math.MinInt + 2, math.MinInt + 1,
+		}},
+		{math.MinInt, math.MaxInt, math.MaxInt, []int{
+			math.MinInt, -1, math.MaxInt - 1,
+		}},
+		{math.MaxInt, math.MinInt, math.MinInt, []int{math.MaxInt, -1}},
+	}
+	for _, test := range tests {
+		got := slices.Collect(Range(test.start, test.end, test.step))
+		if !SliceEq(got, test.want) {
+			t.Errorf(
+				"Range(%d, %d, %d): expected %v, got %v",
+				test.start, test.end, test.step, test.want, got,
+			)
+		}
+	}
+	for i := range Range(0, 100, 1) {
+		if i == 2 {
+			break
+		}
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for step of 0")
+			}
+		}()
+		Range(0, 1, 0)
+	}()
+
+	if got := RangeSlice(4); !SliceEq(got, []int{0, 1, 2, 3}) {
+		t.Errorf("unexpected slice: %v", got)
+	}
+	if got := RangeSlice(-1); len(got) != 0 {
+		t.Errorf("expected empty slice, got %v", got)
+	}
+}
+
+func TestSpan(t *testing.T) {
+	s := NewSpan(10, 1)
+	if s.Start != 1 || s.End != 10 || s.IsEmpty() {
+		t.Fatalf("unexpected span: %+v", s)
+	}
+	if !s.Contains(1) || !s.Contains(10) || s.Contains(11) {
+		t.Fatal("bad Contains results")
+	}
+	if s.Clamp(-5) != 1 || s.Clamp(5) != 5 || s.Clamp(50) != 10 {
+		t.Fatal("bad Clamp results")
+	}
+	if !s.ContainsSpan(NewSpan(2, 10)) || s.ContainsSpan(NewSpan(0, 2)) {
+		t.Fatal("bad ContainsSpan results")
+	}
+
+	if !s.Overlaps(NewSpan(10, 20)) || s.Overlaps(NewSpan(11, 20)) {
+		t.Fatal("bad Overlaps results")
+	}
+	empty := Span[int]{Start: 5, End: 4}
+	if !empty.IsEmpty() || s.Overlaps(empty) || !s.ContainsSpan(empty) {
+		t.Fatal("bad empty span results")
+	}
+	if got, ok := s.Intersect(NewSpan(5, 15)); !ok || got != NewSpan(5, 10) {
+		t.Fatalf("unexpected intersection: %+v (%v)", got, ok)
+	}
+	if _, ok := s.Intersect(NewSpan(20, 30)); ok {
+		t.Fatal("expected no intersection")
+	}
+
+	letters := NewSpan("a", "m")
+	if !letters.Contains("hello") || letters.Contains("zebra") {
+		t.Fatal("bad string span results")
+	}
+}
@@ -0,0 +1,198 @@
+package utils
+
+// ReadOnlyMap is a read-only view of a map.
+type ReadOnlyMap[K comparable, V any] interface {
+	// Get gets the value for the key or returns the default.
+	Get(key K) V
+	// GetOk gets the value for the key, returning true if it exists.
+	GetOk(key K) (V, bool)
+	// ContainsKey returns whether the map contains the given key.
+	ContainsKey(key K) bool
+	// Len returns the number of key/value pairs in the map.
+	Len() int
+	// Range iterates over each key/value pair, applying a given function that
+	// returns whether the iterations should continue.
+	Range(f func(K, V) bool)
+}
+
+// ReadOnlySet is a read-only view of a set.
+type ReadOnlySet[T comparable] interface {
+	// Contains returns whether the set contains the item.
+	Contains(item T) bool
+	// Len returns the number of items in the set.
+	Len() int
+	// Range iterates over each item, applying a given function that returns
+	// whether the iterations should continue.
+	Range(f func(T) bool)
+}
+
+// ReadOnlySlice is a read-only view of a slice.
+type ReadOnlySlice[T any] interface {
+	// Get gets the element at the given index, panicking if the index is out
+	// of bounds.
+	Get(i int) T
+	// GetSafe gets the element at the given index, returning false if the
+	// index is out of bounds.
+	GetSafe(i int) (T, bool)
+	// Len returns the length of the slice.
+	Len() int
+	// Index finds the first element satisfying the predicate, returning the
+	// index or -1.
+	Index(f func(T) bool) int
+	// Contains returns whether the slice contains an element satisfying the
+	// predicate.
+	Contains(f func(T) bool) bool
+	// Range iterates over each index and element in order, applying a given
+	// function that returns whether the iterations should continue.
+	Range(f func(int, T) bool)
+}
+
+var (
+	_ ReadOnlyMap[int, int] = (*Map[int, int])(nil)
+	_ ReadOnlyMap[int, int] = (*ShardedMap[int, int])(nil)
+	_ ReadOnlyMap[int, int] = (*BiMap[int, int])(nil)
+	_ ReadOnlyMap[int, int] = (*FrozenMap[int, int])(nil)
+
+	_ ReadOnlySet[int] = (*Set[int])(nil)
+	_ ReadOnlySet[int] = (*FrozenSet[int])(nil)
+
+	_ ReadOnlySlice[int] = (*Slice[int])(nil)
+	_ ReadOnlySlice[int] = (*SlicePtr[int])(nil)
+	_ ReadOnlySlice[int] = (*FrozenSlice[int])(nil)
+)
+
+// FrozenMap is an immutable map. Since it can't be modified, it is safe for
+// concurrent use.
+type FrozenMap[K comparable, V any] struct {
+	m *Map[K, V]
+}
+
+// NewFrozenMap creates a new FrozenMap with a copy of the given map.
+func NewFrozenMap[K comparable, V any](m map[K]V) *FrozenMap[K, V] {
+	return &FrozenMap[K, V]{m: MapFromMap(CloneMap(m))}
+}
+
+// Get gets the value for the key or returns the default.
+func (fm *FrozenMap[K, V]) Get(key K) V {
+	return fm.m.Get(key)
+}
+
+// GetOk gets the value for the key, returning true if it exists.
+func (fm *FrozenMap[K, V]) GetOk(key K) (V, bool) {
+	return fm.m.GetOk(key)
+}
+
+// ContainsKey returns whether the map contains the given key.
+func (fm *FrozenMap[K, V]) ContainsKey(key K) bool {
+	return fm.m.ContainsKey(key)
+}
+
+// Len returns the number of key/value pairs in the map.
+func (fm *FrozenMap[K, V]) Len() int {
+	return fm.m.Len()
+}
+
+// Range iterates over each key/value pair in random order, applying a given
+// function that returns whether the iterations should continue.
+func (fm *FrozenMap[K, V]) Range(f func(K, V) bool) {
+	fm.m.Range(f)
+}
+
+// ToGoMap returns a copy of the map.
+func (fm *FrozenMap[K, V]) ToGoMap() map[K]V {
+	return fm.m.ToGoMap()
+}
+
+// Thaw returns a mutable Map with a copy of the map's contents.
+func (fm *FrozenMap[K, V]) Thaw() *Map[K, V] {
+	return fm.m.Clone()
+}
+
+// FrozenSet is an immutable set. Since it can't be modified, it is safe for
+// concurrent use.
+type FrozenSet[T comparable] struct {
+	s *Set[T]
+}
+
+// NewFrozenSet creates a new FrozenSet containing the given items.
+func NewFrozenSet[T comparable](items ...T) *FrozenSet[T] {
+	return &FrozenSet[T]{s: SetFromSlice(items)}
+}
+
+// Contains returns whether the set contains the item.
+func (fs *FrozenSet[T]) Contains(item T) bool {
+	return fs.s.Contains(item)
+}
+
+// Len returns the number of items in the set.
+func (fs *FrozenSet[T]) Len() int {
+	return fs.s.Len()
+}
+
+// Range iterates over each item in random order, applying a given function
+// that returns whether the iterations should continue.
+func (fs *FrozenSet[T]) Range(f func(T) bool) {
+	fs.s.Range(f)
+}
+
+// ToSlice returns the items of the set as a slice.
+func (fs *FrozenSet[T]) ToSlice() []T {
+	return fs.s.ToSlice()
+}
+
+// Thaw returns a mutable Set with a copy of the set's contents.
+func (fs *FrozenSet[T]) Thaw() *Set[T] {
+	return fs.s.Clone()
+}
+
+// FrozenSlice is an immutable slice. Since it can't be modified, it is safe
+// for concurrent use.
+type FrozenSlice[T any] struct {
+	sp *SlicePtr[T]
+}
+
+// NewFrozenSlice creates a new FrozenSlice with a copy of the given slice.
+func NewFrozenSlice[T any](s []T) *FrozenSlice[T] {
+	s = CloneSlice(s)
+	return &FrozenSlice[T]{sp: NewSlicePtr(&s)}
+}
+
+// Get gets the element at the given index, panicking if the index is out of
+// bounds.
+func (fs *FrozenSlice[T]) Get(i int) T {
+	return fs.sp.Get(i)
+}
+
+// GetSafe gets the element at the given index, returning false if the index
+// is out of bounds.
+func (fs *FrozenSlice[T]) GetSafe(i int) (T, bool) {
+	return fs.sp.GetSafe(i)
+}
+
+// Len returns the length of the slice.
+func (fs *FrozenSlice[T]) Len() int {
+	return fs.sp.Len()
+}
+
+// Index finds the first element satisfying the predicate, returning the
+// index or -1.
+func (fs *FrozenSlice[T]) Index(f func(T) bool) int {
+	return fs.sp.Index(f)
+}
+
+// Contains returns whether the slice contains an element satisfying the
+// predicate.
+func (fs *FrozenSlice[T]) Contains(f func(T) bool) bool {
+	return fs.sp.Contains(f)
+}
+
+// Range iterates over each index and element in order, applying a given
+// function that returns whether the iterations should continue.
+func (fs *FrozenSlice[T]) Range(f func(int, T) bool) {
+	fs.sp.Range(f)
+}
+
+// ToSlice returns a copy of the slice.
+func (fs *FrozenSlice[T]) ToSlice() []T {
+	return CloneSlice(fs.sp.Data())
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+)
+
+func sumReadOnlyMap(m ReadOnlyMap[string, int]) (sum int) {
+	m.Range(func(_ string, v int) bool {
+		sum += v
+		return true
+	})
+	return
+}
+
+func TestFrozen(t *testing.T) {
+	goMap := map[string]int{"a": 1, "b": 2}
+	m := MapFromMap(goMap)
+	fm := m.Freeze()
+	m.Set("c", 3)
+	if fm.Len() != 2 || fm.ContainsKey("c") || fm.Get("b") != 2 {
+		t.Fatal("frozen map changed")
+	}
+	if sumReadOnlyMap(m) != 6 || sumReadOnlyMap(fm) != 3 {
+		t.Fatal("unexpected sums")
+	}
+	thawed := fm.Thaw()
+	thawed.Set("d", 4)
+	if fm.ContainsKey("d") {
+		t.Fatal("frozen map changed by thawed map")
+	}
+
+	s := SetFromSlice([]int{1, 2})
+	fs := s.Freeze()
+	s.Insert(3)
+	if fs.Len() != 2 || fs.Contains(3) || !fs.Contains(1) {
+		t.Fatal("frozen set changed")
+	}
+	var rs ReadOnlySet[int] = NewUnionView[int](fs, NewFrozenSet(5))
+	if rs.Len() != 3 || !rs.Contains(5) {
+		t.Fatal("unexpected union of frozen sets")
+	}
+
+	data := []int{1, 2, 3}
+	fsl := NewSlice(data).Freeze()
+	data[0] = 100
+	if fsl.Get(0) != 1 || fsl.Len() != 3 {
+		t.Fatal("frozen slice changed")
+	}
+	var rsl ReadOnlySlice[int] = fsl
+	sum := 0
+	rsl.Range(func(i, n int) bool {
+		sum += n
+		return i < 1
+	})
+	if sum != 3 {
+		t.Fatalf("expected sum of 3, got %d", sum)
+	}
+	if i := rsl.Index(func(n int) bool { return n == 3 }); i != 2 {
+		t.Fatalf("expected index 2, got %d", i)
+	}
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"io"
+	"sync"
+)
+
+// LockedReader is a wrapper to lock reads on an underlying reader.
+type LockedReader struct {
+	r   io.Reader
+	mtx sync.Mutex
+}
+
+// NewLockedReader returns a new LockedReader.
+func NewLockedReader(r io.Reader) *LockedReader {
+	return &LockedReader{r: r}
+}
+
+// Read locks (and unlocks) the reader and reads from the underlying reader.
+func (lr *LockedReader) Read(p []byte) (n int, err error) {
+	lr.Lock()
+	n, err = lr.LockedRead(p)
+	lr.Unlock()
+	return
+}
+
+// LockedRead reads from the underlying reader without locking. Useful if the
+// lock is already held.
+func (lr *LockedReader) LockedRead(p []byte) (n int, err error) {
+	return lr.r.Read(p)
+}
+
+// TryRead attempts to lock the reader and read from the underlying reader.
+// Returns 0, nil, false if it failed to lock, otherwise, returns true along
+// with the results of the read.
+func (lr *LockedReader) TryRead(p []byte) (n int, err error, locked bool) {
+	if locked = lr.TryLock(); !locked {
+		return
+	}
+	n, err = lr.LockedRead(p)
+	lr.Unlock()
+	return
+}
+
+// ReadFull locks (and unlocks) the reader and reads exactly len(p) bytes
+// (see io.ReadFull).
+func (lr *LockedReader) ReadFull(p []byte) (n int, err error) {
+	lr.Lock()
+	n, err = lr.LockedReadFull(p)
+	lr.Unlock()
+	return
+}
+
+// LockedReadFull reads exactly len(p) bytes without locking (see
+// io.ReadFull).
+func (lr *LockedReader) LockedReadFull(p []byte) (n int, err error) {
+	return io.ReadFull(lr.r, p)
+}
+
+// TryReadFull attempts to lock (and subsequently unlock) the reader and read
+// exactly len(p) bytes (see io.ReadFull). Returns false if locking failed.
+func (lr *LockedReader) TryReadFull(
+	p []byte,
+) (n int, err error, locked bool) {
+	if locked = lr.TryLock(); !locked {
+		return
+	}
+	n, err = lr.LockedReadFull(p)
+	lr.Unlock()
+	return
+}
+
+// LockReader locks the reader and returns the underlying reader.
+func (lr *LockedReader) LockReader() io.Reader {
+	lr.Lock()
+	return lr.r
+}
+
+// TryLockReader attempts to lock the reader, returning false if it failed to
+// lock.
+func (lr *LockedReader) TryLockReader() (io.Reader, bool) {
+	if !lr.TryLock() {
+		return nil, false
+	}
+	return lr.r, true
+}
+
+// Unwrap returns the underlying reader.
+func (lr *LockedReader) Unwrap() io.Reader {
+	return lr.r
+}
+
+// Lock locks the reader.
+func (lr *LockedReader) Lock() {
+	lr.mtx.Lock()
+}
+
+// TryLock attempts to lock the reader, returning true if successful.
+func (lr *LockedReader) TryLock() bool {
+	return lr.mtx.TryLock()
+}
+
+// Unlock unlocks the reader.
+func (lr *LockedReader) Unlock() {
+	lr.mtx.Unlock()
+}
+
+// LockedReadWriter locks reads and writes on an underlying io.ReadWriter
+// (e.g., a net.Conn shared between goroutines). Reads and writes use separate
+// locks, so a read doesn't block a write and vice versa.
+type LockedReadWriter struct {
+	// Reader is used for the reads.
+	Reader *LockedReader
+	// Writer is used for the writes.
+	Writer *LockedWriter
+}
+
+// NewLockedReadWriter returns a new LockedReadWriter.
+func NewLockedReadWriter(rw io.ReadWriter) *LockedReadWriter {
+	return &LockedReadWriter{
+		Reader: NewLockedReader(rw),
+		Writer: NewLockedWriter(rw),
+	}
+}
+
+// Read locks (and unlocks) the reading side and reads from the underlying
+// reader.
+func (lrw *LockedReadWriter) Read(p []byte) (int, error) {
+	return lrw.Reader.Read(p)
+}
+
+// ReadFull locks (and unlocks) the reading side and reads exactly len(p)
+// bytes (see io.ReadFull).
+func (lrw *LockedReadWriter) ReadFull(p []byte) (int, error) {
+	return lrw.Reader.ReadFull(p)
+}
+
+// Write locks (and unlocks) the writing side and writes to the underlying
+// writer.
+func (lrw *LockedReadWriter) Write(p []byte) (int, error) {
+	return lrw.Writer.Write(p)
+}
+
+// WriteAll locks (and unlocks) the writing side and attempts to write all of
+// the bytes passed. Returns err == nil iff n == len(p).
+func (lrw *LockedReadWriter) WriteAll(p []byte) (int64, error) {
+	return lrw.Writer.WriteAll(p)
+}
+
+// Close closes the underlying ReadWriter if it is an io.Closer (see
+// LockedWriter.Close). Only the writing side is locked, so a Close can
+// unblock a pending Read (as with a net.Conn).
+func (lrw *LockedReadWriter) Close() error {
+	return lrw.Writer.Close()
+}
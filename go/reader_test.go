@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLockedReader(t *testing.T) {
+	lr := NewLockedReader(strings.NewReader("0123456789"))
+	p := make([]byte, 4)
+	if n, err := lr.ReadFull(p); err != nil || n != 4 || string(p) != "0123" {
+		t.Fatalf("unexpected read: %d, %v, %q", n, err, p)
+	}
+
+	r := lr.LockReader()
+	if _, _, locked := lr.TryRead(p); locked {
+		t.Fatal("expected TryRead to fail to lock")
+	}
+	if _, _, locked := lr.TryReadFull(p); locked {
+		t.Fatal("expected TryReadFull to fail to lock")
+	}
+	if _, ok := lr.TryLockReader(); ok {
+		t.Fatal("expected TryLockReader to fail to lock")
+	}
+	r.Read(p[:1])
+	lr.Unlock()
+
+	n, err, locked := lr.TryRead(p)
+	if !locked || err != nil || string(p[:n]) != "5678" {
+		t.Fatalf("unexpected read: %d, %v, %v, %q", n, err, locked, p[:n])
+	}
+	if n, err := lr.ReadFull(p); err != io.ErrUnexpectedEOF || n != 1 {
+		t.Fatalf("expected ErrUnexpectedEOF, got %d, %v", n, err)
+	}
+}
+
+func TestLockedReadWriter(t *testing.T) {
+	c1, c2 := net.Pipe()
+	lrw := NewLockedReadWriter(c1)
+
+	const numWriters, msgLen = 10, 8
+	var wg sync.WaitGroup
+	for i := 0; i < numWriters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lrw.WriteAll(bytes.Repeat([]byte{byte('a' + i)}, msgLen))
+		}(i)
+	}
+	got := make([]byte, numWriters*msgLen)
+	if _, err := io.ReadFull(c2, got); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	// Each message should have been written contiguously.
+	for i := 0; i < len(got); i += msgLen {
+		if msg := got[i : i+msgLen]; !bytes.Equal(
+			msg, bytes.Repeat(msg[:1], msgLen),
+		) {
+			t.Fatalf("interleaved message: %q", msg)
+		}
+	}
+
+	go c2.Write([]byte("pong"))
+	p := make([]byte, 4)
+	if _, err := lrw.ReadFull(p); err != nil || string(p) != "pong" {
+		t.Fatalf("unexpected read: %v, %q", err, p)
+	}
+
+	// Close should unblock a pending read.
+	done := make(chan error, 1)
+	go func() {
+		_, err := lrw.Read(p)
+		done <- err
+	}()
+	if err := lrw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err == nil {
+		t.Fatal("expected error from read after close")
+	}
+	c2.Close()
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// RepeaterOpts are options for a Repeater.
+type RepeaterOpts struct {
+	// Interval is the time between the end of one run and the start of the
+	// next. Must be positive.
+	Interval time.Duration
+	// Jitter randomly adjusts each interval by up to the given fraction
+	// (clamped to [0, 1]) in either direction. For example, 0.1 with an
+	// interval of 10s gives waits between 9s and 11s.
+	Jitter float64
+	// Immediate is whether to run as soon as the Repeater starts rather than
+	// after the first interval.
+	Immediate bool
+	// OnError, if not nil, is called with each error returned by the
+	// function. If the function panicked, the error is a *PanicError.
+	OnError func(error)
+}
+
+// RepeaterStats are the stats of a Repeater.
+type RepeaterStats struct {
+	// Runs is the number of times the function has been run.
+	Runs uint64
+	// Failures is the number of runs that returned an error or panicked.
+	Failures uint64
+	// Skipped is the number of runs skipped while paused.
+	Skipped uint64
+	// LastRun is when the last run started.
+	LastRun time.Time
+	// LastDuration is how long the last run took.
+	LastDuration time.Duration
+	// LastErr is the error of the last run (nil if it succeeded).
+	LastErr error
+}
+
+// Repeater runs a function repeatedly at an interval. Runs never overlap.
+// It implements Runner, so it can be added to a Supervisor.
+type Repeater struct {
+	f      func(context.Context) error
+	opts   RepeaterOpts
+	paused atomic.Bool
+	stats  Mutex[RepeaterStats]
+}
+
+// NewRepeater creates a new Repeater for the function. Panics if the
+// interval isn't positive.
+func NewRepeater(
+	f func(context.Context) error, opts RepeaterOpts,
+) *Repeater {
+	if opts.Interval <= 0 {
+		panic("utils: non-positive interval for NewRepeater")
+	}
+	opts.Jitter = min(max(opts.Jitter, 0), 1)
+	return &Repeater{f: f, opts: opts}
+}
+
+// Run runs the function repeatedly until the context is done, returning
+// nil. The context is passed to each run.
+func (r *Repeater) Run(ctx context.Context) error {
+	if r.opts.Immediate {
+		r.runOnce(ctx)
+	}
+	timer := time.NewTimer(r.nextWait())
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil
+		}
+		r.runOnce(ctx)
+		timer.Reset(r.nextWait())
+	}
+}
+
+func (r *Repeater) nextWait() time.Duration {
+	wait := r.opts.Interval
+	if r.opts.Jitter > 0 {
+		delta := r.opts.Jitter * float64(wait) * (2*rand.Float64() - 1)
+		wait += time.Duration(delta)
+	}
+	return wait
+}
+
+func (r *Repeater) runOnce(ctx context.Context) {
+	if r.paused.Load() {
+		r.stats.Apply(func(s *RepeaterStats) { s.Skipped++ })
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	start := time.Now()
+	var err error
+	if perr := Safe(func() { err = r.f(ctx) }); perr != nil {
+		err = perr
+	}
+	dur := time.Since(start)
+	r.stats.Apply(func(s *RepeaterStats) {
+		s.Runs++
+		if err != nil {
+			s.Failures++
+		}
+		s.LastRun, s.LastDuration, s.LastErr = start, dur, err
+	})
+	if err != nil && r.opts.OnError != nil {
+		r.opts.OnError(err)
+	}
+}
+
+// Pause pauses the Repeater, skipping runs until Resume is called. A run in
+// progress isn't affected. Returns false if it was already paused.
+func (r *Repeater) Pause() bool {
+	return !r.paused.Swap(true)
+}
+
+// Resume resumes the Repeater, with the next run happening at the end of the
+// current interval. Returns false if it wasn't paused.
+func (r *Repeater) Resume() bool {
+	return r.paused.Swap(false)
+}
+
+// IsPaused returns whether the Repeater is paused.
+func (r *Repeater) IsPaused() bool {
+	return r.paused.Load()
+}
+
+// Stats returns the stats of the Repeater.
+func (r *Repeater) Stats() RepeaterStats {
+	defer r.stats.Unlock()
+	return *r.stats.Lock()
+}
+
+var _ Runner = (*Repeater)(nil)
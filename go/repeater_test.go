@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRepeater(t *testing.T) {
+	var calls atomic.Int32
+	errTest := errors.New("test")
+	var errs atomic.Int32
+	r := NewRepeater(func(ctx context.Context) error {
+		switch calls.Add(1) {
+		case 2:
+			return errTest
+		case 3:
+			panic("boom")
+		}
+		return nil
+	}, RepeaterOpts{
+		Interval:  time.Millisecond * 5,
+		Jitter:    0.5,
+		Immediate: true,
+		OnError:   func(error) { errs.Add(1) },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for r.Stats().Runs < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 5 runs, got %+v", r.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !r.Pause() || r.Pause() {
+		t.Fatal("expected only first Pause to succeed")
+	}
+	time.Sleep(time.Millisecond * 10)
+	paused := r.Stats()
+	time.Sleep(time.Millisecond * 30)
+	stats := r.Stats()
+	if stats.Runs != paused.Runs || stats.Skipped == 0 {
+		t.Fatalf("expected runs to be skipped while paused: %+v", stats)
+	}
+	if !r.Resume() || r.IsPaused() {
+		t.Fatal("expected Resume to succeed")
+	}
+	for r.Stats().Runs == stats.Runs {
+		if time.Now().After(deadline) {
+			t.Fatal("no runs after Resume")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	stats = r.Stats()
+	if stats.Failures != 2 || errs.Load() != 2 {
+		t.Fatalf("expected 2 failures, got %+v", stats)
+	}
+	if stats.LastErr != nil || stats.LastRun.IsZero() {
+		t.Fatalf("unexpected last run stats: %+v", stats)
+	}
+}
+
+func TestRepeaterNotImmediate(t *testing.T) {
+	var calls atomic.Int32
+	r := NewRepeater(func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}, RepeaterOpts{Interval: time.Hour})
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*10,
+	)
+	defer cancel()
+	r.Run(ctx)
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("expected no runs, got %d", n)
+	}
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrExists means something already exists.
+var ErrExists = errors.New("already exists")
+
+// OrphanPolicy determines what a Responder does with responses that have no
+// pending request.
+type OrphanPolicy int
+
+const (
+	// OrphanDrop drops orphaned responses.
+	OrphanDrop OrphanPolicy = iota
+	// OrphanKeep keeps orphaned responses so that a later call to Expect with
+	// the same ID is completed immediately. Kept responses are never expired, so
+	// this should only be used when orphans are known to be expected later.
+	OrphanKeep
+)
+
+// ResponderOpts are options for a Responder.
+type ResponderOpts[K comparable, V any] struct {
+	// Timeout is the default timeout for requests from Expect. If 0, requests
+	// never time out.
+	Timeout time.Duration
+	// OrphanPolicy is the policy for orphaned responses.
+	OrphanPolicy OrphanPolicy
+	// OnOrphan, if set, is called with every orphaned response (regardless of
+	// the policy).
+	OnOrphan func(id K, v V, err error)
+}
+
+// Responder correlates asynchronous responses with pending requests. A
+// request is registered using Expect, returning a Future that is completed
+// when the response is passed to Fulfill.
+type Responder[K comparable, V any] struct {
+	opts ResponderOpts[K, V]
+	m    *Mutex[responderState[K, V]]
+}
+
+type responderState[K comparable, V any] struct {
+	pending  map[K]*responderEntry[V]
+	orphans  map[K]responderResult[V]
+	isClosed bool
+}
+
+type responderEntry[V any] struct {
+	p     *Promise[V]
+	timer *time.Timer
+}
+
+type responderResult[V any] struct {
+	v   V
+	err error
+}
+
+// NewResponder creates a new Responder.
+func NewResponder[K comparable, V any](
+	opts ResponderOpts[K, V],
+) *Responder[K, V] {
+	return &Responder[K, V]{
+		opts: opts,
+		m: NewMutex(responderState[K, V]{
+			pending: make(map[K]*responderEntry[V]),
+			orphans: make(map[K]responderResult[V]),
+		}),
+	}
+}
+
+// Expect registers a pending request with the given ID, using the default
+// timeout, returning the Future completed with the response. If there is
+// already a request pending with the ID, the returned future is rejected with
+// ErrExists. If the Responder is closed, it is rejected with ErrClosed.
+func (r *Responder[K, V]) Expect(id K) *Future[V] {
+	return r.ExpectTimeout(id, r.opts.Timeout)
+}
+
+// ExpectTimeout is the same as Expect but uses the given timeout rather than
+// the default. If the timeout is reached, the future is rejected with
+// ErrTimedOut. A timeout of 0 means no timeout.
+func (r *Responder[K, V]) ExpectTimeout(
+	id K, timeout time.Duration,
+) *Future[V] {
+	state := r.m.Lock()
+	defer r.m.Unlock()
+	if state.isClosed {
+		return RejectedFuture[V](ErrClosed)
+	}
+	if _, ok := state.pending[id]; ok {
+		return RejectedFuture[V](ErrExists)
+	}
+	if res, ok := state.orphans[id]; ok {
+		delete(state.orphans, id)
+		p := NewPromise[V]()
+		p.Complete(res.v, res.err)
+		return p.Future()
+	}
+	e := &responderEntry[V]{p: NewPromise[V]()}
+	if timeout > 0 {
+		e.timer = time.AfterFunc(timeout, func() {
+			if r.remove(id, e) {
+				e.p.Reject(ErrTimedOut)
+			}
+		})
+	}
+	state.pending[id] = e
+	return e.p.Future()
+}
+
+// remove removes the entry for the given ID if it is the given entry,
+// returning true if it was removed.
+func (r *Responder[K, V]) remove(id K, e *responderEntry[V]) bool {
+	state := r.m.Lock()
+	defer r.m.Unlock()
+	if state.pending[id] != e {
+		return false
+	}
+	delete(state.pending, id)
+	return true
+}
+
+// Fulfill completes the pending request with the given ID with the response.
+// Returns false if there was no pending request, in which case, the response
+// is handled according to the OrphanPolicy.
+func (r *Responder[K, V]) Fulfill(id K, v V, err error) bool {
+	state := r.m.Lock()
+	e, ok := state.pending[id]
+	if ok {
+		delete(state.pending, id)
+	} else if r.opts.OrphanPolicy == OrphanKeep && !state.isClosed {
+		state.orphans[id] = responderResult[V]{v: v, err: err}
+	}
+	r.m.Unlock()
+
+	if !ok {
+		if r.opts.OnOrphan != nil {
+			r.opts.OnOrphan(id, v, err)
+		}
+		return false
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	return e.p.Complete(v, err)
+}
+
+// Cancel cancels the pending request with the given ID, rejecting its future
+// with ErrCanceled. Returns false if there was no pending request.
+func (r *Responder[K, V]) Cancel(id K) bool {
+	state := r.m.Lock()
+	e, ok := state.pending[id]
+	delete(state.pending, id)
+	r.m.Unlock()
+	if !ok {
+		return false
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	return e.p.Reject(ErrCanceled)
+}
+
+// Len returns the number of pending requests.
+func (r *Responder[K, V]) Len() int {
+	state := r.m.Lock()
+	defer r.m.Unlock()
+	return len(state.pending)
+}
+
+// Close closes the Responder, rejecting all pending requests with ErrClosed
+// and dropping all kept orphans. Returns false if already closed.
+func (r *Responder[K, V]) Close() bool {
+	state := r.m.Lock()
+	if state.isClosed {
+		r.m.Unlock()
+		return false
+	}
+	state.isClosed = true
+	pending := state.pending
+	state.pending, state.orphans = nil, nil
+	r.m.Unlock()
+
+	for _, e := range pending {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		e.p.Reject(ErrClosed)
+	}
+	return true
+}
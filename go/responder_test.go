@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResponder(t *testing.T) {
+	var orphans []int
+	r := NewResponder(ResponderOpts[int, string]{
+		OnOrphan: func(id int, _ string, _ error) {
+			orphans = append(orphans, id)
+		},
+	})
+
+	f1, f2 := r.Expect(1), r.Expect(2)
+	if _, err := r.Expect(1).Wait(); err != ErrExists {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if l := r.Len(); l != 2 {
+		t.Fatalf("expected 2 pending, got %d", l)
+	}
+	testErr := errors.New("test error")
+	if !r.Fulfill(2, "", testErr) {
+		t.Fatal("expected pending request")
+	}
+	if !r.Fulfill(1, "one", nil) {
+		t.Fatal("expected pending request")
+	}
+	if r.Fulfill(3, "three", nil) {
+		t.Fatal("unexpected pending request")
+	}
+	if s, err := f1.Wait(); err != nil || s != "one" {
+		t.Fatalf("expected \"one\", nil, got %q, %v", s, err)
+	}
+	if _, err := f2.Wait(); err != testErr {
+		t.Fatalf("expected test error, got %v", err)
+	}
+	if !SliceEq(orphans, []int{3}) {
+		t.Fatalf("expected orphans [3], got %v", orphans)
+	}
+
+	f := r.ExpectTimeout(4, time.Millisecond)
+	if _, err := f.Wait(); err != ErrTimedOut {
+		t.Fatalf("expected ErrTimedOut, got %v", err)
+	}
+	if r.Fulfill(4, "four", nil) {
+		t.Fatal("timed out request still pending")
+	}
+
+	f = r.Expect(5)
+	if !r.Cancel(5) {
+		t.Fatal("expected pending request")
+	}
+	if _, err := f.Wait(); err != ErrCanceled {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+
+	f = r.Expect(6)
+	if !r.Close() {
+		t.Fatal("responder unexpectedly closed")
+	}
+	if r.Close() {
+		t.Fatal("responder not closed")
+	}
+	if _, err := f.Wait(); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if _, err := r.Expect(7).Wait(); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestResponderKeepOrphans(t *testing.T) {
+	r := NewResponder(ResponderOpts[string, int]{OrphanPolicy: OrphanKeep})
+	if r.Fulfill("a", 1, nil) {
+		t.Fatal("unexpected pending request")
+	}
+	if n, err, ok := r.Expect("a").TryGet(); !ok || err != nil || n != 1 {
+		t.Fatalf("expected 1, nil, true, got %d, %v, %v", n, err, ok)
+	}
+	if _, _, ok := r.Expect("a").TryGet(); ok {
+		t.Fatal("orphan unexpectedly kept after being used")
+	}
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures Retry. The zero value retries every error forever
+// (until the context is done) using the default delays.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts (including the first).
+	// If 0, there is no limit.
+	MaxAttempts int
+	// MaxElapsed is the maximum time spent retrying. A retry isn't attempted
+	// if its delay would exceed it. If 0, there is no limit.
+	MaxElapsed time.Duration
+	// InitialDelay is the delay before the first retry. If 0, 100ms is used.
+	InitialDelay time.Duration
+	// MaxDelay is the maximum delay between attempts. If 0, 30s is used.
+	MaxDelay time.Duration
+	// Multiplier is what the delay is multiplied by after each retry. If less
+	// than 1, 2 is used.
+	Multiplier float64
+	// Jitter is the fraction (from 0 to 1) of each delay that is randomized,
+	// so that a delay d is chosen uniformly from [d*(1-Jitter), d]. Jitter
+	// keeps many clients from retrying in lockstep.
+	Jitter float64
+	// RetryIf, if not nil, is called with each error to decide whether it is
+	// retried. Errors wrapped with Permanent are never retried.
+	RetryIf func(err error) bool
+	// OnRetry, if not nil, is called before each retry's delay with the
+	// number of the attempt that failed (starting at 1), its error, and the
+	// delay before the next attempt.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// RetryError is returned by Retry when it gives up.
+type RetryError struct {
+	// Attempts is the number of attempts made.
+	Attempts int
+	// Err is the error from the last attempt.
+	Err error
+	// ctxErr is the cancellation error if the context was done.
+	ctxErr error
+}
+
+// Error implements the error interface.
+func (re *RetryError) Error() string {
+	s := fmt.Sprintf("after %d attempt", re.Attempts)
+	if re.Attempts != 1 {
+		s += "s"
+	}
+	if re.ctxErr != nil {
+		s += " (" + re.ctxErr.Error() + ")"
+	}
+	return s + ": " + re.Err.Error()
+}
+
+// Unwrap returns the last attempt's error, as well as an error wrapping
+// ErrCanceled and the context's error if the context was done.
+func (re *RetryError) Unwrap() []error {
+	if re.ctxErr != nil {
+		return []error{re.Err, re.ctxErr}
+	}
+	return []error{re.Err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (pe permanentError) Error() string {
+	return pe.err.Error()
+}
+
+func (pe permanentError) Unwrap() error {
+	return pe.err
+}
+
+// Permanent wraps the error so that Retry doesn't retry it. Retry returns the
+// wrapped error (unwrapped from the permanent wrapper) in its RetryError.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// Retry calls f until it succeeds or the policy gives up, waiting between
+// attempts with exponential backoff. If it gives up, a *RetryError wrapping
+// the last error is returned. If the context is done while waiting, it gives
+// up immediately (the context isn't checked while f is running, so f should
+// use it too if needed).
+func Retry(ctx context.Context, policy RetryPolicy, f func() error) error {
+	_, err := RetryValue(ctx, policy, func() (Unit, error) {
+		return Unit{}, f()
+	})
+	return err
+}
+
+// RetryValue is like Retry, but for functions returning a value, which is
+// returned once f succeeds.
+func RetryValue[T any](
+	ctx context.Context, policy RetryPolicy, f func() (T, error),
+) (T, error) {
+	delay := policy.InitialDelay
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+	maxDelay := policy.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+	mult := policy.Multiplier
+	if mult < 1 {
+		mult = 2
+	}
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		t, err := f()
+		if err == nil {
+			return t, nil
+		}
+		var pe permanentError
+		if errors.As(err, &pe) {
+			return t, &RetryError{Attempts: attempt, Err: pe.err}
+		}
+		if (policy.RetryIf != nil && !policy.RetryIf(err)) ||
+			(policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts) {
+			return t, &RetryError{Attempts: attempt, Err: err}
+		}
+		wait := min(delay, maxDelay)
+		if policy.Jitter > 0 {
+			jitter := min(policy.Jitter, 1) * float64(wait)
+			wait -= time.Duration(rand.Float64() * jitter)
+		}
+		if policy.MaxElapsed > 0 && time.Since(start)+wait > policy.MaxElapsed {
+			return t, &RetryError{Attempts: attempt, Err: err}
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return t, &RetryError{
+				Attempts: attempt, Err: err, ctxErr: newCanceledError(ctx),
+			}
+		}
+		delay = time.Duration(min(float64(delay)*mult, float64(maxDelay)))
+	}
+}
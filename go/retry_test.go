@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	errTemp := errors.New("temporary")
+	ctx := context.Background()
+	var delays []time.Duration
+	policy := RetryPolicy{
+		InitialDelay: time.Millisecond,
+		MaxDelay:     4 * time.Millisecond,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			if attempt != len(delays)+1 || err != errTemp {
+				t.Errorf("unexpected retry hook args: %d, %v", attempt, err)
+			}
+			delays = append(delays, delay)
+		},
+	}
+	calls := 0
+	n, err := RetryValue(ctx, policy, func() (int, error) {
+		if calls++; calls < 5 {
+			return 0, errTemp
+		}
+		return 42, nil
+	})
+	if err != nil || n != 42 || calls != 5 {
+		t.Fatalf("expected 42 after 5 calls, got %d, %v after %d", n, err, calls)
+	}
+	want := []time.Duration{1, 2, 4, 4}
+	for i := range want {
+		want[i] *= time.Millisecond
+	}
+	if !SliceEq(delays, want) {
+		t.Fatalf("expected delays %v, got %v", want, delays)
+	}
+
+	// Max attempts
+	policy = RetryPolicy{InitialDelay: time.Millisecond, MaxAttempts: 3}
+	calls = 0
+	err = Retry(ctx, policy, func() error {
+		calls++
+		return errTemp
+	})
+	var re *RetryError
+	if !errors.As(err, &re) || re.Attempts != 3 || calls != 3 {
+		t.Fatalf("expected RetryError after 3 attempts, got %v", err)
+	}
+	if !errors.Is(err, errTemp) || err.Error() != "after 3 attempts: temporary" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// RetryIf and Permanent
+	errFatal := errors.New("fatal")
+	policy.RetryIf = func(err error) bool { return err != errFatal }
+	calls = 0
+	err = Retry(ctx, policy, func() error {
+		calls++
+		return errFatal
+	})
+	if !errors.Is(err, errFatal) || calls != 1 {
+		t.Fatalf("expected fatal error after 1 call, got %v after %d", err, calls)
+	}
+	calls = 0
+	err = Retry(ctx, policy, func() error {
+		calls++
+		return Permanent(errTemp)
+	})
+	if !errors.As(err, &re) || re.Err != errTemp || calls != 1 {
+		t.Fatalf("expected permanent error after 1 call, got %v", err)
+	}
+}
+
+func TestRetryLimits(t *testing.T) {
+	errTemp := errors.New("temporary")
+	policy := RetryPolicy{
+		InitialDelay: 5 * time.Millisecond, Multiplier: 1, Jitter: 0.5,
+		MaxElapsed: 30 * time.Millisecond,
+		OnRetry: func(_ int, _ error, delay time.Duration) {
+			if delay < 2500*time.Microsecond || delay > 5*time.Millisecond {
+				t.Errorf("delay out of jitter range: %v", delay)
+			}
+		},
+	}
+	start := time.Now()
+	err := Retry(context.Background(), policy, func() error { return errTemp })
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Fatalf("exceeded max elapsed: %v", elapsed)
+	}
+	var re *RetryError
+	if !errors.As(err, &re) || re.Attempts < 2 {
+		t.Fatalf("expected multiple attempts, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 10*time.Millisecond,
+	)
+	defer cancel()
+	err = Retry(ctx, RetryPolicy{InitialDelay: time.Hour}, func() error {
+		return errTemp
+	})
+	if !errors.Is(err, errTemp) || !errors.Is(err, ErrCanceled) ||
+		!errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected temporary and canceled errors, got %v", err)
+	}
+}
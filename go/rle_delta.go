@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"encoding/binary"
+)
+
+// RLERun is a run of a repeated value.
+type RLERun[T comparable] struct {
+	Value T
+	Count int
+}
+
+// RLEEncode run-length encodes the slice (e.g., [a a a b] becomes
+// [{a 3} {b 1}]).
+func RLEEncode[T comparable](s []T) []RLERun[T] {
+	var runs []RLERun[T]
+	for _, t := range s {
+		if n := len(runs); n != 0 && runs[n-1].Value == t {
+			runs[n-1].Count++
+		} else {
+			runs = append(runs, RLERun[T]{Value: t, Count: 1})
+		}
+	}
+	return runs
+}
+
+// RLEDecode decodes runs produced by RLEEncode. Runs with non-positive counts
+// are skipped.
+func RLEDecode[T comparable](runs []RLERun[T]) []T {
+	total := 0
+	for _, r := range runs {
+		if r.Count > 0 {
+			total += r.Count
+		}
+	}
+	s := make([]T, 0, total)
+	for _, r := range runs {
+		for i := 0; i < r.Count; i++ {
+			s = append(s, r.Value)
+		}
+	}
+	return s
+}
+
+// DeltaEncode returns a new slice with the first element followed by the
+// differences between consecutive elements (e.g., [100 101 103] becomes
+// [100 1 2]). Sorted or slowly changing values (e.g., timestamps or IDs)
+// produce small deltas which encode compactly as varints. Overflow wraps, so
+// DeltaDecode always restores the original.
+func DeltaEncode[T Integer](s []T) []T {
+	res := make([]T, len(s))
+	var prev T
+	for i, t := range s {
+		res[i] = t - prev
+		prev = t
+	}
+	return res
+}
+
+// DeltaDecode reverses DeltaEncode.
+func DeltaDecode[T Integer](deltas []T) []T {
+	res := make([]T, len(deltas))
+	var prev T
+	for i, d := range deltas {
+		prev += d
+		res[i] = prev
+	}
+	return res
+}
+
+// AppendDeltaVarints appends the delta encoding of the slice to b as the
+// number of elements followed by each delta as a (zig-zag) varint.
+func AppendDeltaVarints[T Integer](b []byte, s []T) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	var prev T
+	for _, t := range s {
+		// Computing the delta in T and sign-extending keeps it small for
+		// unsigned types too (e.g., a delta of -1 as a uint8 is 255, which
+		// becomes -1 as an int8)
+		b = binary.AppendVarint(b, signExtend(t-prev))
+		prev = t
+	}
+	return b
+}
+
+// ReadDeltaVarints reads a slice encoded by AppendDeltaVarints, returning the
+// remaining bytes. Returns ErrBinaryFormat if the data is malformed.
+func ReadDeltaVarints[T Integer](b []byte) ([]T, []byte, error) {
+	l, b, err := readUvarint(b)
+	if err != nil {
+		return nil, b, err
+	} else if l > uint64(len(b)) {
+		return nil, b, ErrBinaryFormat
+	}
+	s := make([]T, l)
+	var prev T
+	for i := range s {
+		d, n := binary.Varint(b)
+		if n <= 0 {
+			return nil, b, ErrBinaryFormat
+		}
+		b = b[n:]
+		prev += T(d)
+		s[i] = prev
+	}
+	return s, b, nil
+}
+
+// signExtend converts the integer to an int64, treating it as signed
+// (two's complement) at its own size.
+func signExtend[T Integer](t T) int64 {
+	switch bits := sizeOfInteger[T](); bits {
+	case 64:
+		return int64(t)
+	default:
+		shift := 64 - bits
+		return int64(uint64(t)<<shift) >> shift
+	}
+}
+
+// sizeOfInteger returns the size of the integer type in bits.
+func sizeOfInteger[T Integer]() int {
+	var t T = 1
+	bits := 0
+	for t != 0 {
+		t <<= 1
+		bits++
+	}
+	return bits
+}
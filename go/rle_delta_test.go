@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRLE(t *testing.T) {
+	s := []string{"a", "a", "a", "b", "c", "c", "a"}
+	runs := RLEEncode(s)
+	want := []RLERun[string]{{"a", 3}, {"b", 1}, {"c", 2}, {"a", 1}}
+	if !SliceEq(runs, want) {
+		t.Fatalf("expected %v, got %v", want, runs)
+	}
+	if got := RLEDecode(runs); !SliceEq(got, s) {
+		t.Fatalf("expected %v, got %v", s, got)
+	}
+	if runs := RLEEncode([]int(nil)); len(runs) != 0 {
+		t.Fatalf("expected no runs, got %v", runs)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	s := []int64{100, 101, 103, 103, 90, math.MaxInt64, math.MinInt64}
+	deltas := DeltaEncode(s)
+	if deltas[0] != 100 || deltas[1] != 1 || deltas[4] != -13 {
+		t.Fatalf("unexpected deltas: %v", deltas)
+	}
+	if got := DeltaDecode(deltas); !SliceEq(got, s) {
+		t.Fatalf("expected %v, got %v", s, got)
+	}
+
+	b := AppendDeltaVarints(nil, s)
+	got, rest, err := ReadDeltaVarints[int64](b)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rest) != 0 || !SliceEq(got, s) {
+		t.Fatalf("expected %v, got %v (rest %v)", s, got, rest)
+	}
+
+	u := []uint8{10, 9, 8, 255, 0}
+	b = AppendDeltaVarints(nil, u)
+	// Length plus one byte per small delta
+	if len(b) != 6 {
+		t.Fatalf("expected 6 bytes, got %d", len(b))
+	}
+	if got, _, err := ReadDeltaVarints[uint8](b); err != nil {
+		t.Fatal(err)
+	} else if !SliceEq(got, u) {
+		t.Fatalf("expected %v, got %v", u, got)
+	}
+
+	if _, _, err := ReadDeltaVarints[int](b[:3]); err != ErrBinaryFormat {
+		t.Fatalf("expected ErrBinaryFormat, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// SecretRedacted is what a Secret is shown as when printed, logged, or
+// marshaled.
+const SecretRedacted = "[REDACTED]"
+
+// Secret holds a sensitive value (e.g., a password or API token) that is
+// redacted when printed with fmt, logged with slog, or marshaled to JSON or
+// text, so it can't be leaked accidentally. The value must be retrieved
+// explicitly with Reveal. Copies of a Secret share the underlying bytes.
+type Secret struct {
+	b []byte
+}
+
+// NewSecret creates a new Secret holding the string.
+func NewSecret(s string) Secret {
+	return Secret{b: []byte(s)}
+}
+
+// NewSecretBytes creates a new Secret holding a copy of the bytes.
+func NewSecretBytes(b []byte) Secret {
+	return Secret{b: CloneSlice(b)}
+}
+
+// EnvSecret loads a Secret using EnvFileOrVar, with the same semantics (the
+// error is from reading the file, in which case the value is from the
+// environment variable itself).
+func EnvSecret(envName string) (Secret, error) {
+	s, err := EnvFileOrVar(envName)
+	return NewSecret(s), err
+}
+
+// Reveal returns the secret value.
+func (s Secret) Reveal() string {
+	return string(s.b)
+}
+
+// RevealBytes returns the secret value's bytes. These are the Secret's actual
+// bytes, so they shouldn't be modified or retained.
+func (s Secret) RevealBytes() []byte {
+	return s.b
+}
+
+// IsEmpty returns whether the secret value is empty.
+func (s Secret) IsEmpty() bool {
+	return len(s.b) == 0
+}
+
+// Equal returns whether the secrets are equal in constant time.
+func (s Secret) Equal(other Secret) bool {
+	return ConstantTimeEqual(s.b, other.b)
+}
+
+// Destroy zeroes the secret's bytes (which are shared by copies of the
+// Secret) and empties it. Strings previously returned by Reveal aren't
+// affected.
+func (s *Secret) Destroy() {
+	clear(s.b)
+	s.b = nil
+}
+
+// String implements fmt.Stringer, returning SecretRedacted.
+func (s Secret) String() string {
+	return SecretRedacted
+}
+
+// GoString implements fmt.GoStringer, returning SecretRedacted.
+func (s Secret) GoString() string {
+	return SecretRedacted
+}
+
+// Format implements fmt.Formatter so that every verb (e.g., %x) prints
+// SecretRedacted.
+func (s Secret) Format(f fmt.State, _ rune) {
+	f.Write([]byte(SecretRedacted))
+}
+
+// LogValue implements slog.LogValuer, returning SecretRedacted.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(SecretRedacted)
+}
+
+// MarshalText implements encoding.TextMarshaler, returning SecretRedacted.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(SecretRedacted), nil
+}
+
+// MarshalJSON implements json.Marshaler, returning SecretRedacted as a JSON
+// string.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SecretRedacted)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, allowing secrets to be
+// loaded from configs.
+func (s *Secret) UnmarshalText(b []byte) error {
+	s.b = CloneSlice(b)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, allowing secrets to be loaded
+// from JSON configs. The JSON value must be a string.
+func (s *Secret) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	s.b = []byte(str)
+	return nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	s := NewSecret("hunter2")
+	type config struct {
+		User     string
+		Password Secret
+		Token    *Secret
+	}
+	cfg := config{User: "me", Password: s, Token: &s}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%x", "%q"} {
+		if out := fmt.Sprintf(format, cfg); strings.Contains(out, "hunter2") ||
+			strings.Contains(out, fmt.Sprintf("%x", "hunter2")) {
+			t.Fatalf("%s: secret leaked: %s", format, out)
+		}
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	} else if bytes.Contains(b, []byte("hunter2")) {
+		t.Fatalf("secret leaked: %s", b)
+	}
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("login", "password", s)
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("secret leaked: %s", buf.String())
+	}
+
+	var loaded config
+	in := `{"User":"me","Password":"hunter2","Token":"abc"}`
+	if err := json.Unmarshal([]byte(in), &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Password.Reveal() != "hunter2" || loaded.Token.Reveal() != "abc" {
+		t.Fatal("unexpected unmarshaled secrets")
+	}
+	if !loaded.Password.Equal(s) || loaded.Token.Equal(s) {
+		t.Fatal("bad Equal results")
+	}
+
+	raw := s.RevealBytes()
+	s.Destroy()
+	if !s.IsEmpty() || !bytes.Equal(raw, make([]byte, 7)) {
+		t.Fatalf("secret not destroyed: %q", raw)
+	}
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(" from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("UTILS_TEST_TOKEN_FILE", path)
+	if s, err := EnvSecret("UTILS_TEST_TOKEN"); err != nil {
+		t.Fatal(err)
+	} else if s.Reveal() != "from-file" {
+		t.Fatalf("expected from-file, got %q", s.Reveal())
+	}
+}
@@ -0,0 +1,276 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Semaphore is a weighted semaphore. Waiters are served in FIFO order, so a
+// large acquire isn't starved by smaller ones.
+type Semaphore struct {
+	mtx     sync.Mutex
+	size    int64
+	cur     int64
+	waiters list.List
+}
+
+type semWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// NewSemaphore creates a new Semaphore with the given total weight.
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+// Acquire acquires a weight of n, blocking until it is available. If the
+// context is done first, ErrCanceled (wrapping the context's error) is
+// returned and nothing is acquired. If n is greater than the size of the
+// semaphore, this blocks until the context is done.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	s.mtx.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mtx.Unlock()
+		return nil
+	}
+	if n > s.size {
+		s.mtx.Unlock()
+		<-ctx.Done()
+		return newCanceledError(ctx)
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(semWaiter{n: n, ready: ready})
+	s.mtx.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mtx.Lock()
+	select {
+	case <-ready:
+		// Acquired after the context was done, so release it
+		s.cur -= n
+		s.notifyWaiters()
+	default:
+		isFront := s.waiters.Front() == elem
+		s.waiters.Remove(elem)
+		// Waiters behind this one may now be able to acquire
+		if isFront && s.size > s.cur {
+			s.notifyWaiters()
+		}
+	}
+	s.mtx.Unlock()
+	return newCanceledError(ctx)
+}
+
+// TryAcquire acquires a weight of n without blocking, returning false if it
+// isn't available.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release releases a weight of n. Panics if more is released than is held.
+func (s *Semaphore) Release(n int64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if n > s.cur {
+		panic("utils: semaphore released more than held")
+	}
+	s.cur -= n
+	s.notifyWaiters()
+}
+
+// Size returns the total weight of the semaphore.
+func (s *Semaphore) Size() int64 {
+	return s.size
+}
+
+// Held returns the weight currently held.
+func (s *Semaphore) Held() int64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.cur
+}
+
+// notifyWaiters wakes as many waiters as possible, in order. The lock must be
+// held.
+func (s *Semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(semWaiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
+
+// KeyedSemaphore is a set of weighted semaphores, one per key, each with the
+// same size. Semaphores are created when first acquired and removed once no
+// longer held or waited on.
+type KeyedSemaphore[K comparable] struct {
+	size int64
+	m    SyncMap[K, *keyedSemEntry]
+}
+
+type keyedSemEntry struct {
+	sem *Semaphore
+	// Protected by mtx
+	mtx sync.Mutex
+	// held is the weight held and pending is the number of acquires in
+	// progress. The entry is removed once both are 0.
+	held    int64
+	pending int
+	dead    bool
+}
+
+// NewKeyedSemaphore creates a new KeyedSemaphore where each key's semaphore
+// has the given size.
+func NewKeyedSemaphore[K comparable](size int64) *KeyedSemaphore[K] {
+	return &KeyedSemaphore[K]{size: size}
+}
+
+// Acquire acquires a weight of n for the key. See Semaphore.Acquire.
+func (ks *KeyedSemaphore[K]) Acquire(
+	ctx context.Context, key K, n int64,
+) error {
+	e := ks.ref(key)
+	err := e.sem.Acquire(ctx, n)
+	ks.acquired(key, e, n, err == nil)
+	return err
+}
+
+// TryAcquire acquires a weight of n for the key without blocking, returning
+// false if it isn't available.
+func (ks *KeyedSemaphore[K]) TryAcquire(key K, n int64) bool {
+	e := ks.ref(key)
+	ok := e.sem.TryAcquire(n)
+	ks.acquired(key, e, n, ok)
+	return ok
+}
+
+// Release releases a weight of n for the key. Panics if more is released than
+// is held.
+func (ks *KeyedSemaphore[K]) Release(key K, n int64) {
+	e, ok := ks.m.Load(key)
+	if !ok {
+		panic("utils: keyed semaphore released more than held")
+	}
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if n > e.held {
+		panic("utils: keyed semaphore released more than held")
+	}
+	e.held -= n
+	e.sem.Release(n)
+	ks.removeIfUnused(key, e)
+}
+
+// Len returns the number of keys currently held or waited on.
+func (ks *KeyedSemaphore[K]) Len() int {
+	n := 0
+	ks.m.Range(func(K, *keyedSemEntry) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// ref gets (creating if needed) the entry for the key, adding a pending
+// acquire.
+func (ks *KeyedSemaphore[K]) ref(key K) *keyedSemEntry {
+	for {
+		e, ok := ks.m.Load(key)
+		if !ok {
+			e, _ = ks.m.LoadOrStore(
+				key, &keyedSemEntry{sem: NewSemaphore(ks.size)},
+			)
+		}
+		e.mtx.Lock()
+		if e.dead {
+			// Being removed, try again
+			e.mtx.Unlock()
+			continue
+		}
+		e.pending++
+		e.mtx.Unlock()
+		return e
+	}
+}
+
+// acquired finishes a pending acquire of a weight of n, which succeeded if ok
+// is true.
+func (ks *KeyedSemaphore[K]) acquired(
+	key K, e *keyedSemEntry, n int64, ok bool,
+) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.pending--
+	if ok {
+		e.held += n
+	}
+	ks.removeIfUnused(key, e)
+}
+
+// removeIfUnused removes the entry if nothing is held or pending. The entry's
+// lock must be held.
+func (ks *KeyedSemaphore[K]) removeIfUnused(key K, e *keyedSemEntry) {
+	if e.held == 0 && e.pending == 0 {
+		e.dead = true
+		ks.m.Delete(key)
+	}
+}
+
+// KeyedMutex is a set of mutexes, one per key, used to serialize access to
+// resources identified by keys.
+type KeyedMutex[K comparable] struct {
+	ks KeyedSemaphore[K]
+}
+
+// NewKeyedMutex creates a new KeyedMutex.
+func NewKeyedMutex[K comparable]() *KeyedMutex[K] {
+	return &KeyedMutex[K]{ks: KeyedSemaphore[K]{size: 1}}
+}
+
+// Lock locks the key.
+func (km *KeyedMutex[K]) Lock(key K) {
+	km.ks.Acquire(context.Background(), key, 1)
+}
+
+// LockContext locks the key, returning ErrCanceled (wrapping the context's
+// error) if the context is done first.
+func (km *KeyedMutex[K]) LockContext(ctx context.Context, key K) error {
+	return km.ks.Acquire(ctx, key, 1)
+}
+
+// TryLock attempts to lock the key without blocking, returning false if it is
+// already locked.
+func (km *KeyedMutex[K]) TryLock(key K) bool {
+	return km.ks.TryAcquire(key, 1)
+}
+
+// Unlock unlocks the key. Panics if the key isn't locked.
+func (km *KeyedMutex[K]) Unlock(key K) {
+	km.ks.Release(key, 1)
+}
+
+// Len returns the number of keys currently locked or waited on.
+func (km *KeyedMutex[K]) Len() int {
+	return km.ks.Len()
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSemaphore(t *testing.T) {
+	s := NewSemaphore(3)
+	ctx := context.Background()
+	if err := s.Acquire(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if s.TryAcquire(2) {
+		t.Fatal("acquired more than size")
+	}
+	if !s.TryAcquire(1) {
+		t.Fatal("failed to acquire available weight")
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	if err := s.Acquire(tctx, 1); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+
+	acquired := make(chan Unit)
+	go func() {
+		if err := s.Acquire(ctx, 3); err != nil {
+			t.Error(err)
+		}
+		close(acquired)
+	}()
+	time.Sleep(time.Millisecond * 10)
+	// Waiter is queued, so small acquires should fail
+	s.Release(1)
+	if s.TryAcquire(1) {
+		t.Fatal("acquired ahead of waiter")
+	}
+	s.Release(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("waiter not woken")
+	}
+	if held := s.Held(); held != 3 {
+		t.Fatalf("expected 3 held, got %d", held)
+	}
+	s.Release(3)
+}
+
+func TestKeyedMutex(t *testing.T) {
+	km := NewKeyedMutex[int]()
+	counts := make([]int, 4)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			km.Lock(key)
+			defer km.Unlock(key)
+			counts[key]++
+		}(i % len(counts))
+	}
+	wg.Wait()
+	for key, n := range counts {
+		if n != 25 {
+			t.Fatalf("key %d: expected 25, got %d", key, n)
+		}
+	}
+	if n := km.Len(); n != 0 {
+		t.Fatalf("expected no keys, got %d", n)
+	}
+
+	km.Lock(1)
+	if km.TryLock(1) {
+		t.Fatal("locked key twice")
+	}
+	if !km.TryLock(2) {
+		t.Fatal("failed to lock different key")
+	}
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*10,
+	)
+	defer cancel()
+	if err := km.LockContext(ctx, 1); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	km.Unlock(1)
+	km.Unlock(2)
+	if n := km.Len(); n != 0 {
+		t.Fatalf("expected no keys, got %d", n)
+	}
+}
+
+func TestKeyedSemaphorePartialRelease(t *testing.T) {
+	ks := NewKeyedSemaphore[string](3)
+	if err := ks.Acquire(context.Background(), "k", 3); err != nil {
+		t.Fatal(err)
+	}
+	// The key is kept until all of the weight is released.
+	for i := 2; i >= 0; i-- {
+		ks.Release("k", 1)
+		if n := ks.Len(); (i == 0) != (n == 0) {
+			t.Fatalf("%d held: unexpected number of keys: %d", i, n)
+		}
+		if i > 0 && ks.TryAcquire("k", int64(4-i)) {
+			t.Fatalf("%d held: acquired %d over the limit", i, 4-i)
+		}
+	}
+	if !ks.TryAcquire("k", 3) {
+		t.Fatal("failed to acquire released key")
+	}
+	ks.Release("k", 3)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic releasing unheld weight")
+			}
+		}()
+		ks.Release("k", 1)
+	}()
+}
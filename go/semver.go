@@ -0,0 +1,375 @@
+package utils
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SemVer is a semantic version (see https://semver.org). Build metadata is
+// kept but, per the spec, ignored when comparing versions.
+type SemVer struct {
+	Major, Minor, Patch uint64
+	// Prerelease is the dot-separated prerelease identifiers (e.g., "rc.1"),
+	// without the leading "-".
+	Prerelease string
+	// Build is the dot-separated build metadata, without the leading "+".
+	Build string
+}
+
+// ParseSemVer parses a semantic version such as "1.2.3", "v1.2.3-rc.1", or
+// "1.2.3+build.5". A leading "v" is allowed.
+func ParseSemVer(s string) (SemVer, error) {
+	v, parts, err := parseSemVerPartial(strings.TrimPrefix(s, "v"))
+	if err == nil && parts != 3 {
+		err = fmt.Errorf("expected major.minor.patch")
+	}
+	if err != nil {
+		return SemVer{}, fmt.Errorf("invalid version %q: %w", s, err)
+	}
+	return v, nil
+}
+
+// MustParseSemVer is like ParseSemVer but panics on error.
+func MustParseSemVer(s string) SemVer {
+	return Must(ParseSemVer(s))
+}
+
+// parseSemVerPartial parses a version where the minor and patch versions are
+// optional (and 0 if not given), returning the number of version parts given.
+func parseSemVerPartial(s string) (v SemVer, parts int, err error) {
+	var hasBuild, hasPre bool
+	s, v.Build, hasBuild = strings.Cut(s, "+")
+	s, v.Prerelease, hasPre = strings.Cut(s, "-")
+	if (hasBuild && v.Build == "") || (hasPre && v.Prerelease == "") {
+		return v, 0, fmt.Errorf("empty prerelease or build metadata")
+	}
+	nums := strings.Split(s, ".")
+	if len(nums) > 3 {
+		return v, 0, fmt.Errorf("too many version parts")
+	}
+	ptrs := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, num := range nums {
+		if !isSemVerNumber(num) {
+			return v, 0, fmt.Errorf("invalid version number %q", num)
+		}
+		*ptrs[i], err = strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return v, 0, fmt.Errorf("invalid version number %q", num)
+		}
+	}
+	if !validSemVerIdents(v.Prerelease, true) {
+		return v, 0, fmt.Errorf("invalid prerelease %q", v.Prerelease)
+	}
+	if !validSemVerIdents(v.Build, false) {
+		return v, 0, fmt.Errorf("invalid build metadata %q", v.Build)
+	}
+	return v, len(nums), nil
+}
+
+func isSemVerNumber(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validSemVerIdents returns whether the dot-separated identifiers are valid.
+// An empty string is valid (no identifiers).
+func validSemVerIdents(s string, noLeadingZeros bool) bool {
+	if s == "" {
+		return true
+	}
+	for _, ident := range strings.Split(s, ".") {
+		if ident == "" {
+			return false
+		}
+		numeric := true
+		for i := 0; i < len(ident); i++ {
+			c := ident[i]
+			switch {
+			case '0' <= c && c <= '9':
+			case c == '-' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+				numeric = false
+			default:
+				return false
+			}
+		}
+		if noLeadingZeros && numeric && !isSemVerNumber(ident) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the version as a string (without a leading "v").
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0, or 1 if v has lower, equal, or higher precedence
+// than other. Build metadata is ignored.
+func (v SemVer) Compare(other SemVer) int {
+	return cmp.Or(
+		cmp.Compare(v.Major, other.Major),
+		cmp.Compare(v.Minor, other.Minor),
+		cmp.Compare(v.Patch, other.Patch),
+		comparePrerelease(v.Prerelease, other.Prerelease),
+	)
+}
+
+// Less returns whether v has lower precedence than other.
+func (v SemVer) Less(other SemVer) bool {
+	return v.Compare(other) < 0
+}
+
+// IsPrerelease returns whether v is a prerelease version.
+func (v SemVer) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// NextMajor returns the next major version (e.g., 1.2.3 becomes 2.0.0).
+func (v SemVer) NextMajor() SemVer {
+	return SemVer{Major: v.Major + 1}
+}
+
+// NextMinor returns the next minor version (e.g., 1.2.3 becomes 1.3.0).
+func (v SemVer) NextMinor() SemVer {
+	return SemVer{Major: v.Major, Minor: v.Minor + 1}
+}
+
+// NextPatch returns the next patch version (e.g., 1.2.3 becomes 1.2.4). A
+// prerelease becomes its release (e.g., 1.2.3-rc.1 becomes 1.2.3).
+func (v SemVer) NextPatch() SemVer {
+	if v.IsPrerelease() {
+		return SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	}
+	return SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// MarshalText implements encoding.TextMarshaler, using String.
+func (v SemVer) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, using ParseSemVer.
+func (v *SemVer) UnmarshalText(text []byte) error {
+	sv, err := ParseSemVer(string(text))
+	if err == nil {
+		*v = sv
+	}
+	return err
+}
+
+// CompareSemVer is SemVer.Compare as a function, for use with slices.SortFunc
+// and the like.
+func CompareSemVer(a, b SemVer) int {
+	return a.Compare(b)
+}
+
+// SortSemVers sorts the versions in increasing order of precedence. The sort
+// is stable, so versions differing only by build metadata keep their order.
+func SortSemVers(vs []SemVer) {
+	slices.SortStableFunc(vs, CompareSemVer)
+}
+
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			return cmp.Compare(an, bn)
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		case as[i] < bs[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// SemVerConstraint is a set of constraints on versions, such as "^1.2.0" or
+// ">=1.0.0, <2.0.0 || ^3.1".
+type SemVerConstraint struct {
+	str string
+	// groups are ORed together; the comparators within a group are ANDed.
+	groups [][]semVerComparator
+}
+
+type semVerComparator struct {
+	op string
+	v  SemVer
+}
+
+// ParseSemVerConstraint parses a version constraint. A constraint is one or
+// more groups separated by "||", any of which must be satisfied. Each group
+// is one or more comparators separated by commas and/or spaces, all of which
+// must be satisfied. Comparators are:
+//
+//	=V, V    equal to V
+//	!=V      not equal to V
+//	>V, >=V  greater than (or equal to) V
+//	<V, <=V  less than (or equal to) V
+//	^V       compatible with V: >=V and less than the next version that
+//	         increments the leftmost nonzero part (e.g., ^1.2.3 is <2.0.0
+//	         and ^0.2.3 is <0.3.0)
+//	~V       >=V and less than the next minor version (or the next major
+//	         version if only the major version is given)
+//	*        any version
+//
+// Versions in comparators may omit the minor and patch versions (which are
+// then 0, e.g., "^1.2" is "^1.2.0"). The upper bounds of ^ and ~ exclude
+// prereleases of the bound (e.g., ^1.2.0 doesn't match 2.0.0-rc.1).
+func ParseSemVerConstraint(s string) (*SemVerConstraint, error) {
+	c := &SemVerConstraint{str: s}
+	for _, groupStr := range strings.Split(s, "||") {
+		fields := strings.FieldsFunc(groupStr, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: empty group", s)
+		}
+		var group []semVerComparator
+		for _, field := range fields {
+			comps, err := parseSemVerComparator(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %w", s, err)
+			}
+			group = append(group, comps...)
+		}
+		c.groups = append(c.groups, group)
+	}
+	return c, nil
+}
+
+// MustParseSemVerConstraint is like ParseSemVerConstraint but panics on error.
+func MustParseSemVerConstraint(s string) *SemVerConstraint {
+	return Must(ParseSemVerConstraint(s))
+}
+
+func parseSemVerComparator(s string) ([]semVerComparator, error) {
+	if s == "*" {
+		return nil, nil
+	}
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op = prefix
+			break
+		}
+	}
+	v, parts, err := parseSemVerPartial(strings.TrimPrefix(s[len(op):], "v"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid comparator %q: %w", s, err)
+	}
+	var upper SemVer
+	switch op {
+	case "", "=":
+		return []semVerComparator{{op: "=", v: v}}, nil
+	case "^":
+		switch {
+		case v.Major != 0 || parts == 1:
+			upper = v.NextMajor()
+		case v.Minor != 0 || parts == 2:
+			upper = v.NextMinor()
+		default:
+			upper = SemVer{Patch: v.Patch + 1}
+		}
+	case "~":
+		if parts == 1 {
+			upper = v.NextMajor()
+		} else {
+			upper = v.NextMinor()
+		}
+	default:
+		return []semVerComparator{{op: op, v: v}}, nil
+	}
+	upper.Prerelease = "0"
+	return []semVerComparator{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+}
+
+// Check returns whether the version satisfies the constraint.
+func (c *SemVerConstraint) Check(v SemVer) bool {
+	for _, group := range c.groups {
+		ok := true
+		for _, comp := range group {
+			if !comp.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (comp semVerComparator) check(v SemVer) bool {
+	c := v.Compare(comp.v)
+	switch comp.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	default:
+		return c <= 0
+	}
+}
+
+// String returns the constraint as it was parsed.
+func (c *SemVerConstraint) String() string {
+	return c.str
+}
+
+// MarshalText implements encoding.TextMarshaler, using String.
+func (c *SemVerConstraint) MarshalText() ([]byte, error) {
+	return []byte(c.str), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, using
+// ParseSemVerConstraint.
+func (c *SemVerConstraint) UnmarshalText(text []byte) error {
+	parsed, err := ParseSemVerConstraint(string(text))
+	if err == nil {
+		*c = *parsed
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	v, err := ParseSemVer("v1.2.3-rc.1+build.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SemVer{1, 2, 3, "rc.1", "build.5"}
+	if v != want {
+		t.Fatalf("expected %+v, got %+v", want, v)
+	}
+	if s := v.String(); s != "1.2.3-rc.1+build.5" {
+		t.Fatalf("unexpected string: %s", s)
+	}
+	for _, s := range []string{
+		"1.2.3", "0.0.0", "1.0.0-alpha-1", "1.0.0-0.3.7", "1.0.0+001",
+	} {
+		if v, err := ParseSemVer(s); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		} else if v.String() != s {
+			t.Fatalf("%s: round-tripped to %s", s, v)
+		}
+	}
+	for _, s := range []string{
+		"", "1", "1.2", "1.2.3.4", "01.2.3", "1.2.x", "1.2.3-", "1.2.3-01",
+		"1.2.3-a..b", "1.2.3+", "1.2.3+a+b", "1.2.3-a_b", "-1.2.3",
+		"99999999999999999999.0.0",
+	} {
+		if _, err := ParseSemVer(s); err == nil {
+			t.Fatalf("%s: expected error", s)
+		}
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	// In increasing order of precedence, from the spec.
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1",
+		"1.1.0", "2.0.0", "10.0.0",
+	}
+	vs := MapSlice(ordered, MustParseSemVer)
+	for i := range vs {
+		for j := range vs {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := vs[i].Compare(vs[j]); got != want {
+				t.Fatalf("%s vs %s: expected %d, got %d", vs[i], vs[j], want, got)
+			}
+		}
+	}
+	if MustParseSemVer("1.0.0+a").Compare(MustParseSemVer("1.0.0+b")) != 0 {
+		t.Fatal("expected build metadata to be ignored")
+	}
+
+	shuffled := ShuffleSlice(CloneSlice(vs), nil)
+	SortSemVers(shuffled)
+	if !SliceEq(shuffled, vs) {
+		t.Fatalf("unexpected sort: %v", shuffled)
+	}
+
+	v := MustParseSemVer("1.2.3-rc.1")
+	if v.NextPatch().String() != "1.2.3" || v.NextMinor().String() != "1.3.0" ||
+		v.NextMajor().String() != "2.0.0" ||
+		MustParseSemVer("1.2.3").NextPatch().String() != "1.2.4" {
+		t.Fatal("unexpected next versions")
+	}
+}
+
+func TestSemVerConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{"^1.2.0", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0-rc.1"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^0", []string{"0.0.1", "0.9.0"}, []string{"1.0.0"}},
+		{"^0.0", []string{"0.0.9"}, []string{"0.1.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{">=1.0.0, <2.0.0", []string{"1.0.0", "1.5.0"}, []string{"2.0.0"}},
+		{">1.0 <=1.2", []string{"1.0.1", "1.2.0"}, []string{"1.0.0", "1.2.1"}},
+		{"1.2.3 || ^3.1", []string{"1.2.3", "3.4.0"}, []string{"2.0.0"}},
+		{"!=1.0.0", []string{"1.0.1"}, []string{"1.0.0"}},
+		{"=v2.0.0", []string{"2.0.0+build"}, []string{"2.0.1"}},
+		{"*", []string{"0.0.1", "9.9.9-rc"}, nil},
+	}
+	for _, test := range tests {
+		c, err := ParseSemVerConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("%s: %v", test.constraint, err)
+		}
+		for _, s := range test.match {
+			if !c.Check(MustParseSemVer(s)) {
+				t.Errorf("%s: expected %s to match", test.constraint, s)
+			}
+		}
+		for _, s := range test.noMatch {
+			if c.Check(MustParseSemVer(s)) {
+				t.Errorf("%s: expected %s to not match", test.constraint, s)
+			}
+		}
+	}
+	for _, s := range []string{"", "^", ">=x", "1.0.0 ||", "^1.2.3.4"} {
+		if _, err := ParseSemVerConstraint(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+func TestSemVerJSON(t *testing.T) {
+	var cfg struct {
+		Version  SemVer
+		Requires *SemVerConstraint
+	}
+	data := `{"Version":"1.4.0","Requires":"^1.2"}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Requires.Check(cfg.Version) {
+		t.Fatal("expected version to satisfy constraint")
+	}
+	if b, err := json.Marshal(cfg); err != nil {
+		t.Fatal(err)
+	} else if string(b) != data {
+		t.Fatalf("expected %s, got %s", data, b)
+	}
+	if err := json.Unmarshal([]byte(`{"Version":"1.x"}`), &cfg); err == nil {
+		t.Fatal("expected error")
+	}
+}
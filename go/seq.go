@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"iter"
+)
+
+// SeqFromUChan returns a sequence of the values received from the UChan. The
+// sequence ends when the UChan is closed (and drained) or the context is
+// done. Values received are removed from the UChan, so the sequence can only
+// be iterated meaningfully once.
+func SeqFromUChan[T any](ctx context.Context, uc *UChan[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			t, err := uc.RecvContext(ctx)
+			if err != nil || !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// UChanFromSeq returns a UChan (with the given chan length) that the values
+// of the sequence are sent over from a new goroutine. The UChan is closed
+// once the sequence ends. Closing the UChan early stops the sequence after
+// the value currently being produced.
+func UChanFromSeq[T any](seq iter.Seq[T], chanLen int) *UChan[T] {
+	uc := NewUChan[T](chanLen)
+	go func() {
+		for t := range seq {
+			if !uc.Send(t) {
+				return
+			}
+		}
+		uc.Close()
+	}()
+	return uc
+}
+
+// SeqFromSlice returns a sequence of the values of the slice, in order.
+func SeqFromSlice[T any](s []T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, t := range s {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// CollectSeq collects the values of the sequence into a new slice.
+func CollectSeq[T any](seq iter.Seq[T]) []T {
+	var s []T
+	for t := range seq {
+		s = append(s, t)
+	}
+	return s
+}
+
+// CollectSeqN collects at most n values of the sequence into a new slice,
+// stopping the sequence after the nth value.
+func CollectSeqN[T any](seq iter.Seq[T], n int) []T {
+	if n < 1 {
+		return nil
+	}
+	s := make([]T, 0, min(n, 64))
+	for t := range seq {
+		s = append(s, t)
+		if len(s) == n {
+			break
+		}
+	}
+	return s
+}
+
+// SeqChunks returns a sequence of chunks of n values of the sequence, with the
+// last chunk possibly having fewer. Each chunk is a new slice. Panics if n is
+// less than 1.
+func SeqChunks[T any](seq iter.Seq[T], n int) iter.Seq[[]T] {
+	if n < 1 {
+		panic("utils: non-positive chunk size for SeqChunks")
+	}
+	return func(yield func([]T) bool) {
+		chunk := make([]T, 0, n)
+		for t := range seq {
+			chunk = append(chunk, t)
+			if len(chunk) == n {
+				if !yield(chunk) {
+					return
+				}
+				chunk = make([]T, 0, n)
+			}
+		}
+		if len(chunk) != 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// MapSeq returns a sequence of the results of f applied to each value of the
+// sequence.
+func MapSeq[T, U any](seq iter.Seq[T], f func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for t := range seq {
+			if !yield(f(t)) {
+				return
+			}
+		}
+	}
+}
+
+// FilterSeq returns a sequence of the values of the sequence for which f
+// returns true.
+func FilterSeq[T any](seq iter.Seq[T], f func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for t := range seq {
+			if f(t) && !yield(t) {
+				return
+			}
+		}
+	}
+}
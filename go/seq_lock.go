@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// SeqLockValue is a value protected by a sequence lock, meant for small,
+// frequently read and rarely written values. Readers never block writers (or
+// each other) and never allocate; instead, they retry if a write happened
+// during the read. Writers are serialized with a mutex.
+//
+// Since readers copy the value while it may be being written (discarding the
+// copy if so), T should be a small value without pointers and the race
+// detector will report concurrent Read and Write calls.
+type SeqLockValue[T any] struct {
+	seq  atomic.Uint64
+	mtx  sync.Mutex
+	data T
+}
+
+// NewSeqLockValue creates a new SeqLockValue with the given value. The zero
+// value of SeqLockValue is also ready to use.
+func NewSeqLockValue[T any](t T) *SeqLockValue[T] {
+	return &SeqLockValue[T]{data: t}
+}
+
+// Read returns a consistent copy of the value, retrying if a write occurs
+// during the read.
+func (v *SeqLockValue[T]) Read() T {
+	for i := 0; ; i++ {
+		seq := v.seq.Load()
+		if seq&1 == 0 {
+			t := v.data
+			if v.seq.Load() == seq {
+				return t
+			}
+		}
+		if i >= spinMutexYieldAfter {
+			runtime.Gosched()
+		}
+	}
+}
+
+// Write calls the given function with a pointer to the value, which can be
+// modified. The pointer should not be used after the function returns.
+func (v *SeqLockValue[T]) Write(f func(*T)) {
+	v.mtx.Lock()
+	v.seq.Add(1)
+	f(&v.data)
+	v.seq.Add(1)
+	v.mtx.Unlock()
+}
+
+// Store stores the given value.
+func (v *SeqLockValue[T]) Store(t T) {
+	v.Write(func(tp *T) { *tp = t })
+}
+
+// Seq returns the current sequence number, which is incremented twice on
+// each write. It can be used to check whether the value has changed.
+func (v *SeqLockValue[T]) Seq() uint64 {
+	return v.seq.Load()
+}
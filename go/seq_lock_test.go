@@ -0,0 +1,57 @@
+//go:build !race
+
+// Reading a SeqLockValue while it is written is an intentional (benign) data
+// race, so these tests aren't run with the race detector.
+
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSeqLockValue(t *testing.T) {
+	type pair struct {
+		a, b, c int64
+	}
+	v := NewSeqLockValue(pair{})
+	if seq := v.Seq(); seq != 0 {
+		t.Fatalf("expected seq 0, got %d", seq)
+	}
+	v.Store(pair{1, 1, 1})
+	if got := v.Read(); got != (pair{1, 1, 1}) {
+		t.Fatalf("expected %v, got %v", pair{1, 1, 1}, got)
+	}
+	if seq := v.Seq(); seq != 2 {
+		t.Fatalf("expected seq 2, got %d", seq)
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				p := v.Read()
+				if p.a != p.b || p.b != p.c {
+					t.Errorf("inconsistent read: %v", p)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100000; i++ {
+		v.Write(func(p *pair) {
+			p.a++
+			p.b++
+			p.c++
+		})
+	}
+	stop.Store(true)
+	wg.Wait()
+	if got := v.Read(); got != (pair{100001, 100001, 100001}) {
+		t.Fatalf("unexpected final value: %v", got)
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSeqAdapters(t *testing.T) {
+	s := RangeSlice(10)
+	if got := CollectSeq(SeqFromSlice(s)); !SliceEq(got, s) {
+		t.Fatalf("expected %v, got %v", s, got)
+	}
+	if got := CollectSeq(SeqFromSlice([]int(nil))); len(got) != 0 {
+		t.Fatalf("expected empty slice, got %v", got)
+	}
+	if got := CollectSeqN(Range(0, 1000, 1), 3); !SliceEq(got, []int{0, 1, 2}) {
+		t.Fatalf("expected [0 1 2], got %v", got)
+	}
+	if got := CollectSeqN(Range(0, 2, 1), 3); !SliceEq(got, []int{0, 1}) {
+		t.Fatalf("expected [0 1], got %v", got)
+	}
+
+	var chunks [][]int
+	for chunk := range SeqChunks(SeqFromSlice(s), 4) {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 3 || !SliceEq(chunks[2], []int{8, 9}) {
+		t.Fatalf("unexpected chunks: %v", chunks)
+	}
+	for chunk := range SeqChunks(SeqFromSlice(s), 4) {
+		if !SliceEq(chunk, []int{0, 1, 2, 3}) {
+			t.Fatalf("unexpected first chunk: %v", chunk)
+		}
+		break
+	}
+
+	evens := FilterSeq(Range(0, 10, 1), func(n int) bool { return n%2 == 0 })
+	doubled := CollectSeq(MapSeq(evens, func(n int) int { return n * 2 }))
+	if !SliceEq(doubled, []int{0, 4, 8, 12, 16}) {
+		t.Fatalf("unexpected values: %v", doubled)
+	}
+}
+
+func TestSeqUChan(t *testing.T) {
+	uc := UChanFromSeq(Range(0, 100, 1), 4)
+	got := CollectSeq(SeqFromUChan(context.Background(), uc))
+	if !SliceEq(got, RangeSlice(100)) {
+		t.Fatalf("unexpected values: %v", got)
+	}
+
+	// Closing the UChan stops an infinite sequence.
+	infinite := func(yield func(int) bool) {
+		for i := 0; yield(i); i++ {
+		}
+	}
+	uc = UChanFromSeq(infinite, 4)
+	got = CollectSeqN(SeqFromUChan(context.Background(), uc), 5)
+	if !SliceEq(got, RangeSlice(5)) {
+		t.Fatalf("unexpected values: %v", got)
+	}
+	uc.Close()
+	uc.Drain()
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), time.Millisecond*10,
+	)
+	defer cancel()
+	open := NewUChan[int](1)
+	open.Send(1)
+	if got := CollectSeq(SeqFromUChan(ctx, open)); !SliceEq(got, []int{1}) {
+		t.Fatalf("expected [1], got %v", got)
+	}
+}
@@ -1,8 +1,11 @@
 package utils
 
+import "context"
+
 // Set is a wrapper for map[T]Unit.
 type Set[T comparable] struct {
-	m map[T]Unit
+	m    map[T]Unit
+	feed *changeFeed[T, Unit]
 }
 
 // NewSet creates a new set.
@@ -39,6 +42,9 @@ func (s *Set[T]) Insert(item T) bool {
 		return false
 	}
 	s.m[item] = Unit{}
+	if s.feed.active() {
+		s.feed.emit(ChangeEvent[T, Unit]{Kind: ChangeInsert, Key: item})
+	}
 	return true
 }
 
@@ -46,6 +52,9 @@ func (s *Set[T]) Insert(item T) bool {
 func (s *Set[T]) Remove(item T) bool {
 	if s.Contains(item) {
 		delete(s.m, item)
+		if s.feed.active() {
+			s.feed.emit(ChangeEvent[T, Unit]{Kind: ChangeDelete, Key: item})
+		}
 		return true
 	}
 	return false
@@ -70,9 +79,13 @@ func (s *Set[T]) Range(f func(T) bool) {
 // Filter iterates over each item in random order, removing items not
 // satisfying the given predicate.
 func (s *Set[T]) Filter(f func(T) bool) {
+	watched := s.feed.active()
 	for t := range s.m {
 		if !f(t) {
 			delete(s.m, t)
+			if watched {
+				s.feed.emit(ChangeEvent[T, Unit]{Kind: ChangeDelete, Key: t})
+			}
 		}
 	}
 }
@@ -104,7 +117,8 @@ func (s *Set[T]) ToMap() *Map[T, Unit] {
 	return MapFromMap(s.ToGoMap())
 }
 
-// AsMap returns a Map that wrapper the Set's inner map.
+// AsMap returns a Map that wrapper the Set's inner map. Changes made through
+// the returned Map aren't seen by the Set's watchers.
 func (s *Set[T]) AsMap() *Map[T, Unit] {
 	return &Map[T, Unit]{m: s.m}
 }
@@ -118,7 +132,31 @@ func (s *Set[T]) ToSlice() []T {
 	return slice
 }
 
-// Inner returns the inner go map.
+// Freeze returns a FrozenSet with a copy of the Set's contents.
+func (s *Set[T]) Freeze() *FrozenSet[T] {
+	return &FrozenSet[T]{s: s.Clone()}
+}
+
+// Inner returns the inner go map. Changes made directly to it aren't seen by
+// watchers.
 func (s *Set[T]) Inner() map[T]Unit {
 	return s.m
 }
+
+// WatchChanges returns a chan that receives an insert or delete event for
+// every change made through the Set's methods until the context is done. See
+// Map.WatchChanges.
+func (s *Set[T]) WatchChanges(ctx context.Context) <-chan ChangeEvent[T, Unit] {
+	return s.WatchChangesOpts(ctx, WatchOpts{})
+}
+
+// WatchChangesOpts is the same as WatchChanges, but with options, such as
+// coalescing events.
+func (s *Set[T]) WatchChangesOpts(
+	ctx context.Context, opts WatchOpts,
+) <-chan ChangeEvent[T, Unit] {
+	if s.feed == nil {
+		s.feed = &changeFeed[T, Unit]{}
+	}
+	return s.feed.watch(ctx, opts)
+}
@@ -0,0 +1,145 @@
+package utils
+
+var (
+	_ ReadOnlySet[int] = (*UnionView[int])(nil)
+	_ ReadOnlySet[int] = (*IntersectView[int])(nil)
+	_ ReadOnlySet[int] = (*DiffView[int])(nil)
+)
+
+// UnionView is a lazily-evaluated union of two sets. Membership is computed
+// on demand from the underlying sets, so changes to them are reflected in
+// the view. Contains is as fast as the underlying sets' Contains, but Len is
+// O(n) since it requires iterating.
+type UnionView[T comparable] struct {
+	a, b ReadOnlySet[T]
+}
+
+// NewUnionView returns a view of the union of a and b.
+func NewUnionView[T comparable](a, b ReadOnlySet[T]) *UnionView[T] {
+	return &UnionView[T]{a: a, b: b}
+}
+
+// Contains returns whether either set contains the item.
+func (v *UnionView[T]) Contains(item T) bool {
+	return v.a.Contains(item) || v.b.Contains(item)
+}
+
+// Len returns the number of items in the union.
+func (v *UnionView[T]) Len() int {
+	return v.a.Len() + rangeCount(v.b, func(t T) bool {
+		return !v.a.Contains(t)
+	})
+}
+
+// Range iterates over each item in the union, applying a given function that
+// returns whether the iterations should stop.
+func (v *UnionView[T]) Range(f func(T) bool) {
+	stopped := false
+	v.a.Range(func(t T) bool {
+		stopped = !f(t)
+		return !stopped
+	})
+	if stopped {
+		return
+	}
+	v.b.Range(func(t T) bool {
+		return v.a.Contains(t) || f(t)
+	})
+}
+
+// ToSet materializes the view into a new Set.
+func (v *UnionView[T]) ToSet() *Set[T] {
+	return viewToSet[T](v)
+}
+
+// IntersectView is a lazily-evaluated intersection of two sets. See UnionView
+// for more.
+type IntersectView[T comparable] struct {
+	a, b ReadOnlySet[T]
+}
+
+// NewIntersectView returns a view of the intersection of a and b. Iteration
+// is over a, so it should be the smaller set.
+func NewIntersectView[T comparable](a, b ReadOnlySet[T]) *IntersectView[T] {
+	return &IntersectView[T]{a: a, b: b}
+}
+
+// Contains returns whether both sets contain the item.
+func (v *IntersectView[T]) Contains(item T) bool {
+	return v.a.Contains(item) && v.b.Contains(item)
+}
+
+// Len returns the number of items in the intersection.
+func (v *IntersectView[T]) Len() int {
+	return rangeCount(v.a, v.b.Contains)
+}
+
+// Range iterates over each item in the intersection, applying a given
+// function that returns whether the iterations should stop.
+func (v *IntersectView[T]) Range(f func(T) bool) {
+	v.a.Range(func(t T) bool {
+		return !v.b.Contains(t) || f(t)
+	})
+}
+
+// ToSet materializes the view into a new Set.
+func (v *IntersectView[T]) ToSet() *Set[T] {
+	return viewToSet[T](v)
+}
+
+// DiffView is a lazily-evaluated difference of two sets (the items in the
+// first set not in the second). See UnionView for more.
+type DiffView[T comparable] struct {
+	a, b ReadOnlySet[T]
+}
+
+// NewDiffView returns a view of the items in a that aren't in b.
+func NewDiffView[T comparable](a, b ReadOnlySet[T]) *DiffView[T] {
+	return &DiffView[T]{a: a, b: b}
+}
+
+// Contains returns whether the first set contains the item and the second
+// doesn't.
+func (v *DiffView[T]) Contains(item T) bool {
+	return v.a.Contains(item) && !v.b.Contains(item)
+}
+
+// Len returns the number of items in the difference.
+func (v *DiffView[T]) Len() int {
+	return rangeCount(v.a, func(t T) bool {
+		return !v.b.Contains(t)
+	})
+}
+
+// Range iterates over each item in the difference, applying a given function
+// that returns whether the iterations should stop.
+func (v *DiffView[T]) Range(f func(T) bool) {
+	v.a.Range(func(t T) bool {
+		return v.b.Contains(t) || f(t)
+	})
+}
+
+// ToSet materializes the view into a new Set.
+func (v *DiffView[T]) ToSet() *Set[T] {
+	return viewToSet[T](v)
+}
+
+func rangeCount[T comparable](s ReadOnlySet[T], pred func(T) bool) int {
+	n := 0
+	s.Range(func(t T) bool {
+		if pred(t) {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+func viewToSet[T comparable](s ReadOnlySet[T]) *Set[T] {
+	set := NewSet[T]()
+	s.Range(func(t T) bool {
+		set.Insert(t)
+		return true
+	})
+	return set
+}
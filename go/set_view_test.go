@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"sort"
+	"testing"
+)
+
+func sortedSetItems(s ReadOnlySet[int]) []int {
+	var items []int
+	s.Range(func(i int) bool {
+		items = append(items, i)
+		return true
+	})
+	sort.Ints(items)
+	return items
+}
+
+func TestSetViews(t *testing.T) {
+	a := SetFromSlice([]int{1, 2, 3, 4})
+	b := SetFromSlice([]int{3, 4, 5})
+
+	union := NewUnionView[int](a, b)
+	if got := sortedSetItems(union); !SliceEq(got, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected union: %v", got)
+	}
+	if union.Len() != 5 || !union.Contains(5) || union.Contains(6) {
+		t.Fatal("bad union results")
+	}
+
+	inter := NewIntersectView[int](a, b)
+	if got := sortedSetItems(inter); !SliceEq(got, []int{3, 4}) {
+		t.Fatalf("unexpected intersection: %v", got)
+	}
+	if inter.Len() != 2 || !inter.Contains(3) || inter.Contains(1) {
+		t.Fatal("bad intersection results")
+	}
+
+	diff := NewDiffView[int](a, b)
+	if got := sortedSetItems(diff); !SliceEq(got, []int{1, 2}) {
+		t.Fatalf("unexpected difference: %v", got)
+	}
+	if diff.Len() != 2 || !diff.Contains(1) || diff.Contains(3) {
+		t.Fatal("bad difference results")
+	}
+
+	// Views are live and composable
+	b.Insert(1)
+	if diff.Contains(1) || union.Len() != 5 {
+		t.Fatal("views not updated")
+	}
+	nested := NewDiffView[int](union, inter)
+	if got := nested.ToSet(); got.Len() != 2 || !got.Contains(2) {
+		t.Fatalf("unexpected nested view: %v", got.ToSlice())
+	}
+
+	// Early stop
+	n := 0
+	union.Range(func(int) bool {
+		n++
+		return n < 2
+	})
+	if n != 2 {
+		t.Fatalf("expected 2 iterations, got %d", n)
+	}
+}
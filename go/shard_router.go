@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// ShardMove is a change in the node owning a shard.
+type ShardMove struct {
+	// Shard is the ID of the shard.
+	Shard int
+	// From is the previous owner ("" if there was none).
+	From string
+	// To is the new owner ("" if there is none).
+	To string
+}
+
+// ShardRouterOpts are options for a ShardRouter.
+type ShardRouterOpts[K comparable] struct {
+	// Shards is the number of shards. If less than 1, DefaultShardCount is
+	// used.
+	Shards int
+	// Replicas is the number of points per node on the hash ring. If less
+	// than 1, DefaultRingReplicas is used.
+	Replicas int
+	// Hash hashes keys to choose shards. Equal keys must have equal hashes,
+	// which should be the same across processes if shards are moved between
+	// them. If nil, StableHash is used (falling back to hashing the key
+	// formatted with %#v if StableHash fails).
+	Hash func(K) uint64
+}
+
+// ShardRouter partitions key/value data into a fixed number of shards, each
+// held in its own SyncMap, and assigns the shards to nodes (e.g., workers)
+// using a consistent-hash ring. Adding or removing nodes reports which
+// shards moved so their data can be migrated (see SnapshotShard and
+// RestoreShard). Shards can also be moved explicitly with MoveShard.
+type ShardRouter[K comparable, V any] struct {
+	hash   func(K) uint64
+	shards []*SyncMap[K, V]
+
+	mtx    sync.RWMutex
+	ring   *HashRing
+	owners []string
+	pinned []bool
+}
+
+// NewShardRouter creates a new ShardRouter with no nodes.
+func NewShardRouter[K comparable, V any](
+	opts ShardRouterOpts[K],
+) *ShardRouter[K, V] {
+	n := opts.Shards
+	if n < 1 {
+		n = DefaultShardCount
+	}
+	sr := &ShardRouter[K, V]{
+		hash:   opts.Hash,
+		shards: make([]*SyncMap[K, V], n),
+		ring:   NewHashRing(opts.Replicas),
+		owners: make([]string, n),
+		pinned: make([]bool, n),
+	}
+	if sr.hash == nil {
+		sr.hash = stableKeyHash[K]
+	}
+	for i := range sr.shards {
+		sr.shards[i] = NewSyncMap[K, V]()
+	}
+	return sr
+}
+
+func stableKeyHash[K comparable](key K) uint64 {
+	if h, err := StableHash(key); err == nil {
+		return h
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%#v", key)
+	return h.Sum64()
+}
+
+// NumShards returns the number of shards.
+func (sr *ShardRouter[K, V]) NumShards() int {
+	return len(sr.shards)
+}
+
+// ShardOf returns the ID of the shard the key belongs to.
+func (sr *ShardRouter[K, V]) ShardOf(key K) int {
+	return int(mixHash(sr.hash(key)) % uint64(len(sr.shards)))
+}
+
+// Shard returns the map holding the data of the shard. Panics if the ID is
+// out of range.
+func (sr *ShardRouter[K, V]) Shard(id int) *SyncMap[K, V] {
+	return sr.shards[id]
+}
+
+// ShardFor returns the map holding the data of the shard the key belongs
+// to.
+func (sr *ShardRouter[K, V]) ShardFor(key K) *SyncMap[K, V] {
+	return sr.shards[sr.ShardOf(key)]
+}
+
+// Load loads the value for the key from its shard.
+func (sr *ShardRouter[K, V]) Load(key K) (V, bool) {
+	return sr.ShardFor(key).Load(key)
+}
+
+// Store stores the key/value pair in the key's shard.
+func (sr *ShardRouter[K, V]) Store(key K, value V) {
+	sr.ShardFor(key).Store(key, value)
+}
+
+// Delete deletes the key from its shard.
+func (sr *ShardRouter[K, V]) Delete(key K) {
+	sr.ShardFor(key).Delete(key)
+}
+
+// AddNode adds a node, returning the shards whose owner changed.
+func (sr *ShardRouter[K, V]) AddNode(node string) []ShardMove {
+	sr.mtx.Lock()
+	defer sr.mtx.Unlock()
+	if sr.ring.Has(node) {
+		return nil
+	}
+	sr.ring.Add(node)
+	return sr.reassign()
+}
+
+// RemoveNode removes a node, returning the shards whose owner changed
+// (including those moved to the node with MoveShard). Returns nil if the
+// node wasn't present.
+func (sr *ShardRouter[K, V]) RemoveNode(node string) []ShardMove {
+	sr.mtx.Lock()
+	defer sr.mtx.Unlock()
+	if !sr.ring.Remove(node) {
+		return nil
+	}
+	for id, owner := range sr.owners {
+		if owner == node {
+			sr.pinned[id] = false
+		}
+	}
+	return sr.reassign()
+}
+
+// Rebalance undoes all MoveShard calls, assigning every shard to its owner on
+// the hash ring, and returns the shards whose owner changed.
+func (sr *ShardRouter[K, V]) Rebalance() []ShardMove {
+	sr.mtx.Lock()
+	defer sr.mtx.Unlock()
+	clear(sr.pinned)
+	return sr.reassign()
+}
+
+// reassign assigns unpinned shards to their owners on the ring. The lock must
+// be held.
+func (sr *ShardRouter[K, V]) reassign() (moves []ShardMove) {
+	for id, owner := range sr.owners {
+		if sr.pinned[id] {
+			continue
+		}
+		newOwner, _ := sr.ring.Get(mixHash(uint64(id)))
+		if newOwner != owner {
+			sr.owners[id] = newOwner
+			moves = append(moves, ShardMove{Shard: id, From: owner, To: newOwner})
+		}
+	}
+	return
+}
+
+// MoveShard assigns the shard to the node, overriding the hash ring until the
+// node is removed or Rebalance is called. Returns ErrNotFound if the shard
+// ID is out of range or the node hasn't been added.
+func (sr *ShardRouter[K, V]) MoveShard(id int, node string) (ShardMove, error) {
+	sr.mtx.Lock()
+	defer sr.mtx.Unlock()
+	if id < 0 || id >= len(sr.shards) {
+		return ShardMove{}, fmt.Errorf("shard %d: %w", id, ErrNotFound)
+	} else if !sr.ring.Has(node) {
+		return ShardMove{}, fmt.Errorf("node %q: %w", node, ErrNotFound)
+	}
+	move := ShardMove{Shard: id, From: sr.owners[id], To: node}
+	sr.owners[id], sr.pinned[id] = node, true
+	return move, nil
+}
+
+// Nodes returns the nodes, sorted.
+func (sr *ShardRouter[K, V]) Nodes() []string {
+	sr.mtx.RLock()
+	defer sr.mtx.RUnlock()
+	return sr.ring.Nodes()
+}
+
+// Owner returns the node owning the shard, returning "" if there are no
+// nodes. Panics if the ID is out of range.
+func (sr *ShardRouter[K, V]) Owner(id int) string {
+	sr.mtx.RLock()
+	defer sr.mtx.RUnlock()
+	return sr.owners[id]
+}
+
+// NodeOf returns the node owning the key's shard, returning "" if there are
+// no nodes.
+func (sr *ShardRouter[K, V]) NodeOf(key K) string {
+	return sr.Owner(sr.ShardOf(key))
+}
+
+// ShardsOf returns the IDs of the shards owned by the node, in order.
+func (sr *ShardRouter[K, V]) ShardsOf(node string) []int {
+	sr.mtx.RLock()
+	defer sr.mtx.RUnlock()
+	var ids []int
+	for id, owner := range sr.owners {
+		if owner == node {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Assignments returns the IDs of the shards owned by each node.
+func (sr *ShardRouter[K, V]) Assignments() map[string][]int {
+	sr.mtx.RLock()
+	defer sr.mtx.RUnlock()
+	m := make(map[string][]int, sr.ring.Len())
+	for _, node := range sr.ring.Nodes() {
+		m[node] = nil
+	}
+	for id, owner := range sr.owners {
+		if owner != "" {
+			m[owner] = append(m[owner], id)
+		}
+	}
+	return m
+}
+
+// SnapshotShard returns a copy of the shard's data. Panics if the ID is out
+// of range.
+func (sr *ShardRouter[K, V]) SnapshotShard(id int) map[K]V {
+	m := make(map[K]V)
+	sr.shards[id].Range(func(k K, v V) bool {
+		m[k] = v
+		return true
+	})
+	return m
+}
+
+// RestoreShard replaces the shard's data with the given data (e.g., from
+// SnapshotShard). Returns an error, without changing the shard, if any key
+// doesn't belong to the shard. Panics if the ID is out of range.
+func (sr *ShardRouter[K, V]) RestoreShard(id int, data map[K]V) error {
+	for k := range data {
+		if other := sr.ShardOf(k); other != id {
+			return fmt.Errorf(
+				"key %v belongs to shard %d, not shard %d", k, other, id,
+			)
+		}
+	}
+	shard := sr.shards[id]
+	shard.Clear()
+	for k, v := range data {
+		shard.Store(k, v)
+	}
+	return nil
+}
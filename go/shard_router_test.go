@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestShardRouter(t *testing.T) {
+	sr := NewShardRouter[string, int](ShardRouterOpts[string]{Shards: 16})
+	if sr.NumShards() != 16 {
+		t.Fatalf("expected 16 shards, got %d", sr.NumShards())
+	}
+	if node := sr.NodeOf("key"); node != "" {
+		t.Fatalf("expected no owner, got %q", node)
+	}
+	for i := 0; i < 100; i++ {
+		sr.Store("key"+strconv.Itoa(i), i)
+	}
+	if v, ok := sr.Load("key42"); !ok || v != 42 {
+		t.Fatalf("expected 42, true, got %d, %v", v, ok)
+	}
+	if _, ok := sr.ShardFor("key42").Load("key42"); !ok {
+		t.Fatal("key not in its shard")
+	}
+
+	moves := sr.AddNode("a")
+	if len(moves) != 16 {
+		t.Fatalf("expected all 16 shards to move to a, got %v", moves)
+	}
+	if sr.AddNode("a") != nil {
+		t.Fatal("expected no moves adding existing node")
+	}
+	moves = sr.AddNode("b")
+	for _, m := range moves {
+		if m.From != "a" || m.To != "b" {
+			t.Fatalf("unexpected move: %+v", m)
+		}
+	}
+	assigned := sr.Assignments()
+	if len(assigned["a"])+len(assigned["b"]) != 16 {
+		t.Fatalf("unexpected assignments: %v", assigned)
+	}
+	if !SliceEq(sr.ShardsOf("b"), assigned["b"]) {
+		t.Fatalf("ShardsOf doesn't match assignments")
+	}
+
+	// Move a shard and migrate its data by snapshot and restore.
+	id := sr.ShardOf("key42")
+	from := sr.Owner(id)
+	to := "a"
+	if from == "a" {
+		to = "b"
+	}
+	move, err := sr.MoveShard(id, to)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	} else if move != (ShardMove{Shard: id, From: from, To: to}) {
+		t.Fatalf("unexpected move: %+v", move)
+	}
+	if sr.NodeOf("key42") != to {
+		t.Fatalf("expected key42 owned by %s", to)
+	}
+	snap := sr.SnapshotShard(id)
+	if snap["key42"] != 42 {
+		t.Fatalf("snapshot missing key42: %v", snap)
+	}
+	sr.Shard(id).Clear()
+	if err := sr.RestoreShard(id, snap); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if v, _ := sr.Load("key42"); v != 42 {
+		t.Fatalf("expected restored 42, got %d", v)
+	}
+	bad := map[string]int{}
+	for i := 0; len(bad) == 0; i++ {
+		if key := "key" + strconv.Itoa(i); sr.ShardOf(key) != id {
+			bad[key] = i
+		}
+	}
+	if err := sr.RestoreShard(id, bad); err == nil {
+		t.Fatal("expected error restoring key from another shard")
+	}
+
+	if _, err := sr.MoveShard(id, "c"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := sr.MoveShard(16, "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// The pinned shard survives adding a node but not a rebalance.
+	sr.AddNode("c")
+	if sr.Owner(id) != to {
+		t.Fatal("pinned shard moved")
+	}
+	sr.Rebalance()
+	other := NewShardRouter[string, int](ShardRouterOpts[string]{Shards: 16})
+	for _, node := range []string{"c", "b", "a"} {
+		other.AddNode(node)
+	}
+	for i := 0; i < 16; i++ {
+		if sr.Owner(i) != other.Owner(i) {
+			t.Fatalf("shard %d: expected %s, got %s",
+				i, other.Owner(i), sr.Owner(i))
+		}
+	}
+
+	moves = sr.RemoveNode("c")
+	for _, m := range moves {
+		if m.From != "c" || m.To == "c" {
+			t.Fatalf("unexpected move: %+v", m)
+		}
+	}
+	if sr.RemoveNode("c") != nil || !SliceEq(sr.Nodes(), []string{"a", "b"}) {
+		t.Fatalf("unexpected nodes: %v", sr.Nodes())
+	}
+}
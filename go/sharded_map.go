@@ -0,0 +1,402 @@
+package utils
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// DefaultShardCount is the default number of shards in a ShardedMap.
+const DefaultShardCount = 32
+
+// ShardedMapOpts are options for a ShardedMap.
+type ShardedMapOpts[K comparable] struct {
+	// Shards is the number of shards, rounded up to a power of 2. If less than
+	// 1, DefaultShardCount is used.
+	Shards int
+	// Hash hashes keys to choose shards. Equal keys must have equal hashes. If
+	// nil, a default is used which handles strings, numbers, and bools
+	// directly and formats other keys using fmt. The default should be
+	// replaced for other key types where performance matters or where equal
+	// keys may format differently (e.g., structs with float fields, since
+	// 0 == -0).
+	Hash func(K) uint64
+}
+
+// ShardedMap is a concurrent map which splits keys across multiple
+// independently locked shards, reducing contention compared to a single lock
+// or SyncMap under write-heavy workloads.
+type ShardedMap[K comparable, V any] struct {
+	shards []mapShard[K, V]
+	mask   uint64
+	hash   func(K) uint64
+}
+
+type mapShard[K comparable, V any] struct {
+	mtx sync.RWMutex
+	m   map[K]V
+	// ver is incremented on every write, used to detect conflicts with
+	// transactions. Guarded by mtx.
+	ver uint64
+	// Pad to avoid false sharing between shards
+	_ [24]byte
+}
+
+// NewShardedMap creates a new ShardedMap.
+func NewShardedMap[K comparable, V any](
+	opts ShardedMapOpts[K],
+) *ShardedMap[K, V] {
+	n := opts.Shards
+	if n < 1 {
+		n = DefaultShardCount
+	}
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	sm := &ShardedMap[K, V]{
+		shards: make([]mapShard[K, V], size),
+		mask:   uint64(size - 1),
+		hash:   opts.Hash,
+	}
+	if sm.hash == nil {
+		sm.hash = defaultKeyHash[K](maphash.MakeSeed())
+	}
+	for i := range sm.shards {
+		sm.shards[i].m = make(map[K]V)
+	}
+	return sm
+}
+
+func defaultKeyHash[K comparable](seed maphash.Seed) func(K) uint64 {
+	mix := mixHash
+	return func(key K) uint64 {
+		switch k := any(key).(type) {
+		case string:
+			return maphash.String(seed, k)
+		case int:
+			return mix(uint64(k))
+		case int8:
+			return mix(uint64(k))
+		case int16:
+			return mix(uint64(k))
+		case int32:
+			return mix(uint64(k))
+		case int64:
+			return mix(uint64(k))
+		case uint:
+			return mix(uint64(k))
+		case uint8:
+			return mix(uint64(k))
+		case uint16:
+			return mix(uint64(k))
+		case uint32:
+			return mix(uint64(k))
+		case uint64:
+			return mix(k)
+		case uintptr:
+			return mix(uint64(k))
+		case float32:
+			return mix(floatKeyBits(float64(k)))
+		case float64:
+			return mix(floatKeyBits(k))
+		case bool:
+			if k {
+				return 1
+			}
+			return 0
+		default:
+			return maphash.String(seed, fmt.Sprintf("%#v", key))
+		}
+	}
+}
+
+// floatKeyBits returns the bits of the float with -0 normalized to 0.
+func floatKeyBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+func (sm *ShardedMap[K, V]) shard(key K) *mapShard[K, V] {
+	return &sm.shards[sm.hash(key)&sm.mask]
+}
+
+// Set sets the key to the value.
+func (sm *ShardedMap[K, V]) Set(key K, value V) {
+	s := sm.shard(key)
+	s.mtx.Lock()
+	s.m[key] = value
+	s.ver++
+	s.mtx.Unlock()
+}
+
+// Insert sets the key to the value, returning the old value if it existed.
+func (sm *ShardedMap[K, V]) Insert(key K, value V) (old V, existed bool) {
+	s := sm.shard(key)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	old, existed = s.m[key]
+	s.m[key] = value
+	s.ver++
+	return
+}
+
+// TrySet sets the key to the value only if the key doesn't exist, returning
+// false if it does.
+func (sm *ShardedMap[K, V]) TrySet(key K, value V) bool {
+	s := sm.shard(key)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.m[key]; ok {
+		return false
+	}
+	s.m[key] = value
+	s.ver++
+	return true
+}
+
+// Update atomically updates the value for the key. f is called with the
+// current value (and whether it exists) and returns the new value and whether
+// to keep it (false deletes the key). f must not use the map.
+func (sm *ShardedMap[K, V]) Update(key K, f func(V, bool) (V, bool)) {
+	s := sm.shard(key)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	old, ok := s.m[key]
+	if v, keep := f(old, ok); keep {
+		s.m[key] = v
+	} else if ok {
+		delete(s.m, key)
+	}
+	s.ver++
+}
+
+// Get gets the value for the key or returns the default.
+func (sm *ShardedMap[K, V]) Get(key K) V {
+	v, _ := sm.GetOk(key)
+	return v
+}
+
+// GetOk gets the value for the key, returning false if it doesn't exist.
+func (sm *ShardedMap[K, V]) GetOk(key K) (V, bool) {
+	s := sm.shard(key)
+	s.mtx.RLock()
+	v, ok := s.m[key]
+	s.mtx.RUnlock()
+	return v, ok
+}
+
+// GetByValue gets a key/value pair for which the value satisfies the given
+// predicate, returning false if one doesn't exist.
+func (sm *ShardedMap[K, V]) GetByValue(
+	pred func(V) bool,
+) (k K, v V, ok bool) {
+	sm.Range(func(key K, val V) bool {
+		if pred(val) {
+			k, v, ok = key, val, true
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// ContainsKey returns whether the map contains the given key.
+func (sm *ShardedMap[K, V]) ContainsKey(key K) bool {
+	_, ok := sm.GetOk(key)
+	return ok
+}
+
+// ContainsValue returns whether the map contains a value that satisfies the
+// given predicate.
+func (sm *ShardedMap[K, V]) ContainsValue(pred func(V) bool) bool {
+	_, _, ok := sm.GetByValue(pred)
+	return ok
+}
+
+// Delete deletes the key.
+func (sm *ShardedMap[K, V]) Delete(key K) {
+	s := sm.shard(key)
+	s.mtx.Lock()
+	delete(s.m, key)
+	s.ver++
+	s.mtx.Unlock()
+}
+
+// GetDelete gets the value for the key and then deletes it, returning false
+// if it didn't exist.
+func (sm *ShardedMap[K, V]) GetDelete(key K) (V, bool) {
+	s := sm.shard(key)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	v, ok := s.m[key]
+	if ok {
+		delete(s.m, key)
+		s.ver++
+	}
+	return v, ok
+}
+
+// Len returns the number of entries. It may be inaccurate while the map is
+// being concurrently modified.
+func (sm *ShardedMap[K, V]) Len() int {
+	n := 0
+	for i := range sm.shards {
+		s := &sm.shards[i]
+		s.mtx.RLock()
+		n += len(s.m)
+		s.mtx.RUnlock()
+	}
+	return n
+}
+
+// Range calls f with each key/value pair, one shard at a time, stopping if f
+// returns false. Each shard's entries are copied before f is called, so f may
+// use the map, though it won't see modifications to shards already copied.
+func (sm *ShardedMap[K, V]) Range(f func(K, V) bool) {
+	type entry struct {
+		k K
+		v V
+	}
+	var entries []entry
+	for i := range sm.shards {
+		s := &sm.shards[i]
+		entries = entries[:0]
+		s.mtx.RLock()
+		for k, v := range s.m {
+			entries = append(entries, entry{k, v})
+		}
+		s.mtx.RUnlock()
+		for _, e := range entries {
+			if !f(e.k, e.v) {
+				return
+			}
+		}
+	}
+}
+
+// FilterMap calls f with each key/value pair, keeping the entry with the
+// returned value if it returns true and deleting it otherwise. Each shard is
+// locked while f is called on its entries, so f must not use the map.
+func (sm *ShardedMap[K, V]) FilterMap(f func(K, V) (V, bool)) {
+	for i := range sm.shards {
+		s := &sm.shards[i]
+		s.mtx.Lock()
+		for k, v := range s.m {
+			if v2, ok := f(k, v); ok {
+				s.m[k] = v2
+			} else {
+				delete(s.m, k)
+			}
+		}
+		s.ver++
+		s.mtx.Unlock()
+	}
+}
+
+// Filter removes the entries not satisfying the predicate. See FilterMap.
+func (sm *ShardedMap[K, V]) Filter(f func(K, V) bool) {
+	sm.FilterMap(func(k K, v V) (V, bool) { return v, f(k, v) })
+}
+
+// FilterKeys removes the entries with keys not satisfying the predicate. See
+// FilterMap.
+func (sm *ShardedMap[K, V]) FilterKeys(f func(K) bool) {
+	sm.FilterMap(func(k K, v V) (V, bool) { return v, f(k) })
+}
+
+// FilterValues removes the entries with values not satisfying the predicate.
+// See FilterMap.
+func (sm *ShardedMap[K, V]) FilterValues(f func(V) bool) {
+	sm.FilterMap(func(_ K, v V) (V, bool) { return v, f(v) })
+}
+
+// FilterMapValues is the same as FilterMap but is only passed the values.
+func (sm *ShardedMap[K, V]) FilterMapValues(f func(V) (V, bool)) {
+	sm.FilterMap(func(_ K, v V) (V, bool) { return f(v) })
+}
+
+// Map maps each value to a new value. See FilterMap.
+func (sm *ShardedMap[K, V]) Map(f func(K, V) V) {
+	sm.FilterMap(func(k K, v V) (V, bool) { return f(k, v), true })
+}
+
+// MapValues maps each value to a new value. See FilterMap.
+func (sm *ShardedMap[K, V]) MapValues(f func(V) V) {
+	sm.FilterMap(func(_ K, v V) (V, bool) { return f(v), true })
+}
+
+// SetAll sets all the key/value pairs from the map, locking each shard once.
+func (sm *ShardedMap[K, V]) SetAll(m map[K]V) {
+	byShard := make(map[*mapShard[K, V]][]K)
+	for k := range m {
+		s := sm.shard(k)
+		byShard[s] = append(byShard[s], k)
+	}
+	for s, keys := range byShard {
+		s.mtx.Lock()
+		for _, k := range keys {
+			s.m[k] = m[k]
+		}
+		s.ver++
+		s.mtx.Unlock()
+	}
+}
+
+// GetAll returns the values for the keys that exist.
+func (sm *ShardedMap[K, V]) GetAll(keys ...K) map[K]V {
+	res := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := sm.GetOk(k); ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// DeleteAll deletes all the keys.
+func (sm *ShardedMap[K, V]) DeleteAll(keys ...K) {
+	for _, k := range keys {
+		sm.Delete(k)
+	}
+}
+
+// Clear deletes all entries.
+func (sm *ShardedMap[K, V]) Clear() {
+	for i := range sm.shards {
+		s := &sm.shards[i]
+		s.mtx.Lock()
+		s.m = make(map[K]V)
+		s.ver++
+		s.mtx.Unlock()
+	}
+}
+
+// ToGoMap returns a copy of the entries as a Go map.
+func (sm *ShardedMap[K, V]) ToGoMap() map[K]V {
+	m := make(map[K]V, sm.Len())
+	sm.Range(func(k K, v V) bool {
+		m[k] = v
+		return true
+	})
+	return m
+}
+
+// Clone returns a copy of the map with the same options.
+func (sm *ShardedMap[K, V]) Clone() *ShardedMap[K, V] {
+	clone := &ShardedMap[K, V]{
+		shards: make([]mapShard[K, V], len(sm.shards)),
+		mask:   sm.mask,
+		hash:   sm.hash,
+	}
+	for i := range sm.shards {
+		s := &sm.shards[i]
+		s.mtx.RLock()
+		clone.shards[i].m = CloneMap(s.m)
+		s.mtx.RUnlock()
+	}
+	return clone
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"hash/maphash"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestShardedMap(t *testing.T) {
+	sm := NewShardedMap[string, int](ShardedMapOpts[string]{Shards: 5})
+	if len(sm.shards) != 8 {
+		t.Fatalf("expected 8 shards, got %d", len(sm.shards))
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sm.Set(strconv.Itoa(i*100+j), i*100+j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := sm.Len(); n != 800 {
+		t.Fatalf("expected 800 entries, got %d", n)
+	}
+	if v, ok := sm.GetOk("123"); !ok || v != 123 {
+		t.Fatalf("expected 123, got %d %v", v, ok)
+	}
+	if sm.TrySet("123", 0) || !sm.TrySet("x", 0) {
+		t.Fatal("bad TrySet")
+	}
+	sm.Update("x", func(v int, ok bool) (int, bool) { return v + 5, ok })
+	if sm.Get("x") != 5 {
+		t.Fatalf("expected 5, got %d", sm.Get("x"))
+	}
+	sm.Update("x", func(int, bool) (int, bool) { return 0, false })
+	if sm.ContainsKey("x") {
+		t.Fatal("expected x deleted")
+	}
+
+	sm.FilterValues(func(v int) bool { return v%2 == 0 })
+	sm.MapValues(func(v int) int { return v / 2 })
+	if n := sm.Len(); n != 400 {
+		t.Fatalf("expected 400 entries, got %d", n)
+	}
+	if v, ok := sm.GetDelete("10"); !ok || v != 5 {
+		t.Fatalf("expected 5, got %d %v", v, ok)
+	}
+
+	sm.SetAll(map[string]int{"a": 1, "b": 2})
+	if got := sm.GetAll("a", "b", "c"); len(got) != 2 || got["b"] != 2 {
+		t.Fatalf("unexpected GetAll result: %v", got)
+	}
+	sm.DeleteAll("a", "b")
+	clone := sm.Clone()
+	sm.Clear()
+	if sm.Len() != 0 || clone.Len() != 399 {
+		t.Fatalf("bad clear/clone: %d %d", sm.Len(), clone.Len())
+	}
+	if m := clone.ToGoMap(); len(m) != 399 || m["20"] != 10 {
+		t.Fatalf("bad ToGoMap: %d", len(m))
+	}
+}
+
+func TestShardedMapDefaultHash(t *testing.T) {
+	hash := defaultKeyHash[float64](maphash.MakeSeed())
+	if hash(0) != hash(math.Copysign(0, -1)) {
+		t.Fatal("expected 0 and -0 to have equal hashes")
+	}
+	type key struct {
+		A string
+		B int
+	}
+	sm := NewShardedMap[key, int](ShardedMapOpts[key]{})
+	sm.Set(key{"a", 1}, 1)
+	if v, ok := sm.GetOk(key{"a", 1}); !ok || v != 1 {
+		t.Fatal("failed to get struct key")
+	}
+}
+
+func BenchmarkShardedMapWrite(b *testing.B) {
+	sm := NewShardedMap[int, int](ShardedMapOpts[int]{})
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			sm.Set(i%1024, i)
+			i++
+		}
+	})
+}
+
+func BenchmarkSyncMapWrite(b *testing.B) {
+	m := NewSyncMap[int, int]()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Store(i%1024, i)
+			i++
+		}
+	})
+}
+
+func BenchmarkShardedMapMixed(b *testing.B) {
+	sm := NewShardedMap[int, int](ShardedMapOpts[int]{})
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%4 == 0 {
+				sm.Set(i%1024, i)
+			} else {
+				sm.Get(i % 1024)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSyncMapMixed(b *testing.B) {
+	m := NewSyncMap[int, int]()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%4 == 0 {
+				m.Store(i%1024, i)
+			} else {
+				m.Load(i % 1024)
+			}
+			i++
+		}
+	})
+}
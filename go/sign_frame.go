@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrBadSignature means a message's signature didn't verify.
+var ErrBadSignature = errors.New("bad signature")
+
+// FrameSignatureSize is the size of the signature appended by SignFrame.
+const FrameSignatureSize = sha256.Size
+
+// SignFrame returns the payload followed by its HMAC-SHA256 signature using
+// the given key.
+func SignFrame(key, payload []byte) []byte {
+	frame := make([]byte, len(payload), len(payload)+FrameSignatureSize)
+	copy(frame, payload)
+	return appendFrameMAC(frame, key, payload)
+}
+
+// VerifyFrame verifies a frame produced by SignFrame, returning the payload
+// (which shares the frame's memory) if the signature is valid, and
+// ErrBadSignature otherwise.
+func VerifyFrame(key, frame []byte) ([]byte, error) {
+	if len(frame) < FrameSignatureSize {
+		return nil, ErrBadSignature
+	}
+	split := len(frame) - FrameSignatureSize
+	payload, sig := frame[:split], frame[split:]
+	if !hmac.Equal(sig, appendFrameMAC(nil, key, payload)) {
+		return nil, ErrBadSignature
+	}
+	return payload, nil
+}
+
+func appendFrameMAC(b, key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(b)
+}
+
+// WriteSignedFrame writes the signed payload to w as a single frame: the
+// 4-byte big-endian length of the signed frame followed by the frame itself.
+func WriteSignedFrame(w io.Writer, key, payload []byte) error {
+	frame := SignFrame(key, payload)
+	buf := make([]byte, 4, 4+len(frame))
+	Place4(buf, uint32(len(frame)))
+	_, err := WriteAll(w, append(buf, frame...))
+	return err
+}
+
+// ReadSignedFrame reads a frame written by WriteSignedFrame, returning the
+// verified payload. Frames with payloads larger than maxSize (if positive)
+// return an error without being read, to avoid allocating for bogus lengths.
+func ReadSignedFrame(r io.Reader, key []byte, maxSize int) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	l := int64(Get4(lenBuf[:]))
+	if l < FrameSignatureSize {
+		return nil, ErrBadSignature
+	}
+	if maxSize > 0 && l-FrameSignatureSize > int64(maxSize) {
+		return nil, fmt.Errorf(
+			"frame payload size %d exceeds max %d", l-FrameSignatureSize, maxSize,
+		)
+	}
+	frame := make([]byte, l)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return VerifyFrame(key, frame)
+}
+
+// ConstantTimeEqual returns whether the byte slices are equal in time that
+// depends only on their lengths, not their contents, to avoid leaking secrets
+// (e.g., tokens) through timing.
+func ConstantTimeEqual(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
+
+// ConstantTimeEqualString is ConstantTimeEqual for strings.
+func ConstantTimeEqualString(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSignFrame(t *testing.T) {
+	key := []byte("secret")
+	frame := SignFrame(key, []byte("hello"))
+	if len(frame) != 5+FrameSignatureSize {
+		t.Fatalf("unexpected frame length: %d", len(frame))
+	}
+	if payload, err := VerifyFrame(key, frame); err != nil {
+		t.Fatal(err)
+	} else if string(payload) != "hello" {
+		t.Fatalf("expected hello, got %q", payload)
+	}
+	if _, err := VerifyFrame([]byte("other"), frame); err != ErrBadSignature {
+		t.Fatalf("expected ErrBadSignature, got %v", err)
+	}
+	frame[0] ^= 1
+	if _, err := VerifyFrame(key, frame); err != ErrBadSignature {
+		t.Fatalf("expected ErrBadSignature, got %v", err)
+	}
+	if _, err := VerifyFrame(key, frame[:3]); err != ErrBadSignature {
+		t.Fatalf("expected ErrBadSignature, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	for _, msg := range []string{"a", "", "bcd"} {
+		if err := WriteSignedFrame(&buf, key, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, msg := range []string{"a", "", "bcd"} {
+		if payload, err := ReadSignedFrame(&buf, key, 2); msg == "bcd" {
+			if err == nil {
+				t.Fatal("expected error for oversized frame")
+			}
+		} else if err != nil {
+			t.Fatal(err)
+		} else if string(payload) != msg {
+			t.Fatalf("expected %q, got %q", msg, payload)
+		}
+	}
+
+	if !ConstantTimeEqualString("abc", "abc") || ConstantTimeEqual(
+		[]byte("abc"), []byte("abd"),
+	) {
+		t.Fatal("bad constant time compare results")
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"encoding/json"
+	"math/rand"
+	"slices"
 	"sort"
 )
 
@@ -219,6 +221,284 @@ func FilterSliceEmptyInPlaceUnstable[T comparable](s []T) []T {
 	return FilterSliceInPlaceUnstable(s, func(x T) bool { return x == t })
 }
 
+// DedupSlice returns a new slice with adjacent duplicate elements removed
+// (e.g., [1 1 2 1] becomes [1 2 1]). Sorting the slice first removes all
+// duplicates.
+func DedupSlice[T comparable](s []T) []T {
+	return DedupSliceInPlace(CloneSlice(s))
+}
+
+// DedupSliceInPlace removes adjacent duplicate elements in place, returning a
+// slice with the same underlying array. Kept elements stay in the same order
+// and those removed are placed at the end of the given slice.
+func DedupSliceInPlace[T comparable](s []T) []T {
+	if len(s) == 0 {
+		return s
+	}
+	w := 1
+	for i := 1; i < len(s); i++ {
+		if s[i] != s[w-1] {
+			s[w], s[i] = s[i], s[w]
+			w++
+		}
+	}
+	return s[:w]
+}
+
+// UniqueSlice returns a new slice with all duplicate elements removed, keeping
+// the first occurrence of each.
+func UniqueSlice[T comparable](s []T) []T {
+	return UniqueBySlice(s, func(t T) T { return t })
+}
+
+// UniqueSliceInPlace is the same as UniqueSlice but removes the duplicates in
+// place, following the same conventions as FilterSliceInPlace.
+func UniqueSliceInPlace[T comparable](s []T) []T {
+	return UniqueBySliceInPlace(s, func(t T) T { return t })
+}
+
+// UniqueSliceInPlaceUnstable is the same as UniqueSliceInPlace but the kept
+// elements may be shuffled around, following the same conventions as
+// FilterSliceInPlaceUnstable.
+func UniqueSliceInPlaceUnstable[T comparable](s []T) []T {
+	return UniqueBySliceInPlaceUnstable(s, func(t T) T { return t })
+}
+
+// UniqueBySlice returns a new slice with elements with duplicate keys (as
+// returned by keyFn) removed, keeping the first element with each key.
+func UniqueBySlice[T any, K comparable](s []T, keyFn func(T) K) []T {
+	seen := SetWithLen[K](len(s))
+	res := make([]T, 0, len(s))
+	for _, t := range s {
+		if seen.Insert(keyFn(t)) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// UniqueBySliceInPlace is the same as UniqueBySlice but removes the
+// duplicates in place, following the same conventions as FilterSliceInPlace.
+func UniqueBySliceInPlace[T any, K comparable](
+	s []T, keyFn func(T) K,
+) []T {
+	seen := SetWithLen[K](len(s))
+	w := 0
+	for i := range s {
+		if seen.Insert(keyFn(s[i])) {
+			s[w], s[i] = s[i], s[w]
+			w++
+		}
+	}
+	return s[:w]
+}
+
+// UniqueBySliceInPlaceUnstable is the same as UniqueBySliceInPlace but the
+// kept elements may be shuffled around, following the same conventions as
+// FilterSliceInPlaceUnstable. Which of the elements with the same key is kept
+// is unspecified.
+func UniqueBySliceInPlaceUnstable[T any, K comparable](
+	s []T, keyFn func(T) K,
+) []T {
+	seen := SetWithLen[K](len(s))
+	back := len(s) - 1
+	for i := 0; i <= back; {
+		if seen.Insert(keyFn(s[i])) {
+			i++
+			continue
+		}
+		s[i], s[back] = s[back], s[i]
+		back--
+	}
+	return s[:back+1]
+}
+
+// ReverseSlice returns a new slice with the elements in reverse order.
+func ReverseSlice[T any](s []T) []T {
+	return ReverseSliceInPlace(CloneSlice(s))
+}
+
+// ReverseSliceInPlace reverses the slice in place, returning it.
+func ReverseSliceInPlace[T any](s []T) []T {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	return s
+}
+
+// RotateSlice returns a new slice with the elements rotated left by k (e.g.,
+// rotating [1 2 3 4] by 1 gives [2 3 4 1]). A negative k rotates right.
+func RotateSlice[T any](s []T, k int) []T {
+	res := make([]T, len(s))
+	if len(s) == 0 {
+		return res
+	}
+	k = rotateAmount(len(s), k)
+	copy(res, s[k:])
+	copy(res[len(s)-k:], s[:k])
+	return res
+}
+
+// RotateSliceInPlace is the same as RotateSlice but rotates the slice in
+// place, returning it.
+func RotateSliceInPlace[T any](s []T, k int) []T {
+	if len(s) == 0 {
+		return s
+	}
+	k = rotateAmount(len(s), k)
+	ReverseSliceInPlace(s[:k])
+	ReverseSliceInPlace(s[k:])
+	return ReverseSliceInPlace(s)
+}
+
+func rotateAmount(l, k int) int {
+	k %= l
+	if k < 0 {
+		k += l
+	}
+	return k
+}
+
+// ShuffleSlice shuffles the slice in place using the given source of
+// randomness, returning it. If rng is nil, the global source from math/rand
+// is used; passing a seeded rng makes the result deterministic (e.g., for
+// tests).
+func ShuffleSlice[T any](s []T, rng *rand.Rand) []T {
+	swap := func(i, j int) { s[i], s[j] = s[j], s[i] }
+	if rng == nil {
+		rand.Shuffle(len(s), swap)
+	} else {
+		rng.Shuffle(len(s), swap)
+	}
+	return s
+}
+
+// SampleSlice returns a new slice of n elements chosen randomly from the
+// slice without replacement, in random order. If n is greater than the
+// length of the slice, all elements are returned (shuffled). The rng is used
+// the same as in ShuffleSlice.
+func SampleSlice[T any](s []T, n int, rng *rand.Rand) []T {
+	if n > len(s) {
+		n = len(s)
+	} else if n < 0 {
+		n = 0
+	}
+	intn := rand.Intn
+	if rng != nil {
+		intn = rng.Intn
+	}
+	// Partial Fisher-Yates shuffle over the indexes so the slice isn't
+	// modified
+	idxs := make(map[int]int, n)
+	res := make([]T, n)
+	for i := 0; i < n; i++ {
+		j := i + intn(len(s)-i)
+		vi, ok := idxs[i]
+		if !ok {
+			vi = i
+		}
+		vj, ok := idxs[j]
+		if !ok {
+			vj = j
+		}
+		idxs[i], idxs[j] = vj, vi
+		res[i] = s[vj]
+	}
+	return res
+}
+
+// MinSlice returns the minimum element of the slice, returning false if the
+// slice is empty.
+func MinSlice[T Ordered](s []T) (T, bool) {
+	return MinBySlice(s, func(t T) T { return t })
+}
+
+// MaxSlice returns the maximum element of the slice, returning false if the
+// slice is empty.
+func MaxSlice[T Ordered](s []T) (T, bool) {
+	return MaxBySlice(s, func(t T) T { return t })
+}
+
+// MinMaxSlice returns the minimum and maximum elements of the slice,
+// returning false if the slice is empty.
+func MinMaxSlice[T Ordered](s []T) (min, max T, ok bool) {
+	if len(s) == 0 {
+		return
+	}
+	min, max = s[0], s[0]
+	for _, t := range s[1:] {
+		if t < min {
+			min = t
+		} else if t > max {
+			max = t
+		}
+	}
+	return min, max, true
+}
+
+// MinBySlice returns the first element with the minimum key, returning false
+// if the slice is empty.
+func MinBySlice[T any, K Ordered](s []T, key func(T) K) (t T, ok bool) {
+	if len(s) == 0 {
+		return
+	}
+	t, minKey := s[0], key(s[0])
+	for _, elem := range s[1:] {
+		if k := key(elem); k < minKey {
+			t, minKey = elem, k
+		}
+	}
+	return t, true
+}
+
+// MaxBySlice returns the first element with the maximum key, returning false
+// if the slice is empty.
+func MaxBySlice[T any, K Ordered](s []T, key func(T) K) (t T, ok bool) {
+	if len(s) == 0 {
+		return
+	}
+	t, maxKey := s[0], key(s[0])
+	for _, elem := range s[1:] {
+		if k := key(elem); k > maxKey {
+			t, maxKey = elem, k
+		}
+	}
+	return t, true
+}
+
+// SumSlice returns the sum of the elements of the slice (0 if empty).
+func SumSlice[T Number](s []T) T {
+	var sum T
+	for _, t := range s {
+		sum += t
+	}
+	return sum
+}
+
+// ProductSlice returns the product of the elements of the slice (1 if
+// empty).
+func ProductSlice[T Number](s []T) T {
+	prod := T(1)
+	for _, t := range s {
+		prod *= t
+	}
+	return prod
+}
+
+// MeanSlice returns the arithmetic mean of the elements of the slice,
+// returning false if the slice is empty. The sum is computed as a float64 to
+// avoid overflowing integer types.
+func MeanSlice[T Number](s []T) (float64, bool) {
+	if len(s) == 0 {
+		return 0, false
+	}
+	sum := 0.0
+	for _, t := range s {
+		sum += float64(t)
+	}
+	return sum / float64(len(s)), true
+}
+
 /*
 // Index is a constraint for types that can be indexed.
 type Index interface {
@@ -268,7 +548,8 @@ func (s *Slice[T]) UnmarshalJSON(b []byte) error {
 // useful when there's a slice elsewhere and operators are to be performed on
 // it without directly being having to reassign it for every operation.
 type SlicePtr[T any] struct {
-	Ptr *[]T
+	Ptr    *[]T
+	growth *SliceGrowthOpts[T]
 }
 
 // NewSlicePtr creates a new slice ptr.
@@ -416,25 +697,37 @@ func (sp *SlicePtr[T]) LastPtrNil() *T {
 
 // PushFront appends the value to the front of the slice.
 func (sp *SlicePtr[T]) PushFront(elem T) {
+	if sp.growth != nil {
+		sp.Insert(0, elem)
+		return
+	}
 	*sp.Ptr = append([]T{elem}, sp.Data()...)
 }
 
 // PushBack appends the value to the back of the slice.
 func (sp *SlicePtr[T]) PushBack(elem T) {
+	sp.reserve(1)
 	*sp.Ptr = append(*sp.Ptr, elem)
 }
 
-// Insert inserts the element at the specified index.
+// Insert inserts the element at the specified index, shifting the elements at
+// and after it back. Panics if the index is not in [0, Len()].
 func (sp *SlicePtr[T]) Insert(i int, elem T) {
-	if i == sp.Len() {
-		sp.PushBack(elem)
-	} else {
-		*sp.Ptr = append(append((*sp.Ptr)[:i], elem), (*sp.Ptr)[i+1:]...)
-	}
+	sp.reserve(1)
+	*sp.Ptr = slices.Insert(*sp.Ptr, i, elem)
+}
+
+// InsertAll inserts the elements at the specified index, in order, shifting
+// the elements at and after it back. Panics if the index is not in
+// [0, Len()].
+func (sp *SlicePtr[T]) InsertAll(i int, elems ...T) {
+	sp.reserve(len(elems))
+	*sp.Ptr = slices.Insert(*sp.Ptr, i, elems...)
 }
 
 // Append appends the elements to the slice.
 func (sp *SlicePtr[T]) Append(elems ...T) {
+	sp.reserve(len(elems))
 	*sp.Ptr = append(*sp.Ptr, elems...)
 }
 
@@ -445,13 +738,38 @@ func (sp *SlicePtr[T]) AppendToSlicePtr(other *[]T) {
 
 // Remove removes an element from the slice, returning it if it exists.
 func (sp *SlicePtr[T]) Remove(i int) (t T, ok bool) {
-	if i >= 0 || i < sp.Len() {
+	if i >= 0 && i < sp.Len() {
 		t, ok = sp.Data()[i], true
-		*sp.Ptr = append(sp.Data()[:i], sp.Data()[i+1:]...)
+		*sp.Ptr = slices.Delete(*sp.Ptr, i, i+1)
 	}
 	return
 }
 
+// RemoveRange removes the elements in [i, j), returning them in a new slice.
+// Panics if the range is out of bounds.
+func (sp *SlicePtr[T]) RemoveRange(i, j int) []T {
+	removed := slices.Clone(sp.Data()[i:j])
+	*sp.Ptr = slices.Delete(*sp.Ptr, i, j)
+	return removed
+}
+
+// Swap swaps the elements at the given indexes. Panics if either index is out
+// of bounds.
+func (sp *SlicePtr[T]) Swap(i, j int) {
+	s := sp.Data()
+	s[i], s[j] = s[j], s[i]
+}
+
+// MoveToFront moves the element at the given index to the front of the slice,
+// shifting the elements before it back by one. Panics if the index is out of
+// bounds.
+func (sp *SlicePtr[T]) MoveToFront(i int) {
+	s := sp.Data()
+	t := s[i]
+	copy(s[1:i+1], s[:i])
+	s[0] = t
+}
+
 // RemoveFirst removes the first element satisfying the predicate, returning it
 // if it exists.
 func (sp *SlicePtr[T]) RemoveFirst(f func(T) bool) (t T, ok bool) {
@@ -520,6 +838,21 @@ func (sp *SlicePtr[T]) IndexLast(f func(T) bool) int {
 	return -1
 }
 
+// Range iterates over each index and element in order, applying a given
+// function that returns whether the iterations should continue.
+func (sp *SlicePtr[T]) Range(f func(int, T) bool) {
+	for i, t := range sp.Data() {
+		if !f(i, t) {
+			return
+		}
+	}
+}
+
+// Freeze returns a FrozenSlice with a copy of the slice's data.
+func (sp *SlicePtr[T]) Freeze() *FrozenSlice[T] {
+	return NewFrozenSlice(sp.Data())
+}
+
 // Contains returns true if the slice contains the element satisfying the
 // predicate.
 func (sp *SlicePtr[T]) Contains(f func(T) bool) bool {
@@ -596,6 +929,43 @@ func (sp *SlicePtr[T]) Sort(less func(i, j int) bool) {
 	sort.Slice(sp.Data(), less)
 }
 
+// SortBy sorts the slice using a less function comparing elements (rather
+// than indexes, like Sort).
+func (sp *SlicePtr[T]) SortBy(less func(a, b T) bool) {
+	data := sp.Data()
+	sort.Slice(data, func(i, j int) bool { return less(data[i], data[j]) })
+}
+
+// IsSorted returns whether the slice is sorted according to cmp, which
+// returns a negative number when a < b, a positive number when a > b, and 0
+// when they're equal (e.g., cmp.Compare).
+func (sp *SlicePtr[T]) IsSorted(cmp func(a, b T) int) bool {
+	return slices.IsSortedFunc(sp.Data(), cmp)
+}
+
+// BinarySearch searches the sorted slice for an element, using cmp to compare
+// elements to the target (negative if the element is before the target,
+// positive if after, and 0 if it's a match). Returns the index of the first
+// match and true, or the index the target would be inserted at and false.
+func (sp *SlicePtr[T]) BinarySearch(cmp func(T) int) (int, bool) {
+	data := sp.Data()
+	i := sort.Search(len(data), func(i int) bool { return cmp(data[i]) >= 0 })
+	return i, i < len(data) && cmp(data[i]) == 0
+}
+
+// InsertSorted inserts the element into the sorted slice, after any equal
+// elements, keeping it sorted according to cmp (see IsSorted). Returns the
+// index the element was inserted at.
+func (sp *SlicePtr[T]) InsertSorted(elem T, cmp func(a, b T) int) int {
+	data := sp.Data()
+	i := sort.Search(len(data), func(i int) bool {
+		return cmp(data[i], elem) > 0
+	})
+	sp.reserve(1)
+	*sp.Ptr = slices.Insert(sp.Data(), i, elem)
+	return i
+}
+
 func (sp *SlicePtr[T]) MarshalJSON() ([]byte, error) {
 	return json.Marshal(sp.Data())
 }
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOutOfRange means an index (or slice bounds) was out of range.
+var ErrOutOfRange = errors.New("out of range")
+
+// IndexError is returned by CheckedSlicePtr when an index or slice bounds are
+// out of range. It matches ErrOutOfRange with errors.Is, and also ErrEmpty if
+// the slice was empty.
+type IndexError struct {
+	// Op is the name of the operation (e.g., "Get").
+	Op string
+	// Index is the index, or the start of the slice bounds.
+	Index int
+	// End is the end of the slice bounds, or -1 if there were none.
+	End int
+	// Len is the length of the slice.
+	Len int
+}
+
+// Error implements the error interface.
+func (ie *IndexError) Error() string {
+	if ie.End != -1 {
+		return fmt.Sprintf(
+			"%s: slice bounds [%d:%d] out of range with length %d",
+			ie.Op, ie.Index, ie.End, ie.Len,
+		)
+	}
+	return fmt.Sprintf(
+		"%s: index %d out of range with length %d", ie.Op, ie.Index, ie.Len,
+	)
+}
+
+// Is implements errors.Is, matching ErrOutOfRange, and ErrEmpty if the
+// length is 0.
+func (ie *IndexError) Is(target error) bool {
+	return target == ErrOutOfRange || (target == ErrEmpty && ie.Len == 0)
+}
+
+// CheckedSlicePtr wraps a SlicePtr, returning errors rather than panicking
+// (or silently doing nothing) for out-of-range indexes. Errors are
+// *IndexError.
+type CheckedSlicePtr[T any] struct {
+	sp *SlicePtr[T]
+}
+
+// NewSlicePtrChecked creates a new CheckedSlicePtr for the slice pointer.
+func NewSlicePtrChecked[T any](ptr *[]T) *CheckedSlicePtr[T] {
+	return NewSlicePtr(ptr).Checked()
+}
+
+// Checked returns a CheckedSlicePtr wrapping the SlicePtr.
+func (sp *SlicePtr[T]) Checked() *CheckedSlicePtr[T] {
+	return &CheckedSlicePtr[T]{sp: sp}
+}
+
+// Unchecked returns the wrapped SlicePtr.
+func (csp *CheckedSlicePtr[T]) Unchecked() *SlicePtr[T] {
+	return csp.sp
+}
+
+// Data returns the data of the underlying slice pointer.
+func (csp *CheckedSlicePtr[T]) Data() []T {
+	return csp.sp.Data()
+}
+
+// Len returns the length of the slice.
+func (csp *CheckedSlicePtr[T]) Len() int {
+	return csp.sp.Len()
+}
+
+func (csp *CheckedSlicePtr[T]) check(op string, i, maxIndex int) error {
+	if i < 0 || i > maxIndex {
+		return &IndexError{Op: op, Index: i, End: -1, Len: csp.Len()}
+	}
+	return nil
+}
+
+// Get returns the element at the index.
+func (csp *CheckedSlicePtr[T]) Get(i int) (t T, err error) {
+	if err = csp.check("Get", i, csp.Len()-1); err == nil {
+		t = csp.sp.Get(i)
+	}
+	return
+}
+
+// GetPtr returns a pointer to the element at the index.
+func (csp *CheckedSlicePtr[T]) GetPtr(i int) (*T, error) {
+	if err := csp.check("GetPtr", i, csp.Len()-1); err != nil {
+		return nil, err
+	}
+	return csp.sp.GetPtr(i), nil
+}
+
+// Set sets the element at the index.
+func (csp *CheckedSlicePtr[T]) Set(i int, t T) error {
+	if err := csp.check("Set", i, csp.Len()-1); err != nil {
+		return err
+	}
+	*csp.sp.GetPtr(i) = t
+	return nil
+}
+
+// GetSlice slices the underlying slice, with -1 meaning the start or end of
+// the slice, respectively (the same as SlicePtr.GetSlice).
+func (csp *CheckedSlicePtr[T]) GetSlice(start, end int) ([]T, error) {
+	l := csp.Len()
+	s, e := start, end
+	if s == -1 {
+		s = 0
+	}
+	if e == -1 {
+		e = l
+	}
+	if s < 0 || e > l || s > e {
+		return nil, &IndexError{Op: "GetSlice", Index: start, End: end, Len: l}
+	}
+	return csp.sp.Data()[s:e], nil
+}
+
+// First returns the first element.
+func (csp *CheckedSlicePtr[T]) First() (t T, err error) {
+	if err = csp.check("First", 0, csp.Len()-1); err == nil {
+		t = csp.sp.Data()[0]
+	}
+	return
+}
+
+// Last returns the last element.
+func (csp *CheckedSlicePtr[T]) Last() (t T, err error) {
+	l := csp.Len()
+	if err = csp.check("Last", l-1, l-1); err == nil {
+		t = csp.sp.Data()[l-1]
+	}
+	return
+}
+
+// Insert inserts the element at the index, shifting the elements at and after
+// it back. The index may be equal to the length, appending the element.
+func (csp *CheckedSlicePtr[T]) Insert(i int, t T) error {
+	if err := csp.check("Insert", i, csp.Len()); err != nil {
+		return err
+	}
+	csp.sp.Insert(i, t)
+	return nil
+}
+
+// Remove removes and returns the element at the index.
+func (csp *CheckedSlicePtr[T]) Remove(i int) (t T, err error) {
+	if err = csp.check("Remove", i, csp.Len()-1); err != nil {
+		return
+	}
+	t, _ = csp.sp.Remove(i)
+	return
+}
+
+// PopFront removes and returns the first element.
+func (csp *CheckedSlicePtr[T]) PopFront() (T, error) {
+	t, ok := csp.sp.PopFront()
+	if !ok {
+		return t, &IndexError{Op: "PopFront", Index: 0, End: -1}
+	}
+	return t, nil
+}
+
+// PopBack removes and returns the last element.
+func (csp *CheckedSlicePtr[T]) PopBack() (T, error) {
+	t, ok := csp.sp.PopBack()
+	if !ok {
+		return t, &IndexError{Op: "PopBack", Index: -1, End: -1}
+	}
+	return t, nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckedSlicePtr(t *testing.T) {
+	var data []int
+	s := NewSlicePtrChecked(&data)
+	if _, err := s.Get(0); !errors.Is(err, ErrOutOfRange) ||
+		!errors.Is(err, ErrEmpty) {
+		t.Fatalf("expected ErrOutOfRange and ErrEmpty, got %v", err)
+	}
+	if _, err := s.PopFront(); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+	if _, err := s.Last(); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+
+	for i, v := range []int{1, 3, 0, 2} {
+		if err := s.Insert(min(i, v), v); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+	if !SliceEq(data, []int{0, 1, 2, 3}) {
+		t.Fatalf("expected [0 1 2 3], got %v", data)
+	}
+	err := s.Insert(5, 5)
+	var ie *IndexError
+	if !errors.As(err, &ie) || ie.Op != "Insert" || ie.Index != 5 ||
+		ie.Len != 4 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err.Error() != "Insert: index 5 out of range with length 4" {
+		t.Fatalf("unexpected error message: %s", err)
+	}
+	if errors.Is(err, ErrEmpty) {
+		t.Fatal("unexpected ErrEmpty for non-empty slice")
+	}
+
+	if v, err := s.Get(2); v != 2 || err != nil {
+		t.Fatalf("expected 2, nil, got %d, %v", v, err)
+	}
+	if _, err := s.Get(-1); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("expected ErrOutOfRange, got %v", err)
+	}
+	if err := s.Set(1, 10); err != nil || data[1] != 10 {
+		t.Fatalf("unexpected Set result: %v, %v", err, data)
+	}
+	if p, err := s.GetPtr(4); p != nil || err == nil {
+		t.Fatal("expected error for out-of-range GetPtr")
+	}
+	if first, _ := s.First(); first != 0 {
+		t.Fatalf("expected first of 0, got %d", first)
+	}
+	if last, _ := s.Last(); last != 3 {
+		t.Fatalf("expected last of 3, got %d", last)
+	}
+
+	if sub, err := s.GetSlice(1, -1); err != nil ||
+		!SliceEq(sub, []int{10, 2, 3}) {
+		t.Fatalf("unexpected GetSlice result: %v, %v", sub, err)
+	}
+	_, err = s.GetSlice(3, 2)
+	if err == nil ||
+		err.Error() != "GetSlice: slice bounds [3:2] out of range with length 4" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v, err := s.Remove(1); v != 10 || err != nil {
+		t.Fatalf("expected 10, nil, got %d, %v", v, err)
+	}
+	if _, err := s.Remove(3); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("expected ErrOutOfRange, got %v", err)
+	}
+	if !SliceEq(data, []int{0, 2, 3}) || s.Len() != 3 {
+		t.Fatalf("expected [0 2 3], got %v", data)
+	}
+	if v, err := s.PopBack(); v != 3 || err != nil {
+		t.Fatalf("expected 3, nil, got %d, %v", v, err)
+	}
+	if s.Unchecked().Len() != 2 || !SliceEq(s.Data(), []int{0, 2}) {
+		t.Fatalf("unexpected data: %v", s.Data())
+	}
+}
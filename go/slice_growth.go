@@ -0,0 +1,86 @@
+package utils
+
+// SliceGrowthOpts control how a Slice or SlicePtr grows its backing array when
+// elements are added (Append, PushBack, PushFront, Insert, InsertAll, and
+// InsertSorted). As with append, slices previously returned by Data (or other
+// views) no longer refer to the slice's data once it grows.
+type SliceGrowthOpts[T any] struct {
+	// Pool, if not nil, is used to get new backing arrays when growing. Arrays
+	// from the pool that are too small are put back and a new one is
+	// allocated. Arrays are only put back into the pool by Release, since
+	// slices previously returned by Data (or other views) may still refer to
+	// old arrays.
+	Pool *SyncPool[[]T]
+	// Factor is how much the capacity is multiplied by when growing. If less
+	// than 1, 2 is used. The capacity is always at least what's needed.
+	Factor float64
+	// MinCap is the minimum capacity to grow to.
+	MinCap int
+}
+
+// NewSliceWithPool creates an empty Slice that gets its backing arrays from
+// the pool when growing. Release should be called when the Slice is no longer
+// used to return the backing array to the pool.
+func NewSliceWithPool[T any](pool *SyncPool[[]T]) *Slice[T] {
+	return NewSliceWithGrowth[T](nil, SliceGrowthOpts[T]{Pool: pool})
+}
+
+// NewSliceWithGrowth creates a Slice around the data that grows according to
+// the given options.
+func NewSliceWithGrowth[T any](
+	data []T, opts SliceGrowthOpts[T],
+) *Slice[T] {
+	s := NewSlice(data)
+	s.SetGrowth(opts)
+	return s
+}
+
+// SetGrowth sets the options used when growing the slice's backing array.
+func (sp *SlicePtr[T]) SetGrowth(opts SliceGrowthOpts[T]) {
+	if opts.Factor < 1 {
+		opts.Factor = 2
+	}
+	sp.growth = &opts
+}
+
+// Release puts the backing array into the growth pool, if there is one, and
+// sets the slice to nil. The array is cleared first, so any slices of it
+// previously returned (e.g., by Data or GetSlice) must not be used after this
+// is called.
+func (sp *SlicePtr[T]) Release() {
+	if sp.Ptr == nil {
+		return
+	}
+	s := *sp.Ptr
+	if g := sp.growth; g != nil && g.Pool != nil && cap(s) != 0 {
+		// Clear so the pool doesn't keep the elements alive.
+		clear(s[:cap(s)])
+		g.Pool.Put(s[:0])
+	}
+	*sp.Ptr = nil
+}
+
+// reserve makes room for n more elements according to the growth options, if
+// there are any. Otherwise, growth is left to append. The old array is left
+// as is (not cleared or pooled) since the caller may still hold slices of it.
+func (sp *SlicePtr[T]) reserve(n int) {
+	g, s := sp.growth, sp.Data()
+	need := len(s) + n
+	if g == nil || need <= cap(s) {
+		return
+	}
+	newCap := max(need, int(float64(cap(s))*g.Factor), g.MinCap)
+	var ns []T
+	if g.Pool != nil {
+		if p := g.Pool.Get(); cap(p) >= newCap {
+			ns = p[:len(s)]
+		} else if cap(p) != 0 {
+			g.Pool.Put(p)
+		}
+	}
+	if ns == nil {
+		ns = make([]T, len(s), newCap)
+	}
+	copy(ns, s)
+	*sp.Ptr = ns
+}
@@ -0,0 +1,101 @@
+package utils
+
+import "testing"
+
+func TestSliceGrowth(t *testing.T) {
+	pool := NewSyncPool[[]int](nil)
+	s := NewSliceWithPool(pool)
+	for i := 0; i < 100; i++ {
+		s.PushBack(i)
+	}
+	s.InsertAll(0, -2, -1)
+	s.PushFront(-3)
+	if s.Len() != 103 || s.First() != -3 || s.Get(3) != 0 || s.Get(102) != 99 {
+		t.Fatalf("unexpected data: %v", s.Data())
+	}
+
+	// The released array should be reused by a new slice.
+	backing := &s.Data()[0]
+	s.Release()
+	if s.Len() != 0 || s.Data() != nil {
+		t.Fatalf("expected nil data after release, got %v", s.Data())
+	}
+	s2 := NewSliceWithGrowth([]int{1}, SliceGrowthOpts[int]{
+		Pool: pool, Factor: 1.5, MinCap: 8,
+	})
+	s2.PushBack(2)
+	if &s2.Data()[0] != backing {
+		// sync.Pool may drop items, so this isn't guaranteed.
+		t.Log("released array wasn't reused")
+	} else if s2.Data()[1] != 2 || s2.Len() != 2 {
+		t.Fatalf("unexpected data: %v", s2.Data())
+	}
+
+	// Without a pool, Factor and MinCap still apply.
+	s3 := NewSliceWithGrowth[int](nil, SliceGrowthOpts[int]{MinCap: 10})
+	s3.PushBack(1)
+	if cap(s3.Data()) != 10 {
+		t.Fatalf("expected cap of 10, got %d", cap(s3.Data()))
+	}
+	s3.Append(RangeSlice(10)...)
+	if cap(s3.Data()) != 20 || s3.Len() != 11 {
+		t.Fatalf("expected len 11 and cap 20, got %d and %d",
+			s3.Len(), cap(s3.Data()))
+	}
+}
+
+func TestSliceGrowthKeepsOldArrays(t *testing.T) {
+	pool := NewSyncPool[[]int](nil)
+	opts := SliceGrowthOpts[int]{Pool: pool}
+
+	// Views taken before growing keep their values.
+	s := NewSliceWithGrowth([]int{}, opts)
+	for i := 1; i <= 3; i++ {
+		s.PushBack(i)
+	}
+	view := s.Data()
+	s.Append(RangeSlice(100)...)
+	if !SliceEq(view, []int{1, 2, 3}) {
+		t.Fatalf("view changed after growing: %v", view)
+	}
+
+	// An array the caller puts in the slice isn't cleared or pooled.
+	mine := []int{7, 8, 9}
+	sp := NewSlicePtr(&mine)
+	sp.SetGrowth(opts)
+	sp.PushBack(1)
+	other := make([]int, 3)
+	copy(other, []int{4, 5, 6})
+	mine = other
+	sp.Append(RangeSlice(100)...)
+	if !SliceEq(other, []int{4, 5, 6}) {
+		t.Fatalf("caller's array changed after growing: %v", other)
+	}
+	if got := sp.Data()[:4]; !SliceEq(got, []int{4, 5, 6, 0}) {
+		t.Fatalf("unexpected data: %v", got)
+	}
+}
+
+func BenchmarkSliceGrowth(b *testing.B) {
+	const n = 4096
+	b.Run("Append", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s := NewSlice[int](nil)
+			for j := 0; j < n; j++ {
+				s.PushBack(j)
+			}
+		}
+	})
+	b.Run("Pool", func(b *testing.B) {
+		pool := NewSyncPool[[]int](nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s := NewSliceWithPool(pool)
+			for j := 0; j < n; j++ {
+				s.PushBack(j)
+			}
+			s.Release()
+		}
+	})
+}
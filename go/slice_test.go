@@ -3,23 +3,10 @@ package utils
 import (
 	"math/rand"
 	"testing"
-	"time"
 )
 
-func generateSlice(l int, shuffle bool) []int {
-	s := make([]int, l)
-	for i := 0; i < l; i++ {
-		s[i] = i
-	}
-	if shuffle {
-		rand.Seed(time.Now().Unix())
-		rand.Shuffle(l, func(i, j int) { s[i], s[j] = s[j], s[i] })
-	}
-	return s
-}
-
 func TestFilterSlice(t *testing.T) {
-	s := generateSlice(1000, false)
+	s := RangeSlice(1000)
 	f := func(i int) bool {
 		return i%2 == 1
 	}
@@ -72,7 +59,7 @@ func TestFilterSlice(t *testing.T) {
 }
 
 func TestFilterMapSlice(t *testing.T) {
-	s := generateSlice(1000, true)
+	s := ShuffleSlice(RangeSlice(1000), nil)
 	f := func(i int) (int, bool) {
 		if i%2 == 1 {
 			return 0, true
@@ -118,7 +105,7 @@ func TestFilterMapSlice(t *testing.T) {
 
 func TestSlice(t *testing.T) {
 	const l = 1000
-	rs := generateSlice(l, true)
+	rs := ShuffleSlice(RangeSlice(l), nil)
 	s := NewSlice(rs)
 
 	t.Run("SliceIndexing", func(t *testing.T) {
@@ -235,3 +222,180 @@ func TestSlice(t *testing.T) {
 
 	// TODO: Rest of tests and check prior tests
 }
+
+func TestSlicePtrMutation(t *testing.T) {
+	data := []int{1, 2, 3}
+	sp := NewSlicePtr(&data)
+	check := func(want ...int) {
+		t.Helper()
+		if !SliceEq(data, want) {
+			t.Fatalf("expected %v, got %v", want, data)
+		}
+	}
+
+	sp.Insert(1, 10)
+	check(1, 10, 2, 3)
+	sp.Insert(0, 0)
+	check(0, 1, 10, 2, 3)
+	sp.Insert(sp.Len(), 4)
+	check(0, 1, 10, 2, 3, 4)
+	sp.InsertAll(2, 7, 8)
+	check(0, 1, 7, 8, 10, 2, 3, 4)
+	sp.InsertAll(3)
+	check(0, 1, 7, 8, 10, 2, 3, 4)
+
+	if n, ok := sp.Remove(4); !ok || n != 10 {
+		t.Fatalf("expected 10, true, got %d, %v", n, ok)
+	}
+	check(0, 1, 7, 8, 2, 3, 4)
+	if _, ok := sp.Remove(-1); ok {
+		t.Fatal("expected false for index -1")
+	}
+	if _, ok := sp.Remove(sp.Len()); ok {
+		t.Fatal("expected false for index Len()")
+	}
+
+	if removed := sp.RemoveRange(2, 4); !SliceEq(removed, []int{7, 8}) {
+		t.Fatalf("expected [7 8], got %v", removed)
+	}
+	check(0, 1, 2, 3, 4)
+	if removed := sp.RemoveRange(1, 1); len(removed) != 0 {
+		t.Fatalf("expected nothing removed, got %v", removed)
+	}
+
+	sp.Swap(0, 4)
+	check(4, 1, 2, 3, 0)
+	sp.MoveToFront(3)
+	check(3, 4, 1, 2, 0)
+	sp.MoveToFront(0)
+	check(3, 4, 1, 2, 0)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic inserting out of bounds")
+			}
+		}()
+		sp.Insert(sp.Len()+1, 5)
+	}()
+}
+
+func TestUniqueSlice(t *testing.T) {
+	s := []int{1, 1, 2, 3, 3, 3, 1, 2, 2}
+	if got := DedupSlice(s); !SliceEq(got, []int{1, 2, 3, 1, 2}) {
+		t.Fatalf("unexpected dedup: %v", got)
+	}
+	if got := UniqueSlice(s); !SliceEq(got, []int{1, 2, 3}) {
+		t.Fatalf("unexpected unique: %v", got)
+	}
+
+	inPlace := CloneSlice(s)
+	got := UniqueSliceInPlace(inPlace)
+	if !SliceEq(got, []int{1, 2, 3}) {
+		t.Fatalf("unexpected unique in place: %v", got)
+	}
+	rest := inPlace[len(got):]
+	if SliceEq(rest, nil) || len(rest) != len(s)-3 {
+		t.Fatalf("unexpected removed elements: %v", rest)
+	}
+
+	got = UniqueSliceInPlaceUnstable(CloneSlice(s))
+	if set := SetFromSlice(got); len(got) != 3 || set.Len() != 3 {
+		t.Fatalf("unexpected unstable unique: %v", got)
+	}
+
+	words := []string{"apple", "avocado", "banana", "blueberry", "cherry"}
+	first := func(s string) byte { return s[0] }
+	want := []string{"apple", "banana", "cherry"}
+	if got := UniqueBySlice(words, first); !SliceEq(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	got2 := UniqueBySliceInPlace(CloneSlice(words), first)
+	if !SliceEq(got2, want) {
+		t.Fatalf("expected %v, got %v", want, got2)
+	}
+	got2 = UniqueBySliceInPlaceUnstable(CloneSlice(words), first)
+	if len(got2) != 3 {
+		t.Fatalf("expected 3 elements, got %v", got2)
+	}
+	if got := DedupSliceInPlace([]int(nil)); len(got) != 0 {
+		t.Fatalf("expected empty slice, got %v", got)
+	}
+}
+
+func TestReorderSlice(t *testing.T) {
+	s := []int{1, 2, 3, 4, 5}
+	if got := ReverseSlice(s); !SliceEq(got, []int{5, 4, 3, 2, 1}) {
+		t.Fatalf("unexpected reverse: %v", got)
+	}
+	if got := RotateSlice(s, 2); !SliceEq(got, []int{3, 4, 5, 1, 2}) {
+		t.Fatalf("unexpected rotate: %v", got)
+	}
+	if got := RotateSlice(s, -1); !SliceEq(got, []int{5, 1, 2, 3, 4}) {
+		t.Fatalf("unexpected rotate: %v", got)
+	}
+	got := RotateSliceInPlace(CloneSlice(s), 7)
+	if !SliceEq(got, []int{3, 4, 5, 1, 2}) {
+		t.Fatalf("unexpected rotate in place: %v", got)
+	}
+	if !SliceEq(s, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("original modified: %v", s)
+	}
+
+	s1 := ShuffleSlice(RangeSlice(20), rand.New(rand.NewSource(1)))
+	s2 := ShuffleSlice(RangeSlice(20), rand.New(rand.NewSource(1)))
+	if !SliceEq(s1, s2) {
+		t.Fatal("expected deterministic shuffle")
+	}
+
+	sample := SampleSlice(s, 3, rand.New(rand.NewSource(2)))
+	if set := SetFromSlice(sample); len(sample) != 3 || set.Len() != 3 {
+		t.Fatalf("unexpected sample: %v", sample)
+	}
+	for _, n := range sample {
+		if n < 1 || n > 5 {
+			t.Fatalf("unexpected sample: %v", sample)
+		}
+	}
+	if all := SampleSlice(s, 10, nil); len(all) != 5 {
+		t.Fatalf("expected all elements, got %v", all)
+	}
+}
+
+func TestAggregateSlice(t *testing.T) {
+	s := []int{3, 1, 4, 1, 5, 9, 2, 6}
+	if min, ok := MinSlice(s); !ok || min != 1 {
+		t.Fatalf("expected min 1, got %d", min)
+	}
+	if max, ok := MaxSlice(s); !ok || max != 9 {
+		t.Fatalf("expected max 9, got %d", max)
+	}
+	if min, max, ok := MinMaxSlice(s); !ok || min != 1 || max != 9 {
+		t.Fatalf("expected 1 and 9, got %d and %d", min, max)
+	}
+	if _, ok := MinSlice([]int(nil)); ok {
+		t.Fatal("expected false for empty slice")
+	}
+	if sum := SumSlice(s); sum != 31 {
+		t.Fatalf("expected sum 31, got %d", sum)
+	}
+	if prod := ProductSlice([]float64{1.5, 2, 3}); prod != 9 {
+		t.Fatalf("expected product 9, got %f", prod)
+	}
+	if prod := ProductSlice([]int(nil)); prod != 1 {
+		t.Fatalf("expected product 1, got %d", prod)
+	}
+	if mean, ok := MeanSlice([]uint8{200, 200, 100}); !ok ||
+		mean != 500.0/3 {
+		t.Fatalf("unexpected mean: %f", mean)
+	}
+
+	words := []string{"pear", "fig", "banana", "kiwi"}
+	length := func(s string) int { return len(s) }
+	if w, _ := MinBySlice(words, length); w != "fig" {
+		t.Fatalf("expected fig, got %s", w)
+	}
+	if w, _ := MaxBySlice(words, length); w != "banana" {
+		t.Fatalf("expected banana, got %s", w)
+	}
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"slices"
+	"sort"
+)
+
+// SortedSlice is a slice that is kept sorted across inserts, useful for
+// building sorted indexes. Lookups are O(log n) while inserts and removals are
+// O(n).
+type SortedSlice[T any] struct {
+	data []T
+	cmp  func(a, b T) int
+}
+
+var _ ReadOnlySlice[int] = (*SortedSlice[int])(nil)
+
+// NewSortedSlice creates a new SortedSlice ordered by cmp, which returns a
+// negative number when a < b, a positive number when a > b, and 0 when
+// they're equal (e.g., cmp.Compare). The given elements are copied and
+// sorted.
+func NewSortedSlice[T any](cmp func(a, b T) int, elems ...T) *SortedSlice[T] {
+	data := CloneSlice(elems)
+	slices.SortStableFunc(data, cmp)
+	return &SortedSlice[T]{data: data, cmp: cmp}
+}
+
+// Insert inserts the element after any equal elements, returning the index
+// it was inserted at.
+func (ss *SortedSlice[T]) Insert(elem T) int {
+	i := ss.upperBound(elem)
+	ss.data = slices.Insert(ss.data, i, elem)
+	return i
+}
+
+// InsertAll inserts all the elements.
+func (ss *SortedSlice[T]) InsertAll(elems ...T) {
+	if len(elems) < 8 {
+		for _, elem := range elems {
+			ss.Insert(elem)
+		}
+		return
+	}
+	ss.data = append(ss.data, elems...)
+	slices.SortStableFunc(ss.data, ss.cmp)
+}
+
+// Search returns the index of the first element equal to elem and true, or
+// the index it would be inserted at and false.
+func (ss *SortedSlice[T]) Search(elem T) (int, bool) {
+	i := ss.lowerBound(elem)
+	return i, i < len(ss.data) && ss.cmp(ss.data[i], elem) == 0
+}
+
+// Has returns whether the slice contains an element equal to elem.
+func (ss *SortedSlice[T]) Has(elem T) bool {
+	_, ok := ss.Search(elem)
+	return ok
+}
+
+// Remove removes the first element equal to elem, returning false if there
+// were none.
+func (ss *SortedSlice[T]) Remove(elem T) bool {
+	i, ok := ss.Search(elem)
+	if ok {
+		ss.RemoveAt(i)
+	}
+	return ok
+}
+
+// RemoveAt removes and returns the element at the given index. Panics if the
+// index is out of bounds.
+func (ss *SortedSlice[T]) RemoveAt(i int) T {
+	t := ss.data[i]
+	ss.data = slices.Delete(ss.data, i, i+1)
+	return t
+}
+
+// Between returns the elements e with lo <= e < hi. The returned slice shares
+// the underlying array, so it shouldn't be modified.
+func (ss *SortedSlice[T]) Between(lo, hi T) []T {
+	start, end := ss.lowerBound(lo), ss.lowerBound(hi)
+	if end < start {
+		end = start
+	}
+	return ss.data[start:end:end]
+}
+
+// Get gets the element at the given index, panicking if the index is out of
+// bounds.
+func (ss *SortedSlice[T]) Get(i int) T {
+	return ss.data[i]
+}
+
+// GetSafe gets the element at the given index, returning false if the index
+// is out of bounds.
+func (ss *SortedSlice[T]) GetSafe(i int) (t T, ok bool) {
+	if i < 0 || i >= len(ss.data) {
+		return
+	}
+	return ss.data[i], true
+}
+
+// Len returns the number of elements.
+func (ss *SortedSlice[T]) Len() int {
+	return len(ss.data)
+}
+
+// Index finds the first element satisfying the predicate, returning the
+// index or -1. This is a linear search; use Search to find elements by value.
+func (ss *SortedSlice[T]) Index(f func(T) bool) int {
+	return slices.IndexFunc(ss.data, f)
+}
+
+// Contains returns whether the slice contains an element satisfying the
+// predicate. This is a linear search; use Has to find elements by value.
+func (ss *SortedSlice[T]) Contains(f func(T) bool) bool {
+	return ss.Index(f) != -1
+}
+
+// Range iterates over each index and element in order, applying a given
+// function that returns whether the iterations should continue.
+func (ss *SortedSlice[T]) Range(f func(int, T) bool) {
+	for i, t := range ss.data {
+		if !f(i, t) {
+			return
+		}
+	}
+}
+
+// Data returns the underlying slice, which shouldn't be modified.
+func (ss *SortedSlice[T]) Data() []T {
+	return ss.data
+}
+
+// Clone clones the SortedSlice.
+func (ss *SortedSlice[T]) Clone() *SortedSlice[T] {
+	return &SortedSlice[T]{data: CloneSlice(ss.data), cmp: ss.cmp}
+}
+
+func (ss *SortedSlice[T]) lowerBound(elem T) int {
+	return sort.Search(len(ss.data), func(i int) bool {
+		return ss.cmp(ss.data[i], elem) >= 0
+	})
+}
+
+func (ss *SortedSlice[T]) upperBound(elem T) int {
+	return sort.Search(len(ss.data), func(i int) bool {
+		return ss.cmp(ss.data[i], elem) > 0
+	})
+}
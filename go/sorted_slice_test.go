@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"cmp"
+	"testing"
+)
+
+func TestSlicePtrSorted(t *testing.T) {
+	data := []int{5, 3, 8, 1}
+	sp := NewSlicePtr(&data)
+	if sp.IsSorted(cmp.Compare[int]) {
+		t.Fatal("expected unsorted slice")
+	}
+	sp.SortBy(func(a, b int) bool { return a < b })
+	if !SliceEq(data, []int{1, 3, 5, 8}) {
+		t.Fatalf("unexpected sort: %v", data)
+	}
+	if i := sp.InsertSorted(4, cmp.Compare[int]); i != 2 {
+		t.Fatalf("expected index 2, got %d", i)
+	}
+	if !SliceEq(data, []int{1, 3, 4, 5, 8}) || !sp.IsSorted(cmp.Compare[int]) {
+		t.Fatalf("unexpected data: %v", data)
+	}
+	if i, ok := sp.BinarySearch(func(n int) int { return n - 5 }); !ok || i != 3 {
+		t.Fatalf("expected 3, true, got %d, %v", i, ok)
+	}
+	if i, ok := sp.BinarySearch(func(n int) int { return n - 6 }); ok || i != 4 {
+		t.Fatalf("expected 4, false, got %d, %v", i, ok)
+	}
+}
+
+func TestSortedSlice(t *testing.T) {
+	ss := NewSortedSlice(cmp.Compare[int], 5, 1, 3)
+	ss.Insert(4)
+	ss.Insert(0)
+	ss.InsertAll(9, 7, 8, 6, 2, 10, 11, 12)
+	if !SliceEq(ss.Data(), RangeSlice(13)) {
+		t.Fatalf("unexpected data: %v", ss.Data())
+	}
+	if i, ok := ss.Search(7); !ok || i != 7 {
+		t.Fatalf("expected 7, true, got %d, %v", i, ok)
+	}
+	if !ss.Remove(7) || ss.Remove(7) || ss.Has(7) {
+		t.Fatal("bad Remove results")
+	}
+	if got := ss.Between(5, 9); !SliceEq(got, []int{5, 6, 8}) {
+		t.Fatalf("unexpected range: %v", got)
+	}
+	if got := ss.Between(9, 5); len(got) != 0 {
+		t.Fatalf("expected empty range, got %v", got)
+	}
+	if n := ss.RemoveAt(0); n != 0 || ss.Len() != 11 {
+		t.Fatalf("unexpected RemoveAt: %d, %d", n, ss.Len())
+	}
+}
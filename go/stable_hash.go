@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// Hashable is implemented by types that provide their own stable hash to
+// StableHash.
+type Hashable interface {
+	// StableHash returns a hash that is the same for equal values across
+	// processes and program versions.
+	StableHash() (uint64, error)
+}
+
+// maxStableHashDepth limits nesting to catch cyclic values.
+const maxStableHashDepth = 100
+
+var (
+	hashableType        = reflect.TypeFor[Hashable]()
+	binaryMarshalerType = reflect.TypeFor[encoding.BinaryMarshaler]()
+)
+
+// StableHash returns a deterministic 64-bit (FNV-1a) hash of the value,
+// suitable for cache keys. Unlike hashing fmt.Sprintf output, map keys are
+// sorted, pointers are followed (so *T and T hash the same), -0 and 0 hash
+// the same, and all NaNs hash the same. Struct field names are included, so
+// renaming a field changes the hash, while unexported fields are ignored.
+//
+// Values implementing Hashable are hashed using their StableHash method and
+// those implementing encoding.BinaryMarshaler (e.g., time.Time) using
+// MarshalBinary. Funcs, chans, unsafe pointers, and cyclic values return an
+// error.
+func StableHash(v any) (uint64, error) {
+	var buf []byte
+	buf, err := appendStableHash(buf, reflect.ValueOf(v), 0)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	h.Write(buf)
+	return h.Sum64(), nil
+}
+
+func appendStableHash(
+	b []byte, v reflect.Value, depth int,
+) ([]byte, error) {
+	if depth > maxStableHashDepth {
+		return b, fmt.Errorf("value too deeply nested (cyclic?)")
+	}
+	if !v.IsValid() {
+		return append(b, 0), nil
+	}
+	isPtr := v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface
+	if isPtr && v.IsNil() {
+		return append(b, 0), nil
+	}
+	if v.Type().Implements(hashableType) && v.CanInterface() {
+		h, err := v.Interface().(Hashable).StableHash()
+		if err != nil {
+			return b, err
+		}
+		return binary.BigEndian.AppendUint64(append(b, 'h'), h), nil
+	}
+	// Pointers and interfaces are transparent, so *T and T hash the same
+	if isPtr {
+		return appendStableHash(b, v.Elem(), depth+1)
+	}
+	b = append(b, byte(v.Kind()))
+	if v.Type().Implements(binaryMarshalerType) && v.CanInterface() {
+		data, err := v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			return b, err
+		}
+		return appendStableBytes(append(b, 'm'), data), nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return binary.AppendVarint(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(b, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return appendStableFloat(b, v.Float()), nil
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return appendStableFloat(appendStableFloat(b, real(c)), imag(c)), nil
+	case reflect.String:
+		return appendStableBytes(b, []byte(v.String())), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return appendStableBytes(b, v.Bytes()), nil
+		}
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		var err error
+		for i := 0; i < v.Len() && err == nil; i++ {
+			b, err = appendStableHash(b, v.Index(i), depth+1)
+		}
+		return b, err
+	case reflect.Map:
+		return appendStableMap(b, v, depth)
+	case reflect.Struct:
+		t := v.Type()
+		var err error
+		for i := 0; i < t.NumField() && err == nil; i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			b = appendStableBytes(b, []byte(sf.Name))
+			b, err = appendStableHash(b, v.Field(i), depth+1)
+		}
+		return b, err
+	default:
+		return b, fmt.Errorf("cannot hash value of type %s", v.Type())
+	}
+}
+
+func appendStableMap(
+	b []byte, v reflect.Value, depth int,
+) ([]byte, error) {
+	type entry struct {
+		key, val []byte
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := appendStableHash(nil, iter.Key(), depth+1)
+		if err != nil {
+			return b, err
+		}
+		val, err := appendStableHash(nil, iter.Value(), depth+1)
+		if err != nil {
+			return b, err
+		}
+		entries = append(entries, entry{key: key, val: val})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	b = binary.AppendUvarint(b, uint64(len(entries)))
+	for _, e := range entries {
+		b = appendStableBytes(b, e.key)
+		b = appendStableBytes(b, e.val)
+	}
+	return b, nil
+}
+
+func appendStableBytes(b, data []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendStableFloat(b []byte, f float64) []byte {
+	switch {
+	case f == 0:
+		// Includes -0
+		f = 0
+	case math.IsNaN(f):
+		f = math.NaN()
+	}
+	return binary.BigEndian.AppendUint64(b, math.Float64bits(f))
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type hashReq struct {
+	Path    string
+	Params  map[string][]string
+	Limit   *int
+	Score   float64
+	Created time.Time
+	Extra   any
+	private int
+}
+
+type hashID string
+
+func (id hashID) StableHash() (uint64, error) {
+	// Case-insensitive IDs
+	return StableHash(len(id))
+}
+
+func TestStableHash(t *testing.T) {
+	limit := 10
+	newReq := func() hashReq {
+		return hashReq{
+			Path: "/items",
+			Params: map[string][]string{
+				"a": {"1", "2"}, "b": {"3"}, "c": nil, "d": {"4"},
+			},
+			Limit:   &limit,
+			Score:   0,
+			Created: time.Unix(100, 0).UTC(),
+			Extra:   []any{1, "x", nil},
+		}
+	}
+	r1, r2 := newReq(), newReq()
+	limit2 := 10
+	r2.Limit = &limit2
+	r2.Score = math.Copysign(0, -1)
+	r2.private = 5
+	h1, err := StableHash(r1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if h2, err := StableHash(&r2); err != nil {
+			t.Fatal(err)
+		} else if h2 != h1 {
+			t.Fatalf("expected equal hashes, got %d and %d", h1, h2)
+		}
+	}
+
+	r2.Params["a"] = []string{"2", "1"}
+	if h2, _ := StableHash(r2); h2 == h1 {
+		t.Fatal("expected different hashes")
+	}
+	r2 = newReq()
+	r2.Created = r2.Created.Add(time.Nanosecond)
+	if h2, _ := StableHash(r2); h2 == h1 {
+		t.Fatal("expected different hashes for different times")
+	}
+
+	n1, _ := StableHash(math.NaN())
+	n2, _ := StableHash(math.Float64frombits(0x7ff8000000000001))
+	if n1 != n2 {
+		t.Fatal("expected NaNs to hash the same")
+	}
+
+	i1, _ := StableHash(map[hashID]int{"abc": 1})
+	i2, _ := StableHash(map[hashID]int{"xyz": 1})
+	if i1 != i2 {
+		t.Fatal("expected Hashable override to be used")
+	}
+
+	if _, err := StableHash(func() {}); err == nil {
+		t.Fatal("expected error for func")
+	}
+	type node struct{ Next *node }
+	n := &node{}
+	n.Next = n
+	if _, err := StableHash(n); err == nil {
+		t.Fatal("expected error for cyclic value")
+	}
+}
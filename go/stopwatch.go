@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Stopwatch measures elapsed time, optionally in laps. The zero value is a
+// stopped stopwatch with no elapsed time. A Stopwatch isn't safe for
+// concurrent use.
+type Stopwatch struct {
+	start   time.Time
+	elapsed time.Duration
+	lapAt   time.Duration
+	laps    []time.Duration
+	running bool
+}
+
+// StartStopwatch returns a new, running Stopwatch.
+func StartStopwatch() *Stopwatch {
+	sw := &Stopwatch{}
+	sw.Start()
+	return sw
+}
+
+// Start starts (or resumes) the stopwatch. Does nothing if it's running.
+func (sw *Stopwatch) Start() {
+	if !sw.running {
+		sw.start, sw.running = time.Now(), true
+	}
+}
+
+// Stop stops the stopwatch, returning the total elapsed time. Does nothing
+// (other than returning the elapsed time) if it isn't running.
+func (sw *Stopwatch) Stop() time.Duration {
+	if sw.running {
+		sw.elapsed += time.Since(sw.start)
+		sw.running = false
+	}
+	return sw.elapsed
+}
+
+// Elapsed returns the total elapsed time.
+func (sw *Stopwatch) Elapsed() time.Duration {
+	if sw.running {
+		return sw.elapsed + time.Since(sw.start)
+	}
+	return sw.elapsed
+}
+
+// Lap records and returns the elapsed time since the last lap (or since the
+// stopwatch was first started).
+func (sw *Stopwatch) Lap() time.Duration {
+	now := sw.Elapsed()
+	lap := now - sw.lapAt
+	sw.lapAt = now
+	sw.laps = append(sw.laps, lap)
+	return lap
+}
+
+// Laps returns the recorded laps.
+func (sw *Stopwatch) Laps() []time.Duration {
+	return CloneSlice(sw.laps)
+}
+
+// IsRunning returns whether the stopwatch is running.
+func (sw *Stopwatch) IsRunning() bool {
+	return sw.running
+}
+
+// Reset stops the stopwatch and clears the elapsed time and laps.
+func (sw *Stopwatch) Reset() {
+	*sw = Stopwatch{}
+}
+
+// Restart resets and starts the stopwatch, returning the elapsed time before
+// the restart.
+func (sw *Stopwatch) Restart() time.Duration {
+	elapsed := sw.Elapsed()
+	sw.Reset()
+	sw.Start()
+	return elapsed
+}
+
+// TimeFunc returns how long f took to run.
+func TimeFunc(f func()) time.Duration {
+	start := time.Now()
+	f()
+	return time.Since(start)
+}
+
+// TimeFuncValue returns the value returned by f along with how long it took
+// to run.
+func TimeFuncValue[T any](f func() T) (T, time.Duration) {
+	start := time.Now()
+	t := f()
+	return t, time.Since(start)
+}
+
+// TimingStats are the stats of the durations recorded for a name in a
+// Timings.
+type TimingStats struct {
+	Count uint64
+	Total time.Duration
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	// The percentiles are computed from the kept samples.
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+}
+
+// String returns the stats formatted on a single line.
+func (ts TimingStats) String() string {
+	return fmt.Sprintf(
+		"n=%d min=%v mean=%v max=%v p50=%v p90=%v p99=%v",
+		ts.Count, ts.Min, ts.Mean, ts.Max, ts.P50, ts.P90, ts.P99,
+	)
+}
+
+// Timings records named durations. It's safe for concurrent use.
+type Timings struct {
+	maxSamples int
+	m          *Mutex[map[string]*timingSeries]
+}
+
+type timingSeries struct {
+	count    uint64
+	total    time.Duration
+	min, max time.Duration
+	samples  []time.Duration
+	// Index of the next sample to overwrite once samples is full
+	next int
+}
+
+// NewTimings creates a new Timings, keeping at most maxSamples recent
+// samples per name for computing percentiles. The count, total, min, and max
+// always include all samples. If maxSamples is less than 1, all samples are
+// kept.
+func NewTimings(maxSamples int) *Timings {
+	return &Timings{
+		maxSamples: maxSamples,
+		m:          NewMutex(make(map[string]*timingSeries)),
+	}
+}
+
+// Record records the duration under the name.
+func (t *Timings) Record(name string, d time.Duration) {
+	t.m.Apply(func(mp *map[string]*timingSeries) {
+		s, ok := (*mp)[name]
+		if !ok {
+			s = &timingSeries{min: d, max: d}
+			(*mp)[name] = s
+		}
+		s.count++
+		s.total += d
+		s.min, s.max = min(s.min, d), max(s.max, d)
+		if t.maxSamples < 1 || len(s.samples) < t.maxSamples {
+			s.samples = append(s.samples, d)
+		} else {
+			s.samples[s.next] = d
+			s.next = (s.next + 1) % t.maxSamples
+		}
+	})
+}
+
+// Time returns a func that records the time elapsed since Time was called
+// under the name. For example:
+//
+//	defer timings.Time("handler")()
+func (t *Timings) Time(name string) func() {
+	start := time.Now()
+	return func() { t.Record(name, time.Since(start)) }
+}
+
+// Stats returns the stats for the name, returning false if nothing has been
+// recorded under it.
+func (t *Timings) Stats(name string) (TimingStats, bool) {
+	var samples []time.Duration
+	var stats TimingStats
+	ok := false
+	t.m.Apply(func(mp *map[string]*timingSeries) {
+		var s *timingSeries
+		if s, ok = (*mp)[name]; ok {
+			stats = s.stats()
+			samples = CloneSlice(s.samples)
+		}
+	})
+	if ok {
+		slices.Sort(samples)
+		stats.P50 = percentileSorted(samples, 50)
+		stats.P90 = percentileSorted(samples, 90)
+		stats.P99 = percentileSorted(samples, 99)
+	}
+	return stats, ok
+}
+
+func (s *timingSeries) stats() TimingStats {
+	return TimingStats{
+		Count: s.count,
+		Total: s.total,
+		Min:   s.min,
+		Max:   s.max,
+		Mean:  s.total / time.Duration(s.count),
+	}
+}
+
+// Percentile returns the pth percentile (0 to 100) of the kept samples for
+// the name using the nearest-rank method, returning false if nothing has
+// been recorded under it.
+func (t *Timings) Percentile(name string, p float64) (time.Duration, bool) {
+	var samples []time.Duration
+	ok := false
+	t.m.Apply(func(mp *map[string]*timingSeries) {
+		var s *timingSeries
+		if s, ok = (*mp)[name]; ok {
+			samples = CloneSlice(s.samples)
+		}
+	})
+	if !ok {
+		return 0, false
+	}
+	slices.Sort(samples)
+	return percentileSorted(samples, p), true
+}
+
+func percentileSorted(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	p = min(max(p, 0), 100)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// Names returns the recorded names, sorted.
+func (t *Timings) Names() []string {
+	defer t.m.Unlock()
+	mp := t.m.Lock()
+	names := make([]string, 0, len(*mp))
+	for name := range *mp {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// All returns the stats of all names.
+func (t *Timings) All() map[string]TimingStats {
+	names := t.Names()
+	all := make(map[string]TimingStats, len(names))
+	for _, name := range names {
+		if stats, ok := t.Stats(name); ok {
+			all[name] = stats
+		}
+	}
+	return all
+}
+
+// Reset clears all recorded durations.
+func (t *Timings) Reset() {
+	t.m.Apply(func(mp *map[string]*timingSeries) {
+		clear(*mp)
+	})
+}
+
+// String returns a report of the stats of all names, one per line, sorted by
+// name.
+func (t *Timings) String() string {
+	all := t.All()
+	var sb strings.Builder
+	for _, name := range t.Names() {
+		if stats, ok := all[name]; ok {
+			fmt.Fprintf(&sb, "%s: %s\n", name, stats)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStopwatch(t *testing.T) {
+	var sw Stopwatch
+	if sw.Elapsed() != 0 || sw.IsRunning() {
+		t.Fatal("expected zero stopwatch to be stopped")
+	}
+	sw.Start()
+	time.Sleep(time.Millisecond * 5)
+	lap1 := sw.Lap()
+	time.Sleep(time.Millisecond * 5)
+	lap2 := sw.Lap()
+	elapsed := sw.Stop()
+	if lap1 < time.Millisecond*5 || lap2 < time.Millisecond*5 {
+		t.Fatalf("laps too short: %v, %v", lap1, lap2)
+	}
+	if elapsed < lap1+lap2 {
+		t.Fatalf("elapsed %v less than laps %v + %v", elapsed, lap1, lap2)
+	}
+	if laps := sw.Laps(); !SliceEq(laps, []time.Duration{lap1, lap2}) {
+		t.Fatalf("unexpected laps: %v", laps)
+	}
+
+	// Stopped time isn't counted.
+	time.Sleep(time.Millisecond * 5)
+	if sw.Elapsed() != elapsed || sw.Stop() != elapsed {
+		t.Fatal("elapsed time changed while stopped")
+	}
+	sw.Start()
+	if sw.Lap() >= time.Millisecond*5 {
+		t.Fatal("lap included stopped time")
+	}
+
+	if before := sw.Restart(); before < elapsed || !sw.IsRunning() {
+		t.Fatalf("unexpected Restart result: %v", before)
+	}
+	if len(sw.Laps()) != 0 || sw.Elapsed() >= elapsed {
+		t.Fatal("expected Restart to clear the stopwatch")
+	}
+
+	d := TimeFunc(func() { time.Sleep(time.Millisecond) })
+	if d < time.Millisecond {
+		t.Fatalf("expected at least 1ms, got %v", d)
+	}
+	v, d := TimeFuncValue(func() int {
+		time.Sleep(time.Millisecond)
+		return 5
+	})
+	if v != 5 || d < time.Millisecond {
+		t.Fatalf("expected 5, >= 1ms, got %d, %v", v, d)
+	}
+}
+
+func TestTimings(t *testing.T) {
+	tm := NewTimings(0)
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tm.Record("a", time.Duration(i)*time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	stats, ok := tm.Stats("a")
+	if !ok {
+		t.Fatal("missing stats")
+	}
+	ms := time.Millisecond
+	want := TimingStats{
+		Count: 100, Total: 5050 * ms, Min: ms, Max: 100 * ms,
+		Mean: 50500 * time.Microsecond, P50: 50 * ms, P90: 90 * ms,
+		P99: 99 * ms,
+	}
+	if stats != want {
+		t.Fatalf("expected %+v, got %+v", want, stats)
+	}
+	if p, _ := tm.Percentile("a", 100); p != 100*ms {
+		t.Fatalf("expected p100 of 100ms, got %v", p)
+	}
+	if _, ok := tm.Percentile("b", 50); ok {
+		t.Fatal("unexpected percentile for missing name")
+	}
+
+	func() {
+		defer tm.Time("b")()
+	}()
+	if !SliceEq(tm.Names(), []string{"a", "b"}) {
+		t.Fatalf("unexpected names: %v", tm.Names())
+	}
+	report := tm.String()
+	if !strings.HasPrefix(report, "a: n=100 min=1ms mean=50.5ms max=100ms") ||
+		!strings.Contains(report, "\nb: n=1 ") {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+	tm.Reset()
+	if len(tm.All()) != 0 {
+		t.Fatal("expected Reset to clear timings")
+	}
+
+	// Only the most recent samples are used for percentiles.
+	tm = NewTimings(10)
+	for i := 1; i <= 100; i++ {
+		tm.Record("a", time.Duration(i))
+	}
+	stats, _ = tm.Stats("a")
+	if stats.Min != 1 || stats.P50 != 95 || stats.Count != 100 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultStringBuilderClasses are the default size classes of a
+// StringBuilderPool.
+var DefaultStringBuilderClasses = []int{64, 256, 1 << 10, 4 << 10, 16 << 10}
+
+// StringBuilderPool hands out *bytes.Buffers, pooled by size class, for
+// building strings without repeatedly allocating and growing buffers.
+//
+// Buffers are used rather than strings.Builders since a Builder's buffer is
+// shared with the strings it builds, so it can't be reused. Since String
+// copies a Buffer's contents, its buffer can be reused once it's put back.
+// Buffers that grew larger than the largest size class aren't pooled, so one
+// large string doesn't keep a large buffer alive.
+type StringBuilderPool struct {
+	classes []int
+	pools   []sync.Pool
+	// The size class index of recently built strings, used when no hint is
+	// given.
+	recent atomic.Int32
+}
+
+// DefaultStringBuilderPool is the pool used by BuildString.
+var DefaultStringBuilderPool = NewStringBuilderPool(nil)
+
+// NewStringBuilderPool creates a new StringBuilderPool with the given size
+// classes, which must be sorted in increasing order. If classes is empty,
+// DefaultStringBuilderClasses is used. Hints larger than the largest class
+// are grown to exactly the hint.
+func NewStringBuilderPool(classes []int) *StringBuilderPool {
+	if len(classes) == 0 {
+		classes = DefaultStringBuilderClasses
+	}
+	classes = append([]int(nil), classes...)
+	p := &StringBuilderPool{
+		classes: classes,
+		pools:   make([]sync.Pool, len(classes)),
+	}
+	for i, c := range classes {
+		p.pools[i].New = func() any {
+			return bytes.NewBuffer(make([]byte, 0, c))
+		}
+	}
+	return p
+}
+
+// Get returns an empty buffer with room for at least sizeHint bytes. If
+// sizeHint is less than 1, the size class of recently built strings is used.
+func (p *StringBuilderPool) Get(sizeHint int) *bytes.Buffer {
+	var i int
+	if sizeHint < 1 {
+		i = int(p.recent.Load())
+	} else if i = p.class(sizeHint); i == len(p.classes) {
+		return bytes.NewBuffer(make([]byte, 0, sizeHint))
+	}
+	return p.pools[i].Get().(*bytes.Buffer)
+}
+
+// Put resets the buffer and returns it to the pool. The buffer's length is
+// used to decide the size of buffers returned by Get without a hint. Strings
+// previously returned by the buffer's String method aren't affected, but
+// slices returned by Bytes must no longer be used.
+func (p *StringBuilderPool) Put(buf *bytes.Buffer) {
+	p.recent.Store(int32(min(p.class(buf.Len()), len(p.classes)-1)))
+	// Pool the buffer in the largest class it has room for, so buffers from
+	// a class's pool always have at least the class's capacity.
+	c := buf.Cap()
+	if c > p.classes[len(p.classes)-1] {
+		return
+	}
+	for i := len(p.classes) - 1; i >= 0; i-- {
+		if c >= p.classes[i] {
+			buf.Reset()
+			p.pools[i].Put(buf)
+			return
+		}
+	}
+}
+
+// class returns the index of the smallest size class fitting n, or
+// len(p.classes) if none do.
+func (p *StringBuilderPool) class(n int) int {
+	for i, c := range p.classes {
+		if n <= c {
+			return i
+		}
+	}
+	return len(p.classes)
+}
+
+// Build gets a buffer, calls f with it, and returns the built string,
+// putting the buffer back afterwards.
+func (p *StringBuilderPool) Build(f func(*bytes.Buffer)) string {
+	buf := p.Get(0)
+	f(buf)
+	s := buf.String()
+	p.Put(buf)
+	return s
+}
+
+// BuildString calls Build on DefaultStringBuilderPool.
+func BuildString(f func(*bytes.Buffer)) string {
+	return DefaultStringBuilderPool.Build(f)
+}
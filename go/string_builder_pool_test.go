@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStringBuilderPool(t *testing.T) {
+	p := NewStringBuilderPool([]int{16, 64})
+	if buf := p.Get(10); buf.Len() != 0 || buf.Cap() < 16 {
+		t.Fatalf("expected empty buffer with cap >= 16, got %d", buf.Cap())
+	}
+	buf := p.Get(40)
+	buf.WriteString(strings.Repeat("a", 40))
+	s := buf.String()
+	p.Put(buf)
+
+	// Recent strings were in the 64 class, and the buffer is reused.
+	buf2 := p.Get(0)
+	if buf2.Len() != 0 || buf2.Cap() < 64 {
+		t.Fatalf("expected empty buffer with cap >= 64, got %d", buf2.Cap())
+	}
+	if buf2 != buf {
+		// sync.Pool may drop items, so this isn't guaranteed.
+		t.Log("buffer wasn't reused")
+	}
+	buf2.WriteString(strings.Repeat("b", 40))
+	if s != strings.Repeat("a", 40) {
+		t.Fatal("built string modified after reusing the buffer")
+	}
+	if buf := p.Get(100); buf.Cap() < 100 {
+		t.Fatalf("expected cap >= 100, got %d", buf.Cap())
+	}
+
+	// Buffers larger than the largest class aren't pooled.
+	big := bytes.NewBuffer(make([]byte, 0, 1000))
+	p.Put(big)
+	for i := 0; i < 10; i++ {
+		if p.Get(64) == big {
+			t.Fatal("large buffer was pooled")
+		}
+	}
+
+	got := BuildString(func(buf *bytes.Buffer) {
+		buf.WriteString("hello, ")
+		buf.WriteString("world")
+	})
+	if got != "hello, world" {
+		t.Fatalf("expected hello, world, got %q", got)
+	}
+}
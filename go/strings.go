@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// JoinFunc formats each element of the slice using f and joins the results
+// with sep.
+func JoinFunc[T any](s []T, sep string, f func(T) string) string {
+	var sb strings.Builder
+	for i, t := range s {
+		if i != 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(f(t))
+	}
+	return sb.String()
+}
+
+// SplitTrim splits the string on sep, trimming the whitespace around each
+// part. Returns nil if the string is empty or only whitespace.
+func SplitTrim(s, sep string) []string {
+	return SplitNTrim(s, sep, -1)
+}
+
+// SplitNTrim is like strings.SplitN, but trims the whitespace around each part
+// and returns nil if the string is empty or only whitespace.
+func SplitNTrim(s, sep string, n int) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	parts := strings.SplitN(s, sep, n)
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
+
+// FieldsQuoted splits the string into fields separated by whitespace, like
+// strings.Fields, except that whitespace within single or double quotes
+// doesn't separate fields, similar to a shell. The quotes themselves are
+// removed. Outside of single quotes, a backslash escapes the next character.
+// Returns an error for an unterminated quote or a trailing backslash.
+func FieldsQuoted(s string) ([]string, error) {
+	var fields []string
+	var sb strings.Builder
+	inField, escaped := false, false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			inField, escaped = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			inField, quote = true, r
+		case unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, sb.String())
+				sb.Reset()
+				inField = false
+			}
+		default:
+			inField = true
+			sb.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	} else if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inField {
+		fields = append(fields, sb.String())
+	}
+	return fields, nil
+}
+
+// FormatCommaList formats the elements of the slice using f, joining them
+// with commas. The result can be passed to BoolMapFlag.Set or parsed with
+// ParseCommaList.
+func FormatCommaList[T any](s []T, f func(T) string) string {
+	return JoinFunc(s, ",", f)
+}
+
+// ParseCommaList parses a comma-separated list, trimming the whitespace around
+// each element and skipping empty elements, then parsing each with parse.
+func ParseCommaList[T any](
+	s string, parse func(string) (T, error),
+) ([]T, error) {
+	var res []T
+	for _, part := range SplitTrim(s, ",") {
+		if part == "" {
+			continue
+		}
+		t, err := parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid list element %q: %w", part, err)
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+// CommaList is a list of strings that implements the flag.Value interface.
+// Each call to Set appends the elements of a comma-separated list (see
+// ParseCommaList), so the flag can be passed multiple times.
+type CommaList []string
+
+// String implements the flag.Value interface, joining the elements with
+// commas.
+func (cl *CommaList) String() string {
+	if cl == nil {
+		return ""
+	}
+	return strings.Join(*cl, ",")
+}
+
+// Set implements the flag.Value interface, appending the elements of the
+// comma-separated list.
+func (cl *CommaList) Set(s string) error {
+	parts, _ := ParseCommaList(s, func(part string) (string, error) {
+		return part, nil
+	})
+	*cl = append(*cl, parts...)
+	return nil
+}
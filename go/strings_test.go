@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"flag"
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestJoinSplit(t *testing.T) {
+	if got := JoinFunc([]int{1, 2, 3}, ", ", strconv.Itoa); got != "1, 2, 3" {
+		t.Fatalf("unexpected join: %q", got)
+	}
+	if got := JoinFunc(nil, ",", strconv.Itoa); got != "" {
+		t.Fatalf("unexpected join: %q", got)
+	}
+	got := SplitTrim(" a , b,,c ", ",")
+	if !SliceEq(got, []string{"a", "b", "", "c"}) {
+		t.Fatalf("unexpected split: %q", got)
+	}
+	if got := SplitTrim("  ", ","); got != nil {
+		t.Fatalf("expected nil, got %q", got)
+	}
+	got = SplitNTrim("key = value = x", "=", 2)
+	if !SliceEq(got, []string{"key", "value = x"}) {
+		t.Fatalf("unexpected split: %q", got)
+	}
+}
+
+func TestFieldsQuoted(t *testing.T) {
+	tests := map[string][]string{
+		`a b  c`:                {"a", "b", "c"},
+		`  cmd "hello world" x`: {"cmd", "hello world", "x"},
+		`'it''s' "a \"b\""`:     {"its", `a "b"`},
+		`a"b c"d`:               {"ab cd"},
+		`'\n' \n ""`:            {`\n`, "n", ""},
+		`a\ b`:                  {"a b"},
+		"":                      nil,
+	}
+	for s, want := range tests {
+		got, err := FieldsQuoted(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		} else if !SliceEq(got, want) {
+			t.Fatalf("%s: expected %q, got %q", s, want, got)
+		}
+	}
+	for _, s := range []string{`"abc`, `a 'b`, `abc\`} {
+		if _, err := FieldsQuoted(s); err == nil {
+			t.Fatalf("%s: expected error", s)
+		}
+	}
+}
+
+func TestCommaList(t *testing.T) {
+	ints, err := ParseCommaList(" 1, 2,,3 ", strconv.Atoi)
+	if err != nil {
+		t.Fatal(err)
+	} else if !SliceEq(ints, []int{1, 2, 3}) {
+		t.Fatalf("unexpected list: %v", ints)
+	}
+	if _, err := ParseCommaList("1,x", strconv.Atoi); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := FormatCommaList(ints, strconv.Itoa); got != "1,2,3" {
+		t.Fatalf("unexpected list: %q", got)
+	}
+
+	// Round-trip with BoolMapFlag
+	bm := NewBoolMapFlag()
+	bm.Set(FormatCommaList([]string{"x", "y"}, func(s string) string {
+		return s
+	}))
+	bm.Set("z")
+	keys, _ := ParseCommaList(bm.String(), func(s string) (string, error) {
+		return s, nil
+	})
+	sort.Strings(keys)
+	if !SliceEq(keys, []string{"x", "y", "z"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var cl CommaList
+	fs.Var(&cl, "tags", "")
+	if err := fs.Parse([]string{"-tags", "a, b", "-tags=c"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cl, CommaList{"a", "b", "c"}) || cl.String() != "a,b,c" {
+		t.Fatalf("unexpected list: %v", cl)
+	}
+}
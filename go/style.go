@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Attr is an ANSI SGR text attribute (e.g., a color).
+type Attr int
+
+// Text attributes.
+const (
+	AttrReset     Attr = 0
+	AttrBold      Attr = 1
+	AttrDim       Attr = 2
+	AttrItalic    Attr = 3
+	AttrUnderline Attr = 4
+	AttrInverse   Attr = 7
+
+	// Foreground colors.
+	FgBlack   Attr = 30
+	FgRed     Attr = 31
+	FgGreen   Attr = 32
+	FgYellow  Attr = 33
+	FgBlue    Attr = 34
+	FgMagenta Attr = 35
+	FgCyan    Attr = 36
+	FgWhite   Attr = 37
+
+	// Background colors.
+	BgBlack   Attr = 40
+	BgRed     Attr = 41
+	BgGreen   Attr = 42
+	BgYellow  Attr = 43
+	BgBlue    Attr = 44
+	BgMagenta Attr = 45
+	BgCyan    Attr = 46
+	BgWhite   Attr = 47
+)
+
+// ColorEnabled returns whether colored output should be written to the
+// writer. Color is disabled if NO_COLOR is set (to anything non-empty) or
+// TERM is "dumb", forced if FORCE_COLOR is set (to anything non-empty), and
+// otherwise only enabled if the writer is a terminal.
+func ColorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if os.Getenv("FORCE_COLOR") != "" {
+		return true
+	}
+	f, ok := w.(interface{ Fd() uintptr })
+	return ok && IsTerminal(f.Fd())
+}
+
+// Styler creates Styles for a given output, doing capability detection once.
+// Output helpers should accept a Styler rather than detecting capabilities
+// themselves.
+type Styler struct {
+	enabled bool
+}
+
+// NewStyler creates a Styler for the writer, enabled according to
+// ColorEnabled.
+func NewStyler(w io.Writer) *Styler {
+	return &Styler{enabled: ColorEnabled(w)}
+}
+
+// NewStylerEnabled creates a Styler that is explicitly enabled or disabled.
+func NewStylerEnabled(enabled bool) *Styler {
+	return &Styler{enabled: enabled}
+}
+
+var (
+	stdoutStyler, stderrStyler         *Styler
+	stdoutStylerOnce, stderrStylerOnce sync.Once
+)
+
+// StdoutStyler returns the Styler for os.Stdout, detected on first call.
+func StdoutStyler() *Styler {
+	stdoutStylerOnce.Do(func() {
+		stdoutStyler = NewStyler(os.Stdout)
+	})
+	return stdoutStyler
+}
+
+// StderrStyler returns the Styler for os.Stderr, detected on first call.
+func StderrStyler() *Styler {
+	stderrStylerOnce.Do(func() {
+		stderrStyler = NewStyler(os.Stderr)
+	})
+	return stderrStyler
+}
+
+// Enabled returns whether styling is enabled.
+func (s *Styler) Enabled() bool {
+	return s.enabled
+}
+
+// Style returns a Style with the given attributes. If the Styler isn't
+// enabled, the Style writes text unchanged.
+func (s *Styler) Style(attrs ...Attr) Style {
+	if !s.enabled || len(attrs) == 0 {
+		return Style{}
+	}
+	codes := make([]string, len(attrs))
+	for i, attr := range attrs {
+		codes[i] = strconv.Itoa(int(attr))
+	}
+	return Style{prefix: "\x1b[" + strings.Join(codes, ";") + "m"}
+}
+
+// Bold returns the string in bold.
+func (s *Styler) Bold(str string) string {
+	return s.Style(AttrBold).Wrap(str)
+}
+
+// Dim returns the string dimmed.
+func (s *Styler) Dim(str string) string {
+	return s.Style(AttrDim).Wrap(str)
+}
+
+// Underline returns the string underlined.
+func (s *Styler) Underline(str string) string {
+	return s.Style(AttrUnderline).Wrap(str)
+}
+
+// Red returns the string in red.
+func (s *Styler) Red(str string) string {
+	return s.Style(FgRed).Wrap(str)
+}
+
+// Green returns the string in green.
+func (s *Styler) Green(str string) string {
+	return s.Style(FgGreen).Wrap(str)
+}
+
+// Yellow returns the string in yellow.
+func (s *Styler) Yellow(str string) string {
+	return s.Style(FgYellow).Wrap(str)
+}
+
+// Blue returns the string in blue.
+func (s *Styler) Blue(str string) string {
+	return s.Style(FgBlue).Wrap(str)
+}
+
+// Style is a set of text attributes. The zero value writes text unchanged.
+type Style struct {
+	prefix string
+}
+
+// IsPlain returns whether the style writes text unchanged.
+func (st Style) IsPlain() bool {
+	return st.prefix == ""
+}
+
+// Wrap wraps the string with the style's attributes.
+func (st Style) Wrap(s string) string {
+	if st.prefix == "" {
+		return s
+	}
+	return st.prefix + s + "\x1b[0m"
+}
+
+// Sprint formats using fmt.Sprint and wraps the result.
+func (st Style) Sprint(a ...any) string {
+	return st.Wrap(fmt.Sprint(a...))
+}
+
+// Sprintf formats using fmt.Sprintf and wraps the result.
+func (st Style) Sprintf(format string, a ...any) string {
+	return st.Wrap(fmt.Sprintf(format, a...))
+}
+
+// Fprint writes the wrapped output of fmt.Sprint to the writer.
+func (st Style) Fprint(w io.Writer, a ...any) (int, error) {
+	return io.WriteString(w, st.Sprint(a...))
+}
+
+// Fprintf writes the wrapped output of fmt.Sprintf to the writer.
+func (st Style) Fprintf(w io.Writer, format string, a ...any) (int, error) {
+	return io.WriteString(w, st.Sprintf(format, a...))
+}
+
+// VisibleLen returns the length of the string in runes, ignoring ANSI escape
+// sequences. Useful for aligning styled text.
+func VisibleLen(s string) int {
+	n, inEsc := 0, false
+	for _, r := range s {
+		switch {
+		case inEsc:
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				inEsc = false
+			}
+		case r == '\x1b':
+			inEsc = true
+		default:
+			n++
+		}
+	}
+	return n
+}
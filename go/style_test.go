@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStyle(t *testing.T) {
+	on, off := NewStylerEnabled(true), NewStylerEnabled(false)
+	if got := off.Style(AttrBold, FgRed).Wrap("hi"); got != "hi" {
+		t.Fatalf("expected plain text, got %q", got)
+	}
+	got := on.Style(AttrBold, FgRed).Sprintf("%d", 5)
+	if got != "\x1b[1;31m5\x1b[0m" {
+		t.Fatalf("unexpected styled text: %q", got)
+	}
+	if n := VisibleLen(on.Green("héllo")); n != 5 {
+		t.Fatalf("expected visible len 5, got %d", n)
+	}
+
+	var sb strings.Builder
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "1")
+	if !ColorEnabled(&sb) {
+		t.Fatal("expected FORCE_COLOR to enable color")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled(&sb) {
+		t.Fatal("expected NO_COLOR to disable color")
+	}
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	if ColorEnabled(&sb) {
+		t.Fatal("expected non-terminal writer to disable color")
+	}
+}
@@ -0,0 +1,374 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Starter is a component that is started (and returns once started).
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is a component that is stopped.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Runner is a component that runs until the context is canceled or it fails.
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// ComponentState is the state of a component in a Supervisor.
+type ComponentState int
+
+const (
+	// StateStopped means the component isn't running.
+	StateStopped ComponentState = iota
+	// StateStarting means the component is being started.
+	StateStarting
+	// StateRunning means the component is running (or has been started).
+	StateRunning
+	// StateRestarting means the component's Run failed and it is waiting to
+	// be restarted.
+	StateRestarting
+	// StateFailed means the component failed and won't be restarted.
+	StateFailed
+	// StateStopping means the component is being stopped.
+	StateStopping
+)
+
+// String returns the name of the state.
+func (s ComponentState) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateRestarting:
+		return "restarting"
+	case StateFailed:
+		return "failed"
+	case StateStopping:
+		return "stopping"
+	default:
+		return fmt.Sprintf("ComponentState(%d)", int(s))
+	}
+}
+
+// SupervisorOpts are options for a Supervisor.
+type SupervisorOpts struct {
+	// MinBackoff is the delay before the first restart of a failed Runner,
+	// doubling with each consecutive failure. If 0, 100ms is used.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay between restarts. A Runner that runs
+	// for at least this long before failing starts over at MinBackoff. If 0,
+	// 30s is used.
+	MaxBackoff time.Duration
+	// MaxRestarts is the maximum number of consecutive restarts of a failed
+	// Runner before it is marked as failed. If 0, there is no limit.
+	MaxRestarts int
+	// StopTimeout is the timeout for stopping the components at the end of
+	// Run. If 0, there is no timeout.
+	StopTimeout time.Duration
+	// OnStateChange, if not nil, is called whenever a component's state
+	// changes, along with the error that caused the change (if any). It is
+	// called synchronously, so it should be quick.
+	OnStateChange func(name string, state ComponentState, err error)
+}
+
+// Supervisor manages the lifecycle of a set of components. Components are
+// started in dependency order and stopped in reverse order. A component can
+// implement any of Starter, Runner, and Stopper. A Runner is run in its own
+// goroutine and restarted with backoff if it returns an error (or panics)
+// before the supervisor is stopped; if it returns nil, it is considered done.
+type Supervisor struct {
+	opts   SupervisorOpts
+	mtx    sync.Mutex
+	comps  []*supervisedComp
+	byName map[string]*supervisedComp
+	// started is the components that have been started, in order.
+	started []*supervisedComp
+	running bool
+}
+
+type supervisedComp struct {
+	name  string
+	comp  any
+	deps  []string
+	state ComponentState
+	err   error
+	// cancel and done are set for running Runners. They're guarded by the
+	// supervisor's lock.
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSupervisor returns a new Supervisor with the given options.
+func NewSupervisor(opts SupervisorOpts) *Supervisor {
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 100 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	return &Supervisor{opts: opts, byName: make(map[string]*supervisedComp)}
+}
+
+// Add adds a component with the given name, which is started after the
+// components it depends on. The component must implement at least one of
+// Starter, Runner, and Stopper. Returns an error wrapping ErrExists if the
+// name is taken. Components can't be added while the supervisor is running.
+func (s *Supervisor) Add(name string, comp any, dependsOn ...string) error {
+	switch comp.(type) {
+	case Starter, Runner, Stopper:
+	default:
+		return fmt.Errorf(
+			"component %q: must implement Starter, Runner, or Stopper", name,
+		)
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.running {
+		return fmt.Errorf("component %q: supervisor is running", name)
+	} else if _, ok := s.byName[name]; ok {
+		return fmt.Errorf("component %q: %w", name, ErrExists)
+	}
+	sc := &supervisedComp{name: name, comp: comp, deps: dependsOn}
+	s.comps = append(s.comps, sc)
+	s.byName[name] = sc
+	return nil
+}
+
+// State returns the state of the component with the given name and the last
+// error it had, if any. Returns false if there is no such component.
+func (s *Supervisor) State(name string) (ComponentState, error, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	sc, ok := s.byName[name]
+	if !ok {
+		return StateStopped, nil, false
+	}
+	return sc.state, sc.err, true
+}
+
+// States returns the states of all the components.
+func (s *Supervisor) States() map[string]ComponentState {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	states := make(map[string]ComponentState, len(s.comps))
+	for _, sc := range s.comps {
+		states[sc.name] = sc.state
+	}
+	return states
+}
+
+// Start starts the components in dependency order (components without
+// dependencies between them are started in the order they were added). The
+// context is passed to the Starters' Start methods; Runners run until Stop
+// is called. If a component fails to start, the components already started
+// are stopped and the error is returned. Returns an error if there is an
+// unknown dependency or a dependency cycle.
+func (s *Supervisor) Start(ctx context.Context) error {
+	s.mtx.Lock()
+	if s.running {
+		s.mtx.Unlock()
+		return errors.New("supervisor already running")
+	}
+	order, err := s.startOrder()
+	if err != nil {
+		s.mtx.Unlock()
+		return err
+	}
+	s.running = true
+	s.mtx.Unlock()
+
+	for _, sc := range order {
+		s.setState(sc, StateStarting, nil)
+		if starter, ok := sc.comp.(Starter); ok {
+			if err := starter.Start(ctx); err != nil {
+				err = fmt.Errorf("starting %q: %w", sc.name, err)
+				s.setState(sc, StateFailed, err)
+				stopErr := s.Stop(context.WithoutCancel(ctx))
+				return errors.Join(err, stopErr)
+			}
+		}
+		// The state is set before the Runner starts so that it doesn't
+		// overwrite a state set by supervise (e.g., restarting).
+		s.setState(sc, StateRunning, nil)
+		s.mtx.Lock()
+		s.started = append(s.started, sc)
+		if runner, ok := sc.comp.(Runner); ok {
+			runCtx, cancel := context.WithCancel(context.Background())
+			sc.cancel, sc.done = cancel, make(chan struct{})
+			go s.supervise(runCtx, sc, runner, sc.done)
+		}
+		s.mtx.Unlock()
+	}
+	return nil
+}
+
+// startOrder returns the components in dependency order. The lock must be
+// held.
+func (s *Supervisor) startOrder() ([]*supervisedComp, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[*supervisedComp]int, len(s.comps))
+	order := make([]*supervisedComp, 0, len(s.comps))
+	var visit func(sc *supervisedComp) error
+	visit = func(sc *supervisedComp) error {
+		switch marks[sc] {
+		case visiting:
+			return fmt.Errorf("dependency cycle involving %q", sc.name)
+		case visited:
+			return nil
+		}
+		marks[sc] = visiting
+		for _, dep := range sc.deps {
+			depComp, ok := s.byName[dep]
+			if !ok {
+				return fmt.Errorf(
+					"component %q: unknown dependency %q", sc.name, dep,
+				)
+			}
+			if err := visit(depComp); err != nil {
+				return err
+			}
+		}
+		marks[sc] = visited
+		order = append(order, sc)
+		return nil
+	}
+	for _, sc := range s.comps {
+		if err := visit(sc); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func (s *Supervisor) supervise(
+	ctx context.Context, sc *supervisedComp, runner Runner,
+	done chan struct{},
+) {
+	defer close(done)
+	backoff, restarts := s.opts.MinBackoff, 0
+	for {
+		start := time.Now()
+		var err error
+		// Panics are treated as failures.
+		if perr := Safe(func() { err = runner.Run(ctx) }); perr != nil {
+			err = perr
+		}
+		if ctx.Err() != nil {
+			return
+		} else if err == nil {
+			s.setState(sc, StateStopped, nil)
+			return
+		}
+		if time.Since(start) >= s.opts.MaxBackoff {
+			backoff, restarts = s.opts.MinBackoff, 0
+		}
+		restarts++
+		if s.opts.MaxRestarts > 0 && restarts > s.opts.MaxRestarts {
+			s.setState(sc, StateFailed, err)
+			return
+		}
+		s.setState(sc, StateRestarting, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+		backoff = min(backoff*2, s.opts.MaxBackoff)
+		s.setState(sc, StateRunning, nil)
+	}
+}
+
+// Stop stops the started components in the reverse of the order they were
+// started. Runners have their contexts canceled and are waited on (until the
+// context is done), then Stoppers are stopped. Errors from all components are
+// joined and returned.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.mtx.Lock()
+	started := s.started
+	s.started = nil
+	s.mtx.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		sc := started[i]
+		s.setState(sc, StateStopping, nil)
+		s.mtx.Lock()
+		cancel, done := sc.cancel, sc.done
+		sc.cancel, sc.done = nil, nil
+		s.mtx.Unlock()
+		var err error
+		if cancel != nil {
+			cancel()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				err = fmt.Errorf("stopping %q: %w", sc.name, newCanceledError(ctx))
+			}
+		}
+		if stopper, ok := sc.comp.(Stopper); ok && err == nil {
+			if serr := stopper.Stop(ctx); serr != nil {
+				err = fmt.Errorf("stopping %q: %w", sc.name, serr)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			s.setState(sc, StateFailed, err)
+		} else {
+			s.setState(sc, StateStopped, nil)
+		}
+	}
+
+	s.mtx.Lock()
+	s.running = false
+	s.mtx.Unlock()
+	return errors.Join(errs...)
+}
+
+// Run starts the components, waits for the context to be done, then stops
+// them (using StopTimeout). This makes a Supervisor a Runner itself, so
+// supervisors can be nested.
+func (s *Supervisor) Run(ctx context.Context) error {
+	if err := s.Start(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	stopCtx := context.WithoutCancel(ctx)
+	if s.opts.StopTimeout > 0 {
+		var cancel context.CancelFunc
+		stopCtx, cancel = context.WithTimeout(stopCtx, s.opts.StopTimeout)
+		defer cancel()
+	}
+	return s.Stop(stopCtx)
+}
+
+func (s *Supervisor) setState(
+	sc *supervisedComp, state ComponentState, err error,
+) {
+	s.mtx.Lock()
+	sc.state = state
+	if err != nil {
+		sc.err = err
+	}
+	s.mtx.Unlock()
+	if s.opts.OnStateChange != nil {
+		s.opts.OnStateChange(sc.name, state, err)
+	}
+}
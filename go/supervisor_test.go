@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testLifecycle struct {
+	name     string
+	events   *Mutex[[]string]
+	startErr error
+}
+
+func (tl *testLifecycle) Start(ctx context.Context) error {
+	tl.events.Apply(func(ep *[]string) {
+		*ep = append(*ep, "start "+tl.name)
+	})
+	return tl.startErr
+}
+
+func (tl *testLifecycle) Stop(ctx context.Context) error {
+	tl.events.Apply(func(ep *[]string) {
+		*ep = append(*ep, "stop "+tl.name)
+	})
+	return nil
+}
+
+type testFlakyRunner struct {
+	runs     atomic.Int32
+	failures int32
+}
+
+func (fr *testFlakyRunner) Run(ctx context.Context) error {
+	if n := fr.runs.Add(1); n <= fr.failures {
+		if n%2 == 0 {
+			panic("flaky panic")
+		}
+		return errors.New("flaky error")
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestSupervisor(t *testing.T) {
+	events := NewMutex[[]string](nil)
+	var stateMtx sync.Mutex
+	var states []string
+	s := NewSupervisor(SupervisorOpts{
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+		OnStateChange: func(name string, state ComponentState, err error) {
+			stateMtx.Lock()
+			states = append(states, name+" "+state.String())
+			stateMtx.Unlock()
+		},
+	})
+	newComp := func(name string) *testLifecycle {
+		return &testLifecycle{name: name, events: events}
+	}
+	runner := &testFlakyRunner{failures: 2}
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(s.Add("server", newComp("server"), "db", "cache"))
+	must(s.Add("db", newComp("db")))
+	must(s.Add("cache", newComp("cache"), "db"))
+	must(s.Add("worker", runner, "db"))
+	if err := s.Add("db", newComp("db")); !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if err := s.Add("bad", 5); err == nil {
+		t.Fatal("expected error for non-component")
+	}
+
+	must(s.Start(context.Background()))
+	if err := s.Add("late", newComp("late")); err == nil {
+		t.Fatal("expected error adding while running")
+	}
+	deadline := time.Now().Add(time.Second)
+	for runner.runs.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if runner.runs.Load() != 3 {
+		t.Fatalf("expected 3 runs, got %d", runner.runs.Load())
+	}
+	time.Sleep(10 * time.Millisecond)
+	if state, err, _ := s.State("worker"); state != StateRunning {
+		t.Fatalf("expected worker to be running, got %s", state)
+	} else if pe := (*PanicError)(nil); !errors.As(err, &pe) {
+		t.Fatalf("expected last error to be a panic, got %v", err)
+	}
+
+	must(s.Stop(context.Background()))
+	want := []string{
+		"start db", "start cache", "start server",
+		"stop server", "stop cache", "stop db",
+	}
+	if got := *events.Lock(); !SliceEq(got, want) {
+		events.Unlock()
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	events.Unlock()
+	for name, state := range s.States() {
+		if state != StateStopped {
+			t.Fatalf("expected %s to be stopped, got %s", name, state)
+		}
+	}
+	stateMtx.Lock()
+	restarts := 0
+	for _, state := range states {
+		if state == "worker restarting" {
+			restarts++
+		}
+	}
+	stateMtx.Unlock()
+	if restarts != 2 {
+		t.Fatalf("expected 2 restarts, got %d", restarts)
+	}
+}
+
+func TestSupervisorErrors(t *testing.T) {
+	events := NewMutex[[]string](nil)
+	s := NewSupervisor(SupervisorOpts{})
+	s.Add("a", &testLifecycle{name: "a", events: events})
+	s.Add("b", &testLifecycle{
+		name: "b", events: events, startErr: errors.New("b failed"),
+	}, "a")
+	s.Add("c", &testLifecycle{name: "c", events: events}, "b")
+	if err := s.Start(context.Background()); err == nil {
+		t.Fatal("expected start error")
+	}
+	want := []string{"start a", "start b", "stop a"}
+	if got := *events.Lock(); !SliceEq(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	events.Unlock()
+	if state, _, _ := s.State("b"); state != StateFailed {
+		t.Fatalf("expected b to have failed, got %s", state)
+	}
+
+	s = NewSupervisor(SupervisorOpts{})
+	s.Add("a", &testFlakyRunner{}, "b")
+	s.Add("b", &testFlakyRunner{}, "a")
+	if err := s.Start(context.Background()); err == nil {
+		t.Fatal("expected cycle error")
+	}
+	s = NewSupervisor(SupervisorOpts{})
+	s.Add("a", &testFlakyRunner{}, "nope")
+	if err := s.Start(context.Background()); err == nil {
+		t.Fatal("expected unknown dependency error")
+	}
+
+	s = NewSupervisor(SupervisorOpts{
+		MinBackoff: time.Millisecond, MaxRestarts: 2,
+	})
+	s.Add("flaky", &testFlakyRunner{failures: 10})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if state, _, _ := s.State("flaky"); state == StateFailed {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if state, err, _ := s.State("flaky"); state != StateFailed || err == nil {
+		t.Fatalf("expected flaky to have failed, got %s, %v", state, err)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// A Runner failing right away isn't shown as running while it waits to
+	// be restarted.
+	s = NewSupervisor(SupervisorOpts{MinBackoff: time.Hour})
+	s.Add("flaky", &testFlakyRunner{failures: 1})
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if state, _, _ := s.State("flaky"); state != StateRestarting {
+		t.Fatalf("expected flaky to be restarting, got %s", state)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
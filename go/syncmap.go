@@ -1,10 +1,16 @@
 package utils
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+)
 
 // SyncMap is a typed sync.Map.
 type SyncMap[K any, V any] struct {
-	m sync.Map
+	m    sync.Map
+	size atomic.Int64
+	// In-progress LoadOrCompute calls, by key
+	computing sync.Map
 }
 
 // NewSyncMap returns a new SyncMap.
@@ -23,7 +29,7 @@ func (m *SyncMap[K, V]) Load(key K) (value V, ok bool) {
 
 // Store stores the given key/value pair.
 func (m *SyncMap[K, V]) Store(key K, value V) {
-	m.m.Store(key, value)
+	m.Swap(key, value)
 }
 
 // LoadOrStore loads the value for the given key, or stores the given value if
@@ -36,22 +42,114 @@ func (m *SyncMap[K, V]) LoadOrStore(
 		actual = v.(V)
 	} else {
 		actual = value
+		m.size.Add(1)
 	}
 	return
 }
 
+// LoadOrCompute loads the value for the given key, or stores the result of
+// compute if not present. Unlike LoadOrStore, compute is called at most once
+// for concurrent callers with the same key; the others wait for its result.
+// loaded is true if the value was already present or computed by another
+// caller.
+func (m *SyncMap[K, V]) LoadOrCompute(
+	key K, compute func() V,
+) (actual V, loaded bool) {
+	actual, loaded, _ = m.LoadOrComputeErr(key, func() (V, error) {
+		return compute(), nil
+	})
+	return
+}
+
+type syncMapCompute[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// LoadOrComputeErr is the same as LoadOrCompute except compute can fail. If
+// it does, nothing is stored and the error is returned to the caller and all
+// callers waiting on it. If compute panics, waiting callers get a
+// *PanicError and the panic is propagated to the caller that ran it.
+func (m *SyncMap[K, V]) LoadOrComputeErr(
+	key K, compute func() (V, error),
+) (actual V, loaded bool, err error) {
+	if actual, loaded = m.Load(key); loaded {
+		return
+	}
+	call := &syncMapCompute[V]{done: make(chan struct{})}
+	if c, ok := m.computing.LoadOrStore(key, call); ok {
+		other := c.(*syncMapCompute[V])
+		<-other.done
+		if other.err != nil {
+			return actual, false, other.err
+		}
+		return other.val, true, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = &PanicError{Value: r}
+			m.computing.Delete(key)
+			close(call.done)
+			panic(r)
+		}
+		call.val, call.err = actual, err
+		m.computing.Delete(key)
+		close(call.done)
+	}()
+	// The value may have been stored by the previous computation
+	if actual, loaded = m.Load(key); loaded {
+		return
+	}
+	var val V
+	if val, err = compute(); err != nil {
+		return
+	}
+	actual, loaded = m.LoadOrStore(key, val)
+	return
+}
+
 // LoadAndDelete loads and deletes the given key, returning the value if there.
 func (m *SyncMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
 	var v any
 	if v, loaded = m.m.LoadAndDelete(key); loaded {
 		value = v.(V)
+		m.size.Add(-1)
 	}
 	return
 }
 
 // Delete deletes the key from the map.
 func (m *SyncMap[K, V]) Delete(key K) {
-	m.m.Delete(key)
+	m.LoadAndDelete(key)
+}
+
+// Swap stores the value for the given key, returning the previous value if
+// there was one.
+func (m *SyncMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	var v any
+	if v, loaded = m.m.Swap(key, value); loaded {
+		previous = v.(V)
+	} else {
+		m.size.Add(1)
+	}
+	return
+}
+
+// CompareAndSwap swaps the old and new values for the given key if the value
+// stored is equal to old. The value type must be comparable.
+func (m *SyncMap[K, V]) CompareAndSwap(key K, old, new V) bool {
+	return m.m.CompareAndSwap(key, old, new)
+}
+
+// CompareAndDelete deletes the entry for the given key if its value is equal
+// to old. The value type must be comparable.
+func (m *SyncMap[K, V]) CompareAndDelete(key K, old V) bool {
+	if m.m.CompareAndDelete(key, old) {
+		m.size.Add(-1)
+		return true
+	}
+	return false
 }
 
 // Range iterators through the list, passing the key/value pairs to f. If f
@@ -61,3 +159,39 @@ func (m *SyncMap[K, V]) Range(f func(key K, value V) bool) {
 		return f(k.(K), v.(V))
 	})
 }
+
+// Len returns the number of entries in the map. It may be inaccurate while
+// the map is being concurrently modified.
+func (m *SyncMap[K, V]) Len() int {
+	return int(m.size.Load())
+}
+
+// Clear deletes all entries in the map.
+func (m *SyncMap[K, V]) Clear() {
+	m.m.Range(func(k, _ any) bool {
+		if _, loaded := m.m.LoadAndDelete(k); loaded {
+			m.size.Add(-1)
+		}
+		return true
+	})
+}
+
+// Keys returns a snapshot of the keys in the map, in random order.
+func (m *SyncMap[K, V]) Keys() []K {
+	keys := make([]K, 0, max(m.Len(), 0))
+	m.Range(func(k K, _ V) bool {
+		keys = append(keys, k)
+		return true
+	})
+	return keys
+}
+
+// Values returns a snapshot of the values in the map, in random order.
+func (m *SyncMap[K, V]) Values() []V {
+	values := make([]V, 0, max(m.Len(), 0))
+	m.Range(func(_ K, v V) bool {
+		values = append(values, v)
+		return true
+	})
+	return values
+}
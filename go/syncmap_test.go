@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSyncMap(t *testing.T) {
+	m := NewSyncMap[int, string]()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Store(i%50, "a")
+			m.LoadOrStore(i%50, "b")
+		}(i)
+	}
+	wg.Wait()
+	if n := m.Len(); n != 50 {
+		t.Fatalf("expected 50 entries, got %d", n)
+	}
+
+	if prev, loaded := m.Swap(0, "c"); !loaded || prev != "a" {
+		t.Fatalf("bad swap: %q %v", prev, loaded)
+	}
+	if m.CompareAndSwap(0, "a", "d") || !m.CompareAndSwap(0, "c", "d") {
+		t.Fatal("bad CompareAndSwap")
+	}
+	if m.CompareAndDelete(0, "c") || !m.CompareAndDelete(0, "d") {
+		t.Fatal("bad CompareAndDelete")
+	}
+	m.Delete(1)
+	m.Delete(1)
+	if n := m.Len(); n != 48 {
+		t.Fatalf("expected 48 entries, got %d", n)
+	}
+
+	keys := m.Keys()
+	sort.Ints(keys)
+	if len(keys) != 48 || keys[0] != 2 || keys[47] != 49 {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	if values := m.Values(); len(values) != 48 {
+		t.Fatalf("expected 48 values, got %d", len(values))
+	}
+
+	m.Clear()
+	if n := m.Len(); n != 0 || len(m.Keys()) != 0 {
+		t.Fatalf("expected empty map, got %d entries", n)
+	}
+
+	// The size can be briefly negative during concurrent swaps and deletes.
+	m.size.Store(-1)
+	if len(m.Keys()) != 0 || len(m.Values()) != 0 {
+		t.Fatal("expected no keys or values")
+	}
+}
+
+func TestSyncMapLoadOrCompute(t *testing.T) {
+	m := NewSyncMap[string, int]()
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _ := m.LoadOrCompute("k", func() int {
+				calls.Add(1)
+				time.Sleep(time.Millisecond * 10)
+				return 5
+			})
+			if v != 5 {
+				t.Errorf("expected 5, got %d", v)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected compute called once, got %d", n)
+	}
+	v, loaded := m.LoadOrCompute("k", func() int { return 6 })
+	if !loaded || v != 5 {
+		t.Fatalf("expected loaded 5, got %d %v", v, loaded)
+	}
+
+	testErr := errors.New("test error")
+	_, _, err := m.LoadOrComputeErr("e", func() (int, error) {
+		return 0, testErr
+	})
+	if err != testErr {
+		t.Fatalf("expected test error, got %v", err)
+	}
+	if _, ok := m.Load("e"); ok {
+		t.Fatal("value stored after error")
+	}
+	v, loaded, err = m.LoadOrComputeErr("e", func() (int, error) {
+		return 7, nil
+	})
+	if err != nil || loaded || v != 7 {
+		t.Fatalf("unexpected result: %d %v %v", v, loaded, err)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package utils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const ioctlGetTermios = syscall.TIOCGETA
+
+// IsTerminal returns whether the file descriptor is a terminal.
+func IsTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL, fd, ioctlGetTermios,
+		uintptr(unsafe.Pointer(&termios)),
+	)
+	return errno == 0
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const ioctlGetTermios = syscall.TCGETS
+
+// IsTerminal returns whether the file descriptor is a terminal.
+func IsTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL, fd, ioctlGetTermios,
+		uintptr(unsafe.Pointer(&termios)),
+	)
+	return errno == 0
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package utils
+
+// IsTerminal returns whether the file descriptor is a terminal. Always false
+// on this platform.
+func IsTerminal(fd uintptr) bool {
+	return false
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Pair is a pair of values. It is marshaled to JSON as a 2-element array.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// NewPair returns a new Pair. Since it takes 2 values, it can be called with
+// the results of a function returning 2 values (e.g., NewPair(f())).
+func NewPair[A, B any](a A, b B) Pair[A, B] {
+	return Pair[A, B]{First: a, Second: b}
+}
+
+// Unpack returns the values of the pair.
+func (p Pair[A, B]) Unpack() (A, B) {
+	return p.First, p.Second
+}
+
+// Swap returns a new pair with the values swapped.
+func (p Pair[A, B]) Swap() Pair[B, A] {
+	return Pair[B, A]{First: p.Second, Second: p.First}
+}
+
+// String implements fmt.Stringer.
+func (p Pair[A, B]) String() string {
+	return fmt.Sprintf("(%v, %v)", p.First, p.Second)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Pair[A, B]) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]any{p.First, p.Second})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Pair[A, B]) UnmarshalJSON(data []byte) error {
+	return unmarshalTuple(data, &p.First, &p.Second)
+}
+
+// MapFirst returns a new pair with the first value mapped using f.
+func MapFirst[A, B, C any](p Pair[A, B], f func(A) C) Pair[C, B] {
+	return Pair[C, B]{First: f(p.First), Second: p.Second}
+}
+
+// MapSecond returns a new pair with the second value mapped using f.
+func MapSecond[A, B, C any](p Pair[A, B], f func(B) C) Pair[A, C] {
+	return Pair[A, C]{First: p.First, Second: f(p.Second)}
+}
+
+// Triple is a triple of values. It is marshaled to JSON as a 3-element array.
+type Triple[A, B, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// NewTriple returns a new Triple.
+func NewTriple[A, B, C any](a A, b B, c C) Triple[A, B, C] {
+	return Triple[A, B, C]{First: a, Second: b, Third: c}
+}
+
+// Unpack returns the values of the triple.
+func (t Triple[A, B, C]) Unpack() (A, B, C) {
+	return t.First, t.Second, t.Third
+}
+
+// String implements fmt.Stringer.
+func (t Triple[A, B, C]) String() string {
+	return fmt.Sprintf("(%v, %v, %v)", t.First, t.Second, t.Third)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Triple[A, B, C]) MarshalJSON() ([]byte, error) {
+	return json.Marshal([3]any{t.First, t.Second, t.Third})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Triple[A, B, C]) UnmarshalJSON(data []byte) error {
+	return unmarshalTuple(data, &t.First, &t.Second, &t.Third)
+}
+
+func unmarshalTuple(data []byte, ptrs ...any) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	if len(raws) != len(ptrs) {
+		return fmt.Errorf(
+			"expected %d-element array, got %d elements", len(ptrs), len(raws),
+		)
+	}
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, ptrs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ZipSlices returns pairs of the elements of the 2 slices. The result has the
+// length of the shorter slice.
+func ZipSlices[A, B any](as []A, bs []B) []Pair[A, B] {
+	res := make([]Pair[A, B], min(len(as), len(bs)))
+	for i := range res {
+		res[i] = Pair[A, B]{First: as[i], Second: bs[i]}
+	}
+	return res
+}
+
+// UnzipSlice splits a slice of pairs into 2 slices.
+func UnzipSlice[A, B any](ps []Pair[A, B]) ([]A, []B) {
+	as, bs := make([]A, len(ps)), make([]B, len(ps))
+	for i, p := range ps {
+		as[i], bs[i] = p.First, p.Second
+	}
+	return as, bs
+}
+
+// Zip3Slices returns triples of the elements of the 3 slices. The result has
+// the length of the shortest slice.
+func Zip3Slices[A, B, C any](as []A, bs []B, cs []C) []Triple[A, B, C] {
+	res := make([]Triple[A, B, C], min(len(as), len(bs), len(cs)))
+	for i := range res {
+		res[i] = Triple[A, B, C]{First: as[i], Second: bs[i], Third: cs[i]}
+	}
+	return res
+}
+
+// Unzip3Slice splits a slice of triples into 3 slices.
+func Unzip3Slice[A, B, C any](ts []Triple[A, B, C]) ([]A, []B, []C) {
+	as, bs, cs := make([]A, len(ts)), make([]B, len(ts)), make([]C, len(ts))
+	for i, t := range ts {
+		as[i], bs[i], cs[i] = t.First, t.Second, t.Third
+	}
+	return as, bs, cs
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestPair(t *testing.T) {
+	p := NewPair(strconv.Atoi("12"))
+	if p.First != 12 || p.Second != nil {
+		t.Fatalf("unexpected pair: %v", p)
+	}
+	q := NewPair("a", 1)
+	if s := q.Swap(); s.First != 1 || s.Second != "a" {
+		t.Fatalf("unexpected swapped pair: %v", s)
+	}
+	m := MapSecond(MapFirst(q, func(s string) int { return len(s) }),
+		strconv.Itoa)
+	if m != NewPair(1, "1") {
+		t.Fatalf("unexpected mapped pair: %v", m)
+	}
+	if s := q.String(); s != "(a, 1)" {
+		t.Fatalf("unexpected string: %s", s)
+	}
+
+	b, err := json.Marshal([]Pair[string, int]{q, NewPair("b", 2)})
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != `[["a",1],["b",2]]` {
+		t.Fatalf("unexpected JSON: %s", b)
+	}
+	var ps []Pair[string, int]
+	if err := json.Unmarshal(b, &ps); err != nil {
+		t.Fatal(err)
+	} else if len(ps) != 2 || ps[0] != q || ps[1] != NewPair("b", 2) {
+		t.Fatalf("unexpected pairs: %v", ps)
+	}
+	if err := json.Unmarshal([]byte(`["a",1,2]`), &q); err == nil {
+		t.Fatal("expected error for wrong length")
+	}
+	if err := json.Unmarshal([]byte(`[1,1]`), &q); err == nil {
+		t.Fatal("expected error for wrong type")
+	}
+}
+
+func TestTriple(t *testing.T) {
+	tr := NewTriple(1, "b", true)
+	b, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != `[1,"b",true]` {
+		t.Fatalf("unexpected JSON: %s", b)
+	}
+	var got Triple[int, string, bool]
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	} else if got != tr {
+		t.Fatalf("expected %v, got %v", tr, got)
+	}
+	if a, b, c := got.Unpack(); a != 1 || b != "b" || !c {
+		t.Fatalf("unexpected values: %v %v %v", a, b, c)
+	}
+}
+
+func TestZipSlices(t *testing.T) {
+	ps := ZipSlices([]int{1, 2, 3}, []string{"a", "b"})
+	if len(ps) != 2 || ps[1] != NewPair(2, "b") {
+		t.Fatalf("unexpected pairs: %v", ps)
+	}
+	ints, strs := UnzipSlice(ps)
+	if !SliceEq(ints, []int{1, 2}) || !SliceEq(strs, []string{"a", "b"}) {
+		t.Fatalf("unexpected slices: %v %v", ints, strs)
+	}
+	ts := Zip3Slices([]int{1}, []int{2, 3}, []int{4, 5})
+	if len(ts) != 1 || ts[0] != NewTriple(1, 2, 4) {
+		t.Fatalf("unexpected triples: %v", ts)
+	}
+	as, bs, cs := Unzip3Slice(ts)
+	if as[0] != 1 || bs[0] != 2 || cs[0] != 4 {
+		t.Fatalf("unexpected slices: %v %v %v", as, bs, cs)
+	}
+
+	m := MapFromPairs([]Pair[string, int]{{"a", 1}, {"b", 2}, {"a", 3}})
+	if m.Len() != 2 || m.Get("a") != 3 {
+		t.Fatalf("unexpected map: %v", m.ToGoMap())
+	}
+	pairs := m.Pairs()
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].First < pairs[j].First
+	})
+	if !SliceEq(pairs, []Pair[string, int]{{"a", 3}, {"b", 2}}) {
+		t.Fatalf("unexpected pairs: %v", pairs)
+	}
+}
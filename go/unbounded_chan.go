@@ -2,6 +2,7 @@ package utils
 
 import (
 	"container/list"
+	"context"
 	"errors"
 	"sync/atomic"
 	"time"
@@ -16,11 +17,41 @@ var (
 	ErrCanceled = errors.New("canceled")
 )
 
+// canceledError is ErrCanceled wrapping the error of the context that caused
+// the cancellation so that errors.Is works for both.
+type canceledError struct {
+	err error
+}
+
+func newCanceledError(ctx context.Context) error {
+	return canceledError{err: ctx.Err()}
+}
+
+// Error implements the error interface.
+func (ce canceledError) Error() string {
+	if ce.err == nil {
+		return ErrCanceled.Error()
+	}
+	return ErrCanceled.Error() + ": " + ce.err.Error()
+}
+
+// Is implements errors.Is, matching ErrCanceled.
+func (ce canceledError) Is(target error) bool {
+	return target == ErrCanceled
+}
+
+// Unwrap implements errors.Unwrap, returning the context's error.
+func (ce canceledError) Unwrap() error {
+	return ce.err
+}
+
 // UChan is an unbounded channel.
 type UChan[T any] struct {
 	ch       chan T
 	buf      *Mutex[*list.List]
 	isClosed atomic.Bool
+	// chClosed is whether ch has been closed. Guarded by buf's lock.
+	chClosed bool
 }
 
 // NewUChan returns a new UChan with the given chan length, `l`. `l` can
@@ -80,6 +111,21 @@ RecvTimeoutLoop:
 // the cancel chan as well as closing it will cancel the operation, returning
 // ErrCanceled.
 func (uc *UChan[T]) RecvCancel(cancel chan struct{}) (t T, err error) {
+	return uc.recvCancel(cancel)
+}
+
+// RecvContext functions the same as RecvCancel except the operation is
+// canceled when the context is done. The returned error wraps the context's
+// error along with ErrCanceled, so either can be checked using errors.Is.
+func (uc *UChan[T]) RecvContext(ctx context.Context) (t T, err error) {
+	t, err = uc.recvCancel(ctx.Done())
+	if err == ErrCanceled {
+		err = newCanceledError(ctx)
+	}
+	return
+}
+
+func (uc *UChan[T]) recvCancel(cancel <-chan struct{}) (t T, err error) {
 	ok := false
 RecvCancelLoop:
 	for {
@@ -105,6 +151,121 @@ RecvCancelLoop:
 	return
 }
 
+// TryRecv attempts to receive from the channel without blocking. Returns
+// ErrClosed if the channel is closed (and empty) and ErrEmpty if there is no
+// value available.
+func (uc *UChan[T]) TryRecv() (t T, err error) {
+	ok := false
+	select {
+	case t, ok = <-uc.ch:
+		if !ok {
+			return t, ErrClosed
+		}
+	default:
+		return t, ErrEmpty
+	}
+	uc.moveMsg()
+	return
+}
+
+// RecvUpTo receives at most n values, blocking until at least one value is
+// available, then receiving the rest of the values that are immediately
+// available. Returns ErrClosed if the channel is closed and there are no more
+// values. If n is less than 1, nil is returned.
+func (uc *UChan[T]) RecvUpTo(n int) ([]T, error) {
+	if n < 1 {
+		return nil, nil
+	}
+	t, ok := uc.Recv()
+	if !ok {
+		return nil, ErrClosed
+	}
+	res := make([]T, 1, n)
+	res[0] = t
+	for len(res) < n {
+		t, err := uc.TryRecv()
+		if err != nil {
+			break
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+// RecvAtLeast receives n values, blocking until all n are received or the
+// deadline is reached (a zero deadline means no deadline). If the deadline is
+// reached, the values received so far are returned along with ErrTimedOut. If
+// the channel is closed before n values are received, the values received are
+// returned along with ErrClosed.
+func (uc *UChan[T]) RecvAtLeast(n int, deadline time.Time) ([]T, error) {
+	if n < 1 {
+		return nil, nil
+	}
+	res := make([]T, 0, n)
+	for len(res) < n {
+		var t T
+		var err error
+		if deadline.IsZero() {
+			var ok bool
+			if t, ok = uc.Recv(); !ok {
+				err = ErrClosed
+			}
+		} else {
+			t, err = uc.RecvTimeout(time.Until(deadline))
+		}
+		if err != nil {
+			return res, err
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+// Drain removes and returns all values currently pending in the channel, in
+// order. This is especially useful after the channel is closed to flush the
+// remaining values.
+func (uc *UChan[T]) Drain() (res []T) {
+	uc.buf.Apply(func(lp **list.List) {
+		buf := *lp
+		res = make([]T, 0, len(uc.ch)+buf.Len())
+	DrainLoop:
+		for {
+			select {
+			case t, ok := <-uc.ch:
+				if !ok {
+					break DrainLoop
+				}
+				res = append(res, t)
+			default:
+				break DrainLoop
+			}
+		}
+		for e := buf.Front(); e != nil; e = e.Next() {
+			res = append(res, e.Value.(T))
+		}
+		buf.Init()
+		if uc.IsClosed() {
+			uc.closeChLocked()
+		}
+	})
+	return
+}
+
+// Len returns the number of values pending in the channel (those in the
+// underlying chan as well as those buffered).
+func (uc *UChan[T]) Len() (l int) {
+	uc.buf.Apply(func(lp **list.List) {
+		l = len(uc.ch) + (*lp).Len()
+	})
+	return
+}
+
+// Cap returns the capacity of the underlying chan. The UChan itself is
+// unbounded.
+func (uc *UChan[T]) Cap() int {
+	return cap(uc.ch)
+}
+
 // Receiver is returned by UChan.RecvChan to receive a value.
 type Receiver[T any] struct {
 	ch       chan T
@@ -157,16 +318,21 @@ func (uc *UChan[T]) RecvChan() *Receiver[T] {
 func (uc *UChan[T]) moveMsg() {
 	uc.buf.Apply(func(lp **list.List) {
 		buf := *lp
-		if buf.Len() == 0 {
-			return
+		// Never block while holding the lock since the chan may have been filled
+		// by a concurrent send, and the receiver may be the only one able to
+		// make room.
+		for e := buf.Front(); e != nil; e = buf.Front() {
+			select {
+			case uc.ch <- e.Value.(T):
+				buf.Remove(e)
+			default:
+				return
+			}
 		}
-		e := buf.Front()
-		uc.ch <- e.Value.(T)
-		buf.Remove(e)
 		// If there are no more messages in the buffer and the UChan is closed, it's
 		// safe to close the chan
-		if buf.Len() == 0 && uc.IsClosed() {
-			close(uc.ch)
+		if uc.IsClosed() {
+			uc.closeChLocked()
 		}
 	})
 }
@@ -178,12 +344,31 @@ func (uc *UChan[T]) Send(val T) bool {
 	if uc.IsClosed() {
 		return false
 	}
-	uc.send(val)
-	return true
+	return uc.send(val)
+}
+
+// SendContext sends the value over the channel if the context isn't done.
+// Since sending never blocks, the context is only checked before sending.
+// Returns ErrClosed if the channel is closed, or ErrCanceled wrapping the
+// context's error if the context is done.
+func (uc *UChan[T]) SendContext(ctx context.Context, val T) error {
+	if err := ctx.Err(); err != nil {
+		return newCanceledError(ctx)
+	}
+	if !uc.Send(val) {
+		return ErrClosed
+	}
+	return nil
 }
 
-func (uc *UChan[T]) send(val T) {
+// send sends the value, returning false if the chan was closed by a
+// concurrent Close (or Drain) after the caller checked IsClosed.
+func (uc *UChan[T]) send(val T) (sent bool) {
 	uc.buf.Apply(func(lp **list.List) {
+		if uc.chClosed {
+			return
+		}
+		sent = true
 		buf := *lp
 		for e := buf.Front(); e != nil; {
 			select {
@@ -202,6 +387,7 @@ func (uc *UChan[T]) send(val T) {
 			buf.PushBack(val)
 		}
 	})
+	return
 }
 
 // SendAndClose sends the value over the channel, closing the UChan in the
@@ -229,11 +415,19 @@ func (uc *UChan[T]) tryCloseChan() {
 		buf := *lp
 		// Nothing more will be sent over the channel; it's safe to close
 		if buf.Len() == 0 {
-			close(uc.ch)
+			uc.closeChLocked()
 		}
 	})
 }
 
+// closeChLocked closes the chan if it hasn't been. The buf lock must be held.
+func (uc *UChan[T]) closeChLocked() {
+	if !uc.chClosed {
+		uc.chClosed = true
+		close(uc.ch)
+	}
+}
+
 // IsClosed returns whether the channel is closed.
 func (uc *UChan[T]) IsClosed() bool {
 	return uc.isClosed.Load()
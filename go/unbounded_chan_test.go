@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 )
@@ -63,7 +66,7 @@ func TestUChanRecvChan(t *testing.T) {
 			rch := ch.RecvChan()
 			_, ok := <-rch.Chan()
 			if !ok {
-				t.Fatal("channel closed")
+				t.Error("channel closed")
 			}
 			done <- true
 		}()
@@ -130,3 +133,191 @@ func TestUChanRecvChan(t *testing.T) {
 		<-timer.C
 	}
 }
+
+func TestUChanContext(t *testing.T) {
+	ch := NewUChan[int](10)
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 20; i++ {
+		if err := ch.SendContext(ctx, i); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if n, err := ch.RecvContext(ctx); err != nil {
+			t.Fatal("unexpected error: ", err)
+		} else if n != i {
+			t.Fatalf("expected %d, got %d", i, n)
+		}
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+	}()
+	_, err := ch.RecvContext(ctx)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected ErrCanceled and context.Canceled, got %v", err)
+	}
+	err = ch.SendContext(ctx, 1)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected ErrCanceled and context.Canceled, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = ch.RecvContext(ctx)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrCanceled and context.DeadlineExceeded, got %v", err)
+	}
+
+	ch.Close()
+	if err := ch.SendContext(context.Background(), 1); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if _, err := ch.RecvContext(context.Background()); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestUChanLenDrain(t *testing.T) {
+	ch := NewUChan[int](10)
+	if ch.Cap() != 10 {
+		t.Fatalf("expected cap of 10, got %d", ch.Cap())
+	}
+	if _, err := ch.TryRecv(); err != ErrEmpty {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+	for i := 0; i < 25; i++ {
+		ch.Send(i)
+	}
+	if l := ch.Len(); l != 25 {
+		t.Fatalf("expected length of 25, got %d", l)
+	}
+	for i := 0; i < 5; i++ {
+		if n, err := ch.TryRecv(); err != nil {
+			t.Fatal("unexpected error: ", err)
+		} else if n != i {
+			t.Fatalf("expected %d, got %d", i, n)
+		}
+	}
+	if l := ch.Len(); l != 20 {
+		t.Fatalf("expected length of 20, got %d", l)
+	}
+
+	got := ch.Drain()
+	if want := RangeSlice(25)[5:]; !SliceEq(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if l := ch.Len(); l != 0 {
+		t.Fatalf("expected length of 0, got %d", l)
+	}
+
+	for i := 0; i < 15; i++ {
+		ch.Send(i)
+	}
+	ch.Close()
+	if got := ch.Drain(); !SliceEq(got, RangeSlice(15)) {
+		t.Fatalf("unexpected drained values: %v", got)
+	}
+	if _, err := ch.TryRecv(); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	if _, ok := ch.Recv(); ok {
+		t.Fatal("expected closed channel")
+	}
+	if got := ch.Drain(); len(got) != 0 {
+		t.Fatalf("expected nothing drained, got %v", got)
+	}
+}
+
+func TestUChanSendClose(t *testing.T) {
+	// Sends racing with Close and Drain (which closes the underlying chan)
+	// must not send on the closed chan, which panics; they either succeed or
+	// return false.
+	for i := 0; i < 20; i++ {
+		ch := NewUChan[int](1)
+		var started, wg sync.WaitGroup
+		for j := 0; j < 2; j++ {
+			started.Add(1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ch.Send(0)
+				started.Done()
+				for ch.Send(j) {
+				}
+			}()
+		}
+		started.Wait()
+		ch.Close()
+		ch.Drain()
+		wg.Wait()
+	}
+}
+
+func TestUChanRecvRefilled(t *testing.T) {
+	// A lone receiver must not deadlock when concurrent sends refill the chan
+	// between its receive and moving buffered values into the chan.
+	const senders, perSender = 4, 500
+	ch := NewUChan[int](1)
+	for i := 0; i < senders; i++ {
+		go func() {
+			for j := 0; j < perSender; j++ {
+				ch.Send(j)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < senders*perSender; i++ {
+			ch.Recv()
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("receiver deadlocked")
+	}
+}
+
+func TestUChanBatchRecv(t *testing.T) {
+	ch := NewUChan[int](10)
+	for i := 0; i < 25; i++ {
+		ch.Send(i)
+	}
+	if got, err := ch.RecvUpTo(10); err != nil {
+		t.Fatal("unexpected error: ", err)
+	} else if !SliceEq(got, RangeSlice(10)) {
+		t.Fatalf("unexpected values: %v", got)
+	}
+	got, err := ch.RecvAtLeast(10, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	} else if !SliceEq(got, RangeSlice(20)[10:]) {
+		t.Fatalf("unexpected values: %v", got)
+	}
+
+	// Partial results
+	got, err = ch.RecvAtLeast(10, time.Now().Add(time.Millisecond*10))
+	if err != ErrTimedOut {
+		t.Fatalf("expected ErrTimedOut, got %v", err)
+	} else if !SliceEq(got, RangeSlice(25)[20:]) {
+		t.Fatalf("unexpected values: %v", got)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		ch.Send(1)
+		ch.SendAndClose(2)
+	}()
+	got, err = ch.RecvAtLeast(10, time.Time{})
+	if err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	} else if !SliceEq(got, []int{1, 2}) {
+		t.Fatalf("expected [1 2], got %v", got)
+	}
+	if _, err := ch.RecvUpTo(10); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
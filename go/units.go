@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes. Since it is an integer type, sizes can be
+// added, subtracted, and scaled directly (e.g., 3 * MiB).
+type ByteSize int64
+
+// Decimal (SI) byte size units.
+const (
+	Byte ByteSize = 1
+	KB            = 1000 * Byte
+	MB            = 1000 * KB
+	GB            = 1000 * MB
+	TB            = 1000 * GB
+	PB            = 1000 * TB
+	EB            = 1000 * PB
+)
+
+// Binary (IEC) byte size units.
+const (
+	KiB = 1024 * Byte
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+	PiB = 1024 * TiB
+	EiB = 1024 * PiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"": Byte, "b": Byte, "byte": Byte, "bytes": Byte,
+	"k": KiB, "kb": KB, "kib": KiB,
+	"m": MiB, "mb": MB, "mib": MiB,
+	"g": GiB, "gb": GB, "gib": GiB,
+	"t": TiB, "tb": TB, "tib": TiB,
+	"p": PiB, "pb": PB, "pib": PiB,
+	"e": EiB, "eb": EB, "eib": EiB,
+}
+
+var binaryByteSizeNames = []string{
+	"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB",
+}
+
+// ParseByteSize parses a byte size such as "512", "1.5GiB", "10 MB", or
+// "4k". Units are case-insensitive; those with an "i" (KiB, MiB, ...) and
+// bare letters (k, m, ...) are binary (powers of 1024) while those without
+// (KB, MB, ...) are decimal (powers of 1000). Fractional sizes are rounded to
+// the nearest byte.
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return !('0' <= r && r <= '9' || r == '.' || r == '-' || r == '+')
+	})
+	num, unit := str, ""
+	if i != -1 {
+		num, unit = str[:i], strings.TrimSpace(str[i:])
+	}
+	mult, ok := byteSizeUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, unit)
+	}
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n != 0 && (n > math.MaxInt64/int64(mult) ||
+			n < math.MinInt64/int64(mult)) {
+			return 0, fmt.Errorf("invalid byte size %q: out of range", s)
+		}
+		return ByteSize(n) * mult, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	f = math.Round(f * float64(mult))
+	if f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, fmt.Errorf("invalid byte size %q: out of range", s)
+	}
+	return ByteSize(f), nil
+}
+
+// String formats the size using the largest binary unit that keeps the value
+// at least 1, with up to 2 decimal places (e.g., "1.5GiB", "512B").
+func (b ByteSize) String() string {
+	abs := math.Abs(float64(b))
+	i := 0
+	for unit := float64(KiB); i < len(binaryByteSizeNames)-1 &&
+		abs >= unit; unit *= 1024 {
+		i++
+	}
+	if i == 0 {
+		return strconv.FormatInt(int64(b), 10) + "B"
+	}
+	val := float64(b) / math.Pow(1024, float64(i))
+	return strconv.FormatFloat(math.Round(val*100)/100, 'f', -1, 64) +
+		binaryByteSizeNames[i]
+}
+
+// Bytes returns the size as an int64.
+func (b ByteSize) Bytes() int64 {
+	return int64(b)
+}
+
+// In returns the size in the given unit (e.g., b.In(MiB)).
+func (b ByteSize) In(unit ByteSize) float64 {
+	return float64(b) / float64(unit)
+}
+
+// MarshalText implements encoding.TextMarshaler. Unlike String, the size isn't
+// rounded; it's formatted using the largest binary unit that divides it
+// exactly (e.g., "1536KiB" or "1025B") so it round-trips through
+// UnmarshalText.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	i, unit := 0, Byte
+	for b != 0 && i < len(binaryByteSizeNames)-1 && b%(unit*1024) == 0 {
+		i, unit = i+1, unit*1024
+	}
+	return []byte(
+		strconv.FormatInt(int64(b/unit), 10) + binaryByteSizeNames[i],
+	), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, using ParseByteSize.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err == nil {
+		*b = size
+	}
+	return err
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either a number of
+// bytes or a string parsed with ParseByteSize.
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*b = ByteSize(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid byte size: %s", data)
+	}
+	return b.UnmarshalText([]byte(s))
+}
+
+// Ratio is a fraction, where 1 is 100%. It is formatted as a percentage.
+type Ratio float64
+
+// ParseRatio parses a ratio, either as a percentage ("50%", "12.5 %") or a
+// fraction ("0.5").
+func ParseRatio(s string) (Ratio, error) {
+	str := strings.TrimSpace(s)
+	pct := strings.HasSuffix(str, "%")
+	if pct {
+		str = strings.TrimSpace(strings.TrimSuffix(str, "%"))
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ratio %q", s)
+	}
+	if pct {
+		f /= 100
+	}
+	return Ratio(f), nil
+}
+
+// Percent returns the ratio as a percentage (e.g., 0.5 returns 50).
+func (r Ratio) Percent() float64 {
+	return float64(r) * 100
+}
+
+// Of returns the ratio of n, rounded to the nearest integer.
+func (r Ratio) Of(n int64) int64 {
+	return int64(math.Round(float64(r) * float64(n)))
+}
+
+// OfSize returns the ratio of the byte size, rounded to the nearest byte.
+func (r Ratio) OfSize(b ByteSize) ByteSize {
+	return ByteSize(r.Of(int64(b)))
+}
+
+// Clamp returns the ratio clamped to [0, 1].
+func (r Ratio) Clamp() Ratio {
+	return Ratio(math.Max(0, math.Min(1, float64(r))))
+}
+
+// String formats the ratio as a percentage (e.g., "12.5%").
+func (r Ratio) String() string {
+	return strconv.FormatFloat(r.Percent(), 'f', -1, 64) + "%"
+}
+
+// MarshalText implements encoding.TextMarshaler, using String.
+func (r Ratio) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, using ParseRatio.
+func (r *Ratio) UnmarshalText(text []byte) error {
+	ratio, err := ParseRatio(string(text))
+	if err == nil {
+		*r = ratio
+	}
+	return err
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either a number (the
+// fraction) or a string parsed with ParseRatio.
+func (r *Ratio) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err == nil {
+		*r = Ratio(f)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid ratio: %s", data)
+	}
+	return r.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestByteSize(t *testing.T) {
+	tests := map[string]ByteSize{
+		"512":     512,
+		"1.5GiB":  GiB + 512*MiB,
+		"10 MB":   10 * MB,
+		"4k":      4 * KiB,
+		"1kib":    KiB,
+		"0.5 B":   1,
+		"-2KiB":   -2 * KiB,
+		"8 bytes": 8,
+	}
+	for s, want := range tests {
+		if got, err := ParseByteSize(s); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		} else if got != want {
+			t.Fatalf("%s: expected %d, got %d", s, want, got)
+		}
+	}
+	for _, s := range []string{"", "GiB", "1.2.3MB", "5 furlongs", "9EiB"} {
+		if _, err := ParseByteSize(s); err == nil {
+			t.Fatalf("%s: expected error", s)
+		}
+	}
+
+	formats := map[ByteSize]string{
+		0:                 "0B",
+		1023:              "1023B",
+		KiB:               "1KiB",
+		GiB + 512*MiB:     "1.5GiB",
+		-3 * MiB:          "-3MiB",
+		10*MB + 123*Byte:  "9.54MiB",
+		ByteSize(1 << 62): "4EiB",
+	}
+	for b, want := range formats {
+		if got := b.String(); got != want {
+			t.Fatalf("%d: expected %s, got %s", int64(b), want, got)
+		}
+	}
+
+	var cfg struct {
+		A ByteSize
+		B ByteSize
+	}
+	if err := json.Unmarshal([]byte(`{"A":"2MiB","B":100}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.A != 2*MiB || cfg.B != 100 {
+		t.Fatalf("unexpected sizes: %+v", cfg)
+	}
+	if b, _ := json.Marshal(cfg); string(b) != `{"A":"2MiB","B":"100B"}` {
+		t.Fatalf("unexpected JSON: %s", b)
+	}
+	// Marshaling is exact, so sizes that String rounds still round-trip.
+	for _, b := range []ByteSize{
+		0, 1025, -1025, 1536 * KiB, 10*MB + 123*Byte, 3 * EiB, math.MaxInt64,
+		math.MinInt64,
+	} {
+		text, _ := b.MarshalText()
+		var got ByteSize
+		if err := got.UnmarshalText(text); err != nil {
+			t.Fatalf("%d: %v", int64(b), err)
+		} else if got != b {
+			t.Fatalf("%d: marshaled as %s, unmarshaled as %d", b, text, got)
+		}
+	}
+	if text, _ := (GiB + 512*MiB).MarshalText(); string(text) != "1536MiB" {
+		t.Fatalf("expected 1536MiB, got %s", text)
+	}
+	if got := (3 * MiB).In(KiB); got != 3072 {
+		t.Fatalf("expected 3072, got %f", got)
+	}
+}
+
+func TestRatio(t *testing.T) {
+	for s, want := range map[string]Ratio{
+		"50%": 0.5, "12.5 %": 0.125, "0.25": 0.25, "150%": 1.5,
+	} {
+		if got, err := ParseRatio(s); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		} else if got != want {
+			t.Fatalf("%s: expected %v, got %v", s, float64(want), float64(got))
+		}
+	}
+	if _, err := ParseRatio("half"); err == nil {
+		t.Fatal("expected error")
+	}
+	r := Ratio(0.125)
+	if r.String() != "12.5%" || r.Of(80) != 10 || r.OfSize(MiB) != 128*KiB {
+		t.Fatalf("unexpected ratio results: %s", r)
+	}
+	if Ratio(1.5).Clamp() != 1 || Ratio(-1).Clamp() != 0 {
+		t.Fatal("bad Clamp results")
+	}
+	var cfg struct{ A, B Ratio }
+	if err := json.Unmarshal([]byte(`{"A":"75%","B":0.1}`), &cfg); err != nil {
+		t.Fatal(err)
+	} else if cfg.A != 0.75 || cfg.B != 0.1 {
+		t.Fatalf("unexpected ratios: %+v", cfg)
+	}
+}
@@ -0,0 +1,373 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PanicError is an error created from a recovered panic.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (pe *PanicError) Unwrap() error {
+	err, _ := pe.Value.(error)
+	return err
+}
+
+// WorkerPoolOpts are options for a WorkerPool.
+type WorkerPoolOpts struct {
+	// Workers is the number of workers. If less than 1, runtime.GOMAXPROCS(0)
+	// is used.
+	Workers int
+	// TaskTimeout is the maximum duration of each task. The context passed to
+	// the task func is canceled once reached and the task's result is
+	// ErrTimedOut (even if the func doesn't return). If 0, there is no timeout.
+	TaskTimeout time.Duration
+	// Ordered is whether results are delivered in the order tasks were
+	// submitted, rather than the order they finish.
+	Ordered bool
+	// ChanLen is the chan length of the task, result, and dead letter UChans.
+	// If less than 1, the number of workers is used.
+	ChanLen int
+	// MaxAttempts is the maximum number of times a task is attempted before
+	// failing. If less than 1, tasks are only attempted once.
+	MaxAttempts int
+	// RetryDelay is the delay before retrying a failed task.
+	RetryDelay time.Duration
+	// Retryable, if set, determines whether a task that failed with the error
+	// should be retried. By default, all errors are retried.
+	Retryable func(error) bool
+	// DeadLetters is whether tasks that fail (after all attempts) are sent to
+	// the dead letter UChan, in addition to their results being delivered.
+	DeadLetters bool
+}
+
+// DeadLetter is a task that failed after all of its attempts.
+type DeadLetter[In any] struct {
+	// Seq is the order the task was submitted in.
+	Seq uint64
+	// In is the task's input.
+	In In
+	// Errors are the errors from each attempt, in order.
+	Errors []error
+	// FirstAttempt is when the task was first attempted.
+	FirstAttempt time.Time
+	// LastAttempt is when the task was last attempted.
+	LastAttempt time.Time
+}
+
+// Attempts returns the number of times the task was attempted.
+func (dl DeadLetter[In]) Attempts() int {
+	return len(dl.Errors)
+}
+
+// Err returns the error from the last attempt.
+func (dl DeadLetter[In]) Err() error {
+	if len(dl.Errors) == 0 {
+		return nil
+	}
+	return dl.Errors[len(dl.Errors)-1]
+}
+
+// WorkerResult is the result of a task run by a WorkerPool.
+type WorkerResult[In, Out any] struct {
+	// Seq is the order the task was submitted in, starting at 0.
+	Seq uint64
+	// In is the task's input.
+	In In
+	// Out is the output from the task.
+	Out Out
+	// Err is the error from the task, if any. If the task panicked, this is a
+	// *PanicError.
+	Err error
+}
+
+type workerTask[In any] struct {
+	seq uint64
+	in  In
+}
+
+// WorkerPool runs tasks from a UChan on a fixed number of workers, delivering
+// the results to another UChan. Results should be received from the results
+// UChan since they are buffered indefinitely.
+type WorkerPool[In, Out any] struct {
+	f    func(context.Context, In) (Out, error)
+	opts WorkerPoolOpts
+
+	tasks       *UChan[workerTask[In]]
+	results     *UChan[WorkerResult[In, Out]]
+	deadLetters *UChan[DeadLetter[In]]
+	submitMtx   sync.Mutex
+	nextSeq     uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	done   chan struct{}
+
+	// Used for ordered delivery
+	reorder     *Mutex[map[uint64]WorkerResult[In, Out]]
+	nextDeliver uint64
+}
+
+// NewWorkerPool creates and starts a new WorkerPool running the given func
+// for each task.
+func NewWorkerPool[In, Out any](
+	f func(ctx context.Context, in In) (Out, error), opts WorkerPoolOpts,
+) *WorkerPool[In, Out] {
+	if opts.Workers < 1 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	if opts.ChanLen < 1 {
+		opts.ChanLen = opts.Workers
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &WorkerPool[In, Out]{
+		f:       f,
+		opts:    opts,
+		tasks:   NewUChan[workerTask[In]](opts.ChanLen),
+		results: NewUChan[WorkerResult[In, Out]](opts.ChanLen),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		reorder: NewMutex(make(map[uint64]WorkerResult[In, Out])),
+	}
+	if opts.DeadLetters {
+		p.deadLetters = NewUChan[DeadLetter[In]](opts.ChanLen)
+	}
+	p.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go p.work()
+	}
+	go func() {
+		p.wg.Wait()
+		p.flushReorder()
+		p.results.Close()
+		if p.deadLetters != nil {
+			p.deadLetters.Close()
+		}
+		close(p.done)
+	}()
+	return p
+}
+
+// Submit submits a task, returning false if the pool is closed.
+func (p *WorkerPool[In, Out]) Submit(in In) bool {
+	// Sequence numbers are only used if the task is actually sent so that there
+	// are no gaps when delivering in order.
+	p.submitMtx.Lock()
+	defer p.submitMtx.Unlock()
+	if !p.tasks.Send(workerTask[In]{seq: p.nextSeq, in: in}) {
+		return false
+	}
+	p.nextSeq++
+	return true
+}
+
+// Results returns the UChan results are delivered to. It is closed once the
+// pool is closed and all tasks have finished.
+func (p *WorkerPool[In, Out]) Results() *UChan[WorkerResult[In, Out]] {
+	return p.results
+}
+
+// DeadLetters returns the UChan failed tasks are sent to, or nil if dead
+// letters aren't enabled. It is closed once the pool is closed and all tasks
+// have finished.
+func (p *WorkerPool[In, Out]) DeadLetters() *UChan[DeadLetter[In]] {
+	return p.deadLetters
+}
+
+// Requeue submits the dead letter's input as a new task, returning false if
+// the pool is closed.
+func (p *WorkerPool[In, Out]) Requeue(dl DeadLetter[In]) bool {
+	return p.Submit(dl.In)
+}
+
+// Close stops the pool from accepting new tasks. Tasks already submitted are
+// still run. Returns false if the pool was already closed.
+func (p *WorkerPool[In, Out]) Close() bool {
+	return p.tasks.Close()
+}
+
+// Wait waits for the pool to be closed and all tasks to finish.
+func (p *WorkerPool[In, Out]) Wait() {
+	<-p.done
+}
+
+// Done returns a chan that is closed once the pool is closed and all tasks
+// have finished.
+func (p *WorkerPool[In, Out]) Done() <-chan struct{} {
+	return p.done
+}
+
+// Shutdown closes the pool and waits for all submitted tasks to finish. If the
+// context is done first, the contexts of running tasks are canceled, pending
+// tasks are dropped, and ErrCanceled (wrapping the context's error) is
+// returned.
+func (p *WorkerPool[In, Out]) Shutdown(ctx context.Context) error {
+	p.Close()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		p.Stop()
+		return newCanceledError(ctx)
+	}
+}
+
+// Stop closes the pool, cancels the contexts of running tasks, and drops all
+// pending tasks. Dropped tasks produce no results.
+func (p *WorkerPool[In, Out]) Stop() {
+	p.Close()
+	p.cancel()
+	p.tasks.Drain()
+}
+
+func (p *WorkerPool[In, Out]) work() {
+	defer p.wg.Done()
+	for {
+		task, ok := p.tasks.Recv()
+		if !ok {
+			return
+		}
+		if p.ctx.Err() != nil {
+			// Stopped
+			continue
+		}
+		out, err := p.attempt(task)
+		p.deliver(WorkerResult[In, Out]{
+			Seq: task.seq,
+			In:  task.in,
+			Out: out,
+			Err: err,
+		})
+	}
+}
+
+// attempt runs the task until it succeeds or runs out of attempts, sending
+// it to the dead letters if it fails.
+func (p *WorkerPool[In, Out]) attempt(task workerTask[In]) (Out, error) {
+	var errs []error
+	first := time.Now()
+	for {
+		out, err := p.run(task.in)
+		if err == nil {
+			return out, nil
+		}
+		errs = append(errs, err)
+		retry := len(errs) < p.opts.MaxAttempts &&
+			p.ctx.Err() == nil &&
+			(p.opts.Retryable == nil || p.opts.Retryable(err))
+		if retry && p.opts.RetryDelay > 0 {
+			timer := time.NewTimer(p.opts.RetryDelay)
+			select {
+			case <-timer.C:
+			case <-p.ctx.Done():
+				timer.Stop()
+				retry = false
+			}
+		}
+		if !retry {
+			if p.deadLetters != nil {
+				p.deadLetters.Send(DeadLetter[In]{
+					Seq:          task.seq,
+					In:           task.in,
+					Errors:       errs,
+					FirstAttempt: first,
+					LastAttempt:  time.Now(),
+				})
+			}
+			return out, err
+		}
+	}
+}
+
+func (p *WorkerPool[In, Out]) run(in In) (out Out, err error) {
+	if p.opts.TaskTimeout <= 0 {
+		return callRecover(p.ctx, p.f, in)
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, p.opts.TaskTimeout)
+	defer cancel()
+	type result struct {
+		out Out
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		out, err := callRecover(ctx, p.f, in)
+		ch <- result{out, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.out, res.err
+	case <-ctx.Done():
+		if p.ctx.Err() != nil {
+			return out, newCanceledError(p.ctx)
+		}
+		return out, ErrTimedOut
+	}
+}
+
+func callRecover[In, Out any](
+	ctx context.Context, f func(context.Context, In) (Out, error), in In,
+) (out Out, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return f(ctx, in)
+}
+
+func (p *WorkerPool[In, Out]) deliver(res WorkerResult[In, Out]) {
+	if !p.opts.Ordered {
+		p.results.Send(res)
+		return
+	}
+	m := p.reorder.Lock()
+	defer p.reorder.Unlock()
+	(*m)[res.Seq] = res
+	for {
+		next, ok := (*m)[p.nextDeliver]
+		if !ok {
+			break
+		}
+		delete(*m, p.nextDeliver)
+		p.results.Send(next)
+		p.nextDeliver++
+	}
+}
+
+// flushReorder delivers all results held for ordered delivery, in order. Used
+// once all workers have exited since there may be gaps left by dropped tasks.
+func (p *WorkerPool[In, Out]) flushReorder() {
+	m := p.reorder.Lock()
+	defer p.reorder.Unlock()
+	seqs := make([]uint64, 0, len(*m))
+	for seq := range *m {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	for _, seq := range seqs {
+		p.results.Send((*m)[seq])
+		delete(*m, seq)
+	}
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	testErr := errors.New("test error")
+	p := NewWorkerPool(
+		func(ctx context.Context, n int) (int, error) {
+			switch n {
+			case -1:
+				return 0, testErr
+			case -2:
+				panic("test panic")
+			case -3:
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			// Have later tasks finish first
+			time.Sleep(time.Duration(100-n) * time.Microsecond * 10)
+			return n * 2, nil
+		},
+		WorkerPoolOpts{
+			Workers:     8,
+			Ordered:     true,
+			TaskTimeout: time.Millisecond * 100,
+		},
+	)
+	inputs := append(RangeSlice(100), -1, -2, -3)
+	for _, n := range inputs {
+		if !p.Submit(n) {
+			t.Fatal("pool unexpectedly closed")
+		}
+	}
+	if !p.Close() {
+		t.Fatal("pool unexpectedly closed")
+	}
+	if p.Submit(1) {
+		t.Fatal("pool not closed")
+	}
+
+	for i, n := range inputs {
+		res, ok := p.Results().Recv()
+		if !ok {
+			t.Fatalf("%d: results unexpectedly closed", i)
+		}
+		if res.Seq != uint64(i) || res.In != n {
+			t.Fatalf("%d: unexpected result order: %+v", i, res)
+		}
+		switch n {
+		case -1:
+			if res.Err != testErr {
+				t.Fatalf("expected test error, got %v", res.Err)
+			}
+		case -2:
+			var pe *PanicError
+			if !errors.As(res.Err, &pe) || pe.Value != "test panic" {
+				t.Fatalf("expected panic error, got %v", res.Err)
+			}
+		case -3:
+			if res.Err != ErrTimedOut {
+				t.Fatalf("expected ErrTimedOut, got %v", res.Err)
+			}
+		default:
+			if res.Err != nil || res.Out != n*2 {
+				t.Fatalf("%d: unexpected result: %+v", i, res)
+			}
+		}
+	}
+	p.Wait()
+	if _, ok := p.Results().Recv(); ok {
+		t.Fatal("expected results to be closed")
+	}
+}
+
+func TestWorkerPoolShutdown(t *testing.T) {
+	started := make(chan Unit, 10)
+	p := NewWorkerPool(
+		func(ctx context.Context, n int) (int, error) {
+			started <- Unit{}
+			<-ctx.Done()
+			return n, ctx.Err()
+		},
+		WorkerPoolOpts{Workers: 2},
+	)
+	for i := 0; i < 10; i++ {
+		p.Submit(i)
+	}
+	<-started
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+	p.Wait()
+	if results := p.Results().Drain(); len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+}
+
+func TestWorkerPoolDeadLetters(t *testing.T) {
+	testErr := errors.New("test error")
+	var attempts sync.Map
+	p := NewWorkerPool(
+		func(ctx context.Context, n int) (int, error) {
+			v, _ := attempts.LoadOrStore(n, new(atomic.Int32))
+			a := v.(*atomic.Int32).Add(1)
+			// Even numbers succeed on the second attempt, odd never do
+			if n%2 == 0 && a >= 2 {
+				return n, nil
+			}
+			return 0, testErr
+		},
+		WorkerPoolOpts{
+			Workers:     4,
+			MaxAttempts: 3,
+			RetryDelay:  time.Millisecond,
+			DeadLetters: true,
+		},
+	)
+	for i := 0; i < 10; i++ {
+		p.Submit(i)
+	}
+	results := make(map[int]WorkerResult[int, int])
+	for i := 0; i < 10; i++ {
+		res, _ := p.Results().Recv()
+		results[res.In] = res
+	}
+	for n, res := range results {
+		if (n%2 == 0) != (res.Err == nil) {
+			t.Fatalf("%d: unexpected result: %+v", n, res)
+		}
+	}
+
+	var dl DeadLetter[int]
+	for i := 0; i < 5; i++ {
+		dl, _ = p.DeadLetters().Recv()
+		if dl.In%2 != 1 || dl.Attempts() != 3 || dl.Err() != testErr {
+			t.Fatalf("unexpected dead letter: %+v", dl)
+		}
+		if dl.LastAttempt.Before(dl.FirstAttempt) {
+			t.Fatal("bad attempt times")
+		}
+	}
+	if !p.Requeue(dl) {
+		t.Fatal("failed to requeue")
+	}
+	p.Close()
+	if res, _ := p.Results().Recv(); res.In != dl.In || res.Seq != 10 {
+		t.Fatalf("unexpected requeued result: %+v", res)
+	}
+	if dl2, _ := p.DeadLetters().Recv(); dl2.In != dl.In {
+		t.Fatalf("unexpected dead letter: %+v", dl2)
+	}
+	p.Wait()
+	if _, ok := p.DeadLetters().Recv(); ok {
+		t.Fatal("expected dead letters to be closed")
+	}
+}
@@ -1,19 +1,36 @@
 package utils
 
 import (
+	"bufio"
 	"io"
 	"sync"
 )
 
 // LockedWriter is a wrapper to lock writes on an underlying writer.
 type LockedWriter struct {
-	w   io.Writer
-	mtx sync.Mutex
+	w io.Writer
+	// buf is the bufio.Writer wrapping under (and is w) in buffered mode.
+	buf   *bufio.Writer
+	under io.Writer
+	mtx   sync.Mutex
 }
 
 // NewLockedWriter returns a new LockedWriter.
 func NewLockedWriter(w io.Writer) *LockedWriter {
-	return &LockedWriter{w: w}
+	return &LockedWriter{w: w, under: w}
+}
+
+// NewBufferedLockedWriter returns a new LockedWriter that buffers writes in a
+// bufio.Writer of the given size (the bufio default if size <= 0). Flush,
+// Sync, or Close must be called to make sure all data is written.
+func NewBufferedLockedWriter(w io.Writer, size int) *LockedWriter {
+	var buf *bufio.Writer
+	if size <= 0 {
+		buf = bufio.NewWriter(w)
+	} else {
+		buf = bufio.NewWriterSize(w, size)
+	}
+	return &LockedWriter{w: buf, buf: buf, under: w}
 }
 
 // Write locks (and unlocks) the writer and writes to the underlying writer.
@@ -86,6 +103,76 @@ func (lw *LockedWriter) TryLockWriter() (io.Writer, bool) {
 	return lw.w, true
 }
 
+// Flush locks (and unlocks) the writer and flushes any buffered data, then
+// flushes the underlying writer if it has a Flush method (either
+// Flush() error or Flush()).
+func (lw *LockedWriter) Flush() error {
+	lw.Lock()
+	defer lw.Unlock()
+	return lw.LockedFlush()
+}
+
+// LockedFlush is Flush without locking.
+func (lw *LockedWriter) LockedFlush() error {
+	if lw.buf != nil {
+		if err := lw.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	switch f := lw.under.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
+
+// Sync locks (and unlocks) the writer and flushes any buffered data, then
+// calls Sync on the underlying writer if it has the method (e.g., *os.File).
+func (lw *LockedWriter) Sync() error {
+	lw.Lock()
+	defer lw.Unlock()
+	if err := lw.LockedFlush(); err != nil {
+		return err
+	}
+	if s, ok := lw.under.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close locks (and unlocks) the writer and flushes any buffered data, then
+// closes the underlying writer if it is an io.Closer. The underlying writer
+// is closed even if flushing fails, with the flush error returned.
+func (lw *LockedWriter) Close() error {
+	lw.Lock()
+	defer lw.Unlock()
+	err := lw.LockedFlush()
+	if c, ok := lw.under.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Buffered returns the number of bytes buffered but not yet written to the
+// underlying writer. Always returns 0 if the writer isn't buffered.
+func (lw *LockedWriter) Buffered() int {
+	if lw.buf == nil {
+		return 0
+	}
+	lw.Lock()
+	defer lw.Unlock()
+	return lw.buf.Buffered()
+}
+
+// Unwrap returns the underlying writer (not the buffer in buffered mode).
+func (lw *LockedWriter) Unwrap() io.Writer {
+	return lw.under
+}
+
 // Lock locks the writer.
 func (lw *LockedWriter) Lock() {
 	lw.mtx.Lock()
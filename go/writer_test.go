@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type testFlushCloser struct {
+	bytes.Buffer
+	flushes, closes int
+}
+
+func (fc *testFlushCloser) Flush() error {
+	fc.flushes++
+	return nil
+}
+
+func (fc *testFlushCloser) Close() error {
+	fc.closes++
+	return errors.New("close error")
+}
+
+func TestLockedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	lw := NewLockedWriter(&buf)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lw.WriteAll([]byte("0123456789"))
+		}()
+	}
+	wg.Wait()
+	if buf.Len() != 100 || lw.Buffered() != 0 {
+		t.Fatalf("expected 100 bytes, got %d", buf.Len())
+	}
+	if err := lw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBufferedLockedWriter(t *testing.T) {
+	fc := &testFlushCloser{}
+	lw := NewBufferedLockedWriter(fc, 16)
+	lw.Write([]byte("hello"))
+	if fc.Len() != 0 || lw.Buffered() != 5 {
+		t.Fatalf("expected data to be buffered, got %q", fc.String())
+	}
+	lw.Write([]byte(" world, this is long"))
+	if fc.Len() == 0 {
+		t.Fatal("expected data to be written once the buffer filled")
+	}
+	if err := lw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if fc.String() != "hello world, this is long" || fc.flushes != 1 {
+		t.Fatalf("unexpected data or flushes: %q, %d", fc.String(), fc.flushes)
+	}
+	lw.Write([]byte("!"))
+	if err := lw.Close(); err == nil || err.Error() != "close error" {
+		t.Fatalf("expected close error, got %v", err)
+	}
+	if fc.String() != "hello world, this is long!" || fc.closes != 1 {
+		t.Fatalf("unexpected data or closes: %q, %d", fc.String(), fc.closes)
+	}
+	if lw.Unwrap() != fc {
+		t.Fatal("unexpected underlying writer")
+	}
+}
+
+func TestBufferedLockedWriterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	f, err := OpenAppend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lw := NewBufferedLockedWriter(f, 0)
+	lw.Write([]byte("line\n"))
+	if b, _ := os.ReadFile(path); len(b) != 0 {
+		t.Fatalf("expected empty file, got %q", b)
+	}
+	if err := lw.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "line\n" {
+		t.Fatalf("unexpected file contents: %q", b)
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err == nil {
+		t.Fatal("expected file to already be closed")
+	}
+}